@@ -0,0 +1,163 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Job accounting attributes
+ */
+
+package goipp
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// JobAccounting contains job accounting data, extracted from
+// the job attributes (i.e., Get-Job-Attributes or Get-Jobs
+// response), for billing and monitoring.
+//
+// Integer fields are -1, if attribute is missed or has an
+// out-of-band value (i.e., unknown). Time fields are zero
+// in this case.
+type JobAccounting struct {
+	JobID    int    // job-id
+	JobName  string // job-name
+	UserName string // job-originating-user-name
+	JobState int    // job-state
+
+	Impressions          int // job-impressions
+	ImpressionsCompleted int // job-impressions-completed
+	MediaSheets          int // job-media-sheets
+	MediaSheetsCompleted int // job-media-sheets-completed
+	KOctets              int // job-k-octets
+	KOctetsProcessed     int // job-k-octets-processed
+
+	Created   time.Time // date-time-at-creation
+	Processed time.Time // date-time-at-processing
+	Completed time.Time // date-time-at-completed
+}
+
+// JobAccounting extracts JobAccounting from the first job
+// attributes group of the message.
+//
+// It returns error, if message has no job attributes group or
+// some of accounting attributes has wrong syntax.
+func (m *Message) JobAccounting() (JobAccounting, error) {
+	for _, grp := range m.attrGroups() {
+		if grp.Tag == TagJobGroup {
+			return ParseJobAccounting(grp.Attrs)
+		}
+	}
+
+	return JobAccounting{}, errors.New("Job attributes missed")
+}
+
+// JobsAccounting extracts JobAccounting from all job attributes
+// groups of the message (i.e., Get-Jobs response), in order.
+func (m *Message) JobsAccounting() ([]JobAccounting, error) {
+	var jobs []JobAccounting
+
+	for _, grp := range m.attrGroups() {
+		if grp.Tag == TagJobGroup {
+			job, err := ParseJobAccounting(grp.Attrs)
+			if err != nil {
+				return nil, err
+			}
+			jobs = append(jobs, job)
+		}
+	}
+
+	return jobs, nil
+}
+
+// ParseJobAccounting extracts JobAccounting from the job attributes
+func ParseJobAccounting(attrs Attributes) (JobAccounting, error) {
+	job := JobAccounting{
+		JobID:                -1,
+		JobState:             -1,
+		Impressions:          -1,
+		ImpressionsCompleted: -1,
+		MediaSheets:          -1,
+		MediaSheetsCompleted: -1,
+		KOctets:              -1,
+		KOctetsProcessed:     -1,
+	}
+
+	var err error
+	for _, attr := range attrs {
+		if len(attr.Values) == 0 || attr.Values[0].T.IsOutOfBand() {
+			continue
+		}
+
+		switch attr.Name {
+		case "job-id":
+			job.JobID, err = accountingInteger(attr)
+		case "job-name":
+			job.JobName, err = accountingName(attr)
+		case "job-originating-user-name":
+			job.UserName, err = accountingName(attr)
+		case "job-state":
+			job.JobState, err = accountingInteger(attr)
+		case "job-impressions":
+			job.Impressions, err = accountingInteger(attr)
+		case "job-impressions-completed":
+			job.ImpressionsCompleted, err = accountingInteger(attr)
+		case "job-media-sheets":
+			job.MediaSheets, err = accountingInteger(attr)
+		case "job-media-sheets-completed":
+			job.MediaSheetsCompleted, err = accountingInteger(attr)
+		case "job-k-octets":
+			job.KOctets, err = accountingInteger(attr)
+		case "job-k-octets-processed":
+			job.KOctetsProcessed, err = accountingInteger(attr)
+		case "date-time-at-creation":
+			job.Created, err = accountingTime(attr)
+		case "date-time-at-processing":
+			job.Processed, err = accountingTime(attr)
+		case "date-time-at-completed":
+			job.Completed, err = accountingTime(attr)
+		}
+
+		if err != nil {
+			return JobAccounting{}, err
+		}
+	}
+
+	return job, nil
+}
+
+// accountingInteger returns value of the integer or enum attribute
+func accountingInteger(attr Attribute) (int, error) {
+	if len(attr.Values) == 1 {
+		if v, ok := attr.Values[0].V.(Integer); ok {
+			return int(v), nil
+		}
+	}
+	return 0, fmt.Errorf("%s: single integer expected", attr.Name)
+}
+
+// accountingName returns value of the name attribute
+func accountingName(attr Attribute) (string, error) {
+	if len(attr.Values) == 1 {
+		switch v := attr.Values[0].V.(type) {
+		case String, Name:
+			return v.String(), nil
+		case TextWithLang:
+			return v.Text, nil
+		}
+	}
+	return "", fmt.Errorf("%s: single name expected", attr.Name)
+}
+
+// accountingTime returns value of the dateTime attribute
+func accountingTime(attr Attribute) (time.Time, error) {
+	if len(attr.Values) == 1 {
+		if v, ok := attr.Values[0].V.(Time); ok {
+			return v.Time, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%s: single dateTime expected",
+		attr.Name)
+}
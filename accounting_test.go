@@ -0,0 +1,100 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Job accounting attributes test
+ */
+
+package goipp
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestJobAccounting tests JobAccounting extraction
+func TestJobAccounting(t *testing.T) {
+	created := time.Date(2020, 1, 13, 15, 35, 12, 0, time.UTC)
+	completed := created.Add(time.Minute)
+
+	m := NewResponse(DefaultVersion, StatusOk, 1)
+	m.Job.Add(MakeAttr("job-id", TagInteger, Integer(17)))
+	m.Job.Add(MakeAttr("job-name", TagNameLang,
+		TextWithLang{"en", "report.pdf"}))
+	m.Job.Add(MakeAttr("job-originating-user-name", TagName,
+		String("alice")))
+	m.Job.Add(MakeAttr("job-state", TagEnum, Integer(9)))
+	m.Job.Add(MakeAttr("job-impressions", TagInteger, Integer(10)))
+	m.Job.Add(MakeAttr("job-impressions-completed", TagInteger,
+		Integer(10)))
+	m.Job.Add(MakeAttr("job-media-sheets-completed", TagInteger,
+		Integer(5)))
+	m.Job.Add(MakeAttr("job-k-octets", TagInteger, Integer(120)))
+	m.Job.Add(MakeAttr("job-media-sheets", TagUnknown, Void{}))
+	m.Job.Add(MakeAttr("date-time-at-creation", TagDateTime,
+		Time{created}))
+	m.Job.Add(MakeAttr("date-time-at-processing", TagNoValue, Void{}))
+	m.Job.Add(MakeAttr("date-time-at-completed", TagDateTime,
+		Time{completed}))
+
+	expected := JobAccounting{
+		JobID:                17,
+		JobName:              "report.pdf",
+		UserName:             "alice",
+		JobState:             9,
+		Impressions:          10,
+		ImpressionsCompleted: 10,
+		MediaSheets:          -1,
+		MediaSheetsCompleted: 5,
+		KOctets:              120,
+		KOctetsProcessed:     -1,
+		Created:              created,
+		Completed:            completed,
+	}
+
+	job, err := m.JobAccounting()
+	assertNoError(t, err)
+
+	if !reflect.DeepEqual(job, expected) {
+		t.Errorf("JobAccounting:\n%+v\nexpected:\n%+v", job, expected)
+	}
+
+	// Get-Jobs response with multiple jobs
+	m2 := NewResponse(DefaultVersion, StatusOk, 1)
+	m2.Groups = Groups{
+		{TagOperationGroup, nil},
+		{TagJobGroup, m.Job},
+		{TagJobGroup, Attributes{
+			MakeAttr("job-id", TagInteger, Integer(18)),
+		}},
+	}
+
+	jobs, err := m2.JobsAccounting()
+	assertNoError(t, err)
+
+	if len(jobs) != 2 || jobs[0].JobID != 17 || jobs[1].JobID != 18 ||
+		jobs[1].Impressions != -1 {
+		t.Errorf("JobsAccounting: %+v", jobs)
+	}
+
+	// Errors
+	_, err = NewResponse(DefaultVersion, StatusOk, 1).JobAccounting()
+	assertErrorIs(t, err, "Job attributes missed")
+
+	_, err = ParseJobAccounting(Attributes{
+		MakeAttr("job-impressions", TagKeyword, String("10")),
+	})
+	assertErrorIs(t, err, "job-impressions: single integer expected")
+
+	_, err = ParseJobAccounting(Attributes{
+		MakeAttr("date-time-at-creation", TagInteger, Integer(10)),
+	})
+	assertErrorIs(t, err, "date-time-at-creation: single dateTime expected")
+
+	_, err = ParseJobAccounting(Attributes{
+		MakeAttr("job-name", TagInteger, Integer(10)),
+	})
+	assertErrorIs(t, err, "job-name: single name expected")
+}
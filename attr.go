@@ -34,9 +34,8 @@ func (attrs Attributes) Equal(attrs2 Attributes) bool {
 		return false
 	}
 
-	for i, attr := range attrs {
-		attr2 := attrs2[i]
-		if !attr.Equal(attr2) {
+	for i := range attrs {
+		if !attrs[i].EqualTo(&attrs2[i]) {
 			return false
 		}
 	}
@@ -97,12 +96,7 @@ func MakeAttribute(name string, tag Tag, value Value) Attribute {
 
 // MakeAttr makes Attribute with one or more values.
 func MakeAttr(name string, tag Tag, val1 Value, values ...Value) Attribute {
-	attr := Attribute{Name: name}
-	attr.Values.Add(tag, val1)
-	for _, val := range values {
-		attr.Values.Add(tag, val)
-	}
-	return attr
+	return Attribute{Name: name, Values: MakeValues(tag, val1, values...)}
 }
 
 // MakeAttrCollection makes [Attribute] with [Collection] value.
@@ -119,7 +113,63 @@ func MakeAttrCollection(name string,
 // Equal checks that Attribute is equal to another Attribute
 // (i.e., names are the same and values are equal)
 func (a Attribute) Equal(a2 Attribute) bool {
-	return a.Name == a2.Name && a.Values.Equal(a2.Values)
+	return a.EqualTo(&a2)
+}
+
+// EqualTo is like Equal, but avoids copying of the Attribute.
+//
+// Comparison is short-circuited for large 1setOf values: values
+// that share the same underlying slice are equal without
+// comparing them, and the last values are compared first, as
+// attributes that differ often differ in the appended values.
+func (a *Attribute) EqualTo(a2 *Attribute) bool {
+	if a == a2 {
+		return true
+	}
+
+	if a.Name != a2.Name || len(a.Values) != len(a2.Values) {
+		return false
+	}
+
+	if last := len(a.Values) - 1; last > 0 {
+		v1, v2 := a.Values[last], a2.Values[last]
+		if v1.T != v2.T || !ValueEqual(v1.V, v2.V) {
+			return false
+		}
+	}
+
+	return a.Values.Equal(a2.Values)
+}
+
+// Prune removes values of the attribute, for which pred returns
+// true, including values of members of collections at any depth.
+// Collection members, left without values, are removed as well.
+//
+// Like Values.RemoveIf, Prune modifies values in place and returns
+// count of removed values. Note, the attribute itself may be left
+// without values.
+func (a *Attribute) Prune(pred func(t Tag, v Value) bool) int {
+	n := a.Values.RemoveIf(pred)
+
+	for i := range a.Values {
+		col, ok := a.Values[i].V.(Collection)
+		if !ok {
+			continue
+		}
+
+		out := 0
+		for j := range col {
+			n += col[j].Prune(pred)
+			if len(col[j].Values) != 0 {
+				col[out] = col[j]
+				out++
+			}
+		}
+
+		a.Values[i].V = col[:out]
+	}
+
+	return n
 }
 
 // Similar checks that Attribute is **logically** equal to another
@@ -131,7 +181,18 @@ func (a Attribute) Similar(a2 Attribute) bool {
 // Unpack attribute value from its wire representation
 func (a *Attribute) unpack(tag Tag, value []byte) error {
 	var err error
-	var val Value
+	var val valueCodec
+
+	if decode := customTagDecoder(tag); decode != nil {
+		var v ValueMarshaler
+		v, err = decode(tag, value)
+		if err == nil {
+			a.Values.Add(tag, v)
+		} else {
+			err = fmt.Errorf("%s: %s", tag, err)
+		}
+		return err
+	}
 
 	switch tag.Type() {
 	case TypeVoid, TypeCollection:
@@ -162,13 +223,14 @@ func (a *Attribute) unpack(tag Tag, value []byte) error {
 		val = Binary(nil)
 
 	default:
-		panic(fmt.Sprintf("(Attribute) uppack(): tag=%s type=%s", tag, tag.Type()))
+		return fmt.Errorf("%s: values of type %s cannot be decoded",
+			tag, tag.Type())
 	}
 
-	val, err = val.decode(value)
+	v, err := val.decode(value)
 
 	if err == nil {
-		a.Values.Add(tag, val)
+		a.Values.Add(tag, v)
 	} else {
 		err = fmt.Errorf("%s: %s", tag, err)
 	}
@@ -0,0 +1,98 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Index of message attributes
+ */
+
+package goipp
+
+// AttrIndex is the index of message attributes for repeated
+// lookups by name, i.e., by servers that query dozens of attributes
+// from each incoming request.
+//
+// Attributes are indexed by group tag and path. Path of the top-level
+// attribute is its name, and members of collections are indexed by
+// paths, joined by "/":
+//
+//	media-col/media-size/x-dimension
+//
+// If the same path occurs multiple times (i.e., members of 1setOf
+// collections or multiple groups with the same tag), the first
+// occurrence is indexed.
+//
+// The index refers to attributes of the Message and doesn't copy
+// their values. Changes of the Message after the index is built are
+// not reflected by the index.
+type AttrIndex struct {
+	attrs map[attrIndexKey]*Attribute // Indexed attributes
+	cnt   int                         // Count of indexed paths
+}
+
+// attrIndexKey is the key of AttrIndex
+type attrIndexKey struct {
+	group Tag    // Group tag, TagZero for any group
+	path  string // Attribute path
+}
+
+// NewAttrIndex builds the AttrIndex for the message
+func NewAttrIndex(m *Message) *AttrIndex {
+	groups := m.attrGroups()
+
+	cnt := 0
+	for _, grp := range groups {
+		cnt += len(grp.Attrs)
+	}
+
+	idx := &AttrIndex{attrs: make(map[attrIndexKey]*Attribute, 2*cnt)}
+	for _, grp := range groups {
+		idx.add(grp.Tag, grp.Attrs, "")
+	}
+
+	return idx
+}
+
+// Get returns attribute by group tag and path
+func (idx *AttrIndex) Get(group Tag, path string) (Attribute, bool) {
+	if attr := idx.attrs[attrIndexKey{group, path}]; attr != nil {
+		return *attr, true
+	}
+	return Attribute{}, false
+}
+
+// Lookup returns attribute by path, from the first group, that
+// contains it
+func (idx *AttrIndex) Lookup(path string) (Attribute, bool) {
+	return idx.Get(TagZero, path)
+}
+
+// Len returns number of indexed paths
+func (idx *AttrIndex) Len() int {
+	return idx.cnt
+}
+
+// add adds attributes to the index
+func (idx *AttrIndex) add(group Tag, attrs Attributes, prefix string) {
+	for i := range attrs {
+		attr := &attrs[i]
+		path := prefix + attr.Name
+
+		key := attrIndexKey{group, path}
+		if idx.attrs[key] == nil {
+			idx.attrs[key] = attr
+			idx.cnt++
+		}
+
+		key.group = TagZero
+		if idx.attrs[key] == nil {
+			idx.attrs[key] = attr
+		}
+
+		for _, val := range attr.Values {
+			if col, ok := val.V.(Collection); ok {
+				idx.add(group, Attributes(col), path+"/")
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Index of message attributes test
+ */
+
+package goipp
+
+import (
+	"testing"
+)
+
+// TestAttrIndex tests AttrIndex
+func TestAttrIndex(t *testing.T) {
+	m := NewRequest(DefaultVersion, OpPrintJob, 1)
+	m.Operation.Add(MakeAttr("attributes-charset", TagCharset,
+		String("utf-8")))
+	m.Operation.Add(MakeAttr("job-name", TagName, String("op")))
+	m.Job.Add(MakeAttr("job-name", TagName, String("job")))
+	m.Job.Add(MakeAttrCollection("media-col",
+		MakeAttrCollection("media-size",
+			MakeAttr("x-dimension", TagInteger, Integer(21000)),
+			MakeAttr("y-dimension", TagInteger, Integer(29700)))))
+
+	idx := NewAttrIndex(m)
+
+	tests := []struct {
+		group Tag
+		path  string
+		value string // "" if not found
+	}{
+		{TagOperationGroup, "attributes-charset", "utf-8"},
+		{TagOperationGroup, "job-name", "op"},
+		{TagJobGroup, "job-name", "job"},
+		{TagZero, "job-name", "op"},
+		{TagJobGroup, "media-col/media-size/x-dimension", "21000"},
+		{TagZero, "media-col/media-size/y-dimension", "29700"},
+		{TagOperationGroup, "media-col", ""},
+		{TagJobGroup, "media-size", ""},
+		{TagJobGroup, "media-col/media-size/z-dimension", ""},
+	}
+
+	for _, test := range tests {
+		attr, found := idx.Get(test.group, test.path)
+		switch {
+		case found != (test.value != ""):
+			t.Errorf("%s %s: found=%v", test.group, test.path, found)
+		case found && attr.Values[0].V.String() != test.value:
+			t.Errorf("%s %s: %s, expected %s", test.group, test.path,
+				attr.Values[0].V, test.value)
+		}
+	}
+
+	if attr, found := idx.Lookup("job-name"); !found ||
+		attr.Values[0].V.String() != "op" {
+		t.Errorf("Lookup: %v %v", attr, found)
+	}
+
+	if n := idx.Len(); n != 7 {
+		t.Errorf("Len: %d, expected 7", n)
+	}
+}
@@ -0,0 +1,353 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Attributes from the textual specs (command line, URL query)
+ */
+
+package goipp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ParseAttrSpec parses attribute from the textual spec, suitable
+// for command-line flags and quick scripting:
+//
+//	copies=3:integer
+//	media=iso_a4_210x297mm:keyword
+//	printer-uri=ipp://localhost:631/ipp/print:uri
+//	page-ranges=1-3,7-9:rangeOfInteger
+//
+// Multiple values are separated by commas. The ':tag' suffix is
+// optional. Tag names are the same as returned by Tag.String (or
+// hexadecimal, like 0x7f), and "name" and "text" are accepted as
+// shortcuts for the nameWithoutLanguage and textWithoutLanguage.
+//
+// If tag is omitted, it is inferred from the registered syntax
+// of the attribute (see AttrSyntax): the first registered tag,
+// that accepts all values, is used. Unknown attributes become
+// integer, boolean or keyword, depending on values.
+//
+// Values are written the same way, as the YAML representation uses
+// (see Message.EncodeYAML). The backslash escapes the following
+// character, so commas and colons may be used within values.
+//
+// Collections are not supported.
+func ParseAttrSpec(spec string) (Attribute, error) {
+	eq := strings.IndexByte(spec, '=')
+	if eq <= 0 {
+		return Attribute{}, fmt.Errorf("%q: name=value expected", spec)
+	}
+
+	name, s := spec[:eq], spec[eq+1:]
+
+	// Split into values and tag
+	var strs []string
+	var tagName string
+	var buf bytes.Buffer
+	escaped := false
+	colon := -1   // Index in strs of the last colon, -1 if none
+	colonOff := 0 // Offset of the last colon within its value
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case escaped:
+			buf.WriteByte(c)
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == ',':
+			strs = append(strs, buf.String())
+			buf.Reset()
+			colon = -1
+		case c == ':':
+			colon, colonOff = len(strs), buf.Len()
+			buf.WriteByte(c)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+
+	if escaped {
+		return Attribute{}, fmt.Errorf("%q: trailing backslash", spec)
+	}
+
+	strs = append(strs, buf.String())
+
+	tag := TagZero
+	if colon == len(strs)-1 {
+		last := strs[colon]
+		if t, found := attrSpecTag(last[colonOff+1:]); found {
+			tag, tagName = t, last[colonOff+1:]
+			strs[colon] = last[:colonOff]
+		}
+	}
+
+	// Parse values
+	switch {
+	case tag == TagZero:
+		tag = attrSpecInferTag(name, strs)
+	case tag.Type() == TypeCollection:
+		return Attribute{}, fmt.Errorf("%q: %s is not supported",
+			spec, tagName)
+	case tag.IsDelimiter() || tag == TagMemberName ||
+		tag == TagEndCollection:
+		return Attribute{}, fmt.Errorf("%q: invalid value tag %q",
+			spec, tagName)
+	}
+
+	attr := Attribute{Name: name}
+	if tag.Type() == TypeVoid {
+		if len(strs) != 1 || strs[0] != "" {
+			return Attribute{}, fmt.Errorf("%q: %s doesn't take values",
+				spec, tag)
+		}
+
+		attr.Values.Add(tag, Void{})
+		return attr, nil
+	}
+
+	for _, str := range strs {
+		v, err := attrSpecParseValue(tag, str)
+		if err != nil {
+			return Attribute{}, fmt.Errorf("%q: %s: %s", spec, tag, err)
+		}
+
+		attr.Values.Add(tag, v)
+	}
+
+	return attr, nil
+}
+
+// FormatAttrSpec formats attribute as the textual spec, understood
+// by ParseAttrSpec. The tag is always included.
+//
+// Attributes with collection values or with values of different
+// tags cannot be represented as spec.
+func FormatAttrSpec(attr Attribute) (string, error) {
+	if len(attr.Values) == 0 {
+		return "", fmt.Errorf("%s: attribute without value", attr.Name)
+	}
+
+	tag := attr.Values[0].T
+	strs := make([]string, len(attr.Values))
+
+	for i, val := range attr.Values {
+		var err error
+
+		switch {
+		case val.T != tag:
+			err = fmt.Errorf("%s: values with different tags (%s, %s)",
+				attr.Name, tag, val.T)
+		case tag.Type() == TypeCollection:
+			err = fmt.Errorf("%s: %s is not supported",
+				attr.Name, tag)
+		case tag.Type() != TypeVoid:
+			strs[i], err = attrSpecFormatValue(tag, val.V)
+			if err != nil {
+				err = fmt.Errorf("%s: %s", attr.Name, err)
+			}
+		}
+
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if tag.Type() == TypeVoid {
+		strs = strs[:1]
+	}
+
+	return attr.Name + "=" + strings.Join(strs, ",") + ":" +
+		tag.String(), nil
+}
+
+// ParseAttrQuery parses attributes from the URL query string, where
+// each parameter is the attribute spec (see ParseAttrSpec), i.e.:
+//
+//	copies=3:integer&media=iso_a4_210x297mm&sides=two-sided-long-edge
+//
+// Order of attributes is preserved. Repeated parameters are merged
+// into the single attribute.
+func ParseAttrQuery(query string) (Attributes, error) {
+	var attrs Attributes
+
+	for _, param := range strings.Split(query, "&") {
+		if param == "" {
+			continue
+		}
+
+		spec, err := url.QueryUnescape(param)
+		if err != nil {
+			return nil, err
+		}
+
+		attr, err := ParseAttrSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		attrs = attrSpecMerge(attrs, attr)
+	}
+
+	return attrs, nil
+}
+
+// FormatAttrQuery formats attributes as the URL query string,
+// understood by ParseAttrQuery
+func FormatAttrQuery(attrs Attributes) (string, error) {
+	params := make([]string, len(attrs))
+
+	for i, attr := range attrs {
+		spec, err := FormatAttrSpec(attr)
+		if err != nil {
+			return "", err
+		}
+
+		eq := strings.IndexByte(spec, '=')
+		params[i] = url.QueryEscape(spec[:eq]) + "=" +
+			url.QueryEscape(spec[eq+1:])
+	}
+
+	return strings.Join(params, "&"), nil
+}
+
+// AttrSpecFlag collects attributes from the repeated command-line
+// flag. It implements flag.Value:
+//
+//	var attrs goipp.AttrSpecFlag
+//	flag.Var(&attrs, "a", "attribute (i.e., copies=2:integer)")
+//	flag.Parse()
+//
+//	req.Job = append(req.Job, attrs...)
+//
+// Repeated attributes are merged into the single attribute.
+type AttrSpecFlag Attributes
+
+// String returns the attribute specs, separated by spaces
+func (f *AttrSpecFlag) String() string {
+	if f == nil {
+		return ""
+	}
+
+	specs := make([]string, 0, len(*f))
+	for _, attr := range *f {
+		if spec, err := FormatAttrSpec(attr); err == nil {
+			specs = append(specs, spec)
+		}
+	}
+
+	return strings.Join(specs, " ")
+}
+
+// Set parses attribute spec and adds attribute
+func (f *AttrSpecFlag) Set(spec string) error {
+	attr, err := ParseAttrSpec(spec)
+	if err == nil {
+		*f = AttrSpecFlag(attrSpecMerge(Attributes(*f), attr))
+	}
+	return err
+}
+
+// attrSpecMerge adds attribute to attrs. If attribute with the
+// same name already exists, values are appended to it
+func attrSpecMerge(attrs Attributes, attr Attribute) Attributes {
+	for i := range attrs {
+		if attrs[i].Name == attr.Name {
+			attrs[i].Values = append(attrs[i].Values, attr.Values...)
+			return attrs
+		}
+	}
+
+	return append(attrs, attr)
+}
+
+// attrSpecTag returns tag by its name or shortcut
+func attrSpecTag(s string) (Tag, bool) {
+	switch s {
+	case "name":
+		return TagName, true
+	case "text":
+		return TagText, true
+	}
+
+	tag, err := parseTag(s)
+	return tag, err == nil
+}
+
+// attrSpecInferTag infers tag for the string values of attribute
+func attrSpecInferTag(name string, strs []string) Tag {
+	accepts := func(tag Tag) bool {
+		for _, s := range strs {
+			if _, err := attrSpecParseValue(tag, s); err != nil {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, tag := range lintAttrs[strings.TrimSuffix(name, "-default")] {
+		switch tag.Type() {
+		case TypeVoid, TypeCollection:
+		default:
+			if accepts(tag) {
+				return tag
+			}
+		}
+	}
+
+	for _, tag := range []Tag{TagInteger, TagBoolean} {
+		if accepts(tag) {
+			return tag
+		}
+	}
+
+	return TagKeyword
+}
+
+// attrSpecParseValue parses non-collection, non-void Value
+func attrSpecParseValue(tag Tag, s string) (Value, error) {
+	if s == "" && tag.Type() != TypeString && tag.Type() != TypeBinary {
+		return nil, errors.New("missed value")
+	}
+
+	return yamlParseValue(tag, s)
+}
+
+// attrSpecFormatValue formats non-collection, non-void Value
+func attrSpecFormatValue(tag Tag, v Value) (string, error) {
+	if tag.Type() != v.Type() {
+		return "", fmt.Errorf("%s: %s value required, %s present",
+			tag, tag.Type(), v.Type())
+	}
+
+	var s string
+	switch v := v.(type) {
+	case Time:
+		s = v.Time.Format(time.RFC3339Nano)
+	case Binary:
+		s = hex.EncodeToString(v)
+	case BinaryRef:
+		data, err := v.Materialize()
+		if err != nil {
+			return "", err
+		}
+		s = hex.EncodeToString(data)
+	default:
+		s = v.String()
+	}
+
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `,`, `\,`, -1)
+
+	return s, nil
+}
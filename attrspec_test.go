@@ -0,0 +1,286 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Attributes from the textual specs test
+ */
+
+package goipp
+
+import (
+	"flag"
+	"io/ioutil"
+	"testing"
+)
+
+// TestParseAttrSpec tests ParseAttrSpec and FormatAttrSpec
+func TestParseAttrSpec(t *testing.T) {
+	type testData struct {
+		spec   string    // Input spec
+		attr   Attribute // Expected attribute
+		err    string    // Expected error, "" if none
+		format string    // Expected FormatAttrSpec output, "" if spec
+	}
+
+	tests := []testData{
+		// Explicit tags
+		{
+			spec: "copies=3:integer",
+			attr: MakeAttribute("copies", TagInteger, Integer(3)),
+		},
+		{
+			spec: "media=iso_a4_210x297mm:keyword",
+			attr: MakeAttribute("media", TagKeyword,
+				String("iso_a4_210x297mm")),
+		},
+		{
+			spec: "printer-uri=ipp://localhost:631/ipp/print:uri",
+			attr: MakeAttribute("printer-uri", TagURI,
+				String("ipp://localhost:631/ipp/print")),
+		},
+		{
+			spec: "page-ranges=1-3,7-9:rangeOfInteger",
+			attr: MakeAttr("page-ranges", TagRange,
+				Range{1, 3}, Range{7, 9}),
+		},
+		{
+			spec: "printer-resolution=600x600dpi:resolution",
+			attr: MakeAttribute("printer-resolution", TagResolution,
+				Resolution{600, 600, UnitsDpi}),
+		},
+		{
+			spec: "job-name=My job:name",
+			attr: MakeAttribute("job-name", TagName,
+				String("My job")),
+			format: "job-name=My job:nameWithoutLanguage",
+		},
+		{
+			spec: "job-name=Report [en]:nameWithLanguage",
+			attr: MakeAttribute("job-name", TagNameLang,
+				TextWithLang{"en", "Report"}),
+		},
+		{
+			spec: "document-password=0102ff:octetString",
+			attr: MakeAttribute("document-password", TagString,
+				Binary{1, 2, 0xff}),
+		},
+		{
+			spec: "x-custom=6162:0x7f",
+			attr: MakeAttribute("x-custom", Tag(0x7f),
+				Binary("ab")),
+		},
+		{
+			spec: "job-hold-until=:no-value",
+			attr: MakeAttribute("job-hold-until", TagNoValue, Void{}),
+		},
+
+		// Escaping
+		{
+			spec: `job-name=a\,b\\c\:keyword:name`,
+			attr: MakeAttribute("job-name", TagName,
+				String(`a,b\c:keyword`)),
+			format: `job-name=a\,b\\c:keyword:nameWithoutLanguage`,
+		},
+
+		// Inferred tags
+		{
+			spec:   "copies=3",
+			attr:   MakeAttribute("copies", TagInteger, Integer(3)),
+			format: "copies=3:integer",
+		},
+		{
+			spec: "printer-uri=ipp://localhost:631/ipp/print",
+			attr: MakeAttribute("printer-uri", TagURI,
+				String("ipp://localhost:631/ipp/print")),
+			format: "printer-uri=ipp://localhost:631/ipp/print:uri",
+		},
+		{
+			spec: "page-ranges=1-3,7-9",
+			attr: MakeAttr("page-ranges", TagRange,
+				Range{1, 3}, Range{7, 9}),
+			format: "page-ranges=1-3,7-9:rangeOfInteger",
+		},
+		{
+			spec:   "print-quality=5",
+			attr:   MakeAttribute("print-quality", TagEnum, Integer(5)),
+			format: "print-quality=5:enum",
+		},
+		{
+			spec: "x-count=10",
+			attr: MakeAttribute("x-count", TagInteger,
+				Integer(10)),
+			format: "x-count=10:integer",
+		},
+		{
+			spec: "x-flag=true",
+			attr: MakeAttribute("x-flag", TagBoolean,
+				Boolean(true)),
+			format: "x-flag=true:boolean",
+		},
+		{
+			spec: "x-word=a,b",
+			attr: MakeAttr("x-word", TagKeyword,
+				String("a"), String("b")),
+			format: "x-word=a,b:keyword",
+		},
+
+		// Errors
+		{spec: "copies", err: `"copies": name=value expected`},
+		{spec: "=3", err: `"=3": name=value expected`},
+		{spec: `job-name=a\`, err: `"job-name=a\\": trailing backslash`},
+		{spec: "copies=x:integer",
+			err: `"copies=x:integer": integer: invalid integer "x"`},
+		{spec: "copies=:integer",
+			err: `"copies=:integer": integer: missed value`},
+		{spec: "media-col=x:collection",
+			err: `"media-col=x:collection": collection is not supported`},
+		{spec: "a=x:memberAttrName",
+			err: `"a=x:memberAttrName": invalid value tag "memberAttrName"`},
+		{spec: "a=x:no-value",
+			err: `"a=x:no-value": no-value doesn't take values`},
+	}
+
+	for _, test := range tests {
+		attr, err := ParseAttrSpec(test.spec)
+		if test.err != "" {
+			assertErrorIs(t, err, test.err)
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%q: %s", test.spec, err)
+			continue
+		}
+
+		if !attr.Equal(test.attr) {
+			t.Errorf("%q:\nexpected: %s\npresent:  %s",
+				test.spec, test.attr, attr)
+			continue
+		}
+
+		format := test.format
+		if format == "" {
+			format = test.spec
+		}
+
+		s, err := FormatAttrSpec(attr)
+		if err != nil {
+			t.Errorf("%q: FormatAttrSpec: %s", test.spec, err)
+		} else if s != format {
+			t.Errorf("%q: FormatAttrSpec:\nexpected: %s\npresent:  %s",
+				test.spec, format, s)
+		}
+	}
+}
+
+// TestFormatAttrSpecErrors tests FormatAttrSpec errors
+func TestFormatAttrSpecErrors(t *testing.T) {
+	type testData struct {
+		attr Attribute // Input attribute
+		err  string    // Expected error
+	}
+
+	tests := []testData{
+		{
+			attr: Attribute{Name: "empty"},
+			err:  "empty: attribute without value",
+		},
+		{
+			attr: Attribute{Name: "media", Values: Values{
+				{TagKeyword, String("a")},
+				{TagName, String("b")},
+			}},
+			err: "media: values with different tags " +
+				"(keyword, nameWithoutLanguage)",
+		},
+		{
+			attr: MakeAttrCollection("media-col",
+				MakeAttribute("media-type", TagKeyword, String("x"))),
+			err: "media-col: collection is not supported",
+		},
+		{
+			attr: MakeAttribute("copies", TagInteger, String("3")),
+			err:  "copies: integer: Integer value required, String present",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := FormatAttrSpec(test.attr)
+		assertErrorIs(t, err, test.err)
+	}
+}
+
+// TestParseAttrQuery tests ParseAttrQuery and FormatAttrQuery
+func TestParseAttrQuery(t *testing.T) {
+	query := "copies=3:integer&media=iso_a4_210x297mm" +
+		"&job-name=My%20job%5C%2C%201%3Aname&&media=na_letter_8.5x11in"
+
+	attrs, err := ParseAttrQuery(query)
+	assertNoError(t, err)
+
+	expected := Attributes{
+		MakeAttribute("copies", TagInteger, Integer(3)),
+		MakeAttr("media", TagKeyword, String("iso_a4_210x297mm"),
+			String("na_letter_8.5x11in")),
+		MakeAttribute("job-name", TagName, String("My job, 1")),
+	}
+
+	if !attrs.Equal(expected) {
+		t.Errorf("ParseAttrQuery:\nexpected: %s\npresent:  %s",
+			expected, attrs)
+	}
+
+	s, err := FormatAttrQuery(attrs)
+	assertNoError(t, err)
+
+	attrs2, err := ParseAttrQuery(s)
+	assertNoError(t, err)
+
+	if !attrs2.Equal(attrs) {
+		t.Errorf("FormatAttrQuery: %s: round trip failed", s)
+	}
+
+	_, err = ParseAttrQuery("copies=%zz")
+	assertWithError(t, err)
+
+	_, err = ParseAttrQuery("copies=x:integer")
+	assertErrorIs(t, err, `"copies=x:integer": integer: invalid integer`)
+
+	_, err = FormatAttrQuery(Attributes{{Name: "empty"}})
+	assertErrorIs(t, err, "empty: attribute without value")
+}
+
+// TestAttrSpecFlag tests AttrSpecFlag
+func TestAttrSpecFlag(t *testing.T) {
+	var attrs AttrSpecFlag
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Var(&attrs, "a", "attribute")
+
+	err := fs.Parse([]string{"-a", "copies=2", "-a", "media=a,b",
+		"-a", "media=c:name"})
+	assertNoError(t, err)
+
+	expected := Attributes{
+		MakeAttribute("copies", TagInteger, Integer(2)),
+		{Name: "media", Values: Values{
+			{TagKeyword, String("a")},
+			{TagKeyword, String("b")},
+			{TagName, String("c")},
+		}},
+	}
+
+	if !Attributes(attrs).Equal(expected) {
+		t.Errorf("expected: %s\npresent:  %s", expected, Attributes(attrs))
+	}
+
+	s := attrs.String()
+	if s != "copies=2:integer" {
+		t.Errorf("String: %q", s)
+	}
+
+	err = fs.Parse([]string{"-a", "copies"})
+	assertWithError(t, err)
+}
@@ -0,0 +1,73 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Streaming writer of attribute values
+ */
+
+package goipp
+
+import (
+	"errors"
+	"io"
+)
+
+// AttributeWriter writes values of a single attribute directly
+// into the output stream, one by one, without building the
+// Values slice in memory.
+//
+// It is intended for attributes with very large number of values
+// (i.e., thousands of media-col-database entries). The first value
+// is written with the attribute name, and each additional value is
+// written as attribute without name, as RFC 8010 requires.
+//
+// AttributeWriter writes only the attribute itself. Framing of the
+// message (header, group tags and the end-of-attributes tag) is
+// the caller's responsibility.
+type AttributeWriter struct {
+	me    messageEncoder // Underlying encoder
+	name  string         // Attribute name
+	count int            // Count of written values
+	err   error          // Sticky error
+}
+
+// NewAttributeWriter creates a new AttributeWriter
+func NewAttributeWriter(out io.Writer, name string) *AttributeWriter {
+	return &AttributeWriter{
+		me:   messageEncoder{out: out},
+		name: name,
+	}
+}
+
+// Add writes the next value of the attribute.
+//
+// After the first error, all subsequent calls return the
+// same error.
+func (aw *AttributeWriter) Add(tag Tag, val Value) error {
+	if aw.err != nil {
+		return aw.err
+	}
+
+	attr := Attribute{Name: ""}
+	if aw.count == 0 {
+		if aw.name == "" {
+			aw.err = errors.New("Attribute without name")
+			return aw.err
+		}
+		attr.Name = aw.name
+	}
+
+	attr.Values.Add(tag, val)
+	aw.err = aw.me.encodeAttr(attr, true)
+	if aw.err == nil {
+		aw.count++
+	}
+
+	return aw.err
+}
+
+// Count returns count of values written so far
+func (aw *AttributeWriter) Count() int {
+	return aw.count
+}
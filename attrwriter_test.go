@@ -0,0 +1,126 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Large 1setOf attributes and AttributeWriter test
+ */
+
+package goipp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// testLargeSetOf creates attributes with thousands of values
+func testLargeSetOf() Attributes {
+	ints := Attribute{Name: "large-integers"}
+	for i := 0; i < 10000; i++ {
+		ints.Values.Add(TagInteger, Integer(i))
+	}
+
+	cols := Attribute{Name: "media-col-database"}
+	for i := 0; i < 2000; i++ {
+		cols.Values.Add(TagBeginCollection, Collection{
+			MakeAttrCollection("media-size",
+				MakeAttr("x-dimension", TagInteger, Integer(i)),
+				MakeAttr("y-dimension", TagInteger, Integer(i+1))),
+			MakeAttr("media-source", TagKeyword, String("auto")),
+		})
+	}
+
+	return Attributes{ints, cols}
+}
+
+// TestLargeSetOf tests encoding and decoding of attributes with
+// thousands of values
+func TestLargeSetOf(t *testing.T) {
+	m := NewResponse(DefaultVersion, StatusOk, 1)
+	m.Printer = testLargeSetOf()
+
+	data, err := m.EncodeBytes()
+	assertNoError(t, err)
+
+	var m2 Message
+	err = m2.DecodeBytes(data)
+	assertNoError(t, err)
+
+	if !m.Equal(m2) {
+		t.Errorf("Message not the same after encoding and decoding")
+	}
+
+	// Each additional value must be encoded as attribute without
+	// name. Count named integer attributes at the wire level:
+	// message header, group tag, then sequence of integer attributes
+	named := 0
+	wire := data[9:]
+	for i := 0; i < 10000; i++ {
+		if Tag(wire[0]) != TagInteger {
+			t.Fatalf("value %d: unexpected tag %s", i, Tag(wire[0]))
+		}
+
+		nameLen := int(binary.BigEndian.Uint16(wire[1:]))
+		if nameLen != 0 {
+			named++
+		}
+
+		wire = wire[1+2+nameLen+2+4:]
+	}
+
+	if named != 1 {
+		t.Errorf("%d named attributes, expected 1", named)
+	}
+}
+
+// TestAttributeWriter tests AttributeWriter
+func TestAttributeWriter(t *testing.T) {
+	attrs := testLargeSetOf()
+
+	// Encode the whole message
+	m := NewResponse(DefaultVersion, StatusOk, 1)
+	m.Printer = attrs
+
+	expected, err := m.EncodeBytes()
+	assertNoError(t, err)
+
+	// Encode message with empty printer group, strip TagEnd,
+	// and then stream attributes using AttributeWriter
+	m.Printer = Attributes{}
+	data, err := m.EncodeBytes()
+	assertNoError(t, err)
+
+	buf := bytes.NewBuffer(data[:len(data)-1])
+
+	for _, attr := range attrs {
+		aw := NewAttributeWriter(buf, attr.Name)
+		for _, v := range attr.Values {
+			err = aw.Add(v.T, v.V)
+			assertNoError(t, err)
+		}
+
+		if aw.Count() != len(attr.Values) {
+			t.Errorf("%s: Count() is %d, expected %d",
+				attr.Name, aw.Count(), len(attr.Values))
+		}
+	}
+
+	buf.WriteByte(byte(TagEnd))
+
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("AttributeWriter output differs from Encode")
+	}
+
+	// Errors are sticky
+	aw := NewAttributeWriter(buf, "attr")
+	err = aw.Add(TagText, Integer(1))
+	assertErrorIs(t, err, "Tag textWithoutLanguage: String value required")
+
+	err = aw.Add(TagText, String("ok"))
+	assertErrorIs(t, err, "Tag textWithoutLanguage: String value required")
+
+	aw = NewAttributeWriter(buf, "")
+	err = aw.Add(TagText, String("ok"))
+	assertErrorIs(t, err, "Attribute without name")
+}
@@ -0,0 +1,235 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Encoder and decoder benchmarks
+ */
+
+package goipp
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// Performance budget
+//
+// The following limits of allocations per decoded message are
+// enforced by TestAllocsBudget, to catch performance regressions.
+// The numbers reflect the current decoder, with some headroom;
+// improvements of the decoder should lower them.
+//
+// Run "make bench" to see the actual numbers.
+var benchBudget = []struct {
+	file   string         // File in testdata
+	opt    DecoderOptions // Decoder options
+	allocs float64        // Max allocations per decoded message
+}{
+	{"hp-officejet-pro-8730.ipp", DecoderOptions{}, 2800},
+	{"hp-officejet-pro-8730.ipp", DecoderOptions{Arena: true}, 1900},
+	{"pantum-m7300fdw.ipp", DecoderOptions{EnableWorkarounds: true}, 320},
+}
+
+// benchLoad loads captured message from testdata
+func benchLoad(tb testing.TB, file string) []byte {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", file))
+	if err != nil {
+		tb.Fatalf("%s", err)
+	}
+	return data
+}
+
+// benchDecode runs decoder benchmark
+func benchDecode(b *testing.B, data []byte, opt DecoderOptions) {
+	var m Message
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		err := m.DecodeBytesEx(data, opt)
+		if err != nil {
+			b.Fatalf("%s", err)
+		}
+	}
+}
+
+// benchEncode runs encoder benchmark
+func benchEncode(b *testing.B, m *Message) {
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, err := m.EncodeBytes()
+		if err != nil {
+			b.Fatalf("%s", err)
+		}
+	}
+}
+
+// BenchmarkDecodeHPOfficeJetPro8730 benchmarks decoding of
+// Get-Printer-Attributes response, captured from HP OfficeJet Pro 8730
+func BenchmarkDecodeHPOfficeJetPro8730(b *testing.B) {
+	benchDecode(b, benchLoad(b, "hp-officejet-pro-8730.ipp"),
+		DecoderOptions{})
+}
+
+// BenchmarkDecodeHPOfficeJetPro8730Arena benchmarks decoding of
+// Get-Printer-Attributes response, captured from HP OfficeJet Pro 8730,
+// with DecoderOptions.Arena enabled
+func BenchmarkDecodeHPOfficeJetPro8730Arena(b *testing.B) {
+	benchDecode(b, benchLoad(b, "hp-officejet-pro-8730.ipp"),
+		DecoderOptions{Arena: true})
+}
+
+// BenchmarkDecodeHPOfficeJetPro8730Bufio benchmarks decoding of
+// Get-Printer-Attributes response, captured from HP OfficeJet Pro 8730,
+// from the bufio.Reader
+func BenchmarkDecodeHPOfficeJetPro8730Bufio(b *testing.B) {
+	data := benchLoad(b, "hp-officejet-pro-8730.ipp")
+	in := bytes.NewReader(data)
+	br := bufio.NewReaderSize(in, 16384)
+	var m Message
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		in.Reset(data)
+		br.Reset(in)
+
+		err := NewDecoder(br, DecoderOptions{}).Decode(&m)
+		if err != nil {
+			b.Fatalf("%s", err)
+		}
+	}
+}
+
+// BenchmarkDecodePantumM7300FDW benchmarks decoding of
+// Get-Printer-Attributes response, captured from Pantum M7300FDW
+func BenchmarkDecodePantumM7300FDW(b *testing.B) {
+	benchDecode(b, benchLoad(b, "pantum-m7300fdw.ipp"),
+		DecoderOptions{EnableWorkarounds: true})
+}
+
+// BenchmarkDecodeLargeSetOf benchmarks decoding of the synthetic
+// message with thousands of values, like large media-col-database
+func BenchmarkDecodeLargeSetOf(b *testing.B) {
+	m := NewResponse(DefaultVersion, StatusOk, 1)
+	m.Printer = testLargeSetOf()
+
+	data, err := m.EncodeBytes()
+	if err != nil {
+		b.Fatalf("%s", err)
+	}
+
+	benchDecode(b, data, DecoderOptions{})
+}
+
+// BenchmarkDecodeLargeSetOfArena benchmarks decoding of the synthetic
+// message with thousands of values, with DecoderOptions.Arena enabled
+func BenchmarkDecodeLargeSetOfArena(b *testing.B) {
+	m := NewResponse(DefaultVersion, StatusOk, 1)
+	m.Printer = testLargeSetOf()
+
+	data, err := m.EncodeBytes()
+	if err != nil {
+		b.Fatalf("%s", err)
+	}
+
+	benchDecode(b, data, DecoderOptions{Arena: true})
+}
+
+// BenchmarkDecodeLargeSetOfNames benchmarks decoding of the synthetic
+// message with thousands of values, with shared NameTable
+func BenchmarkDecodeLargeSetOfNames(b *testing.B) {
+	m := NewResponse(DefaultVersion, StatusOk, 1)
+	m.Printer = testLargeSetOf()
+
+	data, err := m.EncodeBytes()
+	if err != nil {
+		b.Fatalf("%s", err)
+	}
+
+	benchDecode(b, data, DecoderOptions{Names: NewNameTable(0)})
+}
+
+// BenchmarkEncodeHPOfficeJetPro8730 benchmarks encoding of
+// Get-Printer-Attributes response, captured from HP OfficeJet Pro 8730
+func BenchmarkEncodeHPOfficeJetPro8730(b *testing.B) {
+	var m Message
+	err := m.DecodeBytes(benchLoad(b, "hp-officejet-pro-8730.ipp"))
+	if err != nil {
+		b.Fatalf("%s", err)
+	}
+
+	benchEncode(b, &m)
+}
+
+// BenchmarkValueEqual benchmarks ValueEqual over the values of
+// Get-Printer-Attributes response, captured from HP OfficeJet Pro 8730
+func BenchmarkValueEqual(b *testing.B) {
+	var m1, m2 Message
+	data := benchLoad(b, "hp-officejet-pro-8730.ipp")
+	if err := m1.DecodeBytes(data); err != nil {
+		b.Fatalf("%s", err)
+	}
+	if err := m2.DecodeBytes(data); err != nil {
+		b.Fatalf("%s", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if !m1.Printer.Equal(m2.Printer) {
+			b.Fatalf("messages are not equal")
+		}
+	}
+}
+
+// BenchmarkAttributeEqualLargeSetOf benchmarks comparison of
+// attributes with large 1setOf values, that differ in the last value
+func BenchmarkAttributeEqualLargeSetOf(b *testing.B) {
+	a1 := Attribute{Name: "job-ids"}
+	a2 := Attribute{Name: "job-ids"}
+	for i := 0; i < 10000; i++ {
+		a1.Values.Add(TagInteger, Integer(i))
+		a2.Values.Add(TagInteger, Integer(i))
+	}
+	a2.Values[len(a2.Values)-1].V = Integer(-1)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if a1.EqualTo(&a2) {
+			b.Fatalf("attributes are equal")
+		}
+	}
+}
+
+// TestAllocsBudget checks that decoder doesn't exceed the
+// performance budget
+func TestAllocsBudget(t *testing.T) {
+	for _, budget := range benchBudget {
+		data := benchLoad(t, budget.file)
+
+		var m Message
+		allocs := testing.AllocsPerRun(10, func() {
+			m.DecodeBytesEx(data, budget.opt)
+		})
+
+		t.Logf("%s: %v allocs", budget.file, allocs)
+		if allocs > budget.allocs {
+			t.Errorf("%s: %v allocs per decode, budget is %v",
+				budget.file, allocs, budget.allocs)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Cache of printer capabilities
+ */
+
+package goipp
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultCapabilityTTL is the default time to live of cached
+// PrinterCapabilities
+const DefaultCapabilityTTL = 5 * time.Minute
+
+// DefaultCapabilityFetchTimeout is the default timeout of fetching
+// PrinterCapabilities
+const DefaultCapabilityFetchTimeout = 30 * time.Second
+
+// PrinterCapabilities contains printer attributes, returned by
+// the Get-Printer-Attributes request.
+type PrinterCapabilities struct {
+	URI     string     // Printer URI
+	Attrs   Attributes // Printer attributes
+	Fetched time.Time  // Time of fetching
+}
+
+// Attr returns printer attribute by name
+func (caps *PrinterCapabilities) Attr(name string) (Attribute, bool) {
+	return attrsFind(caps.Attrs, name)
+}
+
+// Supports reports whether printer supports the operation,
+// according to the operations-supported attribute.
+func (caps *PrinterCapabilities) Supports(op Op) bool {
+	attr, _ := caps.Attr("operations-supported")
+	for _, val := range attr.Values {
+		if v, ok := val.V.(Integer); ok && Op(v) == op {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CapabilityCache is the cache of PrinterCapabilities, keyed by
+// printer URI. It is safe for concurrent use.
+//
+// When capabilities are missed or expired, they are fetched using
+// the Get-Printer-Attributes request. Concurrent requests for
+// the same URI share a single fetch.
+//
+// Errors are not cached: if fetch fails, the error is returned to
+// all callers, waiting for this fetch, and the next call retries.
+type CapabilityCache struct {
+	// Client is used to fetch capabilities. If nil, the zero
+	// Client is used.
+	Client *Client
+
+	// TTL is the time to live of cached capabilities. If zero,
+	// DefaultCapabilityTTL is used.
+	TTL time.Duration
+
+	// FetchTimeout limits duration of the fetch. If zero,
+	// DefaultCapabilityFetchTimeout is used.
+	FetchTimeout time.Duration
+
+	// RequestedAttributes are sent with Get-Printer-Attributes
+	// request. If nil, "all" is requested.
+	RequestedAttributes []string
+
+	lock    sync.Mutex                      // Access lock
+	entries map[string]*PrinterCapabilities // Cached capabilities
+	calls   map[string]*capabilityCall      // Fetches in progress
+	gen     uint64                          // Bumped by Invalidate
+}
+
+// capabilityCall represents the fetch in progress
+type capabilityCall struct {
+	done chan struct{}        // Closed when fetch is completed
+	gen  uint64               // cache.gen when fetch was started
+	caps *PrinterCapabilities // Fetched capabilities
+	err  error                // Fetch error
+}
+
+// Get returns capabilities of the printer, either cached or fetched.
+//
+// If fetch is already in progress, Get waits for its completion
+// or for ctx expiration.
+//
+// The fetch is shared between callers, so it doesn't use ctx of
+// any of them: cancellation of one caller doesn't affect others,
+// and the fetch continues in background, even if all callers gave
+// up, so its result is cached. Its duration is limited by the
+// FetchTimeout instead.
+func (cache *CapabilityCache) Get(ctx context.Context,
+	uri string) (*PrinterCapabilities, error) {
+
+	cache.lock.Lock()
+
+	if caps := cache.entries[uri]; caps != nil &&
+		time.Since(caps.Fetched) < cache.ttl() {
+		cache.lock.Unlock()
+		return caps, nil
+	}
+
+	call := cache.calls[uri]
+	if call == nil {
+		call = &capabilityCall{
+			done: make(chan struct{}),
+			gen:  cache.gen,
+		}
+		if cache.calls == nil {
+			cache.calls = make(map[string]*capabilityCall)
+		}
+		cache.calls[uri] = call
+
+		go cache.fetch(uri, call)
+	}
+
+	cache.lock.Unlock()
+
+	select {
+	case <-call.done:
+		return call.caps, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Invalidate removes capabilities of the printer from the cache.
+//
+// Fetches, already in progress, still complete for callers waiting
+// for them, but their results are not cached, as they may be stale.
+// The next Get starts a new fetch.
+func (cache *CapabilityCache) Invalidate(uri string) {
+	cache.lock.Lock()
+	cache.gen++
+	delete(cache.entries, uri)
+	delete(cache.calls, uri)
+	cache.lock.Unlock()
+}
+
+// ttl returns the effective TTL
+func (cache *CapabilityCache) ttl() time.Duration {
+	if cache.TTL != 0 {
+		return cache.TTL
+	}
+	return DefaultCapabilityTTL
+}
+
+// fetch fetches capabilities and completes the call
+func (cache *CapabilityCache) fetch(uri string, call *capabilityCall) {
+	timeout := cache.FetchTimeout
+	if timeout == 0 {
+		timeout = DefaultCapabilityFetchTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	call.caps, call.err = cache.request(ctx, uri)
+	cancel()
+
+	cache.lock.Lock()
+	if cache.calls[uri] == call {
+		delete(cache.calls, uri)
+	}
+	if call.err == nil && call.gen == cache.gen {
+		cache.store(call.caps)
+	}
+	cache.lock.Unlock()
+
+	close(call.done)
+}
+
+// store adds capabilities to the cache and prunes expired entries,
+// so the cache doesn't grow with every URI it has ever seen.
+// It must be called under the cache.lock.
+func (cache *CapabilityCache) store(caps *PrinterCapabilities) {
+	if cache.entries == nil {
+		cache.entries = make(map[string]*PrinterCapabilities)
+	}
+
+	ttl := cache.ttl()
+	for uri, old := range cache.entries {
+		if time.Since(old.Fetched) >= ttl {
+			delete(cache.entries, uri)
+		}
+	}
+
+	cache.entries[caps.URI] = caps
+}
+
+// request performs the Get-Printer-Attributes request
+func (cache *CapabilityCache) request(ctx context.Context,
+	uri string) (*PrinterCapabilities, error) {
+
+	clnt := cache.Client
+	if clnt == nil {
+		clnt = &Client{}
+	}
+
+	requested := Attribute{Name: "requested-attributes"}
+	if cache.RequestedAttributes == nil {
+		requested.Values.Add(TagKeyword, String("all"))
+	}
+	for _, name := range cache.RequestedAttributes {
+		requested.Values.Add(TagKeyword, String(name))
+	}
+
+	req := NewRequest(DefaultVersion, OpGetPrinterAttributes, 1)
+	req.Operation.Add(MakeAttribute("printer-uri", TagURI, String(uri)))
+	req.Operation.Add(requested)
+	FillDefaults(req)
+
+	rsp, err := clnt.Do(ctx, uri, req, nil)
+	if err == nil {
+		err = AsResponse(rsp).Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	caps := &PrinterCapabilities{URI: uri, Fetched: time.Now()}
+	for _, grp := range rsp.attrGroups() {
+		if grp.Tag == TagPrinterGroup {
+			caps.Attrs = append(caps.Attrs, grp.Attrs...)
+		}
+	}
+
+	return caps, nil
+}
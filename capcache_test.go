@@ -0,0 +1,183 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Cache of printer capabilities test
+ */
+
+package goipp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCapabilityCache tests CapabilityCache
+func TestCapabilityCache(t *testing.T) {
+	var count int32
+
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&count, 1)
+			time.Sleep(50 * time.Millisecond)
+
+			var req Message
+			err := req.Decode(r.Body)
+			assertNoError(t, err)
+
+			status := StatusOk
+			if r.URL.Path == "/missed" {
+				status = StatusErrorNotFound
+			}
+
+			rsp := NewResponse(DefaultVersion, status, req.RequestID)
+			rsp.Printer.Add(MakeAttr("printer-make-and-model",
+				TagText, String("Test Printer")))
+			rsp.Printer.Add(MakeAttr("operations-supported",
+				TagEnum, Integer(OpPrintJob),
+				Integer(OpGetPrinterAttributes)))
+
+			data, _ := rsp.EncodeBytes()
+			w.Header().Set("Content-Type", ContentType)
+			w.Write(data)
+		}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	cache := &CapabilityCache{}
+
+	// Concurrent requests share a single fetch
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			caps, err := cache.Get(ctx, srv.URL)
+			assertNoError(t, err)
+			if caps != nil && !caps.Supports(OpPrintJob) {
+				t.Errorf("Print-Job: not supported")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&count); n != 1 {
+		t.Errorf("%d requests sent, expected 1", n)
+	}
+
+	caps, err := cache.Get(ctx, srv.URL)
+	assertNoError(t, err)
+
+	if n := atomic.LoadInt32(&count); n != 1 {
+		t.Errorf("%d requests sent, expected 1", n)
+	}
+
+	attr, _ := caps.Attr("printer-make-and-model")
+	if len(attr.Values) != 1 ||
+		attr.Values[0].V.String() != "Test Printer" {
+		t.Errorf("printer-make-and-model: %s", attr.Values)
+	}
+
+	if caps.Supports(OpCancelJob) {
+		t.Errorf("Cancel-Job: unexpectedly supported")
+	}
+
+	// Invalidate forces the next fetch
+	cache.Invalidate(srv.URL)
+	_, err = cache.Get(ctx, srv.URL)
+	assertNoError(t, err)
+
+	if n := atomic.LoadInt32(&count); n != 2 {
+		t.Errorf("%d requests sent, expected 2", n)
+	}
+
+	// Expired entries are fetched again
+	cache.TTL = time.Nanosecond
+	_, err = cache.Get(ctx, srv.URL)
+	assertNoError(t, err)
+
+	if n := atomic.LoadInt32(&count); n != 3 {
+		t.Errorf("%d requests sent, expected 3", n)
+	}
+
+	// Errors are returned and not cached
+	for i := 0; i < 2; i++ {
+		_, err = cache.Get(ctx, srv.URL+"/missed")
+		assertErrorIs(t, err, "client-error-not-found")
+	}
+
+	if n := atomic.LoadInt32(&count); n != 5 {
+		t.Errorf("%d requests sent, expected 5", n)
+	}
+
+	// Get respects ctx of the waiting caller
+	ctx2, cancel := context.WithTimeout(ctx, time.Millisecond)
+	defer cancel()
+
+	_, err = cache.Get(ctx2, srv.URL+"/other")
+	assertErrorIs(t, err, context.DeadlineExceeded.Error())
+
+	// Cancellation of the caller, that started the fetch, doesn't
+	// affect other callers
+	ctx3, cancel3 := context.WithCancel(ctx)
+	errs := make(chan error)
+	go func() {
+		_, err := cache.Get(ctx3, srv.URL+"/shared")
+		errs <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	go func() {
+		_, err := cache.Get(ctx, srv.URL+"/shared")
+		errs <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel3()
+
+	assertErrorIs(t, <-errs, context.Canceled.Error())
+	assertNoError(t, <-errs)
+
+	// Expired entries are pruned on insert
+	cache.lock.Lock()
+	n := len(cache.entries)
+	cache.lock.Unlock()
+
+	if n != 1 {
+		t.Errorf("%d entries cached, expected 1", n)
+	}
+
+	// Result of the fetch, started before Invalidate, is not cached
+	cache.TTL = 0
+	atomic.StoreInt32(&count, 0)
+
+	done := make(chan struct{})
+	go func() {
+		cache.Get(ctx, srv.URL+"/invalidated")
+		close(done)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cache.Invalidate(srv.URL + "/invalidated")
+	<-done
+
+	_, err = cache.Get(ctx, srv.URL+"/invalidated")
+	assertNoError(t, err)
+
+	if n := atomic.LoadInt32(&count); n != 2 {
+		t.Errorf("%d requests sent, expected 2", n)
+	}
+
+	// FetchTimeout limits the fetch
+	cache.FetchTimeout = time.Millisecond
+	_, err = cache.Get(ctx, srv.URL+"/slow")
+	if err == nil {
+		t.Errorf("FetchTimeout: error expected")
+	}
+}
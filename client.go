@@ -0,0 +1,281 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Minimal IPP client over HTTP
+ */
+
+package goipp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Client is the minimal IPP client, that sends IPP requests
+// over HTTP and receives responses.
+//
+// It doesn't implement any high-level operations, its purpose
+// is only to transfer messages.
+type Client struct {
+	// HTTPClient is the HTTP client, used for requests.
+	// If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	// Dial, if not nil, is used to establish connections instead
+	// of the default dialer (i.e., to connect via unix-domain socket
+	// or SOCKS proxy). URIs, passed to the Client, still refer to
+	// the logical printer, and only the transport is affected.
+	//
+	// Dial is used only if HTTPClient is nil. See also UnixDialer.
+	Dial func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// NoExpectContinue disables sending of the "Expect: 100-continue"
+	// HTTP header with requests, that have document payload.
+	//
+	// With this header, the printer may reject the request (i.e.,
+	// because of authentication or size limits) before the document
+	// is transmitted. Note, the HTTP transport waits for the
+	// "100 Continue" response only if its ExpectContinueTimeout is
+	// not zero (http.DefaultTransport uses 1 second).
+	NoExpectContinue bool
+
+	// Quirks, if not nil, enables automatic workarounds for
+	// known-buggy devices. Quirks are learned from responses,
+	// containing printer-make-and-model (i.e., Get-Printer-Attributes),
+	// and applied to subsequent requests to the same URI and
+	// their responses.
+	Quirks QuirksDB
+
+	lock       sync.Mutex        // Access lock
+	quirks     map[string]Quirks // Learned quirks, by URI
+	dialClient *http.Client      // HTTP client, that uses Dial
+}
+
+// CUPSDomainSocket is the default path of the CUPS unix-domain
+// socket on Linux systems
+const CUPSDomainSocket = "/run/cups/cups.sock"
+
+// UnixDialer returns the dial function for Client.Dial, that
+// connects to the unix-domain socket at the specified path,
+// regardless of the requested address.
+func UnixDialer(path string) func(ctx context.Context,
+	network, addr string) (net.Conn, error) {
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, "unix", path)
+	}
+}
+
+// NewClient creates a new Client
+func NewClient(httpClient *http.Client) *Client {
+	return &Client{HTTPClient: httpClient}
+}
+
+// Do sends the request to the printer and returns its response.
+//
+// The uri may use "ipp", "ipps", "http" or "https" scheme. The
+// optional doc, if not nil, is sent after the request, as document
+// payload. If printer responds before the request body is sent
+// (see Client.NoExpectContinue), doc is not consumed.
+func (c *Client) Do(ctx context.Context, uri string,
+	req *Message, doc io.Reader) (*Message, error) {
+
+	body, err := c.post(ctx, uri, req, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	defer body.Close()
+
+	rsp := &Message{Role: MessageRoleResponse}
+	quirks, known := c.lookupQuirks(uri)
+
+	if c.Quirks != nil && !known {
+		// Quirks of the device are not known yet. If response
+		// cannot be decoded, retry with decoder workarounds of
+		// all known quirks.
+		var data []byte
+		data, err = ioutil.ReadAll(body)
+		if err == nil {
+			err = rsp.DecodeBytes(data)
+		}
+		if err != nil && data != nil {
+			opt := Quirks(c.Quirks).DecoderOptions(DecoderOptions{})
+			if rsp.DecodeBytesEx(data, opt) == nil {
+				err = nil
+			}
+		}
+	} else {
+		// The rest of body is discarded anyway, so read-ahead
+		// is safe here
+		opt := quirks.DecoderOptions(DecoderOptions{ReadAhead: true})
+		err = rsp.DecodeEx(body, opt)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.learnQuirks(uri, rsp)
+
+	// Drain remaining data, so HTTP connection may be reused
+	io.Copy(ioutil.Discard, body)
+
+	return rsp, nil
+}
+
+// post sends the request and returns HTTP response body
+func (c *Client) post(ctx context.Context, uri string,
+	req *Message, doc io.Reader) (io.ReadCloser, error) {
+
+	httpURL, err := HTTPURL(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	quirks, _ := c.lookupQuirks(uri)
+	data, err := req.EncodeBytesEx(quirks.EncoderOptions(EncoderOptions{}))
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader = bytes.NewReader(data)
+	if doc != nil {
+		body = io.MultiReader(body, doc)
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, httpURL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq = httpReq.WithContext(ctx)
+	httpReq.Header.Set("Content-Type", ContentType)
+	httpReq.Header.Set("Accept", ContentType)
+	if doc == nil {
+		httpReq.ContentLength = int64(len(data))
+	} else if !c.NoExpectContinue {
+		httpReq.Header.Set("Expect", "100-continue")
+	}
+
+	httpRsp, err := c.httpClient().Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if httpRsp.StatusCode/100 != 2 {
+		httpRsp.Body.Close()
+		return nil, fmt.Errorf("HTTP: %s", httpRsp.Status)
+	}
+
+	return httpRsp.Body, nil
+}
+
+// lookupQuirks returns quirks, learned for the URI, and
+// reports whether quirks of the URI are known
+func (c *Client) lookupQuirks(uri string) (Quirks, bool) {
+	if c.Quirks == nil {
+		return nil, false
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	quirks, known := c.quirks[uri]
+	return quirks, known
+}
+
+// learnQuirks learns quirks of the device from its response
+func (c *Client) learnQuirks(uri string, rsp *Message) {
+	if c.Quirks == nil {
+		return
+	}
+
+	for _, grp := range rsp.attrGroups() {
+		if grp.GroupTag() != GroupTagPrinter {
+			continue
+		}
+
+		if _, found := attrsFind(grp.Attrs,
+			"printer-make-and-model"); found {
+
+			quirks := c.Quirks.LookupPrinter(grp.Attrs)
+
+			c.lock.Lock()
+			if c.quirks == nil {
+				c.quirks = make(map[string]Quirks)
+			}
+			c.quirks[uri] = quirks
+			c.lock.Unlock()
+		}
+
+		return
+	}
+}
+
+// httpClient returns HTTP client to be used
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+
+	if c.Dial != nil {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		if c.dialClient == nil {
+			c.dialClient = &http.Client{
+				Transport: &http.Transport{
+					DialContext:           c.Dial,
+					MaxIdleConnsPerHost:   4,
+					IdleConnTimeout:       90 * time.Second,
+					ExpectContinueTimeout: 1 * time.Second,
+				},
+			}
+		}
+
+		return c.dialClient
+	}
+
+	return http.DefaultClient
+}
+
+// HTTPURL converts IPP URI into HTTP URL.
+//
+// The "ipp" scheme is converted into "http", "ipps" into "https",
+// and port 631 is used by default for both of them, as
+// defined by RFC 3510 and RFC 7472. "http" and "https" URLs are
+// returned unchanged.
+func HTTPURL(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+
+	switch u.Scheme {
+	case "ipp":
+		u.Scheme = "http"
+	case "ipps":
+		u.Scheme = "https"
+	case "http", "https":
+		return uri, nil
+	default:
+		return "", fmt.Errorf("%s: unsupported URI scheme", uri)
+	}
+
+	if u.Port() == "" {
+		u.Host += ":631"
+	}
+
+	return u.String(), nil
+}
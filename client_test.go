@@ -0,0 +1,132 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Minimal IPP client test
+ */
+
+package goipp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// countingReader counts bytes, read from the underlying io.Reader
+type countingReader struct {
+	r   io.Reader
+	cnt int
+}
+
+func (cr *countingReader) Read(buf []byte) (int, error) {
+	n, err := cr.r.Read(buf)
+	cr.cnt += n
+	return n, err
+}
+
+// TestClientExpectContinue tests Expect: 100-continue handling
+func TestClientExpectContinue(t *testing.T) {
+	const maxSize = 1024
+
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", ContentType)
+
+			status := StatusOk
+			if r.ContentLength < 0 &&
+				r.Header.Get("Expect") == "100-continue" {
+				// Reject before reading the body
+				status = StatusErrorRequestEntity
+			} else {
+				var req Message
+				err := req.Decode(r.Body)
+				assertNoError(t, err)
+				io.Copy(ioutil.Discard, r.Body)
+			}
+
+			data, _ := NewResponse(DefaultVersion, status, 1).EncodeBytes()
+			w.Write(data)
+		}))
+	defer srv.Close()
+
+	req := NewRequest(DefaultVersion, OpPrintJob, 1)
+
+	for _, noExpect := range []bool{false, true} {
+		doc := &countingReader{r: bytes.NewReader(make([]byte, 1<<20))}
+		clnt := &Client{NoExpectContinue: noExpect}
+
+		rsp, err := clnt.Do(context.Background(), srv.URL, req, doc)
+		assertNoError(t, err)
+
+		switch {
+		case !noExpect && Status(rsp.Code) != StatusErrorRequestEntity:
+			t.Errorf("Expect: status %s, expected %s",
+				Status(rsp.Code), StatusErrorRequestEntity)
+
+		case !noExpect && doc.cnt > maxSize:
+			t.Errorf("Expect: %d bytes of document sent", doc.cnt)
+
+		case noExpect && Status(rsp.Code) != StatusOk:
+			t.Errorf("NoExpectContinue: status %s, expected %s",
+				Status(rsp.Code), StatusOk)
+
+		case noExpect && doc.cnt != 1<<20:
+			t.Errorf("NoExpectContinue: %d bytes of document sent",
+				doc.cnt)
+		}
+	}
+}
+
+// TestClientUnixSocket tests Client.Dial with UnixDialer
+func TestClientUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goipp")
+	assertNoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cups.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("unix sockets not available: %s", err)
+	}
+
+	var printerURI string
+	srv := &http.Server{Handler: http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var req Message
+			err := req.Decode(r.Body)
+			assertNoError(t, err)
+
+			printerURI = req.Operation[0].Values[0].V.String()
+
+			w.Header().Set("Content-Type", ContentType)
+			data, _ := NewResponse(DefaultVersion, StatusOk, 1).EncodeBytes()
+			w.Write(data)
+		})}
+	go srv.Serve(l)
+	defer srv.Close()
+
+	uri := "ipp://localhost/printers/test"
+	req := NewRequest(DefaultVersion, OpGetPrinterAttributes, 1)
+	req.Operation.Add(MakeAttribute("printer-uri", TagURI, String(uri)))
+
+	clnt := &Client{Dial: UnixDialer(path)}
+	rsp, err := clnt.Do(context.Background(), uri, req, nil)
+	assertNoError(t, err)
+
+	if Status(rsp.Code) != StatusOk {
+		t.Errorf("Status: %s", Status(rsp.Code))
+	}
+
+	if printerURI != uri {
+		t.Errorf("printer-uri: %q, expected %q", printerURI, uri)
+	}
+}
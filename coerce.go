@@ -0,0 +1,173 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Automatic correction of tag/type mismatches
+ */
+
+package goipp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CoerceValue converts value into the type, required by the tag,
+// if mismatch is obvious. It returns the converted value and true,
+// or the original value and false, if conversion is not needed or
+// not possible.
+//
+// The following conversions are performed:
+//   - String to Binary, for octetString and other binary tags
+//   - Binary to String, for text, name, keyword and other
+//     string tags
+//   - String with decimal number to Integer, for integer and
+//     enum tags
+//   - Name, Keyword, MimeType and URI to String, when used with
+//     tag other than their own
+//
+// It is used by encoder with EncoderOptions.Coerce.
+func CoerceValue(tag Tag, v Value) (Value, bool) {
+	tagType := tag.Type()
+	if tagType == TypeVoid {
+		return v, false
+	}
+
+	switch v := v.(type) {
+	case String:
+		switch tagType {
+		case TypeBinary:
+			return Binary(v), true
+		case TypeInteger:
+			n, err := strconv.ParseInt(strings.TrimSpace(string(v)),
+				10, 32)
+			if err == nil {
+				return Integer(n), true
+			}
+		}
+
+	case Binary:
+		if tagType == TypeString {
+			return String(v), true
+		}
+
+	case Name:
+		if tag != TagName && tagType == TypeString {
+			return String(v), true
+		}
+
+	case Keyword:
+		if tag != TagKeyword && tagType == TypeString {
+			return String(v), true
+		}
+
+	case MimeType:
+		if tag != TagMimeType && tagType == TypeString {
+			return String(v), true
+		}
+
+	case URI:
+		if tag != TagURI && tagType == TypeString {
+			return String(v.String()), true
+		}
+	}
+
+	return v, false
+}
+
+// coerceHandler applies EncoderOptions.Coerce to attributes
+type coerceHandler struct {
+	opt *EncoderOptions // Encoder options
+}
+
+// apply applies coercion to the message and returns its shallow
+// copy, if something has changed, or message itself
+func (h coerceHandler) apply(m *Message) *Message {
+	groups := m.attrGroups()
+	groups2 := make(Groups, len(groups))
+	changed := false
+
+	for i, grp := range groups {
+		attrs, chg := h.attrs(grp.Attrs, MakePath(grp.Tag))
+		groups2[i] = MakeGroup(grp.GroupTag(), attrs)
+		changed = changed || chg
+	}
+
+	if !changed {
+		return m
+	}
+
+	m2 := *m
+	m2.Groups = groups2
+
+	return &m2
+}
+
+// attrs applies coercion to attributes. Attributes are copied
+// only if something has changed, and the changed flag is returned.
+func (h coerceHandler) attrs(attrs Attributes, path Path) (
+	attrs2 Attributes, changed bool) {
+
+	attrs2 = attrs
+
+	for i, attr := range attrs {
+		values, chg := h.values(attr.Values, path.Append(attr.Name))
+		if chg {
+			if !changed {
+				attrs2 = attrs.Clone()
+				changed = true
+			}
+			attrs2[i].Values = values
+		}
+	}
+
+	return
+}
+
+// values applies coercion to values of the attribute, addressed
+// by path. Values are copied only if something has changed.
+func (h coerceHandler) values(values Values, path Path) (
+	values2 Values, changed bool) {
+
+	values2 = values
+
+	for i, val := range values {
+		v := val.V
+
+		if col, ok := v.(Collection); ok {
+			col2, chg := h.attrs(Attributes(col), path)
+			if !chg {
+				continue
+			}
+			v = Collection(col2)
+		} else {
+			v2, chg := CoerceValue(val.T, v)
+			if !chg {
+				continue
+			}
+
+			h.warning(fmt.Errorf("%s: %s value coerced to %s",
+				path.WithIndex(i), v.Type(), val.T))
+			v = v2
+		}
+
+		if !changed {
+			values2 = make(Values, len(values))
+			copy(values2, values)
+			changed = true
+		}
+
+		values2[i].V = v
+	}
+
+	return
+}
+
+// warning reports a warning via EncoderOptions.Warning callback
+func (h coerceHandler) warning(err error) {
+	if h.opt.Warning != nil {
+		h.opt.Warning(err)
+	}
+}
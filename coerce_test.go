@@ -0,0 +1,123 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Automatic correction of tag/type mismatches test
+ */
+
+package goipp
+
+import (
+	"net/url"
+	"testing"
+)
+
+// TestCoerceValue tests CoerceValue
+func TestCoerceValue(t *testing.T) {
+	u, _ := url.Parse("ipp://localhost/")
+
+	type testData struct {
+		tag     Tag   // Value tag
+		v       Value // Input value
+		out     Value // Expected output
+		coerced bool  // Expected coercion
+	}
+
+	tests := []testData{
+		{TagString, String("abc"), Binary("abc"), true},
+		{TagText, Binary("abc"), String("abc"), true},
+		{TagKeyword, Binary("abc"), String("abc"), true},
+		{TagEnum, String("3"), Integer(3), true},
+		{TagInteger, String(" -5 "), Integer(-5), true},
+		{TagInteger, String("five"), String("five"), false},
+		{TagInteger, String("9999999999"), String("9999999999"), false},
+		{TagText, Name("abc"), String("abc"), true},
+		{TagName, Keyword("abc"), String("abc"), true},
+		{TagText, MimeType("text/plain"), String("text/plain"), true},
+		{TagText, URI{u}, String("ipp://localhost/"), true},
+		{TagName, Name("abc"), Name("abc"), false},
+		{TagURI, URI{u}, URI{u}, false},
+		{TagText, String("abc"), String("abc"), false},
+		{TagBoolean, String("true"), String("true"), false},
+		{TagNoValue, String("abc"), String("abc"), false},
+	}
+
+	for _, test := range tests {
+		out, coerced := CoerceValue(test.tag, test.v)
+		if coerced != test.coerced || !ValueEqual(out, test.out) {
+			t.Errorf("%s %T(%s): expected %T(%s) %v, present %T(%s) %v",
+				test.tag, test.v, test.v,
+				test.out, test.out, test.coerced,
+				out, out, coerced)
+		}
+	}
+}
+
+// TestEncodeCoerce tests EncoderOptions.Coerce
+func TestEncodeCoerce(t *testing.T) {
+	m := NewResponse(DefaultVersion, StatusOk, 1)
+	m.Printer.Add(MakeAttr("printer-state", TagEnum, String("3")))
+	m.Printer.Add(MakeAttr("printer-alert", TagString,
+		String("code=other")))
+	m.Printer.Add(MakeAttrCollection("media-col-default",
+		MakeAttr("media-type", TagKeyword, Binary("stationery"))))
+
+	// Without Coerce, encoding fails
+	_, err := m.EncodeBytes()
+	assertErrorIs(t, err, "Tag enum: Integer value required, String present")
+
+	// With Coerce, it succeeds with warnings
+	var warnings []string
+	opt := EncoderOptions{
+		Coerce: true,
+		Warning: func(err error) {
+			warnings = append(warnings, err.Error())
+		},
+	}
+
+	data, err := m.EncodeBytesEx(opt)
+	assertNoError(t, err)
+
+	expectedWarnings := []string{
+		"printer-attributes-tag/printer-state[0]: " +
+			"String value coerced to enum",
+		"printer-attributes-tag/printer-alert[0]: " +
+			"String value coerced to octetString",
+		"printer-attributes-tag/media-col-default/media-type[0]: " +
+			"Binary value coerced to keyword",
+	}
+
+	if len(warnings) != len(expectedWarnings) {
+		t.Fatalf("warnings: expected %q, present %q",
+			expectedWarnings, warnings)
+	}
+
+	for i := range warnings {
+		if warnings[i] != expectedWarnings[i] {
+			t.Errorf("warning %d: expected %q, present %q",
+				i, expectedWarnings[i], warnings[i])
+		}
+	}
+
+	// Check decoded message
+	var m2 Message
+	err = m2.DecodeBytes(data)
+	assertNoError(t, err)
+
+	expected := NewResponse(DefaultVersion, StatusOk, 1)
+	expected.Printer.Add(MakeAttr("printer-state", TagEnum, Integer(3)))
+	expected.Printer.Add(MakeAttr("printer-alert", TagString,
+		Binary("code=other")))
+	expected.Printer.Add(MakeAttrCollection("media-col-default",
+		MakeAttr("media-type", TagKeyword, String("stationery"))))
+
+	if !m2.Similar(*expected) {
+		t.Errorf("decoded message mismatch:\n%s", ExplainDiff(*expected, m2))
+	}
+
+	// Original message is not modified
+	if _, ok := m.Printer[0].Values[0].V.(String); !ok {
+		t.Errorf("original message modified")
+	}
+}
@@ -0,0 +1,131 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Corpus of real device captures test
+ */
+
+package goipp
+
+import (
+	"bytes"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// corpusEntry describes the real device capture in testdata
+type corpusEntry struct {
+	file   string         // File in testdata
+	device string         // Device, the capture comes from
+	opt    DecoderOptions // Decoder options, required by capture
+
+	// exact is true if capture is encoded canonically, so
+	// re-encoded message must be byte-to-byte equal to it
+	exact bool
+}
+
+// corpus lists all captures in testdata. Every *.ipp file in
+// testdata must be listed here, and TestCorpus checks it.
+//
+// Captures must come from real devices and must be sanitized
+// (serial numbers, UUIDs, network addresses and so on) before
+// adding.
+//
+// The corpus doesn't have Epson, Kyocera and CUPS captures yet.
+// Synthetic data must not be added instead of them.
+var corpus = []corpusEntry{
+	{
+		file:   "hp-officejet-pro-8730.ipp",
+		device: "HP OfficeJet Pro 8730",
+		exact:  true,
+	},
+	{
+		file:   "pantum-m7300fdw.ipp",
+		device: "Pantum M7300FDW",
+		opt:    DecoderOptions{EnableWorkarounds: true},
+	},
+}
+
+// corpusLoad loads all captures from the corpus
+func corpusLoad(tb testing.TB) map[string][]byte {
+	captures := make(map[string][]byte)
+	for _, entry := range corpus {
+		captures[entry.file] = benchLoad(tb, entry.file)
+	}
+	return captures
+}
+
+// TestCorpus decodes each capture in the corpus, re-encodes it
+// and checks that re-encoded message decodes into the same message
+func TestCorpus(t *testing.T) {
+	// Check that corpus lists all captures
+	files, err := filepath.Glob(filepath.Join("testdata", "*.ipp"))
+	assertNoError(t, err)
+
+	var listed, present []string
+	for _, entry := range corpus {
+		listed = append(listed, entry.file)
+	}
+	for _, file := range files {
+		present = append(present, filepath.Base(file))
+	}
+
+	sort.Strings(listed)
+	sort.Strings(present)
+	if strings.Join(listed, " ") != strings.Join(present, " ") {
+		t.Errorf("corpus out of sync with testdata:\n"+
+			"listed:  %s\npresent: %s", listed, present)
+	}
+
+	captures := corpusLoad(t)
+
+	for _, entry := range corpus {
+		data := captures[entry.file]
+
+		var m1 Message
+		err := m1.DecodeBytesEx(data, entry.opt)
+		if err != nil {
+			t.Errorf("%s: decode: %s", entry.device, err)
+			continue
+		}
+
+		// Captures, decoded without workarounds, must be valid UTF-8
+		if !entry.opt.EnableWorkarounds {
+			var m Message
+			err = m.DecodeBytesEx(data, DecoderOptions{
+				ValidateUTF8: UTF8Reject,
+			})
+			assertNoError(t, err)
+		}
+
+		encoded, err := m1.EncodeBytes()
+		if err != nil {
+			t.Errorf("%s: encode: %s", entry.device, err)
+			continue
+		}
+
+		if entry.exact && !bytes.Equal(data, encoded) {
+			t.Errorf("%s: re-encoded message differs", entry.device)
+		}
+
+		var m2 Message
+		err = m2.DecodeBytes(encoded)
+		if err != nil {
+			t.Errorf("%s: decode re-encoded: %s", entry.device, err)
+			continue
+		}
+
+		if diffs := ExplainDiff(m1, m2); diffs != nil {
+			t.Errorf("%s: re-encode round trip:\n%s",
+				entry.device, diffs)
+		}
+
+		if !m1.Similar(m2) {
+			t.Errorf("%s: re-encoded message is not similar",
+				entry.device)
+		}
+	}
+}
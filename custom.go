@@ -0,0 +1,115 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * User-defined values of custom tags
+ */
+
+package goipp
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+)
+
+// ValueMarshaler is implemented by user-defined Values, used
+// with custom tags (i.e., vendor extensions), registered by
+// RegisterCustomTag.
+type ValueMarshaler interface {
+	Value
+
+	// MarshalIPP returns wire representation of the value
+	MarshalIPP() ([]byte, error)
+}
+
+// CustomTagDecoder decodes wire representation of the value of
+// the custom tag into the user-defined Value.
+//
+// Data is only valid during the call, and must be copied, if
+// retained.
+type CustomTagDecoder func(tag Tag, data []byte) (ValueMarshaler, error)
+
+// RegisterCustomTag registers the custom value tag (i.e., the vendor
+// extension), so values of this tag are represented by user-defined
+// Values:
+//   - encoder accepts values, that implement ValueMarshaler, with
+//     this tag
+//   - decoder uses decode to create values of this tag
+//
+// Tags, known by this package, delimiter and out-of-band tags
+// cannot be registered. Extension tags (0x100 and above) are
+// allowed. Registering the same tag again replaces the decoder.
+//
+// The registry is process-wide; use UnregisterCustomTag to remove
+// the registration.
+func RegisterCustomTag(tag Tag, decode CustomTagDecoder) error {
+	switch {
+	case tag < 0 || tag == TagExtension:
+		return fmt.Errorf("Tag %s: invalid", tag)
+	case tag.IsDelimiter() || tag.IsOutOfBand():
+		return fmt.Errorf("Tag %s is not a value tag", tag)
+	case int(tag) < len(tagNames) && tagNames[tag] != "":
+		return fmt.Errorf("Tag %s is already defined", tag)
+	case decode == nil:
+		return fmt.Errorf("Tag %s: missed decoder", tag)
+	}
+
+	customTagsLock.Lock()
+	customTags[tag] = decode
+	customTagsLock.Unlock()
+
+	return nil
+}
+
+// UnregisterCustomTag removes registration of the custom tag, made
+// by RegisterCustomTag. Values of this tag are decoded as Binary
+// again. Unregistering a tag that is not registered does nothing.
+func UnregisterCustomTag(tag Tag) {
+	customTagsLock.Lock()
+	delete(customTags, tag)
+	customTagsLock.Unlock()
+}
+
+// customTags contains decoders of custom tags, registered by
+// RegisterCustomTag
+var (
+	customTags     = make(map[Tag]CustomTagDecoder)
+	customTagsLock sync.RWMutex
+)
+
+// customTagDecoder returns decoder of the custom tag, or nil,
+// if tag is not registered
+func customTagDecoder(tag Tag) CustomTagDecoder {
+	customTagsLock.RLock()
+	decode := customTags[tag]
+	customTagsLock.RUnlock()
+	return decode
+}
+
+// valueEncode returns wire representation of the value
+func valueEncode(v Value) ([]byte, error) {
+	switch v := v.(type) {
+	case valueCodec:
+		return v.encode()
+	case ValueMarshaler:
+		return v.MarshalIPP()
+	}
+
+	return nil, fmt.Errorf("%T: value cannot be encoded", v)
+}
+
+// valueCustomEqual compares user-defined value with another value
+// by their wire representation
+func valueCustomEqual(v1 ValueMarshaler, v2 Value) bool {
+	m2, ok := v2.(ValueMarshaler)
+	if !ok || v1.Type() != v2.Type() {
+		return false
+	}
+
+	data1, err1 := v1.MarshalIPP()
+	data2, err2 := m2.MarshalIPP()
+
+	return err1 == nil && err2 == nil && bytes.Equal(data1, data2)
+}
@@ -0,0 +1,110 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * User-defined values of custom tags test
+ */
+
+package goipp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// testPoint is the user-defined Value for tests
+type testPoint struct {
+	X, Y uint16
+}
+
+func (p testPoint) String() string { return fmt.Sprintf("%d,%d", p.X, p.Y) }
+func (testPoint) Type() Type       { return TypeBinary }
+
+func (p testPoint) MarshalIPP() ([]byte, error) {
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint16(data[0:], p.X)
+	binary.BigEndian.PutUint16(data[2:], p.Y)
+	return data, nil
+}
+
+func testPointDecode(tag Tag, data []byte) (ValueMarshaler, error) {
+	if len(data) != 4 {
+		return nil, errors.New("value must be 4 bytes")
+	}
+
+	return testPoint{
+		binary.BigEndian.Uint16(data[0:]),
+		binary.BigEndian.Uint16(data[2:]),
+	}, nil
+}
+
+// TestCustomTag tests RegisterCustomTag and user-defined values
+func TestCustomTag(t *testing.T) {
+	const tagPoint = Tag(0x7000abcd)
+	const tagOther = Tag(0x7000abce)
+
+	// Invalid registrations
+	assertErrorIs(t, RegisterCustomTag(TagInteger, testPointDecode),
+		"Tag integer is already defined")
+	assertErrorIs(t, RegisterCustomTag(TagJobGroup, testPointDecode),
+		"Tag job-attributes-tag is not a value tag")
+	assertErrorIs(t, RegisterCustomTag(TagNoValue, testPointDecode),
+		"Tag no-value is not a value tag")
+	assertErrorIs(t, RegisterCustomTag(tagPoint, nil),
+		"Tag 0x7000abcd: missed decoder")
+
+	// Unregistered tag rejects user-defined values
+	m := NewResponse(DefaultVersion, StatusOk, 1)
+	m.Printer.Add(MakeAttr("printer-origin", tagOther, testPoint{1, 2}))
+	_, err := m.EncodeBytes()
+	assertErrorIs(t, err, "Tag 0x7000abce: goipp.testPoint value cannot be used")
+
+	// Round trip
+	assertNoError(t, RegisterCustomTag(tagPoint, testPointDecode))
+	defer UnregisterCustomTag(tagPoint)
+
+	m = NewResponse(DefaultVersion, StatusOk, 1)
+	m.Printer.Add(MakeAttr("printer-origin", tagPoint,
+		testPoint{1, 2}, testPoint{3, 4}))
+
+	data, err := m.EncodeBytes()
+	assertNoError(t, err)
+
+	var m2 Message
+	err = m2.DecodeBytes(data)
+	assertNoError(t, err)
+
+	if !m2.Printer.Equal(m.Printer) {
+		t.Errorf("round trip:\nexpected: %s\npresent:  %s",
+			m.Printer, m2.Printer)
+	}
+
+	if ValueEqual(testPoint{1, 2}, testPoint{1, 3}) ||
+		ValueEqual(testPoint{1, 2}, Binary{0, 1, 0, 2}) {
+		t.Errorf("ValueEqual: different values are equal")
+	}
+
+	// Decode errors are reported
+	m = NewResponse(DefaultVersion, StatusOk, 1)
+	m.Printer.Add(MakeAttr("printer-origin", tagPoint, Binary{1, 2, 3}))
+
+	data, err = m.EncodeBytes()
+	assertNoError(t, err)
+
+	err = m2.DecodeBytes(data)
+	assertErrorIs(t, err, "0x7000abcd: value must be 4 bytes")
+
+	// Unregistered tag decodes as Binary again
+	UnregisterCustomTag(tagPoint)
+
+	err = m2.DecodeBytes(data)
+	assertNoError(t, err)
+
+	if !m2.Printer.Equal(m.Printer) {
+		t.Errorf("unregister:\nexpected: %s\npresent:  %s",
+			m.Printer, m2.Printer)
+	}
+}
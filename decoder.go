@@ -9,10 +9,18 @@
 package goipp
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 )
 
 // DecoderOptions represents message decoder options
@@ -28,14 +36,412 @@ type DecoderOptions struct {
 	// The list of implemented workarounds may grow in the
 	// future
 	EnableWorkarounds bool
+
+	// Arena, if set to true, enables allocation of Values of
+	// decoded attributes from the larger per-message chunks of
+	// memory, instead of allocating them one by one.
+	//
+	// It significantly reduces number of allocations, when
+	// decoding large messages, but the whole chunk remains
+	// referenced as long as any of its Values is referenced.
+	// Appending values to the decoded attribute is safe, as
+	// arena-allocated Values have no spare capacity.
+	Arena bool
+
+	// Names, if not nil, is used to intern attribute and collection
+	// member names, so repeated names (like "media-size" and
+	// "x-dimension", appearing thousands of times in
+	// media-col-database) share a single string allocation.
+	//
+	// Use a fresh NameTable for each message to limit interning
+	// to a single message, or share one NameTable between decoders
+	// to intern names globally.
+	Names *NameTable
+
+	// AttributeFilter, if not nil, is called for each top-level
+	// attribute with the group tag and attribute name. If it returns
+	// false, the attribute, including all its values and collection
+	// content, is skipped without building it. It is useful when
+	// only a handful of attributes is needed from the giant response.
+	//
+	// Skipped attributes are still checked for the wire format
+	// consistency, but their values are not validated.
+	AttributeFilter func(group Tag, name string) bool
+
+	// ValidateUTF8 defines, how text and name values, that are not
+	// valid UTF-8, are handled. Validation is performed only if
+	// attributes-charset of the message is utf-8 or missed.
+	ValidateUTF8 UTF8Policy
+
+	// BinaryRefs, if not nil, enables representation of octetString
+	// values as BinaryRef, referring to BinaryRefs, instead of
+	// Binary, so their content is not copied.
+	//
+	// BinaryRefs must provide the same data as the decoder input,
+	// at offsets counted from the beginning of the message (i.e.,
+	// bytes.Reader of the DecodeBytes input or memory-mapped
+	// capture file).
+	//
+	// Only values of at least BinaryRefMin bytes are represented
+	// this way; shorter values are still copied.
+	BinaryRefs   io.ReaderAt
+	BinaryRefMin int
+
+	// DateTimeLocation, if not nil, enables decoding of dateTime
+	// values without time zone information (8 or 9 bytes long,
+	// instead of 11), sent by some devices. Such values are
+	// interpreted as local time in this location (i.e., the
+	// printer-configured time zone, or time.UTC).
+	//
+	// Location of decoded Time reveals which case occurred:
+	// values with time zone have a fixed zone like "UTC+3",
+	// while values without time zone have DateTimeLocation.
+	// Additionally, each such value is reported via Warning.
+	//
+	// If nil, dateTime values without time zone are rejected.
+	DateTimeLocation *time.Location
+
+	// Deadline and MaxDuration, if not zero, limit the total time
+	// of decoding, protecting servers from pathological inputs that
+	// parse slowly, even if their size is bounded. If both are set,
+	// the earlier of them takes effect.
+	//
+	// Time is checked between attributes and collection members,
+	// and decoding fails when it is exceeded. Note, time spent in
+	// the blocking reads is counted, but reads are not interrupted;
+	// use deadlines of the underlying connection for that.
+	Deadline    time.Time
+	MaxDuration time.Duration
+
+	// Progress, if not nil, is called during decoding with count
+	// of bytes, consumed so far, so long decodes of huge messages
+	// over slow links can drive progress bars and health checks.
+	//
+	// It is called between attributes and collection members,
+	// when at least ProgressStep bytes (DefaultProgressStep, if
+	// zero) were consumed since the previous call, and once more
+	// when the end of message is reached.
+	Progress     func(offset int)
+	ProgressStep int
+
+	// MaxMessageSize, if not zero, limits the total number of
+	// bytes, consumed from input. If message doesn't fit, decoding
+	// fails with ErrMessageTooLarge, before data beyond the limit is
+	// read, so servers, exposed to the network, can bound memory
+	// usage without wrapping their readers.
+	//
+	// Document payload, that follows the message, is not counted.
+	MaxMessageSize int
+
+	// ReadAhead, if set to true, enables internal read-ahead
+	// buffering of the input stream, so decoding of large messages
+	// from the unbuffered readers (i.e., http.Response.Body or
+	// net.Conn) doesn't issue a separate Read for each field.
+	// Inputs, that are bufio.Reader or bytes.Buffer already, are
+	// used as is.
+	//
+	// Note, with ReadAhead, data may be read from the input beyond
+	// the end of message and this data is lost. Use it only if the
+	// message is the last thing in the stream, or the rest of the
+	// stream is not needed (like Client does with responses).
+	// Otherwise, use NewDecoder, which buffers input the same way,
+	// but gives access to the data, that follows the message.
+	ReadAhead bool
+
+	// MaxCollectionDepth limits nesting of collections. Deeper
+	// messages are rejected with CollectionDepthError, protecting
+	// the decoder from stack exhaustion by hostile messages. If zero
+	// or negative, DefaultMaxCollectionDepth is used.
+	MaxCollectionDepth int
+
+	// MaxAttributes and MaxValuesPerAttribute, if not zero, limit
+	// the total count of attributes in the message, including
+	// collection members, and the count of values of each attribute
+	// or member, so hostile messages with millions of tiny attributes
+	// or additional values cannot force unbounded memory growth.
+	//
+	// Decoding fails, when limit is exceeded, and the error
+	// contains the name of the offending attribute. Attributes,
+	// skipped by AttributeFilter, are not counted.
+	MaxAttributes         int
+	MaxValuesPerAttribute int
+
+	// NamelessAcrossGroups, if set to true, enables attaching of
+	// values without name (additional values of 1setOf attribute),
+	// found at the beginning of the group, to the last attribute
+	// of the previous group, as some devices send them this way.
+	// Each such case is reported via Warning.
+	//
+	// By default, such values are rejected with NamelessValueError.
+	NamelessAcrossGroups bool
+
+	// Result, if not nil, is filled by DecodeEx and DecodeBytesEx
+	// with metadata of the decoded message. See DecodeResult for
+	// details.
+	Result *DecodeResult
+
+	// Warning, if not nil, is called for non-fatal problems, found
+	// by the decoder (i.e., invalid UTF-8 with UTF8Warn policy).
+	// Decoding continues after the call.
+	Warning func(err error)
+}
+
+// DecodeResult contains metadata of the decoded message, so
+// callers can log or verify what exactly was parsed without
+// re-reading the stream.
+//
+// It is filled even if decoding fails, and in this case
+// describes the consumed part of the input.
+type DecodeResult struct {
+	Bytes  int      // Count of bytes, consumed from input
+	SHA256 [32]byte // SHA-256 of the consumed bytes
+	Groups int      // Count of groups of attributes
+	Attrs  int      // Count of attributes, excluding filtered out
+	Values int      // Count of values, including collection members
+}
+
+// ErrMessageTooLarge is returned by decoder, when message exceeds
+// DecoderOptions.MaxMessageSize. It is returned as is, without
+// offset, so it can be compared directly.
+var ErrMessageTooLarge = errors.New("Message too large")
+
+// DefaultMaxCollectionDepth is the default
+// DecoderOptions.MaxCollectionDepth. Real devices hardly use
+// more than 3-4 levels of nesting.
+const DefaultMaxCollectionDepth = 32
+
+// CollectionDepthError is returned by decoder, when nesting of
+// collections exceeds DecoderOptions.MaxCollectionDepth
+type CollectionDepthError struct {
+	Max    int // Max allowed depth
+	Offset int // Offset of the offending collection within the message
+}
+
+// Error returns the error string
+func (e *CollectionDepthError) Error() string {
+	return fmt.Sprintf("Collection nesting exceeds %d levels at 0x%x",
+		e.Max, e.Offset)
+}
+
+// NamelessValueError is returned by decoder, when the value without
+// name (additional value of 1setOf attribute) is not preceded by
+// the attribute it belongs to, i.e., appears at the beginning of
+// the group. See also DecoderOptions.NamelessAcrossGroups.
+type NamelessValueError struct {
+	Group  Tag // Group tag, TagZero if outside of any group
+	Tag    Tag // Value tag
+	Offset int // Offset of the value data within the message
 }
 
+// Error returns the error string
+func (e *NamelessValueError) Error() string {
+	return fmt.Sprintf("Additional value without preceding attribute "+
+		"(%s in %s) at 0x%x", e.Tag, e.Group, e.Offset)
+}
+
+// UTF8Policy defines how decoder handles text and name values,
+// that are not valid UTF-8
+type UTF8Policy int
+
+// UTF-8 policies:
+const (
+	// UTF8Ignore disables UTF-8 validation. This is the default.
+	UTF8Ignore UTF8Policy = iota
+
+	// UTF8Reject causes decoding to fail
+	UTF8Reject
+
+	// UTF8Warn reports invalid values via DecoderOptions.Warning
+	// and keeps them as is
+	UTF8Warn
+)
+
+// NameTable is the table of interned names, used by decoder.
+// It is safe for concurrent use.
+type NameTable struct {
+	lock  sync.Mutex        // Access lock
+	names map[string]string // Interned names
+	max   int               // Max number of names
+}
+
+// NameTableDefaultSize is the default maximum number of names
+// in the NameTable
+const NameTableDefaultSize = 4096
+
+// NewNameTable creates a new NameTable.
+//
+// The max parameter limits the number of interned names, which
+// protects from unbounded growth of the table when decoding hostile
+// input. When table is full, new names are not interned anymore.
+// If max is 0, NameTableDefaultSize is used.
+func NewNameTable(max int) *NameTable {
+	if max <= 0 {
+		max = NameTableDefaultSize
+	}
+
+	return &NameTable{
+		names: make(map[string]string),
+		max:   max,
+	}
+}
+
+// Len returns number of interned names
+func (nt *NameTable) Len() int {
+	nt.lock.Lock()
+	defer nt.lock.Unlock()
+	return len(nt.names)
+}
+
+// intern returns interned string for data
+func (nt *NameTable) intern(data []byte) string {
+	nt.lock.Lock()
+	defer nt.lock.Unlock()
+
+	if s, found := nt.names[string(data)]; found {
+		return s
+	}
+
+	s := string(data)
+	if len(nt.names) < nt.max {
+		nt.names[s] = s
+	}
+
+	return s
+}
+
+// Decoder decodes messages from the buffered input stream.
+//
+// When input is bufio.Reader, decoder takes data directly from
+// its buffer, avoiding small reads and copying, which measurably
+// speeds up decoding of large messages. Message.Decode and
+// Message.DecodeEx do the same, when bufio.Reader or bytes.Buffer
+// is passed to them, and Message.DecodeBytes always takes this
+// path. Other readers are read exactly by the needed amount of
+// bytes, without intermediate buffering, as otherwise data beyond
+// the end of message would be consumed and lost. For better
+// performance, wrap them into bufio.Reader (i.e., with NewDecoder)
+// or use DecoderOptions.ReadAhead.
+type Decoder struct {
+	in  *bufio.Reader  // Input stream
+	opt DecoderOptions // Decoder options
+}
+
+// NewDecoder creates a new Decoder.
+//
+// If in is not bufio.Reader, it is wrapped into the new one. Note,
+// in this case data may be read from in beyond the end of message,
+// so use Decoder.Reader to consume the data that follows message.
+func NewDecoder(in io.Reader, opt DecoderOptions) *Decoder {
+	br, ok := in.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(in)
+	}
+
+	return &Decoder{in: br, opt: opt}
+}
+
+// Decode decodes the next message from the input stream.
+// Exactly one message is consumed from the Decoder.Reader.
+func (d *Decoder) Decode(m *Message) error {
+	return m.DecodeEx(d.in, d.opt)
+}
+
+// Reader returns the buffered input stream of the Decoder,
+// positioned right after the last decoded message
+func (d *Decoder) Reader() *bufio.Reader {
+	return d.in
+}
+
+// DefaultProgressStep is the default DecoderOptions.ProgressStep
+const DefaultProgressStep = 4096
+
+// decoderArenaSize is the size of the Values arena chunk,
+// in values
+const decoderArenaSize = 256
+
 // messageDecoder represents Message decoder
 type messageDecoder struct {
-	in  io.Reader      // Input stream
-	off int            // Offset of last read
-	cnt int            // Count of read bytes
-	opt DecoderOptions // Options
+	in    io.Reader      // Input stream
+	off   int            // Offset of last read
+	cnt   int            // Count of read bytes
+	opt   DecoderOptions // Options
+	tmp   [4]byte        // Scratch space for integers
+	buf   []byte         // Scratch buffer for raw data
+	arena Values         // Arena for Values
+	noUTF bool           // Charset is not UTF-8
+	limit time.Time      // Decode deadline, zero if none
+	br    *bufio.Reader  // Input stream, if buffered
+	bb    *bytes.Buffer  // Input stream, if in-memory
+	sized sizedReader    // Input stream, if its size is known
+	rep   int            // Count of bytes, last reported by Progress
+	depth int            // Current nesting of collections
+	attrs int            // Count of decoded attributes
+	sum   hash.Hash      // Hash of consumed bytes, for Result
+}
+
+// sizedReader is the input stream, that knows count of bytes,
+// remaining in it, like bytes.Buffer, bytes.Reader and strings.Reader
+type sizedReader interface {
+	Len() int
+}
+
+// newMessageDecoder creates a new messageDecoder
+func newMessageDecoder(in io.Reader, opt DecoderOptions) messageDecoder {
+	in = decoderReadAhead(in, opt)
+	md := messageDecoder{in: in, opt: opt, limit: opt.Deadline}
+	md.br, _ = in.(*bufio.Reader)
+	md.bb, _ = in.(*bytes.Buffer)
+	md.sized, _ = in.(sizedReader)
+
+	if opt.Result != nil {
+		md.sum = sha256.New()
+	}
+
+	if opt.MaxDuration > 0 {
+		limit := time.Now().Add(opt.MaxDuration)
+		if md.limit.IsZero() || limit.Before(md.limit) {
+			md.limit = limit
+		}
+	}
+
+	return md
+}
+
+// decoderReadAhead wraps input stream into bufio.Reader, if
+// DecoderOptions.ReadAhead is set and input is not buffered yet
+func decoderReadAhead(in io.Reader, opt DecoderOptions) io.Reader {
+	switch in.(type) {
+	case *bufio.Reader, *bytes.Buffer:
+		return in
+	}
+
+	if opt.ReadAhead {
+		in = bufio.NewReader(in)
+	}
+
+	return in
+}
+
+// checkpoint is called between attributes and collection members.
+// It reports progress and returns error, if decode deadline is
+// exceeded.
+func (md *messageDecoder) checkpoint() error {
+	if md.opt.Progress != nil {
+		step := md.opt.ProgressStep
+		if step <= 0 {
+			step = DefaultProgressStep
+		}
+
+		if md.cnt-md.rep >= step {
+			md.rep = md.cnt
+			md.opt.Progress(md.cnt)
+		}
+	}
+
+	if !md.limit.IsZero() && time.Now().After(md.limit) {
+		return errors.New("Decode deadline exceeded")
+	}
+	return nil
 }
 
 // Decode the message
@@ -48,7 +454,16 @@ func (md *messageDecoder) decode(m *Message) error {
 	//   variable: attributes
 	//   1 byte:   TagEnd
 
-	// Parse message header
+	err := md.decodeHeader(m)
+	if err == nil {
+		_, err = md.decodeAttrs(m, TagZero, false)
+	}
+
+	return md.wrapErr(err)
+}
+
+// Decode message header
+func (md *messageDecoder) decodeHeader(m *Message) error {
 	var err error
 	m.Version, err = md.decodeVersion()
 	if err == nil {
@@ -58,22 +473,65 @@ func (md *messageDecoder) decode(m *Message) error {
 		m.RequestID, err = md.decodeU32()
 	}
 
-	// Now parse attributes
+	return err
+}
+
+// Decode message attributes, up to and including TagEnd
+//
+// If pending is not TagZero, it is used as the first tag, instead
+// of reading it from the input stream.
+//
+// If stop is true, decoding stops at the first group tag, except
+// the leading TagOperationGroup, and this tag is returned as
+// pending tag to continue decoding later. At the end of message,
+// TagZero is returned.
+func (md *messageDecoder) decodeAttrs(m *Message, pending Tag,
+	stop bool) (Tag, error) {
+
+	var err error
 	done := false
 	var group *Attributes
 	var attr Attribute
 	var prev *Attribute
+	var groupTag Tag
+	skipping := false
+
+	// With NamelessAcrossGroups, carried is the last attribute of
+	// the previous group and carriedIdx is its group's index in
+	// m.Groups, until the first named attribute of the new group
+	var carried *Attribute
+	var carriedIdx int
 
 	for err == nil && !done {
+		if err = md.checkpoint(); err != nil {
+			break
+		}
+
 		var tag Tag
-		tag, err = md.decodeTag()
+		if pending != TagZero {
+			tag, pending = pending, TagZero
+		} else {
+			tag, err = md.decodeTag()
+		}
 
 		if err != nil {
 			break
 		}
 
+		if stop && tag.IsDelimiter() && tag != TagEnd &&
+			(tag != TagOperationGroup || groupTag != TagZero) {
+			return tag, nil
+		}
+
 		if tag.IsDelimiter() {
+			carried = nil
+			if md.opt.NamelessAcrossGroups && prev != nil {
+				carried, carriedIdx = prev, len(m.Groups)-1
+			}
+
 			prev = nil
+			groupTag = tag
+			skipping = false
 		}
 
 		if tag.IsGroup() {
@@ -85,6 +543,10 @@ func (md *messageDecoder) decode(m *Message) error {
 			err = errors.New("Invalid tag 0")
 		case TagEnd:
 			done = true
+			if md.opt.Progress != nil && md.rep != md.cnt {
+				md.rep = md.cnt
+				md.opt.Progress(md.cnt)
+			}
 
 		case TagOperationGroup:
 			group = &m.Operation
@@ -119,10 +581,30 @@ func (md *messageDecoder) decode(m *Message) error {
 			// Decode attribute
 			if tag == TagMemberName || tag == TagEndCollection {
 				err = fmt.Errorf("Unexpected tag %s", tag)
-			} else {
-				attr, err = md.decodeAttribute(tag)
+				break
+			}
+
+			var name string
+			name, err = md.decodeName()
+			if err != nil {
+				break
+			}
+
+			if name != "" && group != nil &&
+				md.opt.AttributeFilter != nil {
+				skipping = !md.opt.AttributeFilter(groupTag, name)
+				if skipping {
+					prev = nil
+				}
+			}
+
+			if skipping && (name != "" || prev == nil) {
+				err = md.skipValue(tag)
+				continue
 			}
 
+			attr, err = md.decodeValue(tag, name)
+
 			if err == nil && tag == TagBeginCollection {
 				attr.Values[0].V, err = md.decodeCollection()
 			}
@@ -132,6 +614,10 @@ func (md *messageDecoder) decode(m *Message) error {
 			case err != nil:
 			case attr.Name == "":
 				if prev != nil {
+					if err = md.checkValues(prev); err != nil {
+						break
+					}
+
 					prev.Values.Add(attr.Values[0].T, attr.Values[0].V)
 
 					// Append value to the last Attribute of the
@@ -145,10 +631,40 @@ func (md *messageDecoder) decode(m *Message) error {
 					gLast := &m.Groups[len(m.Groups)-1]
 					aLast := &gLast.Attrs[len(gLast.Attrs)-1]
 					aLast.Values.Add(attr.Values[0].T, attr.Values[0].V)
+				} else if carried != nil {
+					if err = md.checkValues(carried); err != nil {
+						break
+					}
+
+					carried.Values.Add(attr.Values[0].T, attr.Values[0].V)
+
+					gPrev := &m.Groups[carriedIdx]
+					aLast := &gPrev.Attrs[len(gPrev.Attrs)-1]
+					aLast.Values.Add(attr.Values[0].T, attr.Values[0].V)
+
+					if md.opt.Warning != nil {
+						md.opt.Warning(md.wrapErr(fmt.Errorf(
+							"%s: value at the beginning of %s "+
+								"attached to the previous group",
+							carried.Name, groupTag)))
+					}
 				} else {
-					err = errors.New("Additional value without preceding attribute")
+					err = &NamelessValueError{
+						Group:  groupTag,
+						Tag:    tag,
+						Offset: md.off,
+					}
 				}
 			case group != nil:
+				if err = md.checkAttrs(attr.Name); err != nil {
+					break
+				}
+
+				if groupTag == TagOperationGroup &&
+					attr.Name == "attributes-charset" {
+					md.checkCharset(attr)
+				}
+				carried = nil
 				group.Add(attr)
 				prev = &(*group)[len(*group)-1]
 				m.Groups[len(m.Groups)-1].Add(attr)
@@ -158,6 +674,22 @@ func (md *messageDecoder) decode(m *Message) error {
 		}
 	}
 
+	return TagZero, err
+}
+
+// wrapErr adds offset of the last read to the error.
+// NamelessValueError and CollectionDepthError already contain
+// offset and returned as is, as well as ErrMessageTooLarge.
+func (md *messageDecoder) wrapErr(err error) error {
+	switch err.(type) {
+	case *NamelessValueError, *CollectionDepthError:
+		return err
+	}
+
+	if err == ErrMessageTooLarge {
+		return err
+	}
+
 	if err != nil {
 		err = fmt.Errorf("%s at 0x%x", err, md.off)
 	}
@@ -192,11 +724,27 @@ func (md *messageDecoder) decode(m *Message) error {
 // 1.x parser silently ignores collections and doesn't get confused
 // with them.
 func (md *messageDecoder) decodeCollection() (Collection, error) {
+	max := md.opt.MaxCollectionDepth
+	if max <= 0 {
+		max = DefaultMaxCollectionDepth
+	}
+
+	if md.depth >= max {
+		return nil, &CollectionDepthError{Max: max, Offset: md.off}
+	}
+
+	md.depth++
+	defer func() { md.depth-- }()
+
 	collection := make(Collection, 0)
 
 	memberName := ""
 
 	for {
+		if err := md.checkpoint(); err != nil {
+			return nil, err
+		}
+
 		tag, err := md.decodeTag()
 		if err != nil {
 			return nil, err
@@ -253,10 +801,18 @@ func (md *messageDecoder) decodeCollection() (Collection, error) {
 
 			if memberName != "" {
 				attr.Name = memberName
+				if err = md.checkAttrs(attr.Name); err != nil {
+					return nil, err
+				}
+
 				collection = append(collection, attr)
 				memberName = ""
 			} else if len(collection) > 0 {
 				l := len(collection)
+				if err = md.checkValues(&collection[l-1]); err != nil {
+					return nil, err
+				}
+
 				collection[l-1].Values.Add(tag, attr.Values[0].V)
 			} else {
 				// We've got a value without preceding TagMemberName
@@ -267,6 +823,31 @@ func (md *messageDecoder) decodeCollection() (Collection, error) {
 	}
 }
 
+// checkAttrs counts the next decoded attribute or collection
+// member and returns error, if DecoderOptions.MaxAttributes is
+// exceeded
+func (md *messageDecoder) checkAttrs(name string) error {
+	md.attrs++
+	if max := md.opt.MaxAttributes; max > 0 && md.attrs > max {
+		return fmt.Errorf("%s: too many attributes (limit is %d)",
+			name, max)
+	}
+
+	return nil
+}
+
+// checkValues returns error, if adding of the next value to the
+// attribute exceeds DecoderOptions.MaxValuesPerAttribute
+func (md *messageDecoder) checkValues(attr *Attribute) error {
+	max := md.opt.MaxValuesPerAttribute
+	if max > 0 && len(attr.Values) >= max {
+		return fmt.Errorf("%s: too many values (limit is %d)",
+			attr.Name, max)
+	}
+
+	return nil
+}
+
 // Decode a tag
 func (md *messageDecoder) decodeTag() (Tag, error) {
 	t, err := md.decodeU8()
@@ -296,16 +877,21 @@ func (md *messageDecoder) decodeCode() (Code, error) {
 // For the extended tag format, Tag is encoded as TagExtension and
 // 4 bytes of the actual tag value prepended to the value bytes
 func (md *messageDecoder) decodeAttribute(tag Tag) (Attribute, error) {
-	var attr Attribute
-	var value []byte
-	var err error
-
-	// Obtain attribute name and raw value
-	attr.Name, err = md.decodeString()
+	name, err := md.decodeName()
 	if err != nil {
-		goto ERROR
+		return Attribute{}, err
 	}
 
+	return md.decodeValue(tag, name)
+}
+
+// Decode value of the attribute, which name is already decoded
+func (md *messageDecoder) decodeValue(tag Tag, name string) (Attribute, error) {
+	attr := Attribute{Name: name}
+	var value []byte
+	var err error
+
+	// Obtain raw value
 	value, err = md.decodeBytes()
 	if err != nil {
 		goto ERROR
@@ -330,7 +916,29 @@ func (md *messageDecoder) decodeAttribute(tag Tag) (Attribute, error) {
 	}
 
 	// Unpack value
-	err = attr.unpack(tag, value)
+	if md.opt.Arena {
+		attr.Values = md.allocValues()
+	}
+
+	if tag == TagMemberName && md.opt.Names != nil {
+		attr.Values.Add(tag, String(md.opt.Names.intern(value)))
+	} else if tag == TagDateTime && md.opt.DateTimeLocation != nil &&
+		(len(value) == 8 || len(value) == 9) {
+		err = md.decodeDateTimeNoZone(&attr, value)
+	} else if md.opt.BinaryRefs != nil && tag.Type() == TypeBinary &&
+		len(value) >= md.opt.BinaryRefMin {
+		attr.Values.Add(tag, BinaryRef{
+			R:   md.opt.BinaryRefs,
+			Off: int64(md.cnt - len(value)),
+			Len: len(value),
+		})
+	} else {
+		err = attr.unpack(tag, value)
+	}
+	if err == nil && md.opt.ValidateUTF8 != UTF8Ignore {
+		err = md.validateUTF8(tag, attr)
+	}
+
 	if err != nil {
 		goto ERROR
 	}
@@ -342,40 +950,226 @@ ERROR:
 	return Attribute{}, err
 }
 
+// decodeDateTimeNoZone decodes dateTime value without time zone,
+// using DecoderOptions.DateTimeLocation, and reports it via
+// DecoderOptions.Warning
+func (md *messageDecoder) decodeDateTimeNoZone(attr *Attribute,
+	value []byte) error {
+
+	loc := md.opt.DateTimeLocation
+	v, err := timeDecode(value, loc)
+	if err != nil {
+		return fmt.Errorf("%s: %s", TagDateTime, err)
+	}
+
+	attr.Values.Add(TagDateTime, v)
+
+	if md.opt.Warning != nil {
+		err = fmt.Errorf("%s value without time zone, assumed %s",
+			TagDateTime, loc)
+		if attr.Name != "" {
+			err = fmt.Errorf("%s: %s", attr.Name, err)
+		}
+		md.opt.Warning(md.wrapErr(err))
+	}
+
+	return nil
+}
+
+// checkCharset checks the attributes-charset attribute and
+// disables UTF-8 validation, if charset is not UTF-8
+func (md *messageDecoder) checkCharset(attr Attribute) {
+	if len(attr.Values) == 1 {
+		charset := strings.ToLower(attr.Values[0].V.String())
+		md.noUTF = charset != "utf-8" && charset != "utf8"
+	}
+}
+
+// validateUTF8 validates text and name values according to
+// the DecoderOptions.ValidateUTF8 policy
+func (md *messageDecoder) validateUTF8(tag Tag, attr Attribute) error {
+	if md.noUTF {
+		return nil
+	}
+
+	var text string
+	switch tag {
+	case TagText, TagName, TagTextLang, TagNameLang:
+		switch v := attr.Values[0].V.(type) {
+		case String:
+			text = string(v)
+		case TextWithLang:
+			text = v.Text
+		}
+	}
+
+	if utf8.ValidString(text) {
+		return nil
+	}
+
+	err := fmt.Errorf("%s value: invalid UTF-8", tag)
+	if attr.Name != "" {
+		err = fmt.Errorf("%s: %s", attr.Name, err)
+	}
+
+	if md.opt.ValidateUTF8 == UTF8Reject {
+		return err
+	}
+
+	if md.opt.Warning != nil {
+		md.opt.Warning(md.wrapErr(err))
+	}
+
+	return nil
+}
+
+// allocValues allocates empty Values with capacity of 1 from
+// the arena
+func (md *messageDecoder) allocValues() Values {
+	if len(md.arena) == 0 {
+		md.arena = make(Values, decoderArenaSize)
+	}
+
+	values := md.arena[0:0:1]
+	md.arena = md.arena[1:]
+
+	return values
+}
+
+// Skip value of the attribute, which name is already decoded.
+// If value is collection, the entire collection is skipped.
+func (md *messageDecoder) skipValue(tag Tag) error {
+	_, err := md.decodeBytes()
+	if err != nil || tag != TagBeginCollection {
+		return err
+	}
+
+	for depth := 1; depth > 0; {
+		tag, err = md.decodeTag()
+		if err == nil && tag.IsDelimiter() {
+			err = fmt.Errorf("Collection: unexpected tag %s", tag)
+		}
+
+		if err == nil {
+			_, err = md.decodeBytes() // Name
+		}
+
+		if err == nil {
+			_, err = md.decodeBytes() // Value
+		}
+
+		if err != nil {
+			return err
+		}
+
+		switch tag {
+		case TagBeginCollection:
+			depth++
+		case TagEndCollection:
+			depth--
+		}
+	}
+
+	return nil
+}
+
 // Decode a 8-bit integer
 func (md *messageDecoder) decodeU8() (uint8, error) {
-	buf := make([]byte, 1)
+	if data := md.peek(1); data != nil {
+		return data[0], nil
+	}
+
+	buf := md.tmp[:1]
 	err := md.read(buf)
 	return buf[0], err
 }
 
 // Decode a 16-bit integer
 func (md *messageDecoder) decodeU16() (uint16, error) {
-	buf := make([]byte, 2)
+	if data := md.peek(2); data != nil {
+		return binary.BigEndian.Uint16(data), nil
+	}
+
+	buf := md.tmp[:2]
 	err := md.read(buf)
-	return binary.BigEndian.Uint16(buf[:]), err
+	return binary.BigEndian.Uint16(buf), err
 }
 
 // Decode a 32-bit integer
 func (md *messageDecoder) decodeU32() (uint32, error) {
-	buf := make([]byte, 4)
+	if data := md.peek(4); data != nil {
+		return binary.BigEndian.Uint32(data), nil
+	}
+
+	buf := md.tmp[:4]
 	err := md.read(buf)
-	return binary.BigEndian.Uint32(buf[:]), err
+	return binary.BigEndian.Uint32(buf), err
 }
 
 // Decode sequence of bytes
+//
+// Returned data is only valid until the next call, as it
+// uses the scratch buffer of the decoder.
 func (md *messageDecoder) decodeBytes() ([]byte, error) {
 	length, err := md.decodeU16()
 	if err != nil {
 		return nil, err
 	}
 
-	data := make([]byte, length)
-	err = md.read(data)
-	if err != nil {
-		return nil, err
+	if data := md.peek(int(length)); data != nil {
+		return data, nil
+	}
+
+	// Check the limits before the buffer is allocated
+	if md.tooLarge(int(length)) {
+		return nil, ErrMessageTooLarge
+	}
+
+	if md.sized != nil && md.sized.Len() < int(length) {
+		// Report the same offset, as read does, when
+		// input ends prematurely
+		md.off = md.cnt + md.sized.Len()
+		return nil, errors.New("Message truncated")
+	}
+
+	if cap(md.buf) >= int(length) {
+		data := md.buf[:length]
+		return data, md.read(data)
 	}
 
+	// Input size is unknown, so grow the buffer while data
+	// arrives, and truncated input doesn't cause allocation
+	// of the full declared length
+	off := md.cnt
+	data := md.buf[:0]
+	for len(data) < int(length) {
+		n := int(length) - len(data)
+		if n > decoderReadChunk {
+			n = decoderReadChunk
+		}
+
+		if cap(data) < len(data)+n {
+			newcap := 2 * cap(data)
+			if newcap < len(data)+n {
+				newcap = len(data) + n
+			}
+			if newcap > int(length) {
+				newcap = int(length)
+			}
+
+			data = append(make([]byte, 0, newcap), data...)
+			md.buf = data
+		}
+
+		err = md.read(data[len(data) : len(data)+n])
+		if err != nil {
+			return nil, err
+		}
+
+		data = data[:len(data)+n]
+	}
+
+	md.off = off
 	return data, nil
 }
 
@@ -389,23 +1183,114 @@ func (md *messageDecoder) decodeString() (string, error) {
 	return string(data), nil
 }
 
+// Decode attribute name, interning it if requested
+func (md *messageDecoder) decodeName() (string, error) {
+	if md.opt.Names == nil {
+		return md.decodeString()
+	}
+
+	data, err := md.decodeBytes()
+	if err != nil || len(data) == 0 {
+		return "", err
+	}
+
+	return md.opt.Names.intern(data), nil
+}
+
+// peek consumes n bytes from the buffered (bufio.Reader) or in-memory
+// (bytes.Buffer) input stream and returns them without copying.
+// Returned data is only valid until the next read. If input is not
+// buffered or n bytes are not available this way, it returns nil,
+// and the caller falls back to read.
+//
+// Exactly n bytes are consumed, so the guarantee of read, that
+// decoder never reads beyond the end of message, is preserved.
+func (md *messageDecoder) peek(n int) []byte {
+	var data []byte
+
+	switch {
+	case md.tooLarge(n):
+		return nil
+
+	case md.bb != nil:
+		if md.bb.Len() < n {
+			return nil
+		}
+		data = md.bb.Next(n)
+
+	case md.br != nil:
+		data, _ = md.br.Peek(n)
+		if len(data) < n {
+			return nil
+		}
+		md.br.Discard(n)
+
+	default:
+		return nil
+	}
+
+	if md.sum != nil {
+		md.sum.Write(data)
+	}
+
+	md.off = md.cnt
+	md.cnt += n
+
+	return data
+}
+
+// tooLarge reports whether consuming of n more bytes exceeds
+// DecoderOptions.MaxMessageSize
+func (md *messageDecoder) tooLarge(n int) bool {
+	return md.opt.MaxMessageSize > 0 && md.cnt+n > md.opt.MaxMessageSize
+}
+
+// decoderReadChunk is the max count of bytes, read at once
+// into the newly allocated memory, when the input size is unknown
+const decoderReadChunk = 4096
+
+// decoderMaxEmptyReads is the max number of consecutive empty
+// reads without error, tolerated by decoder
+const decoderMaxEmptyReads = 100
+
 // Read a piece of raw data from input stream
+//
+// Exactly len(data) bytes are consumed from the input stream,
+// so decoder never reads beyond the end of message.
 func (md *messageDecoder) read(data []byte) error {
 	md.off = md.cnt
+	empty := 0
+
+	if md.tooLarge(len(data)) {
+		return ErrMessageTooLarge
+	}
 
 	for len(data) > 0 {
 		n, err := md.in.Read(data)
 		if n > 0 {
+			if md.sum != nil {
+				md.sum.Write(data[:n])
+			}
 			md.cnt += n
 			data = data[n:]
-		} else {
-			md.off = md.cnt
-			if err == nil || err == io.EOF {
-				err = errors.New("Message truncated")
+			empty = 0
+			continue
+		}
+
+		// io.Reader may return 0, nil; it means nothing happened
+		if err == nil {
+			empty++
+			if empty < decoderMaxEmptyReads {
+				continue
 			}
-			return err
+			err = io.ErrNoProgress
 		}
 
+		md.off = md.cnt
+		if err == io.EOF {
+			err = errors.New("Message truncated")
+		}
+		return err
 	}
 
 	return nil
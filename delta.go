@@ -0,0 +1,84 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Get-Printer-Attributes delta requests
+ */
+
+package goipp
+
+// Missing returns names from the list, that are not present
+// in the attrs.
+func (attrs Attributes) Missing(names []string) []string {
+	present := make(map[string]struct{}, len(attrs))
+	for _, attr := range attrs {
+		present[attr.Name] = struct{}{}
+	}
+
+	var missing []string
+	for _, name := range names {
+		if _, found := present[name]; !found {
+			missing = append(missing, name)
+		}
+	}
+
+	return missing
+}
+
+// Merge merges delta into attrs.
+//
+// Attributes, present in both attrs and delta, are replaced with
+// their values from delta in place. Other attributes from delta
+// are appended to attrs.
+func (attrs *Attributes) Merge(delta Attributes) {
+	index := make(map[string]int, len(*attrs))
+	for i, attr := range *attrs {
+		if _, found := index[attr.Name]; !found {
+			index[attr.Name] = i
+		}
+	}
+
+	for _, attr := range delta {
+		if i, found := index[attr.Name]; found {
+			(*attrs)[i] = attr
+		} else {
+			index[attr.Name] = len(*attrs)
+			attrs.Add(attr)
+		}
+	}
+}
+
+// NewPrinterAttributesDeltaRequest creates Get-Printer-Attributes
+// request, that requests only attributes from the needed list, that
+// are missed in the snapshot of previously obtained printer attributes.
+//
+// If snapshot already contains all needed attributes, it returns nil,
+// and there is no need to query the printer.
+//
+// Note, group names (like "printer-description") are never found in
+// the snapshot, and so always requested. The same is true for
+// attributes, not supported by the printer. Use [Attributes.Merge]
+// to merge response into the snapshot.
+func NewPrinterAttributesDeltaRequest(v Version, id uint32,
+	printerURI string, snapshot Attributes, needed []string) *Message {
+
+	missing := snapshot.Missing(needed)
+	if len(missing) == 0 {
+		return nil
+	}
+
+	m := NewRequest(v, OpGetPrinterAttributes, id)
+	m.Operation.Add(MakeAttribute("printer-uri", TagURI,
+		String(printerURI)))
+
+	requested := Attribute{Name: "requested-attributes"}
+	for _, name := range missing {
+		requested.Values.Add(TagKeyword, String(name))
+	}
+	m.Operation.Add(requested)
+
+	FillDefaults(m)
+
+	return m
+}
@@ -0,0 +1,71 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Get-Printer-Attributes delta requests test
+ */
+
+package goipp
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestPrinterAttributesDelta tests delta requests and merging
+func TestPrinterAttributesDelta(t *testing.T) {
+	snapshot := Attributes{
+		MakeAttr("printer-name", TagName, String("printer")),
+		MakeAttr("printer-state", TagEnum, Integer(3)),
+	}
+
+	// All attributes present
+	m := NewPrinterAttributesDeltaRequest(DefaultVersion, 1,
+		"ipp://localhost/ipp/print", snapshot,
+		[]string{"printer-state", "printer-name"})
+	if m != nil {
+		t.Errorf("NewPrinterAttributesDeltaRequest: unexpected request")
+	}
+
+	// Some attributes missed
+	m = NewPrinterAttributesDeltaRequest(DefaultVersion, 1,
+		"ipp://localhost/ipp/print", snapshot,
+		[]string{"printer-state", "media-ready", "copies-supported"})
+	if m == nil {
+		t.Fatalf("NewPrinterAttributesDeltaRequest: request expected")
+	}
+
+	requested, _ := attrsFind(m.Operation, "requested-attributes")
+	expected := MakeAttr("requested-attributes", TagKeyword,
+		String("media-ready"), String("copies-supported"))
+	if !requested.Equal(expected) {
+		t.Errorf("requested-attributes: expected %s, present %s",
+			expected.Values, requested.Values)
+	}
+
+	if missed := FillDefaults(m); missed != nil {
+		t.Errorf("request misses %v", missed)
+	}
+
+	// Merge response
+	snapshot.Merge(Attributes{
+		MakeAttr("printer-state", TagEnum, Integer(4)),
+		MakeAttr("media-ready", TagKeyword, String("iso_a4_210x297mm")),
+	})
+
+	expectedAttrs := Attributes{
+		MakeAttr("printer-name", TagName, String("printer")),
+		MakeAttr("printer-state", TagEnum, Integer(4)),
+		MakeAttr("media-ready", TagKeyword, String("iso_a4_210x297mm")),
+	}
+
+	if !snapshot.Equal(expectedAttrs) {
+		t.Errorf("Merge: unexpected result")
+	}
+
+	missing := snapshot.Missing([]string{"media-ready", "copies-supported"})
+	if !reflect.DeepEqual(missing, []string{"copies-supported"}) {
+		t.Errorf("Missing: unexpected result %v", missing)
+	}
+}
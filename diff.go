@@ -0,0 +1,226 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Explanation of differences between messages
+ */
+
+package goipp
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// Diff describes a single difference between two messages
+type Diff struct {
+	// Path to the different element, i.e.
+	// "job-attributes-tag/media-col[0]/media-size[0]/x-dimension[0]".
+	// Empty for the message header.
+	Path string
+
+	// Msg is the human-readable description of the difference
+	Msg string
+}
+
+// Diffs represents a list of differences
+type Diffs []Diff
+
+// String returns Diff as "path: msg" string
+func (d Diff) String() string {
+	if d.Path == "" {
+		return d.Msg
+	}
+	return d.Path + ": " + d.Msg
+}
+
+// String returns Diffs as text, one difference per line
+func (diffs Diffs) String() string {
+	var buf bytes.Buffer
+	for _, d := range diffs {
+		buf.WriteString(d.String())
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+// ExplainDiff explains why two messages are not equal, in terms
+// of [Message.Equal]. If messages are equal, it returns nil.
+func ExplainDiff(m1, m2 Message) Diffs {
+	dc := diffContext{similar: false}
+	dc.messages(m1, m2)
+	return dc.diffs
+}
+
+// ExplainSimilarDiff explains why two messages are not similar, in
+// terms of [Message.Similar]. If messages are similar, it returns nil.
+func ExplainSimilarDiff(m1, m2 Message) Diffs {
+	dc := diffContext{similar: true}
+	dc.messages(m1, m2)
+	return dc.diffs
+}
+
+// diffContext contains state of message comparison
+type diffContext struct {
+	similar bool  // Compare for similarity
+	diffs   Diffs // Collected differences
+}
+
+// add adds difference to the list
+func (dc *diffContext) add(path Path, format string, args ...interface{}) {
+	dc.diffs = append(dc.diffs,
+		Diff{path.String(), fmt.Sprintf(format, args...)})
+}
+
+// messages compares two messages
+func (dc *diffContext) messages(m1, m2 Message) {
+	if m1.Version != m2.Version {
+		dc.add(Path{}, "version %s != %s", m1.Version, m2.Version)
+	}
+
+	if m1.Code != m2.Code {
+		dc.add(Path{}, "code 0x%4.4x != 0x%4.4x", m1.Code, m2.Code)
+	}
+
+	if m1.RequestID != m2.RequestID {
+		dc.add(Path{}, "request-id %d != %d", m1.RequestID, m2.RequestID)
+	}
+
+	groups1, groups2 := m1.attrGroups(), m2.attrGroups()
+	if dc.similar {
+		groups1, groups2 = groups1.Clone(), groups2.Clone()
+		sort.SliceStable(groups1, func(i, j int) bool {
+			return groups1[i].Tag < groups1[j].Tag
+		})
+		sort.SliceStable(groups2, func(i, j int) bool {
+			return groups2[i].Tag < groups2[j].Tag
+		})
+	}
+
+	// Compute paths of groups
+	paths := diffGroupPaths(groups1)
+	if len(groups2) > len(groups1) {
+		paths = diffGroupPaths(groups2)
+	}
+
+	for i := 0; i < len(groups1) || i < len(groups2); i++ {
+		switch {
+		case i >= len(groups1):
+			dc.add(paths[i], "missed in the first message")
+		case i >= len(groups2):
+			dc.add(paths[i], "missed in the second message")
+		case groups1[i].Tag != groups2[i].Tag:
+			dc.add(paths[i], "group tag %s != %s",
+				groups1[i].Tag, groups2[i].Tag)
+		default:
+			dc.attributes(paths[i], groups1[i].Attrs, groups2[i].Attrs)
+		}
+	}
+}
+
+// diffGroupPaths returns paths of groups. If message contains
+// multiple groups of the same tag, the second and subsequent
+// occurrences are suffixed with index, i.e., "job-attributes-tag[1]"
+func diffGroupPaths(groups Groups) []Path {
+	paths := make([]Path, len(groups))
+	counts := make(map[Tag]int)
+
+	for i, grp := range groups {
+		cnt := counts[grp.Tag]
+		counts[grp.Tag]++
+
+		paths[i] = MakePath(grp.Tag)
+		if cnt > 0 {
+			paths[i].GroupIndex = cnt
+		}
+	}
+
+	return paths
+}
+
+// attributes compares two sets of attributes
+func (dc *diffContext) attributes(path Path, attrs1, attrs2 Attributes) {
+	if !dc.similar {
+		for i := 0; i < len(attrs1) || i < len(attrs2); i++ {
+			switch {
+			case i >= len(attrs1):
+				dc.add(path.Append(attrs2[i].Name),
+					"missed in the first message")
+			case i >= len(attrs2):
+				dc.add(path.Append(attrs1[i].Name),
+					"missed in the second message")
+			case attrs1[i].Name != attrs2[i].Name:
+				dc.add(path, "attribute #%d: name %q != %q",
+					i, attrs1[i].Name, attrs2[i].Name)
+			default:
+				dc.values(path.Append(attrs1[i].Name),
+					attrs1[i].Values, attrs2[i].Values)
+			}
+		}
+		return
+	}
+
+	// For similarity, attributes are matched by name
+	byName1 := make(map[string][]Attribute)
+	byName2 := make(map[string][]Attribute)
+	names := []string{}
+
+	for _, attr := range attrs1 {
+		if byName1[attr.Name] == nil {
+			names = append(names, attr.Name)
+		}
+		byName1[attr.Name] = append(byName1[attr.Name], attr)
+	}
+
+	for _, attr := range attrs2 {
+		if byName1[attr.Name] == nil && byName2[attr.Name] == nil {
+			names = append(names, attr.Name)
+		}
+		byName2[attr.Name] = append(byName2[attr.Name], attr)
+	}
+
+	for _, name := range names {
+		l1, l2 := byName1[name], byName2[name]
+		for i := 0; i < len(l1) || i < len(l2); i++ {
+			p := path.Append(name)
+			switch {
+			case i >= len(l1):
+				dc.add(p, "missed in the first message")
+			case i >= len(l2):
+				dc.add(p, "missed in the second message")
+			default:
+				dc.values(p, l1[i].Values, l2[i].Values)
+			}
+		}
+	}
+}
+
+// values compares two sets of values
+func (dc *diffContext) values(path Path, values1, values2 Values) {
+	if len(values1) != len(values2) {
+		dc.add(path, "%d values != %d values", len(values1), len(values2))
+	}
+
+	for i := 0; i < len(values1) && i < len(values2); i++ {
+		v1, v2 := values1[i], values2[i]
+		p := path.WithIndex(i)
+
+		col1, ok1 := v1.V.(Collection)
+		col2, ok2 := v2.V.(Collection)
+
+		switch {
+		case v1.T != v2.T:
+			dc.add(p, "tag %s != %s", v1.T, v2.T)
+
+		case ok1 && ok2:
+			dc.attributes(p, Attributes(col1), Attributes(col2))
+
+		case dc.similar && !ValueSimilar(v1.V, v2.V),
+			!dc.similar && !ValueEqual(v1.V, v2.V):
+			dc.add(p, "%s %q != %s %q",
+				v1.V.Type(), v1.V, v2.V.Type(), v2.V)
+		}
+	}
+}
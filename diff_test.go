@@ -0,0 +1,72 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Explanation of differences test
+ */
+
+package goipp
+
+import (
+	"testing"
+)
+
+// TestExplainDiff tests ExplainDiff and ExplainSimilarDiff
+func TestExplainDiff(t *testing.T) {
+	m1 := testEncodeDecodeMessage()
+	if diffs := ExplainDiff(*m1, *m1); diffs != nil {
+		t.Errorf("ExplainDiff: unexpected differences:\n%s", diffs)
+	}
+
+	mediaCol := func(x int) Attribute {
+		return MakeAttrCollection("media-col",
+			MakeAttrCollection("media-size",
+				MakeAttr("x-dimension", TagInteger, Integer(x)),
+				MakeAttr("y-dimension", TagInteger, Integer(29700))))
+	}
+
+	m1 = NewRequest(DefaultVersion, OpPrintJob, 1)
+	m1.Operation.Add(MakeAttr("attributes-charset", TagCharset, String("utf-8")))
+	m1.Job.Add(MakeAttr("copies", TagInteger, Integer(1)))
+	m1.Job.Add(mediaCol(21000))
+
+	m2 := NewRequest(DefaultVersion, OpPrintJob, 2)
+	m2.Operation.Add(MakeAttr("attributes-charset", TagCharset, String("utf-8")))
+	m2.Job.Add(mediaCol(21001))
+	m2.Job.Add(MakeAttr("copies", TagInteger, Integer(1), Integer(2)))
+
+	diffs := ExplainDiff(*m1, *m2)
+	expected := "" +
+		"request-id 1 != 2\n" +
+		"job-attributes-tag: attribute #0: name \"copies\" != \"media-col\"\n" +
+		"job-attributes-tag: attribute #1: name \"media-col\" != \"copies\"\n"
+
+	if diffs.String() != expected {
+		t.Errorf("ExplainDiff:\nexpected:\n%s\npresent:\n%s",
+			expected, diffs)
+	}
+
+	diffs = ExplainSimilarDiff(*m1, *m2)
+	expected = "" +
+		"request-id 1 != 2\n" +
+		"job-attributes-tag/copies: 1 values != 2 values\n" +
+		"job-attributes-tag/media-col[0]/media-size[0]/x-dimension[0]: " +
+		"Integer \"21000\" != Integer \"21001\"\n"
+
+	if diffs.String() != expected {
+		t.Errorf("ExplainSimilarDiff:\nexpected:\n%s\npresent:\n%s",
+			expected, diffs)
+	}
+
+	// Missed groups
+	m2 = NewRequest(DefaultVersion, OpPrintJob, 1)
+	m2.Operation = m1.Operation
+	diffs = ExplainSimilarDiff(*m1, *m2)
+	expected = "job-attributes-tag: missed in the second message\n"
+
+	if diffs.String() != expected {
+		t.Errorf("ExplainSimilarDiff:\nexpected:\n%s\npresent:\n%s",
+			expected, diffs)
+	}
+}
@@ -0,0 +1,97 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Media dimensions
+ */
+
+package goipp
+
+import (
+	"math"
+	"strconv"
+)
+
+// Dimension represents a media dimension, as used by the x-dimension,
+// y-dimension, media-*-margin and similar attributes.
+//
+// These attributes are integers, measured in hundredths of millimeters
+// (i.e., 21000 for 210mm), see PWG 5100.7 for details.
+type Dimension int
+
+// DimensionsPerInch is the number of Dimension units in one inch
+const DimensionsPerInch = 2540
+
+// Rounding defines how fractional Dimension values are rounded
+type Rounding int
+
+// Rounding policies
+const (
+	RoundNearest Rounding = iota // Round to nearest, half away from zero
+	RoundDown                    // Round toward negative infinity
+	RoundUp                      // Round toward positive infinity
+	RoundTrunc                   // Round toward zero
+)
+
+// round rounds x according to the rounding policy
+func (r Rounding) round(x float64) float64 {
+	switch r {
+	case RoundDown:
+		return math.Floor(x)
+	case RoundUp:
+		return math.Ceil(x)
+	case RoundTrunc:
+		return math.Trunc(x)
+	}
+
+	// Go 1.11 lacks math.Round, so do it manually
+	if x < 0 {
+		return -math.Floor(-x + 0.5)
+	}
+	return math.Floor(x + 0.5)
+}
+
+// DimensionFromMM converts millimeters to Dimension
+func DimensionFromMM(mm float64, r Rounding) Dimension {
+	return Dimension(r.round(mm * 100))
+}
+
+// DimensionFromInches converts inches to Dimension
+func DimensionFromInches(in float64, r Rounding) Dimension {
+	return Dimension(r.round(in * DimensionsPerInch))
+}
+
+// MM returns Dimension in millimeters
+func (d Dimension) MM() float64 {
+	return float64(d) / 100
+}
+
+// Inches returns Dimension in inches
+func (d Dimension) Inches() float64 {
+	return float64(d) / DimensionsPerInch
+}
+
+// FormatMM formats Dimension in millimeters with the specified
+// number of digits after the decimal point (i.e., "210.0mm")
+func (d Dimension) FormatMM(prec int) string {
+	return strconv.FormatFloat(d.MM(), 'f', prec, 64) + "mm"
+}
+
+// FormatInches formats Dimension in inches with the specified
+// number of digits after the decimal point (i.e., "8.50in")
+func (d Dimension) FormatInches(prec int) string {
+	return strconv.FormatFloat(d.Inches(), 'f', prec, 64) + "in"
+}
+
+// String formats Dimension in millimeters with one digit after
+// the decimal point (i.e., "210.0mm")
+func (d Dimension) String() string {
+	return d.FormatMM(1)
+}
+
+// Integer returns Dimension as Integer value, suitable for
+// the IPP attributes
+func (d Dimension) Integer() Integer {
+	return Integer(d)
+}
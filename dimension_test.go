@@ -0,0 +1,63 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Media dimensions test
+ */
+
+package goipp
+
+import (
+	"testing"
+)
+
+// TestDimension tests Dimension conversions
+func TestDimension(t *testing.T) {
+	type testData struct {
+		in  float64  // Input value
+		mm  bool     // Input in millimeters (inches otherwise)
+		r   Rounding // Rounding policy
+		out Dimension
+	}
+
+	tests := []testData{
+		{210, true, RoundNearest, 21000},
+		{210.005, true, RoundNearest, 21001},
+		{210.005, true, RoundDown, 21000},
+		{210.001, true, RoundUp, 21001},
+		{-0.005, true, RoundTrunc, 0},
+		{-0.005, true, RoundNearest, -1},
+		{8.5, false, RoundNearest, 21590},
+		{11, false, RoundNearest, 27940},
+		{8.2677, false, RoundDown, 20999},
+		{8.2677, false, RoundNearest, 21000},
+	}
+
+	for _, test := range tests {
+		var d Dimension
+		if test.mm {
+			d = DimensionFromMM(test.in, test.r)
+		} else {
+			d = DimensionFromInches(test.in, test.r)
+		}
+
+		if d != test.out {
+			t.Errorf("%v (mm=%v, rounding=%d): expected %d, present %d",
+				test.in, test.mm, test.r, test.out, d)
+		}
+	}
+
+	d := Dimension(21590)
+	if s := d.String(); s != "215.9mm" {
+		t.Errorf("String: expected %q, present %q", "215.9mm", s)
+	}
+
+	if s := d.FormatInches(2); s != "8.50in" {
+		t.Errorf("FormatInches: expected %q, present %q", "8.50in", s)
+	}
+
+	if s := d.FormatMM(0); s != "216mm" {
+		t.Errorf("FormatMM: expected %q, present %q", "216mm", s)
+	}
+}
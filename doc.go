@@ -38,11 +38,8 @@ response messages
 
     // Build IPP OpGetPrinterAttributes request
     func makeRequest() ([]byte, error) {
-	    m := goipp.NewRequest(goipp.DefaultVersion, goipp.OpGetPrinterAttributes, 1)
-	    m.Operation.Add(goipp.MakeAttribute("attributes-charset",
-		    goipp.TagCharset, goipp.String("utf-8")))
-	    m.Operation.Add(goipp.MakeAttribute("attributes-natural-language",
-		    goipp.TagLanguage, goipp.String("en-US")))
+	    m := goipp.NewRequestWithDefaults(goipp.DefaultVersion,
+		    goipp.OpGetPrinterAttributes, 1)
 	    m.Operation.Add(goipp.MakeAttribute("printer-uri",
 		    goipp.TagURI, goipp.String(uri)))
 	    m.Operation.Add(goipp.MakeAttribute("requested-attributes",
@@ -108,11 +105,8 @@ response messages
     // ExamplePrintPDF demo
     func main() {
 	    // Build and encode IPP request
-	    req := goipp.NewRequest(goipp.DefaultVersion, goipp.OpPrintJob, 1)
-	    req.Operation.Add(goipp.MakeAttribute("attributes-charset",
-		    goipp.TagCharset, goipp.String("utf-8")))
-	    req.Operation.Add(goipp.MakeAttribute("attributes-natural-language",
-		    goipp.TagLanguage, goipp.String("en-US")))
+	    req := goipp.NewRequestWithDefaults(goipp.DefaultVersion,
+		    goipp.OpPrintJob, 1)
 	    req.Operation.Add(goipp.MakeAttribute("printer-uri",
 		    goipp.TagURI, goipp.String(PrinterURL)))
 	    req.Operation.Add(goipp.MakeAttribute("requesting-user-name",
@@ -12,9 +12,51 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"math"
 )
 
+// EncoderOptions represents message encoder options
+type EncoderOptions struct {
+	// Lengths defines handling of values, that exceed length
+	// limits, defined by IPP specification for the particular
+	// attribute or value syntax. See ValueLengthLimit for details.
+	Lengths LengthPolicy
+
+	// Oversize defines handling of values, that don't fit the
+	// wire format limit (MaxAttrValueLength). OversizeAttrs
+	// overrides it for particular attributes (and collection
+	// members), by name.
+	Oversize      OversizePolicy
+	OversizeAttrs map[string]OversizePolicy
+
+	// Version, if not zero, overrides the message version.
+	// The Message itself is not modified.
+	Version Version
+
+	// Coerce, if set to true, enables automatic correction of
+	// obvious mismatches between value tags and types, instead of
+	// failing, which eases encoding of messages, constructed from
+	// loosely-typed sources, like JSON. Each correction is reported
+	// via Warning. The Message itself is not modified.
+	//
+	// See CoerceValue for the list of corrections.
+	Coerce bool
+
+	// Atomic, if set to true, guarantees that the output never
+	// receives a half-encoded message, if encoding fails (i.e.,
+	// because of too long value).
+	//
+	// If output is *bytes.Buffer, on failure it is truncated back
+	// to its length before encoding. Otherwise, message is staged
+	// in the intermediate buffer and written to the output with a
+	// single Write, only if encoding succeeds. Note, errors of
+	// the output itself may still cause partial writes.
+	Atomic bool
+
+	// Warning, if not nil, is called for each non-fatal problem,
+	// i.e., for each value truncated by OversizeTruncate
+	Warning func(err error)
+}
+
 // Type messageEncoder represents Message encoder
 type messageEncoder struct {
 	out io.Writer // Output stream
@@ -43,13 +85,15 @@ func (me *messageEncoder) encode(m *Message) error {
 	// Encode attributes
 	for _, grp := range m.attrGroups() {
 		err = me.encodeTag(grp.Tag)
-		if err == nil {
-			for _, attr := range grp.Attrs {
-				if attr.Name == "" {
-					err = errors.New("Attribute without name")
-				} else {
-					err = me.encodeAttr(attr, true)
-				}
+		for _, attr := range grp.Attrs {
+			if err != nil {
+				break
+			}
+
+			if attr.Name == "" {
+				err = errors.New("Attribute without name")
+			} else {
+				err = me.encodeAttr(attr, true)
 			}
 		}
 
@@ -143,9 +187,9 @@ func (me *messageEncoder) encodeTag(tag Tag) error {
 
 // Encode Attribute name
 func (me *messageEncoder) encodeName(name string) error {
-	if len(name) > math.MaxInt16 {
+	if len(name) > MaxAttrNameLength {
 		return fmt.Errorf("Attribute name exceeds %d bytes",
-			math.MaxInt16)
+			MaxAttrNameLength)
 	}
 
 	err := me.encodeU16(uint16(len(name)))
@@ -160,19 +204,47 @@ func (me *messageEncoder) encodeName(name string) error {
 func (me *messageEncoder) encodeValue(tag Tag, v Value) error {
 	// Check Value type vs the Tag
 	tagType := tag.Type()
+	_, builtin := v.(valueCodec)
+
 	if tagType == TypeVoid {
 		v = Void{} // Ignore supplied value
+	} else if !builtin {
+		// User-defined values allowed only with custom tags
+		if customTagDecoder(tag) == nil {
+			return fmt.Errorf("Tag %s: %T value cannot be used",
+				tag, v)
+		}
 	} else if tagType != v.Type() {
 		return fmt.Errorf("Tag %s: %s value required, %s present",
 			tag, tagType, v.Type())
 	}
 
+	// Check Values, restricted to particular tags
+	switch v.(type) {
+	case Name:
+		if tag != TagName {
+			return fmt.Errorf("Tag %s: Name value cannot be used", tag)
+		}
+	case Keyword:
+		if tag != TagKeyword {
+			return fmt.Errorf("Tag %s: Keyword value cannot be used", tag)
+		}
+	case MimeType:
+		if tag != TagMimeType {
+			return fmt.Errorf("Tag %s: MimeType value cannot be used", tag)
+		}
+	case URI:
+		if tag != TagURI {
+			return fmt.Errorf("Tag %s: URI value cannot be used", tag)
+		}
+	}
+
 	// Encode the value
 	//
 	// If tag >= 0x100, tag is replaced with TagExtension, and actual
 	// tag value prepended to the data bytes. See RFC 8010, 3.5.2 for
 	// details
-	data, err := v.encode()
+	data, err := valueEncode(v)
 	if err != nil {
 		return err
 	}
@@ -182,9 +254,9 @@ func (me *messageEncoder) encodeValue(tag Tag, v Value) error {
 		valueLen += 4 // Prepend extension tag value to the data
 	}
 
-	if valueLen > math.MaxInt16 {
+	if valueLen > MaxAttrValueLength {
 		return fmt.Errorf("Attribute value exceeds %d bytes",
-			math.MaxInt16)
+			MaxAttrValueLength)
 	}
 
 	err = me.encodeU16(uint16(valueLen))
@@ -205,14 +277,15 @@ func (me *messageEncoder) encodeValue(tag Tag, v Value) error {
 
 // Encode collection
 func (me *messageEncoder) encodeCollection(tag Tag, collection Collection) error {
-	for _, attr := range collection {
-		if attr.Name == "" {
-			return errors.New("Collection member without name")
-		}
+	err := collection.checkNames("")
+	if err != nil {
+		return err
+	}
 
+	for _, attr := range collection {
 		attrName := MakeAttribute("", TagMemberName, String(attr.Name))
 
-		err := me.encodeAttr(attrName, false)
+		err = me.encodeAttr(attrName, false)
 		if err == nil {
 			err = me.encodeAttr(Attribute{Name: "", Values: attr.Values}, true)
 		}
@@ -0,0 +1,165 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Processing of ipp-attribute-fidelity and must-honor attributes
+ */
+
+package goipp
+
+// FidelityResult is the result of ProcessFidelity
+type FidelityResult struct {
+	// Status is the response status: StatusOk, if all attributes
+	// are honored, StatusOkIgnoredOrSubstituted, if some attributes
+	// are substituted or ignored, or StatusErrorAttributesOrValues,
+	// if the job must be rejected
+	Status Status
+
+	// Honored contains attributes, honored as requested
+	Honored Attributes
+
+	// Substituted contains attributes with unsupported values,
+	// substituted with xxx-default values of the printer
+	Substituted Attributes
+
+	// Effective contains effective job attributes: honored and
+	// substituted, in order of request
+	Effective Attributes
+
+	// Unsupported contains attributes for the unsupported attributes
+	// group of the response. Attributes, not supported at all, have
+	// the out-of-band unsupported value, and attributes with
+	// unsupported values contain these values.
+	Unsupported Attributes
+}
+
+// ProcessFidelity implements the ipp-attribute-fidelity algorithm
+// (RFC 8011, 5.1.4.3), applied to the Job Template attributes of
+// the job creation request.
+//
+// Each attribute of the request job group is checked against the
+// xxx-supported attribute of the printer (see ParseSupported).
+// For collection attributes, where xxx-supported lists names of
+// supported members (i.e., media-col-supported), member names are
+// checked.
+//
+// Attribute, that is not supported, or has unsupported values:
+//   - if ipp-attribute-fidelity is true, causes the job to be
+//     rejected
+//   - if attribute is listed in job-mandatory-attributes
+//     (PWG 5100.7), causes the job to be rejected as well
+//   - otherwise, attribute is substituted with xxx-default, if
+//     printer has supported default value, or ignored
+//
+// In all cases such attribute is reported in the Unsupported.
+func ProcessFidelity(req *Message, printer Attributes) FidelityResult {
+	res := FidelityResult{Status: StatusOk}
+
+	var ops, job Attributes
+	for _, grp := range req.attrGroups() {
+		switch grp.Tag {
+		case TagOperationGroup:
+			ops = append(ops, grp.Attrs...)
+		case TagJobGroup:
+			job = append(job, grp.Attrs...)
+		}
+	}
+
+	fidelity := false
+	if attr, found := attrsFind(ops, "ipp-attribute-fidelity"); found &&
+		len(attr.Values) == 1 {
+		if v, ok := attr.Values[0].V.(Boolean); ok {
+			fidelity = bool(v)
+		}
+	}
+
+	mandatory := make(map[string]struct{})
+	if attr, found := attrsFind(ops, "job-mandatory-attributes"); found {
+		for _, val := range attr.Values {
+			mandatory[val.V.String()] = struct{}{}
+		}
+	}
+
+	for _, attr := range job {
+		sup, found := attrsFind(printer, attr.Name+"-supported")
+
+		var unsupported Values
+		if found {
+			for _, val := range attr.Values {
+				if !fidelityMatches(sup, val.V) {
+					unsupported.Add(val.T, val.V)
+				}
+			}
+		}
+
+		switch {
+		case found && unsupported == nil:
+			res.Honored.Add(attr)
+			res.Effective.Add(attr)
+			continue
+
+		case !found:
+			res.Unsupported.Add(MakeAttribute(attr.Name,
+				TagUnsupportedValue, Void{}))
+
+		default:
+			res.Unsupported.Add(Attribute{Name: attr.Name,
+				Values: unsupported})
+		}
+
+		_, must := mandatory[attr.Name]
+		if fidelity || must {
+			res.Status = StatusErrorAttributesOrValues
+			continue
+		}
+
+		if res.Status == StatusOk {
+			res.Status = StatusOkIgnoredOrSubstituted
+		}
+
+		if def, ok := fidelityDefault(printer, sup, attr.Name); ok {
+			res.Substituted.Add(def)
+			res.Effective.Add(def)
+		}
+	}
+
+	return res
+}
+
+// fidelityMatches reports whether value is supported
+func fidelityMatches(sup Attribute, v Value) bool {
+	col, ok := v.(Collection)
+	if !ok || len(sup.Values) == 0 || sup.Values[0].T != TagKeyword {
+		return ParseSupported(sup).Matches(v)
+	}
+
+	// xxx-supported lists names of supported members
+	names := SupportedSet(sup.Values)
+	for _, member := range col {
+		if !names.Matches(String(member.Name)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// fidelityDefault returns xxx-default attribute of the printer,
+// renamed to name, if it exists and is supported
+func fidelityDefault(printer Attributes, sup Attribute,
+	name string) (Attribute, bool) {
+
+	def, found := attrsFind(printer, name+"-default")
+	if !found || len(def.Values) == 0 || def.Values.OutOfBand() {
+		return Attribute{}, false
+	}
+
+	for _, val := range def.Values {
+		if !fidelityMatches(sup, val.V) {
+			return Attribute{}, false
+		}
+	}
+
+	return Attribute{Name: name, Values: def.Values}, true
+}
@@ -0,0 +1,157 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Processing of ipp-attribute-fidelity and must-honor attributes test
+ */
+
+package goipp
+
+import (
+	"testing"
+)
+
+// TestProcessFidelity tests ProcessFidelity
+func TestProcessFidelity(t *testing.T) {
+	printer := Attributes{
+		MakeAttr("copies-supported", TagRange, Range{1, 99}),
+		MakeAttr("sides-supported", TagKeyword,
+			String("one-sided"), String("two-sided-long-edge")),
+		MakeAttr("sides-default", TagKeyword, String("one-sided")),
+		MakeAttr("print-quality-supported", TagEnum,
+			Integer(4), Integer(5)),
+		MakeAttr("media-col-supported", TagKeyword,
+			String("media-size"), String("media-type")),
+	}
+
+	mediaCol := MakeAttrCollection("media-col",
+		MakeAttr("media-type", TagKeyword, String("stationery")))
+	badMediaCol := MakeAttrCollection("media-col",
+		MakeAttr("media-color", TagKeyword, String("blue")))
+
+	request := func(ops Attributes, job ...Attribute) *Message {
+		m := NewRequest(DefaultVersion, OpPrintJob, 1)
+		m.Operation = ops
+		m.Job = job
+		return m
+	}
+
+	type testData struct {
+		req         *Message
+		status      Status
+		honored     []string
+		substituted []string
+		effective   []string
+		unsupported []string
+	}
+
+	names := func(attrs Attributes) []string {
+		var s []string
+		for _, attr := range attrs {
+			s = append(s, attr.Name)
+		}
+		return s
+	}
+
+	copies := MakeAttr("copies", TagInteger, Integer(2))
+	badSides := MakeAttr("sides", TagKeyword,
+		String("two-sided-short-edge"))
+	badQuality := MakeAttr("print-quality", TagEnum, Integer(3))
+	finishings := MakeAttr("finishings", TagEnum, Integer(4))
+
+	fidelity := Attributes{MakeAttr("ipp-attribute-fidelity",
+		TagBoolean, Boolean(true))}
+	mandatory := Attributes{MakeAttr("job-mandatory-attributes",
+		TagKeyword, String("print-quality"))}
+
+	tests := []testData{
+		{
+			req:       request(nil, copies, mediaCol),
+			status:    StatusOk,
+			honored:   []string{"copies", "media-col"},
+			effective: []string{"copies", "media-col"},
+		},
+
+		{
+			req: request(nil, copies, badSides, badQuality,
+				finishings, badMediaCol),
+			status:      StatusOkIgnoredOrSubstituted,
+			honored:     []string{"copies"},
+			substituted: []string{"sides"},
+			effective:   []string{"copies", "sides"},
+			unsupported: []string{"sides", "print-quality",
+				"finishings", "media-col"},
+		},
+
+		{
+			req:         request(fidelity, copies, badSides),
+			status:      StatusErrorAttributesOrValues,
+			honored:     []string{"copies"},
+			effective:   []string{"copies"},
+			unsupported: []string{"sides"},
+		},
+
+		{
+			req:         request(mandatory, copies, badQuality),
+			status:      StatusErrorAttributesOrValues,
+			honored:     []string{"copies"},
+			effective:   []string{"copies"},
+			unsupported: []string{"print-quality"},
+		},
+
+		{
+			req:         request(mandatory, badSides),
+			status:      StatusOkIgnoredOrSubstituted,
+			substituted: []string{"sides"},
+			effective:   []string{"sides"},
+			unsupported: []string{"sides"},
+		},
+	}
+
+	for i, test := range tests {
+		res := ProcessFidelity(test.req, printer)
+
+		if res.Status != test.status {
+			t.Errorf("test %d: status %s, expected %s",
+				i, res.Status, test.status)
+		}
+
+		check := func(what string, attrs Attributes, expected []string) {
+			present := names(attrs)
+			if len(present) != len(expected) {
+				t.Errorf("test %d: %s: %q, expected %q",
+					i, what, present, expected)
+				return
+			}
+
+			for j := range present {
+				if present[j] != expected[j] {
+					t.Errorf("test %d: %s: %q, expected %q",
+						i, what, present, expected)
+					return
+				}
+			}
+		}
+
+		check("Honored", res.Honored, test.honored)
+		check("Substituted", res.Substituted, test.substituted)
+		check("Effective", res.Effective, test.effective)
+		check("Unsupported", res.Unsupported, test.unsupported)
+	}
+
+	// Check values of the unsupported group and substitution
+	res := ProcessFidelity(request(nil, badSides, finishings), printer)
+
+	if !res.Unsupported[0].Equal(badSides) {
+		t.Errorf("unsupported value: %s", res.Unsupported[0].Values)
+	}
+
+	if res.Unsupported[1].Values[0].T != TagUnsupportedValue {
+		t.Errorf("unsupported attribute: %s", res.Unsupported[1].Values)
+	}
+
+	if res.Substituted[0].Values[0].V.String() != "one-sided" {
+		t.Errorf("substituted: %s", res.Substituted[0].Values)
+	}
+}
@@ -0,0 +1,257 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * finishings-col and job constraints collections
+ */
+
+package goipp
+
+import (
+	"fmt"
+)
+
+// FinishingsOptions represents the content of the finishings-col
+// collection (PWG 5100.1) in a friendly form.
+//
+// Only commonly used members are represented by fields. Other
+// members (baling, binding, stitching and so on) are preserved
+// as is in the Other field.
+type FinishingsOptions struct {
+	Template   string     // finishing-template, i.e. "staple-top-left"
+	Finishings []int      // finishings enum values
+	SizeName   string     // media-size-name
+	Other      Collection // Other members, in original order
+}
+
+// MakeFinishingsCol makes the finishings-col attribute from
+// FinishingsOptions.
+func MakeFinishingsCol(opts FinishingsOptions) Attribute {
+	return Attribute{
+		Name: "finishings-col",
+		Values: Values{
+			{TagBeginCollection, MakeFinishingsColCollection(opts)},
+		},
+	}
+}
+
+// MakeFinishingsColCollection makes the finishings-col Collection
+// value from FinishingsOptions.
+func MakeFinishingsColCollection(opts FinishingsOptions) Collection {
+	var col Collection
+
+	if opts.Template != "" {
+		col.Add(MakeAttribute("finishing-template", TagKeyword,
+			String(opts.Template)))
+	}
+
+	if len(opts.Finishings) != 0 {
+		attr := Attribute{Name: "finishings"}
+		for _, f := range opts.Finishings {
+			attr.Values.Add(TagEnum, Integer(f))
+		}
+		col.Add(attr)
+	}
+
+	if opts.SizeName != "" {
+		col.Add(MakeAttribute("media-size-name", TagKeyword,
+			String(opts.SizeName)))
+	}
+
+	col = append(col, opts.Other...)
+
+	return col
+}
+
+// ParseFinishingsCol parses the finishings-col Collection into
+// FinishingsOptions.
+func ParseFinishingsCol(col Collection) (FinishingsOptions, error) {
+	var opts FinishingsOptions
+	var err error
+
+	for _, attr := range col {
+		switch attr.Name {
+		case "finishing-template":
+			opts.Template, err = memberString("finishings-col", attr)
+
+		case "finishings":
+			for _, v := range attr.Values {
+				f, ok := v.V.(Integer)
+				if !ok {
+					err = fmt.Errorf(
+						"finishings-col: %s: enum expected",
+						attr.Name)
+					break
+				}
+				opts.Finishings = append(opts.Finishings, int(f))
+			}
+
+		case "media-size-name":
+			opts.SizeName, err = memberString("finishings-col", attr)
+
+		default:
+			opts.Other.Add(attr)
+		}
+
+		if err != nil {
+			return FinishingsOptions{}, err
+		}
+	}
+
+	return opts, nil
+}
+
+// JobConstraint represents a single value of the
+// job-constraints-supported attribute (PWG 5100.13).
+//
+// The constraint lists Job Template attributes with values, that
+// cannot be used together. Resolver names the entry of the
+// job-resolvers-supported, that resolves the conflict.
+type JobConstraint struct {
+	Resolver string     // resolver-name
+	Attrs    Attributes // Constrained Job Template attributes
+}
+
+// JobResolver represents a single value of the
+// job-resolvers-supported attribute (PWG 5100.13).
+//
+// The resolver lists Job Template attributes with values, that
+// should be used instead of the conflicting ones.
+type JobResolver struct {
+	Name  string     // resolver-name
+	Attrs Attributes // Job Template attributes to apply
+}
+
+// Matches reports whether the job attributes violate the constraint.
+//
+// The constraint is violated when every constrained attribute is
+// present in the job and at least one of its values is listed by
+// the constraint.
+func (c JobConstraint) Matches(job Attributes) bool {
+	if len(c.Attrs) == 0 {
+		return false
+	}
+
+	for _, constrained := range c.Attrs {
+		attr, found := attrsFind(job, constrained.Name)
+		if !found || !valuesIntersect(attr.Values, constrained.Values) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// MakeJobConstraintsSupported makes the job-constraints-supported
+// attribute from the slice of JobConstraint.
+func MakeJobConstraintsSupported(constraints []JobConstraint) Attribute {
+	attr := Attribute{Name: "job-constraints-supported"}
+	for _, c := range constraints {
+		attr.Values.Add(TagBeginCollection,
+			makeResolverCollection(c.Resolver, c.Attrs))
+	}
+	return attr
+}
+
+// ParseJobConstraintsSupported parses the job-constraints-supported
+// attribute into the slice of JobConstraint.
+func ParseJobConstraintsSupported(attr Attribute) ([]JobConstraint, error) {
+	var constraints []JobConstraint
+
+	for _, v := range attr.Values {
+		name, attrs, err := parseResolverCollection(attr.Name, v.V)
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, JobConstraint{name, attrs})
+	}
+
+	return constraints, nil
+}
+
+// MakeJobResolversSupported makes the job-resolvers-supported
+// attribute from the slice of JobResolver.
+func MakeJobResolversSupported(resolvers []JobResolver) Attribute {
+	attr := Attribute{Name: "job-resolvers-supported"}
+	for _, r := range resolvers {
+		attr.Values.Add(TagBeginCollection,
+			makeResolverCollection(r.Name, r.Attrs))
+	}
+	return attr
+}
+
+// ParseJobResolversSupported parses the job-resolvers-supported
+// attribute into the slice of JobResolver.
+func ParseJobResolversSupported(attr Attribute) ([]JobResolver, error) {
+	var resolvers []JobResolver
+
+	for _, v := range attr.Values {
+		name, attrs, err := parseResolverCollection(attr.Name, v.V)
+		if err != nil {
+			return nil, err
+		}
+		resolvers = append(resolvers, JobResolver{name, attrs})
+	}
+
+	return resolvers, nil
+}
+
+// FindJobResolver returns JobResolver by name
+func FindJobResolver(resolvers []JobResolver, name string) (JobResolver, bool) {
+	for _, r := range resolvers {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return JobResolver{}, false
+}
+
+// makeResolverCollection makes collection with the resolver-name
+// member, followed by attrs
+func makeResolverCollection(name string, attrs Attributes) Collection {
+	col := Collection{MakeAttribute("resolver-name", TagName, String(name))}
+	return append(col, attrs...)
+}
+
+// parseResolverCollection parses collection with the resolver-name
+// member and other attributes
+func parseResolverCollection(attrName string, v Value) (
+	name string, attrs Attributes, err error) {
+
+	col, ok := v.(Collection)
+	if !ok {
+		err = fmt.Errorf("%s: collection expected", attrName)
+		return
+	}
+
+	for _, attr := range col {
+		if attr.Name == "resolver-name" {
+			name, err = memberString(attrName, attr)
+			if err != nil {
+				return
+			}
+		} else {
+			attrs.Add(attr)
+		}
+	}
+
+	if name == "" {
+		err = fmt.Errorf("%s: missed resolver-name", attrName)
+	}
+
+	return
+}
+
+// valuesIntersect reports whether v1 and v2 have at least one
+// common value
+func valuesIntersect(v1, v2 Values) bool {
+	for _, x := range v1 {
+		for _, y := range v2 {
+			if ValueEqual(x.V, y.V) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * finishings-col and job constraints collections test
+ */
+
+package goipp
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestFinishingsCol tests MakeFinishingsCol and ParseFinishingsCol
+func TestFinishingsCol(t *testing.T) {
+	opts := FinishingsOptions{
+		Template:   "staple-top-left",
+		Finishings: []int{4, 20},
+		Other: Collection{
+			MakeAttrCollection("stitching",
+				MakeAttr("stitching-reference-edge", TagKeyword,
+					String("top"))),
+		},
+	}
+
+	attr := MakeFinishingsCol(opts)
+
+	expected := MakeAttrCollection("finishings-col",
+		MakeAttr("finishing-template", TagKeyword,
+			String("staple-top-left")),
+		Attribute{Name: "finishings", Values: Values{
+			{TagEnum, Integer(4)}, {TagEnum, Integer(20)}}},
+		MakeAttrCollection("stitching",
+			MakeAttr("stitching-reference-edge", TagKeyword,
+				String("top"))),
+	)
+
+	if !attr.Equal(expected) {
+		t.Errorf("MakeFinishingsCol:\nexpected: %s\npresent:  %s",
+			expected.Values, attr.Values)
+	}
+
+	opts2, err := ParseFinishingsCol(attr.Values[0].V.(Collection))
+	assertNoError(t, err)
+
+	if !reflect.DeepEqual(opts, opts2) {
+		t.Errorf("ParseFinishingsCol:\nexpected: %+v\npresent:  %+v",
+			opts, opts2)
+	}
+
+	_, err = ParseFinishingsCol(Collection{
+		MakeAttr("finishings", TagKeyword, String("staple")),
+	})
+	assertErrorIs(t, err, "finishings-col: finishings: enum expected")
+}
+
+// TestJobConstraints tests job-constraints-supported and
+// job-resolvers-supported helpers
+func TestJobConstraints(t *testing.T) {
+	constraints := []JobConstraint{
+		{
+			Resolver: "fix-staple",
+			Attrs: Attributes{
+				MakeAttr("finishings", TagEnum, Integer(20)),
+				MakeAttr("media", TagKeyword,
+					String("na_index-4x6_4x6in"),
+					String("na_5x7_5x7in")),
+			},
+		},
+	}
+
+	resolvers := []JobResolver{
+		{
+			Name: "fix-staple",
+			Attrs: Attributes{
+				MakeAttr("finishings", TagEnum, Integer(3)),
+			},
+		},
+	}
+
+	// Round trip
+	attr := MakeJobConstraintsSupported(constraints)
+	constraints2, err := ParseJobConstraintsSupported(attr)
+	assertNoError(t, err)
+	if !reflect.DeepEqual(constraints, constraints2) {
+		t.Errorf("ParseJobConstraintsSupported:\n"+
+			"expected: %+v\npresent:  %+v", constraints, constraints2)
+	}
+
+	attr = MakeJobResolversSupported(resolvers)
+	resolvers2, err := ParseJobResolversSupported(attr)
+	assertNoError(t, err)
+	if !reflect.DeepEqual(resolvers, resolvers2) {
+		t.Errorf("ParseJobResolversSupported:\n"+
+			"expected: %+v\npresent:  %+v", resolvers, resolvers2)
+	}
+
+	// Matches
+	tests := []struct {
+		job     Attributes
+		matches bool
+	}{
+		{
+			job: Attributes{
+				MakeAttr("finishings", TagEnum, Integer(20)),
+				MakeAttr("media", TagKeyword, String("na_5x7_5x7in")),
+			},
+			matches: true,
+		},
+		{
+			job: Attributes{
+				MakeAttr("finishings", TagEnum, Integer(20)),
+				MakeAttr("media", TagKeyword,
+					String("iso_a4_210x297mm")),
+			},
+			matches: false,
+		},
+		{
+			job: Attributes{
+				MakeAttr("finishings", TagEnum, Integer(20)),
+			},
+			matches: false,
+		},
+	}
+
+	for _, test := range tests {
+		matches := constraints[0].Matches(test.job)
+		if matches != test.matches {
+			t.Errorf("Matches(%v): expected %v, present %v",
+				test.job, test.matches, matches)
+		}
+	}
+
+	// FindJobResolver
+	r, found := FindJobResolver(resolvers, constraints[0].Resolver)
+	if !found || r.Name != "fix-staple" {
+		t.Errorf("FindJobResolver: resolver not found")
+	}
+
+	// Errors
+	_, err = ParseJobConstraintsSupported(MakeAttr(
+		"job-constraints-supported", TagKeyword, String("x")))
+	assertErrorIs(t, err, "job-constraints-supported: collection expected")
+
+	_, err = ParseJobResolversSupported(MakeAttrCollection(
+		"job-resolvers-supported",
+		MakeAttr("finishings", TagEnum, Integer(3))))
+	assertErrorIs(t, err, "job-resolvers-supported: missed resolver-name")
+}
@@ -0,0 +1,80 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Projection of attributes to flat key/value pairs
+ */
+
+package goipp
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Flatten projects attributes into flat key/value pairs, convenient
+// for templating engines, metric labels and quick dumps.
+//
+// Members of collections are flattened into paths, joined by
+// separator:
+//
+//	media-col-default.media-size.x-dimension => 21000
+//
+// If attribute contains multiple collections, their index is
+// included into the path:
+//
+//	media-col-database.0.media-size.x-dimension => 21000
+//
+// Multiple values of other attributes are joined with ",".
+// Text and name values with language are represented by text only,
+// and out-of-band values by the tag name (i.e., "no-value").
+func (attrs Attributes) Flatten(separator string) map[string]string {
+	out := make(map[string]string)
+	attrs.flatten(out, "", separator)
+	return out
+}
+
+// flatten adds flattened attributes to out
+func (attrs Attributes) flatten(out map[string]string,
+	prefix, separator string) {
+
+	for _, attr := range attrs {
+		path := prefix + attr.Name
+		values := make([]string, 0, len(attr.Values))
+		cols := 0
+
+		for _, val := range attr.Values {
+			if _, ok := val.V.(Collection); ok {
+				cols++
+			}
+		}
+
+		idx := 0
+		for _, val := range attr.Values {
+			switch v := val.V.(type) {
+			case Collection:
+				colPath := path + separator
+				if cols > 1 {
+					colPath += strconv.Itoa(idx) + separator
+					idx++
+				}
+				Attributes(v).flatten(out, colPath, separator)
+
+			case TextWithLang:
+				values = append(values, v.Text)
+
+			default:
+				if val.T.IsOutOfBand() {
+					values = append(values, val.T.String())
+				} else {
+					values = append(values, v.String())
+				}
+			}
+		}
+
+		if len(values) != 0 || cols == 0 {
+			out[path] = strings.Join(values, ",")
+		}
+	}
+}
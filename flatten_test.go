@@ -0,0 +1,60 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Projection of attributes to flat key/value pairs test
+ */
+
+package goipp
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestFlatten tests Attributes.Flatten
+func TestFlatten(t *testing.T) {
+	size := func(x, y int) Attribute {
+		return MakeAttrCollection("media-size",
+			MakeAttr("x-dimension", TagInteger, Integer(x)),
+			MakeAttr("y-dimension", TagInteger, Integer(y)))
+	}
+
+	database := MakeAttribute("media-col-database", TagBeginCollection,
+		Collection{size(21000, 29700)})
+	database.Values.Add(TagBeginCollection, Collection{size(21590, 27940)})
+
+	attrs := Attributes{
+		MakeAttr("printer-name", TagNameLang,
+			TextWithLang{"en-us", "Printer"}),
+		MakeAttr("sides-supported", TagKeyword,
+			String("one-sided"), String("two-sided-long-edge")),
+		MakeAttr("printer-geo-location", TagUnknown, Void{}),
+		MakeAttrCollection("media-col-default", size(21000, 29700)),
+		database,
+	}
+
+	expected := map[string]string{
+		"printer-name":                                "Printer",
+		"sides-supported":                             "one-sided,two-sided-long-edge",
+		"printer-geo-location":                        "unknown",
+		"media-col-default.media-size.x-dimension":    "21000",
+		"media-col-default.media-size.y-dimension":    "29700",
+		"media-col-database.0.media-size.x-dimension": "21000",
+		"media-col-database.0.media-size.y-dimension": "29700",
+		"media-col-database.1.media-size.x-dimension": "21590",
+		"media-col-database.1.media-size.y-dimension": "27940",
+	}
+
+	present := attrs.Flatten(".")
+	if !reflect.DeepEqual(present, expected) {
+		t.Errorf("Flatten:\nexpected: %v\npresent:  %v",
+			expected, present)
+	}
+
+	present = Attributes{MakeAttrCollection("a", size(1, 2))}.Flatten("/")
+	if present["a/media-size/x-dimension"] != "1" {
+		t.Errorf("Flatten with \"/\": %v", present)
+	}
+}
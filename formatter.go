@@ -27,8 +27,18 @@ const (
 	// If text appears to be too wide, Formatter will try to use
 	// mukti-line output, where possible.
 	FormatterMaxWidth = 78
+
+	// FormatterMaxBinary is the default maximum number of bytes of
+	// binary values, Formatter outputs. Longer values are truncated.
+	FormatterMaxBinary = 32
 )
 
+// ValueFormatter is the callback that formats a single value of
+// the attribute or collection member. It may be used to customize
+// Formatter output, see [Formatter.SetTypeFormatter] and
+// [Formatter.SetAttrFormatter].
+type ValueFormatter func(name string, tag Tag, val Value) string
+
 // Formatter formats IPP messages, attributes, groups etc
 // for pretty-printing.
 //
@@ -37,7 +47,12 @@ const (
 type Formatter struct {
 	indent     int          // Indentation level
 	userIndent int          // User-settable indent
+	maxBinary  int          // Binary values limit, 0 for default
+	showSyntax bool         // Show registered syntax of attributes
 	buf        bytes.Buffer // Output buffer
+
+	typeFormatters map[Type]ValueFormatter   // Per-type formatters
+	attrFormatters map[string]ValueFormatter // Per-attribute formatters
 }
 
 // NewFormatter returns a new Formatter
@@ -61,6 +76,60 @@ func (f *Formatter) SetIndent(n int) {
 	}
 }
 
+// SetMaxBinary configures the maximum number of bytes of binary
+// values, Formatter outputs. Longer values are shown as
+// "N bytes: <prefix>...".
+//
+// If n is zero, FormatterMaxBinary is used. If n is negative,
+// binary values are never truncated.
+func (f *Formatter) SetMaxBinary(n int) {
+	f.maxBinary = n
+}
+
+// SetShowSyntax configures output of the registered syntax of
+// well-known attributes and collection members (see [AttrSyntax])
+// next to their names, i.e.:
+//
+//	ATTR "job-state-reasons" (1setOf keyword) keyword: none
+//
+// Attributes with unknown syntax are shown as usual.
+func (f *Formatter) SetShowSyntax(show bool) {
+	f.showSyntax = show
+}
+
+// SetTypeFormatter installs ValueFormatter for all values of
+// the specified Type. Use nil to remove the formatter.
+//
+// Formatters are not applied to collections, only to their members.
+func (f *Formatter) SetTypeFormatter(t Type, vf ValueFormatter) {
+	if vf == nil {
+		delete(f.typeFormatters, t)
+		return
+	}
+
+	if f.typeFormatters == nil {
+		f.typeFormatters = make(map[Type]ValueFormatter)
+	}
+	f.typeFormatters[t] = vf
+}
+
+// SetAttrFormatter installs ValueFormatter for values of attributes
+// or collection members with the specified name. Use nil to remove
+// the formatter.
+//
+// Per-attribute formatters take precedence over per-type formatters.
+func (f *Formatter) SetAttrFormatter(name string, vf ValueFormatter) {
+	if vf == nil {
+		delete(f.attrFormatters, name)
+		return
+	}
+
+	if f.attrFormatters == nil {
+		f.attrFormatters = make(map[string]ValueFormatter)
+	}
+	f.attrFormatters[name] = vf
+}
+
 // Bytes returns formatted text as a byte slice
 func (f *Formatter) Bytes() []byte {
 	return f.buf.Bytes()
@@ -100,6 +169,21 @@ func (f *Formatter) Printf(format string, args ...interface{}) (int, error) {
 	return cnt, nil
 }
 
+// FmtMessage formats a [Message], according to its Role.
+//
+// If Role is unknown, Code is formatted as hexadecimal number.
+func (f *Formatter) FmtMessage(msg *Message) {
+	switch msg.Role {
+	case MessageRoleRequest:
+		f.fmtMessage(msg, true)
+	case MessageRoleResponse:
+		f.fmtMessage(msg, false)
+	default:
+		f.fmtMessageWithCode(msg,
+			fmt.Sprintf("CODE 0x%4.4x", int(msg.Code)))
+	}
+}
+
 // FmtRequest formats a request [Message].
 func (f *Formatter) FmtRequest(msg *Message) {
 	f.fmtMessage(msg, true)
@@ -112,17 +196,23 @@ func (f *Formatter) FmtResponse(msg *Message) {
 
 // fmtMessage formats a request or response Message
 func (f *Formatter) fmtMessage(msg *Message, request bool) {
+	if request {
+		f.fmtMessageWithCode(msg, fmt.Sprintf("OPERATION %s",
+			Op(msg.Code)))
+	} else {
+		f.fmtMessageWithCode(msg, fmt.Sprintf("STATUS %s",
+			Status(msg.Code)))
+	}
+}
+
+// fmtMessageWithCode formats a Message with preformatted Code line
+func (f *Formatter) fmtMessageWithCode(msg *Message, code string) {
 	f.Printf("{")
 	f.indent++
 
 	f.Printf("REQUEST-ID %d", msg.RequestID)
 	f.Printf("VERSION %s", msg.Version)
-
-	if request {
-		f.Printf("OPERATION %s", Op(msg.Code))
-	} else {
-		f.Printf("STATUS %s", Status(msg.Code))
-	}
+	f.Printf("%s", code)
 
 	if groups := msg.attrGroups(); len(groups) != 0 {
 		f.Printf("")
@@ -172,6 +262,12 @@ func (f *Formatter) fmtAttributeOrMember(attr Attribute, member bool) {
 		fmt.Fprintf(buf, "ATTR %q", attr.Name)
 	}
 
+	if f.showSyntax {
+		if syntax := AttrSyntax(attr.Name); syntax != "" {
+			fmt.Fprintf(buf, " (%s)", syntax)
+		}
+	}
+
 	tag := TagZero
 	for _, val := range attr.Values {
 		if val.T != tag {
@@ -194,6 +290,10 @@ func (f *Formatter) fmtAttributeOrMember(attr Attribute, member bool) {
 
 			f.indent--
 			f.Printf("}")
+		} else if vf := f.valueFormatter(attr.Name, val.V); vf != nil {
+			fmt.Fprintf(buf, " %s", vf(attr.Name, val.T, val.V))
+		} else if val.V.Type() == TypeBinary {
+			fmt.Fprintf(buf, " %s", f.fmtBinary(binaryBytes(val.V)))
 		} else {
 			fmt.Fprintf(buf, " %s", val.V)
 		}
@@ -202,6 +302,52 @@ func (f *Formatter) fmtAttributeOrMember(attr Attribute, member bool) {
 	f.forceNL()
 }
 
+// valueFormatter returns ValueFormatter for the value, if any
+func (f *Formatter) valueFormatter(name string, v Value) ValueFormatter {
+	if vf := f.attrFormatters[name]; vf != nil {
+		return vf
+	}
+	return f.typeFormatters[v.Type()]
+}
+
+// fmtBinary formats Binary value.
+//
+// Printable ASCII data is shown as quoted string, other data
+// as hex. Data longer than the configured limit is truncated.
+func (f *Formatter) fmtBinary(v Binary) string {
+	limit := f.maxBinary
+	if limit == 0 {
+		limit = FormatterMaxBinary
+	}
+
+	data := []byte(v)
+	truncated := limit > 0 && len(data) > limit
+	if truncated {
+		data = data[:limit]
+	}
+
+	printable := true
+	for _, c := range data {
+		if c < 0x20 || c > 0x7e {
+			printable = false
+			break
+		}
+	}
+
+	var s string
+	if printable {
+		s = fmt.Sprintf("%q", data)
+	} else {
+		s = fmt.Sprintf("%x", data)
+	}
+
+	if truncated {
+		s = fmt.Sprintf("%d bytes: %s...", len(v), s)
+	}
+
+	return s
+}
+
 // onNL returns true if formatter is at the beginning of new line
 func (f *Formatter) onNL() bool {
 	b := f.buf.Bytes()
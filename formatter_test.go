@@ -9,6 +9,7 @@
 package goipp
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -136,6 +137,30 @@ func TestFmtAttribute(t *testing.T) {
 				`ATTR "page-ranges" integer: 1 2 3 rangeOfInteger: 5-7`,
 			},
 		},
+
+		// Binary values
+		{
+			attr: MakeAttr("binary", TagString,
+				Binary("printable"),
+				Binary{0x00, 0x01, 0xff}),
+
+			out: []string{
+				`ATTR "binary" octetString: "printable" 0001ff`,
+			},
+		},
+
+		// Long binary values
+		{
+			attr: MakeAttr("binary", TagString,
+				Binary(strings.Repeat("x", 100)),
+				Binary(strings.Repeat("\x00", 1000))),
+
+			out: []string{
+				`ATTR "binary" octetString: ` +
+					`100 bytes: "` + strings.Repeat("x", 32) + `"... ` +
+					`1000 bytes: ` + strings.Repeat("00", 32) + `...`,
+			},
+		},
 	}
 
 	f := NewFormatter()
@@ -156,6 +181,103 @@ func TestFmtAttribute(t *testing.T) {
 	}
 }
 
+// TestFmtValueFormatter tests Formatter.SetTypeFormatter and
+// Formatter.SetAttrFormatter
+func TestFmtValueFormatter(t *testing.T) {
+	f := NewFormatter()
+
+	f.SetTypeFormatter(TypeResolution,
+		func(name string, tag Tag, val Value) string {
+			res := val.(Resolution)
+			return fmt.Sprintf("%d %s", res.Xres, res.Units)
+		})
+
+	f.SetTypeFormatter(TypeInteger,
+		func(name string, tag Tag, val Value) string {
+			return "int:" + val.String()
+		})
+
+	f.SetAttrFormatter("print-quality",
+		func(name string, tag Tag, val Value) string {
+			return map[Integer]string{3: "draft", 4: "normal",
+				5: "high"}[val.(Integer)]
+		})
+
+	attrs := Attributes{
+		MakeAttr("printer-resolution-default", TagResolution,
+			Resolution{600, 600, UnitsDpi}),
+		MakeAttr("print-quality", TagEnum, Integer(4)),
+		MakeAttr("copies", TagInteger, Integer(1)),
+	}
+
+	expected := strings.Join([]string{
+		`ATTR "printer-resolution-default" resolution: 600 dpi`,
+		`ATTR "print-quality" enum: normal`,
+		`ATTR "copies" integer: int:1`,
+	}, "\n") + "\n"
+
+	f.FmtAttributes(attrs)
+	if out := f.String(); out != expected {
+		t.Errorf("output mismatch\n"+
+			"expected:\n%s"+
+			"present:\n%s",
+			expected, out)
+	}
+
+	// Remove formatters
+	f.Reset()
+	f.SetTypeFormatter(TypeInteger, nil)
+	f.SetAttrFormatter("print-quality", nil)
+	f.FmtAttributes(attrs[1:])
+
+	expected = strings.Join([]string{
+		`ATTR "print-quality" enum: 4`,
+		`ATTR "copies" integer: 1`,
+	}, "\n") + "\n"
+
+	if out := f.String(); out != expected {
+		t.Errorf("output mismatch\n"+
+			"expected:\n%s"+
+			"present:\n%s",
+			expected, out)
+	}
+}
+
+// TestFmtShowSyntax tests Formatter.SetShowSyntax
+func TestFmtShowSyntax(t *testing.T) {
+	f := NewFormatter()
+	f.SetShowSyntax(true)
+
+	attrs := Attributes{
+		MakeAttr("printer-uri", TagURI, String("ipp://localhost/")),
+		MakeAttr("job-state-reasons", TagKeyword, String("none")),
+		MakeAttr("media-default", TagKeyword, String("iso_a4_210x297mm")),
+		MakeAttrCollection("media-col",
+			MakeAttribute("media-type", TagKeyword, String("stationery")),
+			MakeAttribute("x-vendor", TagInteger, Integer(1))),
+		MakeAttr("x-vendor", TagInteger, Integer(1)),
+	}
+
+	expected := strings.Join([]string{
+		`ATTR "printer-uri" (uri(1023)) uri: ipp://localhost/`,
+		`ATTR "job-state-reasons" (1setOf keyword) keyword: none`,
+		`ATTR "media-default" (keyword | name(MAX)) keyword: iso_a4_210x297mm`,
+		`ATTR "media-col" (collection) collection: {`,
+		`    MEMBER "media-type" (keyword | name(MAX)) keyword: stationery`,
+		`    MEMBER "x-vendor" integer: 1`,
+		`}`,
+		`ATTR "x-vendor" integer: 1`,
+	}, "\n") + "\n"
+
+	f.FmtAttributes(attrs)
+	if out := f.String(); out != expected {
+		t.Errorf("output mismatch\n"+
+			"expected:\n%s"+
+			"present:\n%s",
+			expected, out)
+	}
+}
+
 // TestFmtRequestResponse runs Formatter.FmtRequest and
 // Formatter.FmtResponse tests
 func TestFmtRequestResponse(t *testing.T) {
@@ -262,5 +384,41 @@ func TestFmtRequestResponse(t *testing.T) {
 				"present:\n%s",
 				expected, out)
 		}
+
+		// FmtMessage must give the same output, if role is known
+		msg := *test.msg
+		msg.Role = MessageRoleResponse
+		if test.rq {
+			msg.Role = MessageRoleRequest
+		}
+
+		f.Reset()
+		f.FmtMessage(&msg)
+		if out = f.String(); out != expected {
+			t.Errorf("FmtMessage: output mismatch\n"+
+				"expected:\n%s"+
+				"present:\n%s",
+				expected, out)
+		}
+	}
+
+	// FmtMessage with unknown role
+	f.Reset()
+	f.FmtMessage(&Message{Version: DefaultVersion, Code: 0x000b,
+		RequestID: 1})
+
+	expected := strings.Join([]string{
+		`{`,
+		`    REQUEST-ID 1`,
+		`    VERSION 2.0`,
+		`    CODE 0x000b`,
+		`}`,
+	}, "\n") + "\n"
+
+	if out := f.String(); out != expected {
+		t.Errorf("FmtMessage: output mismatch\n"+
+			"expected:\n%s"+
+			"present:\n%s",
+			expected, out)
 	}
 }
@@ -0,0 +1,261 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Random message generator for property tests
+ */
+
+package goipp
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// GenerateOptions defines constraints of messages, generated by
+// GenerateMessage. Zero values of fields mean defaults.
+type GenerateOptions struct {
+	Groups int // Max count of groups after operation group, default 4
+	Attrs  int // Max count of attributes per group, default 8
+	Values int // Max count of values per attribute, default 4
+
+	// Depth is the max nesting depth of collections, default 2.
+	// Negative value disables collections.
+	Depth int
+
+	// Tags contains value tags to use. If nil, all tags, supported
+	// by generator, are used: integer, enum, boolean, octetString,
+	// dateTime, resolution, rangeOfInteger, textWithLanguage,
+	// nameWithLanguage, textWithoutLanguage, nameWithoutLanguage,
+	// keyword, uri, uriScheme, charset, naturalLanguage,
+	// mimeMediaType, begCollection and out-of-band tags.
+	Tags []Tag
+
+	// GroupTags contains tags of groups after the operation group.
+	// If nil, all group tags, except operation and future groups,
+	// are used.
+	GroupTags []Tag
+}
+
+// generateTags contains default value tags of GenerateMessage
+var generateTags = []Tag{
+	TagInteger, TagEnum, TagBoolean, TagString, TagDateTime,
+	TagResolution, TagRange, TagTextLang, TagNameLang, TagText,
+	TagName, TagKeyword, TagURI, TagURIScheme, TagCharset,
+	TagLanguage, TagMimeType, TagBeginCollection,
+	TagUnsupportedValue, TagUnknown, TagNoValue,
+}
+
+// generateGroupTags contains default group tags of GenerateMessage
+var generateGroupTags = []Tag{
+	TagJobGroup, TagPrinterGroup, TagUnsupportedGroup,
+	TagSubscriptionGroup, TagEventNotificationGroup,
+	TagResourceGroup, TagDocumentGroup, TagSystemGroup,
+}
+
+// generateOps and generateStatuses contain codes of generated
+// requests and responses
+var (
+	generateOps = []Op{
+		OpPrintJob, OpValidateJob, OpCancelJob, OpGetJobAttributes,
+		OpGetJobs, OpGetPrinterAttributes,
+	}
+
+	generateStatuses = []Status{
+		StatusOk, StatusOkIgnoredOrSubstituted,
+		StatusErrorNotFound, StatusErrorInternal,
+	}
+)
+
+// GenerateMessage generates a random valid message, for
+// property-based testing (i.e., round trip of encoding and
+// decoding).
+//
+// Generated message is either request or response (see
+// Message.Role), starts with operation group, containing
+// attributes-charset and attributes-natural-language, followed by
+// random groups of random attributes. Each attribute contains
+// values of the same tag, and attribute names are unique within
+// group and collection.
+//
+// Generated message is determined by state of r, so the same
+// seed gives the same message.
+func GenerateMessage(r *rand.Rand, opt GenerateOptions) *Message {
+	gen := generator{r: r, opt: opt}
+	if gen.opt.Groups <= 0 {
+		gen.opt.Groups = 4
+	}
+	if gen.opt.Attrs <= 0 {
+		gen.opt.Attrs = 8
+	}
+	if gen.opt.Values <= 0 {
+		gen.opt.Values = 4
+	}
+	if gen.opt.Depth == 0 {
+		gen.opt.Depth = 2
+	}
+	if gen.opt.Tags == nil {
+		gen.opt.Tags = generateTags
+	}
+	if gen.opt.GroupTags == nil {
+		gen.opt.GroupTags = generateGroupTags
+	}
+
+	return gen.message()
+}
+
+// generator contains state of GenerateMessage
+type generator struct {
+	r   *rand.Rand      // Source of randomness
+	opt GenerateOptions // Options, with defaults applied
+}
+
+// message generates the message
+func (gen *generator) message() *Message {
+	var m *Message
+	id := uint32(gen.r.Int31())
+
+	if gen.r.Intn(2) == 0 {
+		op := generateOps[gen.r.Intn(len(generateOps))]
+		m = NewRequest(DefaultVersion, op, id)
+	} else {
+		status := generateStatuses[gen.r.Intn(len(generateStatuses))]
+		m = NewResponse(DefaultVersion, status, id)
+	}
+
+	ops := Attributes{
+		MakeAttr("attributes-charset", TagCharset, String("utf-8")),
+		MakeAttr("attributes-natural-language", TagLanguage,
+			String("en-us")),
+	}
+	m.Groups.Add(Group{TagOperationGroup, gen.attrs(ops, 0)})
+
+	for n := gen.r.Intn(gen.opt.Groups + 1); n > 0; n-- {
+		tag := gen.opt.GroupTags[gen.r.Intn(len(gen.opt.GroupTags))]
+		m.Groups.Add(Group{tag, gen.attrs(nil, 0)})
+	}
+
+	return m
+}
+
+// attrs appends random attributes to attrs
+func (gen *generator) attrs(attrs Attributes, depth int) Attributes {
+	for n := gen.r.Intn(gen.opt.Attrs + 1); n > 0; n-- {
+		name := fmt.Sprintf("%s-%d", gen.keyword(), len(attrs))
+		attrs.Add(gen.attr(name, depth))
+	}
+
+	// Collections must not be empty
+	if depth > 0 && len(attrs) == 0 {
+		attrs.Add(gen.attr("member-0", depth))
+	}
+
+	return attrs
+}
+
+// attr generates random attribute
+func (gen *generator) attr(name string, depth int) Attribute {
+	tag := gen.tag(depth)
+	attr := Attribute{Name: name}
+
+	n := 1
+	if !tag.IsOutOfBand() {
+		n += gen.r.Intn(gen.opt.Values)
+	}
+
+	for ; n > 0; n-- {
+		attr.Values.Add(tag, gen.value(tag, depth))
+	}
+
+	return attr
+}
+
+// tag chooses random value tag. Collection tag is chosen only
+// if collections at the depth are allowed.
+func (gen *generator) tag(depth int) Tag {
+	for i := 0; i < 16; i++ {
+		tag := gen.opt.Tags[gen.r.Intn(len(gen.opt.Tags))]
+		if tag != TagBeginCollection || depth < gen.opt.Depth {
+			return tag
+		}
+	}
+
+	return TagInteger
+}
+
+// value generates random value for the tag
+func (gen *generator) value(tag Tag, depth int) Value {
+	switch tag {
+	case TagInteger, TagEnum:
+		return Integer(gen.r.Int31() - gen.r.Int31())
+	case TagBoolean:
+		return Boolean(gen.r.Intn(2) == 1)
+	case TagString:
+		data := make(Binary, gen.r.Intn(32))
+		gen.r.Read(data)
+		return data
+	case TagDateTime:
+		return Time{gen.time()}
+	case TagResolution:
+		return Resolution{1 + gen.r.Intn(4800), 1 + gen.r.Intn(4800),
+			[]Units{UnitsDpi, UnitsDpcm}[gen.r.Intn(2)]}
+	case TagRange:
+		lower := gen.r.Intn(1000)
+		return Range{lower, lower + gen.r.Intn(1000)}
+	case TagTextLang, TagNameLang:
+		return TextWithLang{"en-us", gen.text()}
+	case TagText, TagName:
+		return String(gen.text())
+	case TagKeyword:
+		return String(gen.keyword())
+	case TagURI:
+		return String("ipp://" + gen.keyword() + ".local/" + gen.keyword())
+	case TagURIScheme:
+		return String([]string{"ipp", "ipps", "http", "https"}[gen.r.Intn(4)])
+	case TagCharset:
+		return String("utf-8")
+	case TagLanguage:
+		return String([]string{"en-us", "de", "fr-ca", "ru"}[gen.r.Intn(4)])
+	case TagMimeType:
+		return String([]string{"application/pdf", "image/jpeg",
+			"image/pwg-raster", string(MimeTypeAny)}[gen.r.Intn(4)])
+	case TagBeginCollection:
+		return Collection(gen.attrs(nil, depth+1))
+	}
+
+	return Void{}
+}
+
+// keyword generates random keyword
+func (gen *generator) keyword() string {
+	const chars = "abcdefghijklmnopqrstuvwxyz"
+	buf := make([]byte, 1+gen.r.Intn(12))
+	for i := range buf {
+		buf[i] = chars[gen.r.Intn(len(chars))]
+	}
+	return string(buf)
+}
+
+// text generates random text, including non-ASCII characters
+func (gen *generator) text() string {
+	runes := []rune("abcxyz ABCXYZ 0123456789-.,привет日本語")
+	buf := make([]rune, gen.r.Intn(24))
+	for i := range buf {
+		buf[i] = runes[gen.r.Intn(len(runes))]
+	}
+	return string(buf)
+}
+
+// time generates random time, representable as dateTime:
+// deci-second precision and zone offset within ±11 hours, as
+// accepted by decoder.
+func (gen *generator) time() time.Time {
+	sec := 946684800 + gen.r.Int63n(50*365*24*3600) // 2000...2050
+	nsec := int64(gen.r.Intn(10)) * int64(100*time.Millisecond)
+	offset := (gen.r.Intn(22*4+1) - 11*4) * 15 * 60
+
+	zone := time.FixedZone("", offset)
+	return time.Unix(sec, nsec).In(zone)
+}
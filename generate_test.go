@@ -0,0 +1,101 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Random message generator test
+ */
+
+package goipp
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+)
+
+// TestGenerateRoundTrip tests encode/decode and JSON round trip
+// of generated messages
+func TestGenerateRoundTrip(t *testing.T) {
+	for seed := int64(0); seed < 200; seed++ {
+		m := GenerateMessage(rand.New(rand.NewSource(seed)),
+			GenerateOptions{})
+
+		data, err := m.EncodeBytes()
+		if err != nil {
+			t.Errorf("seed %d: encode: %s", seed, err)
+			continue
+		}
+
+		var m2 Message
+		err = m2.DecodeBytes(data)
+		if err != nil {
+			t.Errorf("seed %d: decode: %s", seed, err)
+			continue
+		}
+
+		if diffs := ExplainDiff(*m, m2); diffs != nil {
+			t.Errorf("seed %d: encode/decode round trip:\n%s",
+				seed, diffs)
+		}
+
+		data, err = json.Marshal(m)
+		assertNoError(t, err)
+
+		var m3 Message
+		err = json.Unmarshal(data, &m3)
+		assertNoError(t, err)
+
+		if diffs := ExplainDiff(*m, m3); diffs != nil {
+			t.Errorf("seed %d: JSON round trip:\n%s", seed, diffs)
+		}
+	}
+}
+
+// TestGenerateOptions tests GenerateMessage constraints
+func TestGenerateOptions(t *testing.T) {
+	opt := GenerateOptions{
+		Groups:    2,
+		Attrs:     3,
+		Values:    1,
+		Depth:     -1,
+		Tags:      []Tag{TagInteger, TagBeginCollection},
+		GroupTags: []Tag{TagJobGroup},
+	}
+
+	for seed := int64(0); seed < 50; seed++ {
+		m1 := GenerateMessage(rand.New(rand.NewSource(seed)), opt)
+		m2 := GenerateMessage(rand.New(rand.NewSource(seed)), opt)
+
+		if !m1.Equal(*m2) {
+			t.Errorf("seed %d: generator is not deterministic", seed)
+		}
+
+		if len(m1.Groups) > 3 {
+			t.Errorf("seed %d: %d groups", seed, len(m1.Groups))
+		}
+
+		for i, grp := range m1.Groups {
+			if i == 0 {
+				if grp.Tag != TagOperationGroup {
+					t.Errorf("seed %d: first group is %s",
+						seed, grp.Tag)
+				}
+				continue
+			}
+
+			if grp.Tag != TagJobGroup || len(grp.Attrs) > 3 {
+				t.Errorf("seed %d: group %s with %d attributes",
+					seed, grp.Tag, len(grp.Attrs))
+			}
+
+			for _, attr := range grp.Attrs {
+				if len(attr.Values) != 1 ||
+					attr.Values[0].T != TagInteger {
+					t.Errorf("seed %d: %s: %v",
+						seed, attr.Name, attr.Values)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,187 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Get-Jobs request options
+ */
+
+package goipp
+
+import (
+	"fmt"
+)
+
+// WhichJobs represents values of the which-jobs operation attribute
+// of the Get-Jobs request (RFC 8011, 4.2.6.1 and PWG 5100.7)
+type WhichJobs string
+
+// WhichJobs values:
+const (
+	WhichJobsCompleted         WhichJobs = "completed"
+	WhichJobsNotCompleted      WhichJobs = "not-completed" // Default
+	WhichJobsAborted           WhichJobs = "aborted"
+	WhichJobsAll               WhichJobs = "all"
+	WhichJobsCanceled          WhichJobs = "canceled"
+	WhichJobsPending           WhichJobs = "pending"
+	WhichJobsPendingHeld       WhichJobs = "pending-held"
+	WhichJobsProcessing        WhichJobs = "processing"
+	WhichJobsProcessingStopped WhichJobs = "processing-stopped"
+	WhichJobsProofPrint        WhichJobs = "proof-print"
+	WhichJobsSaved             WhichJobs = "saved"
+	WhichJobsFetchable         WhichJobs = "fetchable"
+)
+
+// Matches reports whether job in the specified job-state matches
+// the which-jobs filter. Empty WhichJobs means the default,
+// WhichJobsNotCompleted.
+//
+// Jobs, selected by proof-print, saved and fetchable values,
+// cannot be determined by job state, and Matches returns false
+// for them.
+func (w WhichJobs) Matches(jobState int) bool {
+	terminated := jobState >= JobStateCanceled
+
+	switch w {
+	case "", WhichJobsNotCompleted:
+		return !terminated
+	case WhichJobsCompleted:
+		return terminated
+	case WhichJobsAll:
+		return true
+	case WhichJobsAborted:
+		return jobState == JobStateAborted
+	case WhichJobsCanceled:
+		return jobState == JobStateCanceled
+	case WhichJobsPending:
+		return jobState == JobStatePending
+	case WhichJobsPendingHeld:
+		return jobState == JobStatePendingHeld
+	case WhichJobsProcessing:
+		return jobState == JobStateProcessing
+	case WhichJobsProcessingStopped:
+		return jobState == JobStateProcessingStopped
+	}
+
+	return false
+}
+
+// GetJobsOptions represents operation attributes of the
+// Get-Jobs request in a friendly form.
+type GetJobsOptions struct {
+	RequestingUserName  string    // requesting-user-name
+	WhichJobs           WhichJobs // which-jobs, "" for default
+	MyJobs              bool      // my-jobs
+	Limit               int       // limit, 0 if not limited
+	RequestedAttributes []string  // requested-attributes
+}
+
+// NewGetJobsRequest creates the Get-Jobs request with the
+// specified options.
+//
+// Attributes with default values (empty WhichJobs, false MyJobs,
+// zero Limit and so on) are omitted. Missed required attributes
+// are filled by FillDefaults.
+func NewGetJobsRequest(v Version, id uint32, printerURI string,
+	opts GetJobsOptions) *Message {
+
+	m := NewRequest(v, OpGetJobs, id)
+	m.Operation.Add(MakeAttribute("printer-uri", TagURI,
+		String(printerURI)))
+
+	if opts.RequestingUserName != "" {
+		m.Operation.Add(MakeAttribute("requesting-user-name",
+			TagName, String(opts.RequestingUserName)))
+	}
+
+	if opts.Limit > 0 {
+		m.Operation.Add(MakeAttribute("limit", TagInteger,
+			Integer(opts.Limit)))
+	}
+
+	if len(opts.RequestedAttributes) != 0 {
+		requested := Attribute{Name: "requested-attributes"}
+		for _, name := range opts.RequestedAttributes {
+			requested.Values.Add(TagKeyword, String(name))
+		}
+		m.Operation.Add(requested)
+	}
+
+	if opts.WhichJobs != "" {
+		m.Operation.Add(MakeAttribute("which-jobs", TagKeyword,
+			String(opts.WhichJobs)))
+	}
+
+	if opts.MyJobs {
+		m.Operation.Add(MakeAttribute("my-jobs", TagBoolean,
+			Boolean(true)))
+	}
+
+	FillDefaults(m)
+
+	return m
+}
+
+// ParseGetJobsOptions extracts GetJobsOptions from the operation
+// attributes of the Get-Jobs request (i.e., on the server side).
+//
+// Missed attributes are returned as zero values, which mean
+// defaults. It returns error, if some attribute has wrong syntax.
+func ParseGetJobsOptions(attrs Attributes) (GetJobsOptions, error) {
+	var opts GetJobsOptions
+
+	for _, attr := range attrs {
+		var err error
+
+		switch attr.Name {
+		case "requesting-user-name":
+			opts.RequestingUserName, err = accountingName(attr)
+
+		case "which-jobs":
+			var s string
+			s, err = getJobsKeyword(attr)
+			opts.WhichJobs = WhichJobs(s)
+
+		case "my-jobs":
+			err = fmt.Errorf("%s: single boolean expected", attr.Name)
+			if len(attr.Values) == 1 {
+				if v, ok := attr.Values[0].V.(Boolean); ok {
+					opts.MyJobs = bool(v)
+					err = nil
+				}
+			}
+
+		case "limit":
+			opts.Limit, err = accountingInteger(attr)
+			if err == nil && opts.Limit < 1 {
+				err = fmt.Errorf("%s: %d out of range",
+					attr.Name, opts.Limit)
+			}
+
+		case "requested-attributes":
+			for _, val := range attr.Values {
+				if val.T != TagKeyword {
+					err = fmt.Errorf("%s: keyword expected",
+						attr.Name)
+					break
+				}
+				opts.RequestedAttributes = append(
+					opts.RequestedAttributes, val.V.String())
+			}
+		}
+
+		if err != nil {
+			return GetJobsOptions{}, err
+		}
+	}
+
+	return opts, nil
+}
+
+// getJobsKeyword returns value of the single keyword attribute
+func getJobsKeyword(attr Attribute) (string, error) {
+	if len(attr.Values) == 1 && attr.Values[0].T == TagKeyword {
+		return attr.Values[0].V.String(), nil
+	}
+	return "", fmt.Errorf("%s: single keyword expected", attr.Name)
+}
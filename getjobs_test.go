@@ -0,0 +1,111 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Get-Jobs request options test
+ */
+
+package goipp
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestGetJobsRequest tests NewGetJobsRequest and ParseGetJobsOptions
+func TestGetJobsRequest(t *testing.T) {
+	tests := []GetJobsOptions{
+		{},
+		{
+			RequestingUserName:  "alice",
+			WhichJobs:           WhichJobsCompleted,
+			MyJobs:              true,
+			Limit:               10,
+			RequestedAttributes: []string{"job-id", "job-state"},
+		},
+	}
+
+	for _, opts := range tests {
+		m := NewGetJobsRequest(DefaultVersion, 1,
+			"ipp://localhost/printers/test", opts)
+
+		if m.Operation[0].Name != "attributes-charset" ||
+			m.Operation[1].Name != "attributes-natural-language" ||
+			m.Operation[2].Name != "printer-uri" {
+			t.Errorf("Wrong order of operation attributes")
+		}
+
+		// Encode/decode round trip
+		data, err := m.EncodeBytes()
+		assertNoError(t, err)
+
+		var m2 Message
+		err = m2.DecodeBytes(data)
+		assertNoError(t, err)
+
+		opts2, err := ParseGetJobsOptions(m2.Operation)
+		assertNoError(t, err)
+
+		if !reflect.DeepEqual(opts, opts2) {
+			t.Errorf("ParseGetJobsOptions:\n%+v\nexpected:\n%+v",
+				opts2, opts)
+		}
+	}
+
+	// Errors
+	errors := []struct {
+		attr Attribute // Operation attribute
+		err  string    // Expected error
+	}{
+		{MakeAttr("which-jobs", TagName, String("all")),
+			"which-jobs: single keyword expected"},
+		{MakeAttr("my-jobs", TagKeyword, String("true")),
+			"my-jobs: single boolean expected"},
+		{MakeAttr("limit", TagInteger, Integer(0)),
+			"limit: 0 out of range"},
+		{Attribute{Name: "requested-attributes", Values: Values{
+			{TagKeyword, String("all")},
+			{TagInteger, Integer(1)},
+		}}, "requested-attributes: keyword expected"},
+	}
+
+	for _, test := range errors {
+		_, err := ParseGetJobsOptions(Attributes{test.attr})
+		assertErrorIs(t, err, test.err)
+	}
+}
+
+// TestWhichJobsMatches tests WhichJobs.Matches
+func TestWhichJobsMatches(t *testing.T) {
+	tests := []struct {
+		which  WhichJobs // Filter
+		states []int     // Matched job states
+	}{
+		{"", []int{3, 4, 5, 6}},
+		{WhichJobsNotCompleted, []int{3, 4, 5, 6}},
+		{WhichJobsCompleted, []int{7, 8, 9}},
+		{WhichJobsAll, []int{3, 4, 5, 6, 7, 8, 9}},
+		{WhichJobsAborted, []int{8}},
+		{WhichJobsCanceled, []int{7}},
+		{WhichJobsPending, []int{3}},
+		{WhichJobsPendingHeld, []int{4}},
+		{WhichJobsProcessing, []int{5}},
+		{WhichJobsProcessingStopped, []int{6}},
+		{WhichJobsSaved, nil},
+	}
+
+	for _, test := range tests {
+		var states []int
+		for state := JobStatePending; state <= JobStateCompleted; state++ {
+			if test.which.Matches(state) {
+				states = append(states, state)
+			}
+		}
+
+		if !reflect.DeepEqual(states, test.states) {
+			t.Errorf("%q: matches %v, expected %v",
+				test.which, states, test.states)
+		}
+	}
+}
@@ -7,7 +7,10 @@
 package goipp
 
 import (
+	"bufio"
 	"bytes"
+	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -104,7 +107,7 @@ func assertEncodeSize(t *testing.T, encode func() ([]byte, error), size int) {
 
 // Check that decode() works without error and returns expected value
 func assertDecode(t *testing.T, data []byte, expected Value) {
-	val, err := expected.decode(data)
+	val, err := expected.(valueCodec).decode(data)
 	assertNoError(t, err)
 
 	if !ValueEqual(val, expected) {
@@ -114,7 +117,7 @@ func assertDecode(t *testing.T, data []byte, expected Value) {
 
 // Check that decode returns error
 func assertDecodeErr(t *testing.T, data []byte, val Value) {
-	_, err := val.decode(data)
+	_, err := val.(valueCodec).decode(data)
 	if err == nil {
 		t.Errorf("decode: expected error")
 	}
@@ -191,6 +194,303 @@ func TestStringValue(t *testing.T) {
 	assertDecode(t, []byte("hello"), String("hello"))
 }
 
+// Test Name Value
+func TestNameValue(t *testing.T) {
+	var v Name
+
+	assertValueType(t, v, TypeString)
+	assertEncodeSize(t, v.encode, 0)
+
+	v = "printer"
+	assertEncodeSize(t, v.encode, 7)
+
+	assertDecode(t, []byte("hello"), Name("hello"))
+
+	// Name is similar but not equal to the String
+	if ValueEqual(v, String(v)) {
+		t.Errorf("Name(%q) and String(%q) must not be equal", v, v)
+	}
+
+	if !ValueSimilar(v, String(v)) {
+		t.Errorf("Name(%q) and String(%q) must be similar", v, v)
+	}
+
+	if !ValueSimilar(v, Binary(v)) {
+		t.Errorf("Name(%q) and Binary(%q) must be similar", v, v)
+	}
+
+	twl := v.WithLang("en")
+	if twl != (TextWithLang{"en", "printer"}) {
+		t.Errorf("Name.WithLang: %v", twl)
+	}
+
+	// Name can only be encoded with TagName
+	m := NewRequest(DefaultVersion, OpGetPrinterAttributes, 1)
+	m.Operation.Add(MakeAttribute("requesting-user-name", TagName, v))
+	_, err := m.EncodeBytes()
+	assertNoError(t, err)
+
+	m.Operation.Add(MakeAttribute("document-format", TagKeyword, v))
+	_, err = m.EncodeBytes()
+	assertWithError(t, err)
+
+	// Name length is limited
+	long := Name(strings.Repeat("x", 300))
+	m = NewRequest(DefaultVersion, OpGetPrinterAttributes, 1)
+	m.Operation.Add(MakeAttribute("job-name", TagName, long))
+	assertWithError(t, m.ValidateLengths())
+
+	data, err := m.EncodeBytesEx(EncoderOptions{Lengths: LengthTruncate})
+	assertNoError(t, err)
+
+	var m2 Message
+	assertNoError(t, m2.DecodeBytes(data))
+	if s := m2.Operation[0].Values[0].V.String(); len(s) != 255 {
+		t.Errorf("Name truncation: %d bytes, expected 255", len(s))
+	}
+}
+
+// Test Keyword Value
+func TestKeywordValue(t *testing.T) {
+	var v Keyword = "one-sided"
+
+	assertValueType(t, v, TypeString)
+	assertEncodeSize(t, v.encode, 9)
+	assertDecode(t, []byte("one-sided"), Keyword("one-sided"))
+
+	if ValueEqual(v, String(v)) {
+		t.Errorf("Keyword(%q) and String(%q) must not be equal", v, v)
+	}
+
+	if !ValueSimilar(v, String(v)) {
+		t.Errorf("Keyword(%q) and String(%q) must be similar", v, v)
+	}
+
+	tests := []struct {
+		kw Keyword
+		ok bool
+	}{
+		{"iso_a4_210x297mm", true},
+		{"application.v1", true},
+		{"a", true},
+		{"", false},
+		{"one sided", false},
+		{"One-sided", false},
+		{"1-sided", false},
+		{"-sided", false},
+		{"sided\x00", false},
+		{Keyword(strings.Repeat("a", int(MaxKeywordLength))), true},
+		{Keyword(strings.Repeat("a", int(MaxKeywordLength)+1)), false},
+	}
+
+	for _, test := range tests {
+		_, err := test.kw.encode()
+		if test.ok && err != nil {
+			t.Errorf("Keyword(%q): unexpected error: %s", test.kw, err)
+		} else if !test.ok && err == nil {
+			t.Errorf("Keyword(%q): error not detected", test.kw)
+		}
+	}
+
+	// Keyword can only be encoded with TagKeyword
+	m := NewRequest(DefaultVersion, OpGetPrinterAttributes, 1)
+	m.Operation.Add(MakeAttribute("sides", TagKeyword, v))
+	_, err := m.EncodeBytes()
+	assertNoError(t, err)
+
+	m.Operation.Add(MakeAttribute("job-name", TagName, v))
+	_, err = m.EncodeBytes()
+	assertWithError(t, err)
+
+	// Invalid Keyword fails the encoding
+	m = NewRequest(DefaultVersion, OpGetPrinterAttributes, 1)
+	m.Operation.Add(MakeAttribute("sides", TagKeyword, Keyword("one sided")))
+	_, err = m.EncodeBytes()
+	assertWithError(t, err)
+}
+
+// Test MimeType Value
+func TestMimeTypeValue(t *testing.T) {
+	var v MimeType = "application/pdf"
+
+	assertValueType(t, v, TypeString)
+	assertEncodeSize(t, v.encode, 15)
+	assertDecode(t, []byte("application/pdf"), v)
+
+	if ValueEqual(v, String(v)) {
+		t.Errorf("MimeType(%q) and String(%q) must not be equal", v, v)
+	}
+
+	// Syntax validation
+	syntax := []struct {
+		mt MimeType
+		ok bool
+	}{
+		{"application/pdf", true},
+		{"image/pwg-raster", true},
+		{"text/plain; charset=utf-8", true},
+		{"text/plain;charset=\"utf-8\";format=flowed", true},
+		{"", false},
+		{"application", false},
+		{"application/", false},
+		{"/pdf", false},
+		{"application/p df", false},
+		{"application/pdf; charset", false},
+		{"text/plain; =utf-8", false},
+	}
+
+	for _, test := range syntax {
+		err := test.mt.Validate()
+		if test.ok && err != nil {
+			t.Errorf("MimeType(%q): unexpected error: %s", test.mt, err)
+		} else if !test.ok && err == nil {
+			t.Errorf("MimeType(%q): error not detected", test.mt)
+		}
+	}
+
+	// Matching
+	match := []struct {
+		mt, pattern MimeType
+		ok          bool
+	}{
+		{"application/pdf", "application/pdf", true},
+		{"Application/PDF", "application/pdf", true},
+		{"text/plain; charset=utf-8", "text/plain", true},
+		{"image/jpeg", "image/*", true},
+		{"image/jpeg", "*/*", true},
+		{"image/jpeg", "application/octet-stream", true},
+		{"application/pdf", "image/*", false},
+		{"application/pdf", "application/postscript", false},
+	}
+
+	for _, test := range match {
+		ok := test.mt.Matches(test.pattern)
+		if ok != test.ok {
+			t.Errorf("MimeType(%q).Matches(%q): %v, expected %v",
+				test.mt, test.pattern, ok, test.ok)
+		}
+	}
+
+	// Negotiation
+	negotiate := []struct {
+		format    MimeType
+		supported []string
+		out       MimeType
+		ok        bool
+	}{
+		{"application/pdf", []string{"application/octet-stream",
+			"application/pdf"}, "application/pdf", true},
+		{"image/urf", []string{"application/octet-stream",
+			"application/pdf"}, MimeTypeAny, true},
+		{"image/urf", []string{"application/pdf"}, "", false},
+		{"image/urf", nil, "", false},
+	}
+
+	for _, test := range negotiate {
+		out, ok := NegotiateMimeType(test.format, test.supported)
+		if out != test.out || ok != test.ok {
+			t.Errorf("NegotiateMimeType(%q, %q): %q %v, expected %q %v",
+				test.format, test.supported, out, ok,
+				test.out, test.ok)
+		}
+	}
+
+	// MimeType can only be encoded with TagMimeType
+	m := NewRequest(DefaultVersion, OpPrintJob, 1)
+	m.Operation.Add(MakeAttribute("document-format", TagMimeType, v))
+	_, err := m.EncodeBytes()
+	assertNoError(t, err)
+
+	m.Operation.Add(MakeAttribute("job-name", TagName, v))
+	_, err = m.EncodeBytes()
+	assertWithError(t, err)
+
+	m = NewRequest(DefaultVersion, OpPrintJob, 1)
+	m.Operation.Add(MakeAttribute("document-format", TagMimeType,
+		MimeType("application")))
+	_, err = m.EncodeBytes()
+	assertWithError(t, err)
+}
+
+// Test URI Value
+func TestURIValue(t *testing.T) {
+	v, err := ParseURI("ipp://localhost/ipp/print")
+	assertNoError(t, err)
+
+	assertValueType(t, v, TypeString)
+	assertEncodeSize(t, v.encode, 25)
+
+	v2, _ := ParseURI("ipp://localhost/ipp/print")
+	if !ValueEqual(v, v2) {
+		t.Errorf("URI(%q) and URI(%q) must be equal", v, v2)
+	}
+
+	if ValueEqual(v, String(v.String())) {
+		t.Errorf("URI(%q) and String(%q) must not be equal", v, v)
+	}
+
+	if !ValueSimilar(v, String(v.String())) {
+		t.Errorf("URI(%q) and String(%q) must be similar", v, v)
+	}
+
+	_, err = ParseURI("/ipp/print")
+	assertWithError(t, err)
+
+	_, err = ParseURI("ipp://local host/")
+	assertWithError(t, err)
+
+	_, err = URI{}.encode()
+	assertWithError(t, err)
+
+	// Scheme checking
+	assertNoError(t, v.CheckScheme("ipp", "ipps"))
+	assertWithError(t, v.CheckScheme("http", "https"))
+	assertWithError(t, URI{}.CheckScheme("ipp"))
+
+	// Normalization
+	normalize := []struct{ in, out string }{
+		{"IPP://LocalHost:631/ipp/print", "ipp://localhost/ipp/print"},
+		{"ipps://localhost:631", "ipps://localhost/"},
+		{"ipp://localhost:8631/ipp", "ipp://localhost:8631/ipp"},
+		{"http://[::1]:80/", "http://[::1]/"},
+		{"https://host:443/x?y=1", "https://host/x?y=1"},
+		{"https://host:80/", "https://host:80/"},
+	}
+
+	for _, test := range normalize {
+		u, err := ParseURI(test.in)
+		assertNoError(t, err)
+
+		before := u.String()
+		out := u.Normalize().String()
+		if out != test.out {
+			t.Errorf("URI(%q).Normalize(): %q, expected %q",
+				test.in, out, test.out)
+		}
+
+		if u.String() != before {
+			t.Errorf("URI(%q).Normalize() modified original", test.in)
+		}
+	}
+
+	// URI can only be encoded with TagURI
+	m := NewRequest(DefaultVersion, OpGetPrinterAttributes, 1)
+	m.Operation.Add(MakeAttribute("printer-uri", TagURI, v))
+	data, err := m.EncodeBytes()
+	assertNoError(t, err)
+
+	var m2 Message
+	assertNoError(t, m2.DecodeBytes(data))
+	if !ValueSimilar(m2.Operation[0].Values[0].V, v) {
+		t.Errorf("URI: decoded %q, expected %q",
+			m2.Operation[0].Values[0].V, v)
+	}
+
+	m.Operation.Add(MakeAttribute("job-name", TagName, v))
+	_, err = m.EncodeBytes()
+	assertWithError(t, err)
+}
+
 // Test Time Value
 func TestDateTimeValue(t *testing.T) {
 	var v Time
@@ -262,6 +562,214 @@ func TestBinaryValue(t *testing.T) {
 	assertDecode(t, data, v)
 }
 
+// Test ValueEqual
+func TestValueEqual(t *testing.T) {
+	tm := time.Date(2020, 1, 13, 15, 35, 12, 300000000, time.UTC)
+	u1, _ := ParseURI("ipp://localhost/printers/test")
+	u2, _ := ParseURI("ipp://localhost/printers/test")
+
+	tests := []struct {
+		v1, v2 Value
+		equal  bool
+	}{
+		{Integer(1), Integer(1), true},
+		{Integer(1), Integer(2), false},
+		{Integer(1), Boolean(true), false},
+		{Boolean(true), Boolean(true), true},
+		{String("a"), String("a"), true},
+		{String("a"), Name("a"), false},
+		{Name("a"), Name("a"), true},
+		{Keyword("a"), Keyword("a"), true},
+		{Keyword("a"), MimeType("a"), false},
+		{MimeType("a"), MimeType("a"), true},
+		{u1, u2, true},
+		{u1, String(u1.String()), false},
+		{TextWithLang{"en", "a"}, TextWithLang{"en", "a"}, true},
+		{TextWithLang{"en", "a"}, TextWithLang{"de", "a"}, false},
+		{Range{1, 2}, Range{1, 2}, true},
+		{Range{1, 2}, Range{1, 3}, false},
+		{Resolution{300, 300, UnitsDpi}, Resolution{300, 300, UnitsDpi}, true},
+		{Resolution{300, 300, UnitsDpi}, Resolution{300, 300, UnitsDpcm}, false},
+		{Time{tm}, Time{tm.In(time.FixedZone("UTC+3", 3*3600))}, true},
+		{Time{tm}, Time{tm.Add(time.Second)}, false},
+		{Void{}, Void{}, true},
+		{Void{}, Integer(0), false},
+		{Binary("abc"), Binary("abc"), true},
+		{Binary("abc"), BinaryRef{strings.NewReader("abc"), 0, 3}, true},
+		{Binary("abc"), String("abc"), false},
+		{Collection{MakeAttr("a", TagInteger, Integer(1))},
+			Collection{MakeAttr("a", TagInteger, Integer(1))}, true},
+		{Collection{MakeAttr("a", TagInteger, Integer(1))},
+			Collection{MakeAttr("a", TagInteger, Integer(2))}, false},
+	}
+
+	for _, test := range tests {
+		equal := ValueEqual(test.v1, test.v2)
+		if equal != test.equal {
+			t.Errorf("ValueEqual(%#v, %#v): %v, expected %v",
+				test.v1, test.v2, equal, test.equal)
+		}
+
+		equal = ValueEqual(test.v2, test.v1)
+		if equal != test.equal {
+			t.Errorf("ValueEqual(%#v, %#v): %v, expected %v",
+				test.v2, test.v1, equal, test.equal)
+		}
+	}
+}
+
+// Test (*Attribute) EqualTo()
+func TestAttributeEqualTo(t *testing.T) {
+	attr := Attribute{Name: "job-ids"}
+	for i := 0; i < 1000; i++ {
+		attr.Values.Add(TagInteger, Integer(i))
+	}
+
+	same := attr
+	if !attr.EqualTo(&same) {
+		t.Errorf("EqualTo: failed for the same values")
+	}
+
+	clone := Attribute{Name: attr.Name}
+	clone.Values = append(clone.Values, attr.Values...)
+	if !attr.EqualTo(&clone) || !attr.Equal(clone) {
+		t.Errorf("EqualTo: failed for equal values")
+	}
+
+	clone.Values[len(clone.Values)-1].V = Integer(-1)
+	if attr.EqualTo(&clone) || attr.Equal(clone) {
+		t.Errorf("EqualTo: failed for different last value")
+	}
+
+	clone.Values[len(clone.Values)-1].V = attr.Values[len(attr.Values)-1].V
+	clone.Values[0].V = Integer(-1)
+	if attr.EqualTo(&clone) {
+		t.Errorf("EqualTo: failed for different first value")
+	}
+
+	clone = Attribute{Name: "job-id", Values: attr.Values}
+	if attr.EqualTo(&clone) {
+		t.Errorf("EqualTo: failed for different names")
+	}
+}
+
+// Test TaggedValue and its compatibility with anonymous struct
+func TestTaggedValue(t *testing.T) {
+	values := MakeValues(TagInteger, Integer(1), Integer(2))
+	values.AddTagged(MakeTaggedValue(TagKeyword, String("three")))
+
+	expected := Values{
+		{TagInteger, Integer(1)},
+		{TagInteger, Integer(2)},
+		{TagKeyword, String("three")},
+	}
+
+	if !values.Equal(expected) {
+		t.Errorf("expected %s, present %s", expected, values)
+	}
+
+	// Values elements are assignable to and from anonymous struct
+	var anon struct {
+		T Tag
+		V Value
+	}
+
+	anon = values[2]
+	values[0] = anon
+
+	if values[0].T != TagKeyword || values[0].V != String("three") {
+		t.Errorf("anonymous struct assignment failed: %s", values)
+	}
+
+	values.Add(TagBoolean, Boolean(true))
+	if tv := values[3]; tv != MakeTaggedValue(TagBoolean, Boolean(true)) {
+		t.Errorf("Values.Add: unexpected value %v", tv)
+	}
+}
+
+// Test Values.Delete, Values.RemoveIf and Attribute.Prune
+func TestValuesRemove(t *testing.T) {
+	values := MakeAttr("a", TagInteger,
+		Integer(1), Integer(2), Integer(3), Integer(4)).Values
+
+	values.Delete(0)
+	values.Delete(1)
+	if s := values.String(); s != "[2,4]" {
+		t.Errorf("Values.Delete: expected [2,4], present %s", s)
+	}
+
+	values.Delete(1)
+	values.Delete(0)
+	if len(values) != 0 {
+		t.Errorf("Values.Delete: expected empty, present %s", values)
+	}
+
+	values = MakeAttr("a", TagInteger,
+		Integer(1), Integer(2), Integer(3), Integer(4)).Values
+	values.Add(TagNoValue, Void{})
+
+	odd := func(t Tag, v Value) bool {
+		i, ok := v.(Integer)
+		return ok && i%2 == 1
+	}
+
+	if n := values.RemoveIf(odd); n != 2 {
+		t.Errorf("Values.RemoveIf: expected 2 removed, present %d", n)
+	}
+
+	if s := values.String(); s != "[2,4,]" {
+		t.Errorf("Values.RemoveIf: expected [2,4,], present %s", s)
+	}
+
+	// Prune
+	attr := MakeAttr("media-col-database", TagBeginCollection,
+		Collection{
+			MakeAttrCollection("media-size",
+				MakeAttr("x-dimension", TagInteger, Integer(21000)),
+				MakeAttr("y-dimension", TagInteger, Integer(29700))),
+			MakeAttr("media-top-margin", TagNoValue, Void{}),
+		},
+		Collection{
+			MakeAttr("media-type", TagKeyword, String("stationery")),
+		})
+	attr.Values.Add(TagUnknown, Void{})
+
+	outOfBand := func(t Tag, v Value) bool {
+		return t.IsOutOfBand()
+	}
+
+	if n := attr.Prune(outOfBand); n != 2 {
+		t.Errorf("Attribute.Prune: expected 2 removed, present %d", n)
+	}
+
+	expected := MakeAttr("media-col-database", TagBeginCollection,
+		Collection{
+			MakeAttrCollection("media-size",
+				MakeAttr("x-dimension", TagInteger, Integer(21000)),
+				MakeAttr("y-dimension", TagInteger, Integer(29700))),
+		},
+		Collection{
+			MakeAttr("media-type", TagKeyword, String("stationery")),
+		})
+
+	if !attr.Equal(expected) {
+		t.Errorf("Attribute.Prune:\nexpected: %s\npresent:  %s",
+			expected.Values, attr.Values)
+	}
+
+	// Pruning of all values of the member removes the member
+	small := func(t Tag, v Value) bool {
+		i, ok := v.(Integer)
+		return ok && i < 25000
+	}
+
+	attr.Prune(small)
+	col := attr.Values[0].V.(Collection)
+	if len(col) != 1 || len(col[0].Values[0].V.(Collection)) != 1 {
+		t.Errorf("Attribute.Prune: unexpected result %s", attr.Values)
+	}
+}
+
 // Test (Attributes) Equal()
 func TestAttributesEqual(t *testing.T) {
 	attr1 := MakeAttribute("attr1", TagInteger, Integer(1))
@@ -552,93 +1060,432 @@ func TestMessageEqual(t *testing.T) {
 	}
 }
 
-// Test Version
-func TestVersion(t *testing.T) {
-	v := MakeVersion(1, 2)
-	if v.Major() != 1 || v.Minor() != 2 {
-		t.Errorf("Version test failed")
-	}
-	if v.String() != "1.2" {
-		t.Errorf("(Version)String() test failed")
+// Test (*Message) ValidateCode()
+func TestMessageValidateCode(t *testing.T) {
+	tests := []struct {
+		role MessageRole
+		code Code
+		err  string
+	}{
+		{MessageRoleRequest, Code(OpPrintJob), ""},
+		{MessageRoleRequest, Code(OpCupsGetDefault), ""},
+		{MessageRoleRequest, 0x0000, "Invalid operation code 0x0000"},
+		{MessageRoleRequest, 0x8000, "Invalid operation code 0x8000"},
+		{MessageRoleResponse, Code(StatusOk), ""},
+		{MessageRoleResponse, Code(StatusErrorBadRequest), ""},
+		{MessageRoleResponse, 0x0580, ""},
+		{MessageRoleResponse, 0x0300, "Invalid status code 0x0300"},
+		{MessageRoleResponse, 0x0600, "Invalid status code 0x0600"},
+		{MessageRoleUnknown, 0x0000, "Message role is unknown"},
 	}
-}
 
-// testEncodeDecodeMessage creates a quite complex message
-// for Encode/Decode test
-func testEncodeDecodeMessage() *Message {
-	m := &Message{
-		Version:   DefaultVersion,
-		Code:      0x1234,
-		RequestID: 0x87654321,
+	for _, test := range tests {
+		m := Message{Code: test.code, Role: test.role}
+		err := m.ValidateCode()
+		if test.err == "" {
+			assertNoError(t, err)
+		} else {
+			assertErrorIs(t, err, test.err)
+		}
 	}
 
-	// Populate all groups
-	m.Operation.Add(MakeAttribute("grp_operation", TagInteger,
-		Integer(1)))
-	m.Job.Add(MakeAttribute("grp_job", TagInteger,
-		Integer(2)))
-	m.Printer.Add(MakeAttribute("grp_printer", TagInteger,
-		Integer(3)))
-	m.Unsupported.Add(MakeAttribute("grp_unsupported", TagInteger,
-		Integer(4)))
-	m.Subscription.Add(MakeAttribute("grp_subscription", TagInteger,
-		Integer(5)))
-	m.EventNotification.Add(MakeAttribute("grp_eventnotification", TagInteger,
-		Integer(6)))
-	m.Resource.Add(MakeAttribute("grp_resource", TagInteger,
-		Integer(7)))
-	m.Document.Add(MakeAttribute("grp_document", TagInteger,
-		Integer(8)))
-	m.System.Add(MakeAttribute("grp_system", TagInteger,
-		Integer(9)))
-	m.Future11.Add(MakeAttribute("grp_future11", TagInteger,
-		Integer(10)))
-	m.Future12.Add(MakeAttribute("grp_future12", TagInteger,
-		Integer(11)))
-	m.Future13.Add(MakeAttribute("grp_future13", TagInteger,
-		Integer(12)))
-	m.Future14.Add(MakeAttribute("grp_future14", TagInteger,
-		Integer(13)))
-	m.Future15.Add(MakeAttribute("grp_future15", TagInteger,
-		Integer(14)))
+	if m := NewRequest(DefaultVersion, OpPrintJob, 1); m.Role != MessageRoleRequest {
+		t.Errorf("NewRequest: role is %s", m.Role)
+	}
 
-	// Use all possible attribute types
-	m.Operation.Add(MakeAttribute("type_integer", TagInteger, Integer(123)))
+	if m := NewResponse(DefaultVersion, StatusOk, 1); m.Role != MessageRoleResponse {
+		t.Errorf("NewResponse: role is %s", m.Role)
+	}
 
-	m.Operation.Add(MakeAttribute("type_boolean_t", TagBoolean, Boolean(true)))
-	m.Operation.Add(MakeAttribute("type_boolean_f", TagBoolean, Boolean(false)))
+	// Decode must preserve Role
+	data, err := NewResponse(DefaultVersion, StatusOk, 1).EncodeBytes()
+	assertNoError(t, err)
 
-	m.Operation.Add(MakeAttribute("type_void", TagUnsupportedValue, Void{}))
+	m := Message{Role: MessageRoleResponse}
+	err = m.DecodeBytes(data)
+	assertNoError(t, err)
+	if m.Role != MessageRoleResponse {
+		t.Errorf("DecodeBytes: role is %s", m.Role)
+	}
+}
 
-	m.Operation.Add(MakeAttribute("type_string_1", TagText, String("hello")))
-	m.Operation.Add(MakeAttribute("type_string_2", TagText, String("")))
+// Test decoding with DecoderOptions.Arena
+func TestDecodeArena(t *testing.T) {
+	var m1, m2 Message
 
-	m.Operation.Add(MakeAttribute("type_time_1", TagDateTime,
-		parseTime("01/02 03:04:05PM '06 -0700")))
-	m.Operation.Add(MakeAttribute("type_time_2", TagDateTime,
-		parseTime("01/02 03:04:05PM '06 +0700")))
-	m.Operation.Add(MakeAttribute("type_time_3", TagDateTime,
-		parseTime("01/02 03:04:05PM '06 -0730")))
+	err := m1.DecodeBytes(attrsHPOfficeJetPro8730)
+	assertNoError(t, err)
 
-	m.Operation.Add(MakeAttribute("type_resolution_1", TagResolution,
-		Resolution{123, 456, UnitsDpi}))
-	m.Operation.Add(MakeAttribute("type_resolution_2", TagResolution,
-		Resolution{78, 90, UnitsDpcm}))
+	err = m2.DecodeBytesEx(attrsHPOfficeJetPro8730,
+		DecoderOptions{Arena: true})
+	assertNoError(t, err)
 
-	m.Operation.Add(MakeAttribute("type_range", TagRange,
-		Range{100, 1000}))
+	if !m1.Equal(m2) {
+		t.Errorf("Arena: decoded messages differ")
+	}
 
-	m.Operation.Add(MakeAttribute("type_textlang_1", TagTextLang,
-		TextWithLang{"hello", "en"}))
-	m.Operation.Add(MakeAttribute("type_textlang_1", TagTextLang,
-		TextWithLang{"привет", "ru"}))
+	// Appending to arena-allocated Values must not affect
+	// other attributes
+	attrs := m2.Printer.Clone()
+	for i := range m2.Printer {
+		m2.Printer[i].Values.Add(TagInteger, Integer(i))
+	}
 
-	return m
+	for i := range m2.Printer {
+		values := m2.Printer[i].Values
+		values = values[:len(values)-1]
+		if !values.Equal(attrs[i].Values) {
+			t.Errorf("Arena: %s: values corrupted",
+				m2.Printer[i].Name)
+		}
+	}
 }
 
-// Test Message Encode, then Decode
-func TestEncodeDecode(t *testing.T) {
-	m1 := testEncodeDecodeMessage()
+// Test decoding with DecoderOptions.Names
+func TestDecodeNames(t *testing.T) {
+	var m1, m2 Message
+
+	err := m1.DecodeBytes(attrsHPOfficeJetPro8730)
+	assertNoError(t, err)
+
+	names := NewNameTable(0)
+	opt := DecoderOptions{Names: names}
+	err = m2.DecodeBytesEx(attrsHPOfficeJetPro8730, opt)
+	assertNoError(t, err)
+
+	if !m1.Equal(m2) {
+		t.Errorf("Names: decoded messages differ")
+	}
+
+	// Decoding the same message again must not grow the table
+	n := names.Len()
+	err = m2.DecodeBytesEx(attrsHPOfficeJetPro8730, opt)
+	assertNoError(t, err)
+
+	if names.Len() != n || n == 0 {
+		t.Errorf("Names: table size %d, expected %d", names.Len(), n)
+	}
+
+	// Table size limit
+	names = NewNameTable(5)
+	err = m2.DecodeBytesEx(attrsHPOfficeJetPro8730,
+		DecoderOptions{Names: names})
+	assertNoError(t, err)
+
+	if names.Len() != 5 {
+		t.Errorf("Names: table size %d, expected 5", names.Len())
+	}
+
+	if !m1.Equal(m2) {
+		t.Errorf("Names: decoded messages differ with full table")
+	}
+}
+
+// Test decoding with DecoderOptions.AttributeFilter
+func TestDecodeAttributeFilter(t *testing.T) {
+	var full, filtered Message
+
+	err := full.DecodeBytes(attrsHPOfficeJetPro8730)
+	assertNoError(t, err)
+
+	wanted := map[string]bool{
+		"attributes-charset":      true,
+		"printer-name":            true,
+		"media-col-default":       true,
+		"media-supported":         true,
+		"printer-resolution-only": true,
+	}
+
+	opt := DecoderOptions{
+		AttributeFilter: func(group Tag, name string) bool {
+			return wanted[name]
+		},
+	}
+
+	err = filtered.DecodeBytesEx(attrsHPOfficeJetPro8730, opt)
+	assertNoError(t, err)
+
+	// Build the expected message from the fully decoded one
+	expected := full
+	expected.Groups = nil
+	for _, grp := range full.Groups {
+		var attrs Attributes
+		for _, attr := range grp.Attrs {
+			if wanted[attr.Name] {
+				attrs.Add(attr)
+			}
+		}
+		expected.Groups.Add(Group{grp.Tag, attrs})
+	}
+
+	if !expected.Equal(filtered) {
+		t.Errorf("AttributeFilter: unexpected result")
+	}
+
+	if len(filtered.Printer) == 0 {
+		t.Errorf("AttributeFilter: no printer attributes decoded")
+	}
+
+	// Skipped attributes are still checked for wire format errors
+	data := append([]byte(nil), attrsHPOfficeJetPro8730...)
+	data = data[:len(data)-1]
+	err = filtered.DecodeBytesEx(data, opt)
+	assertWithError(t, err)
+}
+
+// Test (*Message) DecodeHeaderAndOperation()
+func TestDecodeHeaderAndOperation(t *testing.T) {
+	var full, m Message
+
+	err := full.DecodeBytes(attrsHPOfficeJetPro8730)
+	assertNoError(t, err)
+
+	doc := []byte("%PDF-1.4")
+	data := append(append([]byte(nil), attrsHPOfficeJetPro8730...), doc...)
+
+	rest, err := m.DecodeHeaderAndOperation(bytes.NewReader(data))
+	assertNoError(t, err)
+
+	if m.Code != full.Code || m.RequestID != full.RequestID ||
+		!m.Operation.Equal(full.Operation) {
+		t.Errorf("DecodeHeaderAndOperation: header mismatch")
+	}
+
+	if len(m.Printer) != 0 || len(m.Groups) != 1 {
+		t.Errorf("DecodeHeaderAndOperation: decoded beyond " +
+			"operation attributes")
+	}
+
+	// The rest must contain the remaining groups and document
+	restData, err := ioutil.ReadAll(rest)
+	assertNoError(t, err)
+
+	hdrLen := len(attrsHPOfficeJetPro8730) - len(restData) + len(doc)
+	if !bytes.Equal(restData, data[hdrLen:]) {
+		t.Errorf("DecodeHeaderAndOperation: rest mismatch")
+	}
+
+	// Message with operation attributes only
+	rq := NewRequest(DefaultVersion, OpGetPrinterAttributes, 1)
+	rq.Operation.Add(MakeAttr("attributes-charset", TagCharset,
+		String("utf-8")))
+	data, err = rq.EncodeBytes()
+	assertNoError(t, err)
+
+	rest, err = m.DecodeHeaderAndOperation(bytes.NewReader(
+		append(data, doc...)))
+	assertNoError(t, err)
+
+	restData, _ = ioutil.ReadAll(rest)
+	if !bytes.Equal(restData, doc) {
+		t.Errorf("DecodeHeaderAndOperation: rest mismatch")
+	}
+}
+
+// Test DecoderOptions.ValidateUTF8
+func TestDecodeValidateUTF8(t *testing.T) {
+	mkmsg := func(charset string) []byte {
+		m := NewResponse(DefaultVersion, StatusOk, 1)
+		m.Operation.Add(MakeAttribute("attributes-charset",
+			TagCharset, String(charset)))
+		m.Printer.Add(MakeAttribute("printer-info", TagText,
+			String("bad \xff text")))
+		m.Printer.Add(MakeAttribute("printer-name", TagNameLang,
+			TextWithLang{"en", "bad \xfe name"}))
+		m.Printer.Add(MakeAttribute("printer-uuid", TagURI,
+			String("urn:\xff")))
+		data, _ := m.EncodeBytes()
+		return data
+	}
+
+	var m Message
+	var warnings []string
+	opt := DecoderOptions{
+		Warning: func(err error) {
+			warnings = append(warnings, err.Error())
+		},
+	}
+
+	// UTF8Ignore
+	data := mkmsg("utf-8")
+	assertNoError(t, m.DecodeBytesEx(data, opt))
+	if len(warnings) != 0 {
+		t.Errorf("UTF8Ignore: unexpected warnings: %q", warnings)
+	}
+
+	// UTF8Reject
+	opt.ValidateUTF8 = UTF8Reject
+	err := m.DecodeBytesEx(data, opt)
+	assertErrorIs(t, err, "printer-info: textWithoutLanguage value: invalid UTF-8 at 0x37")
+
+	// UTF8Warn
+	opt.ValidateUTF8 = UTF8Warn
+	assertNoError(t, m.DecodeBytesEx(data, opt))
+	expected := []string{
+		"printer-info: textWithoutLanguage value: invalid UTF-8 at 0x37",
+		"printer-name: nameWithLanguage value: invalid UTF-8 at 0x52",
+	}
+	if !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("UTF8Warn: warnings:\n%q\nexpected:\n%q",
+			warnings, expected)
+	}
+
+	// Non-UTF-8 charset disables validation
+	opt.ValidateUTF8 = UTF8Reject
+	assertNoError(t, m.DecodeBytesEx(mkmsg("iso-8859-1"), opt))
+}
+
+// Test (*Message) DecodeDeferred()
+func TestDecodeDeferred(t *testing.T) {
+	var full, m Message
+
+	err := full.DecodeBytes(attrsHPOfficeJetPro8730)
+	assertNoError(t, err)
+
+	doc := []byte("%PDF-1.4")
+	in := bytes.NewReader(append(append([]byte(nil),
+		attrsHPOfficeJetPro8730...), doc...))
+
+	cont, err := m.DecodeDeferred(in)
+	assertNoError(t, err)
+
+	if cont.Done() || len(m.Printer) != 0 {
+		t.Errorf("DecodeDeferred: decoded beyond operation attributes")
+	}
+
+	// Operation attributes may be modified before Finish
+	m.Operation.Add(MakeAttr("x-proxy", TagKeyword, String("yes")))
+
+	err = cont.Finish()
+	assertNoError(t, err)
+
+	if !cont.Done() {
+		t.Errorf("DecodeContinuation.Finish: not done")
+	}
+
+	if len(m.Groups) != len(full.Groups) ||
+		len(m.Operation) != len(full.Operation)+1 {
+		t.Errorf("DecodeContinuation.Finish: groups mismatch")
+	}
+
+	if !m.Printer.Equal(full.Printer) {
+		t.Errorf("DecodeContinuation.Finish: printer attributes mismatch")
+	}
+
+	// Input stream must be positioned after the message
+	rest, _ := ioutil.ReadAll(in)
+	if !bytes.Equal(rest, doc) {
+		t.Errorf("DecodeContinuation.Finish: input position mismatch")
+	}
+
+	// Finish after Rest must fail
+	cont, err = m.DecodeDeferred(bytes.NewReader(attrsHPOfficeJetPro8730))
+	assertNoError(t, err)
+
+	cont.Rest()
+	err = cont.Finish()
+	assertErrorIs(t, err, "Message already consumed by Rest")
+
+	// Decoding errors are sticky
+	data := attrsHPOfficeJetPro8730[:len(attrsHPOfficeJetPro8730)-1]
+	cont, err = m.DecodeDeferred(bytes.NewReader(data))
+	assertNoError(t, err)
+
+	err = cont.Finish()
+	assertWithError(t, err)
+
+	if err2 := cont.Finish(); err2 != err {
+		t.Errorf("DecodeContinuation.Finish: error is not sticky")
+	}
+}
+
+// Test Version
+func TestVersion(t *testing.T) {
+	v := MakeVersion(1, 2)
+	if v.Major() != 1 || v.Minor() != 2 {
+		t.Errorf("Version test failed")
+	}
+	if v.String() != "1.2" {
+		t.Errorf("(Version)String() test failed")
+	}
+}
+
+// testEncodeDecodeMessage creates a quite complex message
+// for Encode/Decode test
+func testEncodeDecodeMessage() *Message {
+	m := &Message{
+		Version:   DefaultVersion,
+		Code:      0x1234,
+		RequestID: 0x87654321,
+	}
+
+	// Populate all groups
+	m.Operation.Add(MakeAttribute("grp_operation", TagInteger,
+		Integer(1)))
+	m.Job.Add(MakeAttribute("grp_job", TagInteger,
+		Integer(2)))
+	m.Printer.Add(MakeAttribute("grp_printer", TagInteger,
+		Integer(3)))
+	m.Unsupported.Add(MakeAttribute("grp_unsupported", TagInteger,
+		Integer(4)))
+	m.Subscription.Add(MakeAttribute("grp_subscription", TagInteger,
+		Integer(5)))
+	m.EventNotification.Add(MakeAttribute("grp_eventnotification", TagInteger,
+		Integer(6)))
+	m.Resource.Add(MakeAttribute("grp_resource", TagInteger,
+		Integer(7)))
+	m.Document.Add(MakeAttribute("grp_document", TagInteger,
+		Integer(8)))
+	m.System.Add(MakeAttribute("grp_system", TagInteger,
+		Integer(9)))
+	m.Future11.Add(MakeAttribute("grp_future11", TagInteger,
+		Integer(10)))
+	m.Future12.Add(MakeAttribute("grp_future12", TagInteger,
+		Integer(11)))
+	m.Future13.Add(MakeAttribute("grp_future13", TagInteger,
+		Integer(12)))
+	m.Future14.Add(MakeAttribute("grp_future14", TagInteger,
+		Integer(13)))
+	m.Future15.Add(MakeAttribute("grp_future15", TagInteger,
+		Integer(14)))
+
+	// Use all possible attribute types
+	m.Operation.Add(MakeAttribute("type_integer", TagInteger, Integer(123)))
+
+	m.Operation.Add(MakeAttribute("type_boolean_t", TagBoolean, Boolean(true)))
+	m.Operation.Add(MakeAttribute("type_boolean_f", TagBoolean, Boolean(false)))
+
+	m.Operation.Add(MakeAttribute("type_void", TagUnsupportedValue, Void{}))
+
+	m.Operation.Add(MakeAttribute("type_string_1", TagText, String("hello")))
+	m.Operation.Add(MakeAttribute("type_string_2", TagText, String("")))
+
+	m.Operation.Add(MakeAttribute("type_time_1", TagDateTime,
+		parseTime("01/02 03:04:05PM '06 -0700")))
+	m.Operation.Add(MakeAttribute("type_time_2", TagDateTime,
+		parseTime("01/02 03:04:05PM '06 +0700")))
+	m.Operation.Add(MakeAttribute("type_time_3", TagDateTime,
+		parseTime("01/02 03:04:05PM '06 -0730")))
+
+	m.Operation.Add(MakeAttribute("type_resolution_1", TagResolution,
+		Resolution{123, 456, UnitsDpi}))
+	m.Operation.Add(MakeAttribute("type_resolution_2", TagResolution,
+		Resolution{78, 90, UnitsDpcm}))
+
+	m.Operation.Add(MakeAttribute("type_range", TagRange,
+		Range{100, 1000}))
+
+	m.Operation.Add(MakeAttribute("type_textlang_1", TagTextLang,
+		TextWithLang{"hello", "en"}))
+	m.Operation.Add(MakeAttribute("type_textlang_1", TagTextLang,
+		TextWithLang{"привет", "ru"}))
+
+	return m
+}
+
+// Test Message Encode, then Decode
+func TestEncodeDecode(t *testing.T) {
+	m1 := testEncodeDecodeMessage()
 
 	data, err := m1.EncodeBytes()
 	assertNoError(t, err)
@@ -666,6 +1513,11 @@ func TestEncodeErrors(t *testing.T) {
 	err = m.Encode(ioutil.Discard)
 	assertErrorIs(t, err, "Attribute without value")
 
+	// Error is not masked by the subsequent valid attribute
+	m.Operation.Add(MakeAttribute("attr2", TagInteger, Integer(1)))
+	err = m.Encode(ioutil.Discard)
+	assertErrorIs(t, err, "Attribute without value")
+
 	// Attribute name exceeds...
 	m = NewRequest(DefaultVersion, OpGetPrinterAttributes, 0x12345678)
 	a = MakeAttribute("attr", TagInteger, Integer(123))
@@ -722,6 +1574,17 @@ func TestEncodeErrors(t *testing.T) {
 	err = m.Encode(ioutil.Discard)
 	assertErrorIs(t, err, "Collection member without name")
 
+	// Duplicated collection member
+	m = NewRequest(DefaultVersion, OpGetPrinterAttributes, 0x12345678)
+	a = MakeAttribute("attr", TagBeginCollection, Collection{
+		MakeAttribute("x", TagInteger, Integer(1)),
+		MakeAttribute("y", TagInteger, Integer(2)),
+		MakeAttribute("x", TagInteger, Integer(3)),
+	})
+	m.Operation.Add(a)
+	err = m.Encode(ioutil.Discard)
+	assertErrorIs(t, err, `Collection member "x" is duplicated`)
+
 	// Tag XXX: YYY value required, ZZZ present
 	m = NewRequest(DefaultVersion, OpGetPrinterAttributes, 0x12345678)
 	a = MakeAttribute("attr", TagText, Integer(123))
@@ -1295,6 +2158,1133 @@ func TestTagExtension(t *testing.T) {
 	assertErrorIs(t, err, "Extension tag out of range")
 }
 
+// Test Collection.Validate
+func TestCollectionValidate(t *testing.T) {
+	size := func(members ...Attribute) Attribute {
+		return MakeAttribute("media-size", TagBeginCollection,
+			Collection(members))
+	}
+
+	x := MakeAttribute("x-dimension", TagInteger, Integer(21000))
+	y := MakeAttribute("y-dimension", TagInteger, Integer(29700))
+	noname := MakeAttribute("", TagInteger, Integer(0))
+
+	tests := []struct {
+		col Collection
+		err string
+	}{
+		{Collection{size(x, y)}, ""},
+		{Collection{noname}, "Collection member without name"},
+		{Collection{size(x, y), size(x, y)},
+			`Collection member "media-size" is duplicated`},
+		{Collection{size(x, noname)},
+			`Collection member "media-size/" without name`},
+		{Collection{size(x, y, x)},
+			`Collection member "media-size/x-dimension" is duplicated`},
+		{Collection{MakeAttr("media-col-database", TagBeginCollection,
+			Collection{size(x, y)}, Collection{size(y, y)})},
+			`Collection member "media-col-database/media-size/` +
+				`y-dimension" is duplicated`},
+	}
+
+	for _, test := range tests {
+		err := test.col.Validate()
+		if err == nil && test.err != "" {
+			t.Errorf("%s: error not detected", test.col)
+		} else if err != nil && err.Error() != test.err {
+			t.Errorf("%s: error %q, expected %q", test.col, err, test.err)
+		}
+	}
+}
+
+// Test 1setOf collections, nested inside collections
+func TestNestedCollections(t *testing.T) {
+	size := func(x, y int) Collection {
+		return Collection{
+			MakeAttribute("x-dimension", TagInteger, Integer(x)),
+			MakeAttribute("y-dimension", TagInteger, Integer(y)),
+		}
+	}
+
+	// media-col-ready, containing 1setOf media-size, containing
+	// 1setOf collections with 1setOf collections inside
+	deep := MakeAttr("level-1", TagBeginCollection,
+		Collection{
+			MakeAttr("level-2", TagBeginCollection,
+				Collection{
+					MakeAttr("level-3", TagInteger,
+						Integer(1), Integer(2), Integer(3)),
+				},
+				Collection{},
+				Collection{
+					MakeAttr("level-3", TagBeginCollection,
+						Collection{}, Collection{}),
+				}),
+		},
+		Collection{})
+
+	mediaCol := Collection{
+		MakeAttr("media-size", TagBeginCollection,
+			size(21000, 29700), size(10000, 15000)),
+		MakeAttr("media-source", TagKeyword, String("main")),
+		deep,
+		MakeAttr("mixed", TagInteger, Integer(1)),
+	}
+	mediaCol[3].Values.Add(TagBeginCollection, size(1, 2))
+	mediaCol[3].Values.Add(TagKeyword, String("none"))
+
+	m1 := NewResponse(DefaultVersion, StatusOk, 1)
+	m1.Printer.Add(MakeAttr("media-col-ready", TagBeginCollection,
+		mediaCol, mediaCol, Collection{}))
+	m1.Printer.Add(MakeAttribute("printer-name", TagName,
+		String("printer")))
+
+	data, err := m1.EncodeBytes()
+	assertNoError(t, err)
+
+	opts := []DecoderOptions{
+		{},
+		{Arena: true},
+		{EnableWorkarounds: true},
+	}
+
+	for _, opt := range opts {
+		var m2 Message
+		err = m2.DecodeBytesEx(data, opt)
+		assertNoError(t, err)
+
+		if !m1.Equal(m2) {
+			t.Errorf("%#v: message is not the same after "+
+				"encoding and decoding", opt)
+		}
+	}
+
+	// Check JSON round trip
+	jdata, err := json.Marshal(m1)
+	assertNoError(t, err)
+
+	var m2 Message
+	err = json.Unmarshal(jdata, &m2)
+	assertNoError(t, err)
+
+	if !m1.Equal(m2) {
+		t.Errorf("message is not the same after JSON round trip")
+	}
+
+	// Check that filter correctly skips the whole thing
+	m2 = Message{}
+	err = m2.DecodeBytesEx(data, DecoderOptions{
+		AttributeFilter: func(group Tag, name string) bool {
+			return name != "media-col-ready"
+		},
+	})
+	assertNoError(t, err)
+
+	if len(m2.Printer) != 1 || m2.Printer[0].Name != "printer-name" {
+		t.Errorf("AttributeFilter: %v", m2.Printer)
+	}
+}
+
+// Test GroupTag
+func TestGroupTag(t *testing.T) {
+	for tag := TagZero; tag < 0x20; tag++ {
+		gt, err := MakeGroupTag(tag)
+		switch {
+		case tag.IsGroup() && err != nil:
+			t.Errorf("MakeGroupTag(%s): unexpected error: %s", tag, err)
+		case tag.IsGroup() && gt.Tag() != tag:
+			t.Errorf("MakeGroupTag(%s): %s", tag, gt)
+		case !tag.IsGroup() && err == nil:
+			t.Errorf("MakeGroupTag(%s): error not detected", tag)
+		}
+	}
+
+	g := MakeGroup(GroupTagPrinter, nil)
+	if g.Tag != TagPrinterGroup {
+		t.Errorf("MakeGroup(%s): tag is %s", GroupTagPrinter, g.Tag)
+	}
+
+	if gt := g.GroupTag(); gt != GroupTagPrinter {
+		t.Errorf("Group.GroupTag(): %s", gt)
+	}
+
+	if s := GroupTagJob.String(); s != TagJobGroup.String() {
+		t.Errorf("GroupTag.String(): %q", s)
+	}
+}
+
+// Test Groups.Filter and Message.CopyGroup
+func TestCopyGroup(t *testing.T) {
+	ops := Attributes{
+		MakeAttr("attributes-charset", TagCharset, String("utf-8")),
+	}
+	job1 := Attributes{MakeAttr("job-id", TagInteger, Integer(1))}
+	job2 := Attributes{MakeAttr("job-id", TagInteger, Integer(2))}
+
+	src := NewMessageWithGroups(DefaultVersion, Code(OpGetJobs), 1,
+		Groups{
+			{TagOperationGroup, ops},
+			{TagJobGroup, job1},
+			{TagJobGroup, job2},
+		})
+
+	filtered := src.Groups.Filter(TagJobGroup)
+	expected := Groups{{TagJobGroup, job1}, {TagJobGroup, job2}}
+	if !filtered.Equal(expected) {
+		t.Errorf("Groups.Filter: unexpected result %v", filtered)
+	}
+
+	if filtered := src.Groups.Filter(TagPrinterGroup); filtered != nil {
+		t.Errorf("Groups.Filter: unexpected result %v", filtered)
+	}
+
+	// Copy to the message with Groups
+	dst := NewMessageWithGroups(DefaultVersion, Code(StatusOk), 1,
+		Groups{{TagOperationGroup, ops}})
+	src.CopyGroup(dst, TagJobGroup)
+
+	expected = Groups{
+		{TagOperationGroup, ops},
+		{TagJobGroup, job1},
+		{TagJobGroup, job2},
+	}
+	if !dst.Groups.Equal(expected) {
+		t.Errorf("Message.CopyGroup: unexpected Groups %v", dst.Groups)
+	}
+
+	if !dst.Job.Equal(append(job1.Clone(), job2...)) {
+		t.Errorf("Message.CopyGroup: unexpected Job %v", dst.Job)
+	}
+
+	// Copy to the message without Groups
+	dst = NewResponse(DefaultVersion, StatusOk, 1)
+	src.CopyGroup(dst, TagOperationGroup)
+
+	if dst.Groups != nil {
+		t.Errorf("Message.CopyGroup: unexpected Groups %v", dst.Groups)
+	}
+
+	if !dst.Operation.Equal(ops) {
+		t.Errorf("Message.CopyGroup: unexpected Operation %v",
+			dst.Operation)
+	}
+
+	// Copied attributes must not share storage with source
+	dst.Operation[0].Name = "changed"
+	if ops[0].Name != "attributes-charset" {
+		t.Errorf("Message.CopyGroup: source modified")
+	}
+}
+
+// Test zero-length values vs out-of-band values
+func TestZeroLengthValues(t *testing.T) {
+	m1 := NewResponse(DefaultVersion, StatusOk, 1)
+	m1.Printer.Add(MakeAttribute("printer-info", TagText, String("")))
+	m1.Printer.Add(MakeAttribute("printer-location", TagNoValue, Void{}))
+	m1.Printer.Add(MakeAttribute("printer-name", TagName, String("")))
+	m1.Printer.Add(MakeAttribute("printer-geo-location", TagUnknown,
+		Void{}))
+
+	data, err := m1.EncodeBytes()
+	assertNoError(t, err)
+
+	m2 := Message{}
+	err = m2.DecodeBytes(data)
+	assertNoError(t, err)
+
+	if !m1.Equal(m2) {
+		t.Errorf("Message is not the same after encoding and decoding")
+	}
+
+	tests := []struct {
+		outOfBand, emptyString bool
+	}{
+		{false, true},
+		{true, false},
+		{false, true},
+		{true, false},
+	}
+
+	for i, test := range tests {
+		attr := m2.Printer[i]
+		if attr.Values.OutOfBand() != test.outOfBand {
+			t.Errorf("%s: OutOfBand() is %v, expected %v",
+				attr.Name, !test.outOfBand, test.outOfBand)
+		}
+		if attr.Values.EmptyString() != test.emptyString {
+			t.Errorf("%s: EmptyString() is %v, expected %v",
+				attr.Name, !test.emptyString, test.emptyString)
+		}
+	}
+
+	if ValueSimilar(String(""), Void{}) {
+		t.Errorf("Empty string must not be similar to Void")
+	}
+
+	// Re-encoding must produce identical bytes
+	data2, err := m2.EncodeBytes()
+	assertNoError(t, err)
+
+	if !bytes.Equal(data, data2) {
+		t.Errorf("Message is not the same after re-encoding")
+	}
+}
+
+// TestBinaryRef tests BinaryRef values
+func TestBinaryRef(t *testing.T) {
+	blob := Binary(bytes.Repeat([]byte("0123456789"), 100))
+
+	m := NewResponse(DefaultVersion, StatusOk, 1)
+	m.Printer.Add(MakeAttr("vendor-blob", TagString, blob))
+	m.Printer.Add(MakeAttr("vendor-short", TagString, Binary("ab")))
+	m.Printer.Add(MakeAttrCollection("vendor-col",
+		MakeAttribute("vendor-blob", TagString, blob)))
+
+	data, err := m.EncodeBytes()
+	assertNoError(t, err)
+
+	// Decode with BinaryRefs
+	var m2 Message
+	err = m2.DecodeBytesEx(data, DecoderOptions{
+		BinaryRefs:   bytes.NewReader(data),
+		BinaryRefMin: 16,
+	})
+	assertNoError(t, err)
+
+	ref, ok := m2.Printer[0].Values[0].V.(BinaryRef)
+	if !ok {
+		t.Fatalf("vendor-blob: %T, expected BinaryRef",
+			m2.Printer[0].Values[0].V)
+	}
+
+	if _, ok := m2.Printer[1].Values[0].V.(Binary); !ok {
+		t.Errorf("vendor-short: %T, expected Binary",
+			m2.Printer[1].Values[0].V)
+	}
+
+	col := m2.Printer[2].Values[0].V.(Collection)
+	if _, ok := col[0].Values[0].V.(BinaryRef); !ok {
+		t.Errorf("vendor-col/vendor-blob: %T, expected BinaryRef",
+			col[0].Values[0].V)
+	}
+
+	materialized, err := ref.Materialize()
+	assertNoError(t, err)
+	if !bytes.Equal(materialized, blob) {
+		t.Errorf("Materialize: data mismatch")
+	}
+
+	if ref.String() != blob.String() {
+		t.Errorf("String: %q, expected %q", ref.String(), blob.String())
+	}
+
+	// BinaryRef compares and encodes like Binary
+	if !m.Equal(m2) {
+		t.Errorf("Message with BinaryRef values is not equal")
+	}
+
+	data2, err := m2.EncodeBytes()
+	assertNoError(t, err)
+	if !bytes.Equal(data, data2) {
+		t.Errorf("Message is not the same after re-encoding")
+	}
+
+	if !ValueSimilar(ref, String(blob)) {
+		t.Errorf("BinaryRef is not similar to String")
+	}
+
+	// Errors
+	_, err = BinaryRef{}.Materialize()
+	assertErrorIs(t, err, "BinaryRef: missed io.ReaderAt")
+
+	_, err = BinaryRef{R: bytes.NewReader(data), Off: 10,
+		Len: len(data)}.Materialize()
+	assertErrorIs(t, err, "BinaryRef: ")
+}
+
+// TestDecodeResult tests DecoderOptions.Result
+func TestDecodeResult(t *testing.T) {
+	m := NewResponse(DefaultVersion, StatusOk, 1)
+	m.Operation.Add(MakeAttr("attributes-charset", TagCharset,
+		String("utf-8")))
+	m.Printer.Add(MakeAttr("sides-supported", TagKeyword,
+		String("one-sided"), String("two-sided-long-edge")))
+	m.Printer.Add(MakeAttrCollection("media-col-default",
+		MakeAttribute("media-type", TagKeyword, String("auto"))))
+
+	data, err := m.EncodeBytes()
+	assertNoError(t, err)
+
+	// Trailing data must not be consumed
+	in := bytes.NewReader(append(append([]byte{}, data...), "tail"...))
+
+	var res DecodeResult
+	var m2 Message
+	err = m2.DecodeEx(in, DecoderOptions{Result: &res})
+	assertNoError(t, err)
+
+	expected := DecodeResult{
+		Bytes:  len(data),
+		SHA256: sha256.Sum256(data),
+		Groups: 2,
+		Attrs:  3,
+		Values: 5,
+	}
+
+	if res != expected {
+		t.Errorf("DecodeResult:\n%+v\nexpected:\n%+v", res, expected)
+	}
+
+	// Buffered inputs keep their fast path, and Result is the same
+	for _, in := range []io.Reader{
+		bytes.NewBuffer(append(append([]byte{}, data...), "tail"...)),
+		bufio.NewReader(bytes.NewReader(
+			append(append([]byte{}, data...), "tail"...))),
+	} {
+		res = DecodeResult{}
+		err = m2.DecodeEx(in, DecoderOptions{Result: &res})
+		assertNoError(t, err)
+
+		if res != expected {
+			t.Errorf("%T: DecodeResult:\n%+v\nexpected:\n%+v",
+				in, res, expected)
+		}
+
+		tail, _ := ioutil.ReadAll(in)
+		if string(tail) != "tail" {
+			t.Errorf("%T: tail %q", in, tail)
+		}
+	}
+
+	// Truncated message: Result describes the consumed part.
+	// The last value, that doesn't fit into the in-memory input,
+	// is not consumed at all.
+	err = m2.DecodeBytesEx(data[:20], DecoderOptions{Result: &res})
+	assertWithError(t, err)
+
+	if res.Bytes == 0 || res.Bytes > 20 ||
+		res.SHA256 != sha256.Sum256(data[:res.Bytes]) {
+		t.Errorf("DecodeResult of truncated message: %+v", res)
+	}
+
+	err = m2.DecodeEx(struct{ io.Reader }{bytes.NewReader(data[:20])},
+		DecoderOptions{Result: &res})
+	assertWithError(t, err)
+
+	if res.Bytes != 20 || res.SHA256 != sha256.Sum256(data[:20]) {
+		t.Errorf("DecodeResult of truncated message: %+v", res)
+	}
+}
+
+// TestDecodeDateTimeNoZone tests DecoderOptions.DateTimeLocation
+func TestDecodeDateTimeNoZone(t *testing.T) {
+	hdr := []byte{
+		0x01, 0x01, // IPP version
+		0x00, 0x02, // Print-Job operation
+		0x01, 0x02, 0x03, 0x04, // Request ID
+		uint8(TagJobGroup),
+	}
+
+	msg := func(values ...[]byte) []byte {
+		d := append([]byte{}, hdr...)
+		name := "date-time-at-creation"
+		for _, v := range values {
+			d = append(d, uint8(TagDateTime),
+				0, uint8(len(name)))
+			d = append(d, name...)
+			d = append(d, 0, uint8(len(v)))
+			d = append(d, v...)
+			name = ""
+		}
+		return append(d, uint8(TagEnd))
+	}
+
+	//                  year        month day   hour  min   sec   s/10
+	noZone := []byte{0x07, 0xe7, 0x02, 0x15, 0x11, 0x23, 0x32, 0x05}
+	noOffset := append(append([]byte{}, noZone...), '+')
+	withZone := append(append([]byte{}, noZone...), '+', 0x03, 0x00)
+
+	loc := time.FixedZone("Printer", 2*3600)
+	var warnings []string
+	opt := DecoderOptions{
+		DateTimeLocation: loc,
+		Warning: func(err error) {
+			warnings = append(warnings, err.Error())
+		},
+	}
+
+	var m Message
+	err := m.DecodeBytesEx(msg(noZone, noOffset, withZone), opt)
+	assertNoError(t, err)
+
+	values := m.Job[0].Values
+	expected := []time.Time{
+		time.Date(2023, 2, 21, 17, 35, 50, 500000000, loc),
+		time.Date(2023, 2, 21, 17, 35, 50, 500000000, loc),
+		time.Date(2023, 2, 21, 17, 35, 50, 500000000,
+			time.FixedZone("UTC+3", 3*3600)),
+	}
+
+	for i, exp := range expected {
+		tm := values[i].V.(Time).Time
+		if !tm.Equal(exp) || tm.Location().String() !=
+			exp.Location().String() {
+			t.Errorf("value %d: %s, expected %s", i, tm, exp)
+		}
+	}
+
+	if len(warnings) != 2 || !strings.HasPrefix(warnings[0],
+		"date-time-at-creation: dateTime value without time zone, "+
+			"assumed Printer") {
+		t.Errorf("Warnings: %q", warnings)
+	}
+
+	// Values without time zone rejected by default
+	err = m.DecodeBytes(msg(noZone))
+	assertErrorIs(t, err, "dateTime: value must be 11 bytes")
+
+	// Ranges still validated
+	bad := append([]byte{}, noZone...)
+	bad[2] = 13
+	err = m.DecodeBytesEx(msg(bad), opt)
+	assertErrorIs(t, err, "dateTime: bad month 13")
+}
+
+// slowReader reads one byte at a time, with delay
+type slowReader struct {
+	in    io.Reader
+	delay time.Duration
+}
+
+func (r slowReader) Read(buf []byte) (int, error) {
+	time.Sleep(r.delay)
+	if len(buf) > 1 {
+		buf = buf[:1]
+	}
+	return r.in.Read(buf)
+}
+
+// Test DecoderOptions.MaxMessageSize
+func TestDecodeMaxMessageSize(t *testing.T) {
+	size := len(goodMessage1)
+
+	for _, max := range []int{size, size - 1, 100, MessageHeaderSize} {
+		inputs := []io.Reader{
+			bytes.NewBuffer(goodMessage1),
+			bufio.NewReaderSize(bytes.NewReader(goodMessage1), 16),
+			&countingReader{r: bytes.NewReader(goodMessage1)},
+		}
+
+		for _, in := range inputs {
+			var m Message
+			err := m.DecodeEx(in, DecoderOptions{MaxMessageSize: max})
+
+			switch {
+			case max >= size && err != nil:
+				t.Errorf("%T, max=%d: %s", in, max, err)
+			case max < size && err != ErrMessageTooLarge:
+				t.Errorf("%T, max=%d: expected %q, present %v",
+					in, max, ErrMessageTooLarge, err)
+			}
+
+			if cr, ok := in.(*countingReader); ok && cr.cnt > max {
+				t.Errorf("max=%d: %d bytes consumed", max, cr.cnt)
+			}
+		}
+	}
+}
+
+// Test DecoderOptions.MaxCollectionDepth
+func TestDecodeMaxCollectionDepth(t *testing.T) {
+	// nested returns message with collections nested depth levels
+	nested := func(depth int) []byte {
+		attr := MakeAttr("member", TagInteger, Integer(1))
+		for i := 0; i < depth; i++ {
+			attr = MakeAttrCollection("col", attr)
+		}
+
+		m := NewResponse(DefaultVersion, StatusOk, 1)
+		m.Printer.Add(attr)
+
+		data, err := m.EncodeBytes()
+		assertNoError(t, err)
+		return data
+	}
+
+	type testData struct {
+		depth int  // Nesting depth
+		max   int  // DecoderOptions.MaxCollectionDepth
+		fail  bool // Decoding expected to fail
+	}
+
+	tests := []testData{
+		{depth: DefaultMaxCollectionDepth},
+		{depth: DefaultMaxCollectionDepth + 1, fail: true},
+		{depth: 2, max: 2},
+		{depth: 3, max: 2, fail: true},
+		{depth: 3, max: -1},
+	}
+
+	for _, test := range tests {
+		var m Message
+		err := m.DecodeBytesEx(nested(test.depth),
+			DecoderOptions{MaxCollectionDepth: test.max})
+
+		max := test.max
+		if max <= 0 {
+			max = DefaultMaxCollectionDepth
+		}
+
+		_, isDepthErr := err.(*CollectionDepthError)
+		switch {
+		case !test.fail && err != nil:
+			t.Errorf("depth=%d, max=%d: %s", test.depth, max, err)
+		case test.fail && !isDepthErr:
+			t.Errorf("depth=%d, max=%d: CollectionDepthError "+
+				"expected, present %v", test.depth, max, err)
+		case test.fail && err.(*CollectionDepthError).Max != max:
+			t.Errorf("depth=%d, max=%d: %s", test.depth, max, err)
+		}
+	}
+
+	// Hostile message: thousands of nested begCollection tags
+	data := []byte{0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
+		byte(TagPrinterGroup),
+		byte(TagBeginCollection), 0x00, 0x01, 'c', 0x00, 0x00}
+	for i := 0; i < 100000; i++ {
+		data = append(data, byte(TagMemberName), 0x00, 0x00,
+			0x00, 0x01, 'c',
+			byte(TagBeginCollection), 0x00, 0x00, 0x00, 0x00)
+	}
+
+	var m Message
+	err := m.DecodeBytes(data)
+	assertErrorIs(t, err, "Collection nesting exceeds 32 levels")
+}
+
+// Test DecoderOptions.MaxAttributes and MaxValuesPerAttribute
+func TestDecodeMaxAttributes(t *testing.T) {
+	m := NewResponse(DefaultVersion, StatusOk, 1)
+	m.Operation.Add(MakeAttribute("attributes-charset",
+		TagCharset, String("utf-8")))
+	m.Printer.Add(MakeAttr("printer-state-reasons", TagKeyword,
+		String("none"), String("toner-low"), String("media-low")))
+	m.Printer.Add(MakeAttrCollection("media-col-default",
+		MakeAttr("media-size-name", TagKeyword,
+			String("iso_a4_210x297mm"), String("na_letter_8.5x11in"))))
+
+	data, err := m.EncodeBytes()
+	assertNoError(t, err)
+
+	type testData struct {
+		attrs, values int    // Limits
+		err           string // Expected error, "" if none
+	}
+
+	tests := []testData{
+		{},
+		{attrs: 4, values: 3},
+		{attrs: 3, err: "media-col-default: too many attributes (limit is 3)"},
+		{attrs: 2, err: "media-size-name: too many attributes (limit is 2)"},
+		{values: 2, err: "printer-state-reasons: too many values (limit is 2)"},
+		{attrs: -1, values: -1},
+	}
+
+	for _, test := range tests {
+		var m2 Message
+		err := m2.DecodeBytesEx(data, DecoderOptions{
+			MaxAttributes:         test.attrs,
+			MaxValuesPerAttribute: test.values,
+		})
+
+		switch {
+		case test.err == "":
+			if err != nil {
+				t.Errorf("attrs=%d, values=%d: %s",
+					test.attrs, test.values, err)
+			}
+		default:
+			assertErrorIs(t, err, test.err)
+		}
+	}
+
+	// Collection member with too many values
+	var m2 Message
+	m.Printer = m.Printer[1:]
+	data, err = m.EncodeBytes()
+	assertNoError(t, err)
+
+	err = m2.DecodeBytesEx(data, DecoderOptions{MaxValuesPerAttribute: 1})
+	assertErrorIs(t, err, "media-size-name: too many values (limit is 1)")
+
+	// Hostile message: lots of additional values
+	data = []byte{0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
+		byte(TagPrinterGroup),
+		byte(TagInteger), 0x00, 0x01, 'i', 0x00, 0x04, 0, 0, 0, 0}
+	for i := 0; i < 100000; i++ {
+		data = append(data, byte(TagInteger), 0x00, 0x00,
+			0x00, 0x04, 0, 0, 0, 0)
+	}
+	data = append(data, byte(TagEnd))
+
+	err = m2.DecodeBytesEx(data, DecoderOptions{MaxValuesPerAttribute: 1000})
+	assertErrorIs(t, err, "i: too many values (limit is 1000)")
+}
+
+// Test decoding of values with declared length beyond the end of input
+func TestDecodeTruncatedValue(t *testing.T) {
+	// message returns message with the single value of the
+	// declared length, followed by avail bytes of data
+	message := func(declared, avail int) []byte {
+		data := []byte{0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
+			byte(TagPrinterGroup),
+			byte(TagString), 0x00, 0x01, 'v',
+			byte(declared >> 8), byte(declared)}
+		data = append(data, make([]byte, avail)...)
+		if declared <= avail {
+			data = append(data, byte(TagEnd))
+		}
+		return data
+	}
+
+	const header = 15 // Size of data before the value
+
+	// Input size is known: fail without allocation
+	data := message(0xffff, 100)
+	md := newMessageDecoder(bytes.NewReader(data), DecoderOptions{})
+	err := md.decode(&Message{})
+	assertErrorIs(t, err, fmt.Sprintf("Message truncated at 0x%x",
+		len(data)))
+
+	// Only the attribute name is read into md.buf
+	if md.cnt != header || cap(md.buf) > 1 {
+		t.Errorf("sized input: %d bytes consumed, %d bytes allocated",
+			md.cnt, cap(md.buf))
+	}
+
+	// Input size is unknown: buffer grows while data arrives
+	md = newMessageDecoder(struct{ io.Reader }{bytes.NewReader(data)},
+		DecoderOptions{})
+	err = md.decode(&Message{})
+	assertErrorIs(t, err, fmt.Sprintf("Message truncated at 0x%x",
+		len(data)))
+
+	if cap(md.buf) > decoderReadChunk {
+		t.Errorf("unsized input: %d bytes allocated", cap(md.buf))
+	}
+
+	// Large values are still decoded correctly
+	for _, size := range []int{decoderReadChunk - 1, decoderReadChunk,
+		decoderReadChunk*3 + 1, 0xffff} {
+
+		data = message(size, size)
+		for i := header; i < header+size; i++ {
+			data[i] = byte(i)
+		}
+
+		var m Message
+		err = m.Decode(struct{ io.Reader }{bytes.NewReader(data)})
+		assertNoError(t, err)
+
+		expected := Binary(data[header : header+size])
+		if len(m.Printer) != 1 ||
+			!ValueEqual(m.Printer[0].Values[0].V, expected) {
+			t.Errorf("size=%d: value decoded incorrectly", size)
+		}
+	}
+}
+
+// Test DecoderOptions.ReadAhead
+func TestDecodeReadAhead(t *testing.T) {
+	data := attrsHPOfficeJetPro8730
+
+	var expected Message
+	err := expected.DecodeBytes(data)
+	assertNoError(t, err)
+
+	// readCount returns count of Read calls, made by decoder
+	readCount := func(opt DecoderOptions) int {
+		reads := 0
+		src := bytes.NewReader(data)
+		var m Message
+		err := m.DecodeEx(readerFunc(func(buf []byte) (int, error) {
+			reads++
+			return src.Read(buf)
+		}), opt)
+		assertNoError(t, err)
+
+		if !m.Equal(expected) {
+			t.Errorf("ReadAhead=%v: message decoded incorrectly",
+				opt.ReadAhead)
+		}
+
+		return reads
+	}
+
+	unbuffered := readCount(DecoderOptions{})
+	buffered := readCount(DecoderOptions{ReadAhead: true})
+
+	if max := len(data)/4096 + 2; buffered > max {
+		t.Errorf("ReadAhead: %d reads, expected at most %d",
+			buffered, max)
+	}
+
+	if unbuffered <= buffered {
+		t.Errorf("ReadAhead: %d reads, %d without read-ahead",
+			buffered, unbuffered)
+	}
+
+	// Result describes only the message, not the read-ahead data
+	var res DecodeResult
+	var m Message
+	in := append(append([]byte(nil), goodMessage1...), "document"...)
+	err = m.DecodeEx(struct{ io.Reader }{bytes.NewReader(in)},
+		DecoderOptions{ReadAhead: true, Result: &res})
+	assertNoError(t, err)
+
+	if res.Bytes != len(goodMessage1) ||
+		res.SHA256 != sha256.Sum256(goodMessage1) {
+		t.Errorf("ReadAhead: invalid DecodeResult: %d bytes", res.Bytes)
+	}
+}
+
+// Test DecoderOptions.Progress
+func TestDecodeProgress(t *testing.T) {
+	data := attrsHPOfficeJetPro8730
+
+	for _, step := range []int{0, 1, 1000, len(data) * 2} {
+		var reported []int
+		opt := DecoderOptions{
+			Progress: func(offset int) {
+				reported = append(reported, offset)
+			},
+			ProgressStep: step,
+		}
+
+		var m Message
+		err := m.DecodeBytesEx(data, opt)
+		assertNoError(t, err)
+
+		if step <= 0 {
+			step = DefaultProgressStep
+		}
+
+		if len(reported) == 0 || reported[len(reported)-1] != len(data) {
+			t.Errorf("step %d: end of message not reported: %v",
+				step, reported)
+			continue
+		}
+
+		for i := 1; i < len(reported); i++ {
+			if reported[i] <= reported[i-1] ||
+				(i < len(reported)-1 &&
+					reported[i]-reported[i-1] < step) {
+				t.Errorf("step %d: unexpected sequence %v",
+					step, reported)
+				break
+			}
+		}
+
+		// Attributes are much shorter than 1000 bytes, so reports
+		// with large steps must not be missed
+		expected := len(data) / step / 2
+		if step >= 1000 && len(reported) < expected {
+			t.Errorf("step %d: %d reports, expected at least %d",
+				step, len(reported), expected)
+		}
+	}
+}
+
+// TestDecodeDeadline tests DecoderOptions.Deadline and MaxDuration
+func TestDecodeDeadline(t *testing.T) {
+	data := goodMessage1
+	var m Message
+
+	// Not exceeded
+	opt := DecoderOptions{
+		Deadline:    time.Now().Add(time.Hour),
+		MaxDuration: time.Hour,
+	}
+	err := m.DecodeBytesEx(data, opt)
+	assertNoError(t, err)
+
+	// Deadline in the past
+	opt = DecoderOptions{Deadline: time.Now().Add(-time.Second)}
+	err = m.DecodeBytesEx(data, opt)
+	assertErrorIs(t, err, "Decode deadline exceeded")
+
+	// Earlier of Deadline and MaxDuration takes effect
+	opt = DecoderOptions{
+		Deadline:    time.Now().Add(time.Hour),
+		MaxDuration: 5 * time.Millisecond,
+	}
+	in := slowReader{bytes.NewReader(data), time.Millisecond}
+	err = m.DecodeEx(in, opt)
+	assertErrorIs(t, err, "Decode deadline exceeded")
+
+	// Deadline is applied to DecodeDeferredEx
+	opt = DecoderOptions{Deadline: time.Now().Add(-time.Second)}
+	_, err = m.DecodeDeferredEx(bytes.NewReader(data), opt)
+	assertErrorIs(t, err, "Decode deadline exceeded")
+}
+
+// emptyReader returns (0, nil) before each successful read
+type emptyReader struct {
+	in    io.Reader
+	empty bool
+}
+
+func (r *emptyReader) Read(buf []byte) (int, error) {
+	r.empty = !r.empty
+	if r.empty {
+		return 0, nil
+	}
+	return r.in.Read(buf)
+}
+
+// Test handling of values without name at the beginning of group
+func TestDecodeNamelessValue(t *testing.T) {
+	data := []byte{
+		0x01, 0x01, // IPP version
+		0x00, 0x02, // Print-Job operation
+		0x01, 0x02, 0x03, 0x04, // Request ID
+
+		uint8(TagJobGroup),
+		uint8(TagInteger),
+		0x00, 0x04, // Name length + name
+		'a', 't', 't', 'r',
+		0x00, 0x04, // Value length + value
+		0x00, 0x00, 0x00, 0x01,
+
+		uint8(TagPrinterGroup),
+		uint8(TagInteger),
+		0x00, 0x00, // No name
+		0x00, 0x04, // Value length + value
+		0x00, 0x00, 0x00, 0x02,
+
+		uint8(TagKeyword),
+		0x00, 0x04, // Name length + name
+		'n', 'e', 'x', 't',
+		0x00, 0x02, // Value length + value
+		'o', 'k',
+
+		uint8(TagEnd),
+	}
+
+	// By default, value is rejected with NamelessValueError
+	var m Message
+	err := m.DecodeBytes(data)
+	assertErrorIs(t, err, "Additional value without preceding attribute "+
+		"(integer in printer-attributes-tag) at 0x1c")
+
+	nameless, ok := err.(*NamelessValueError)
+	switch {
+	case !ok:
+		t.Errorf("NamelessValueError expected, present %T", err)
+	case nameless.Group != TagPrinterGroup || nameless.Tag != TagInteger:
+		t.Errorf("NamelessValueError: unexpected %#v", nameless)
+	}
+
+	// With NamelessAcrossGroups, value is attached to the last
+	// attribute of the previous group
+	var warnings []string
+	opt := DecoderOptions{
+		NamelessAcrossGroups: true,
+		Warning: func(err error) {
+			warnings = append(warnings, err.Error())
+		},
+	}
+
+	m = Message{}
+	err = m.DecodeBytesEx(data, opt)
+	assertNoError(t, err)
+
+	expected := NewMessageWithGroups(MakeVersion(1, 1), Code(OpPrintJob),
+		0x01020304, Groups{
+			{TagJobGroup, Attributes{
+				MakeAttr("attr", TagInteger, Integer(1), Integer(2)),
+			}},
+			{TagPrinterGroup, Attributes{
+				MakeAttr("next", TagKeyword, String("ok")),
+			}},
+		})
+
+	if !m.Equal(*expected) {
+		t.Errorf("NamelessAcrossGroups: unexpected message")
+	}
+
+	if !m.Job.Equal(expected.Job) || !m.Printer.Equal(expected.Printer) {
+		t.Errorf("NamelessAcrossGroups: unexpected named groups")
+	}
+
+	expWarnings := []string{"attr: value at the beginning of " +
+		"printer-attributes-tag attached to the previous group at 0x1c"}
+	if !reflect.DeepEqual(warnings, expWarnings) {
+		t.Errorf("NamelessAcrossGroups: unexpected warnings:\n"+
+			"expected: %q\npresent:  %q", expWarnings, warnings)
+	}
+
+	// Leniency doesn't apply to the first group
+	m = Message{}
+	err = m.DecodeBytesEx(append(data[:8:8], data[22:]...), opt)
+	if _, ok := err.(*NamelessValueError); !ok {
+		t.Errorf("NamelessValueError expected, present %v", err)
+	}
+}
+
+// TestDecodeSequential tests that Decode consumes exactly one
+// message, so multiple messages can be decoded sequentially
+func TestDecodeSequential(t *testing.T) {
+	var data []byte
+	data = append(data, goodMessage1...)
+	data = append(data, goodMessage2...)
+	data = append(data, goodMessage1...)
+	data = append(data, "document data"...)
+
+	readers := []struct {
+		name string
+		in   io.Reader
+	}{
+		{"bytes.Reader", bytes.NewReader(data)},
+		{"bufio.Reader", bufio.NewReader(bytes.NewReader(data))},
+		{"emptyReader", &emptyReader{in: bytes.NewReader(data)}},
+	}
+
+	for _, r := range readers {
+		for i, expected := range [][]byte{goodMessage1, goodMessage2,
+			goodMessage1} {
+			var m, m2 Message
+			err := m.Decode(r.in)
+			assertNoError(t, err)
+
+			m2.DecodeBytes(expected)
+			if !m.Equal(m2) {
+				t.Errorf("%s: message %d decoded incorrectly",
+					r.name, i)
+			}
+		}
+
+		rest, _ := ioutil.ReadAll(r.in)
+		if string(rest) != "document data" {
+			t.Errorf("%s: rest of data: %q", r.name, rest)
+		}
+
+		var m Message
+		err := m.Decode(r.in)
+		assertErrorIs(t, err, "Message truncated")
+	}
+
+	// Concatenation is detected via DecodeResult
+	var res DecodeResult
+	var m Message
+	err := m.DecodeBytesEx(data, DecoderOptions{Result: &res})
+	assertNoError(t, err)
+
+	if res.Bytes != len(goodMessage1) {
+		t.Errorf("DecodeResult.Bytes: %d, expected %d",
+			res.Bytes, len(goodMessage1))
+	}
+
+	// Reader that makes no progress
+	err = m.Decode(readerFunc(func([]byte) (int, error) { return 0, nil }))
+	assertErrorIs(t, err, io.ErrNoProgress.Error())
+}
+
+// TestDecoder tests Decoder
+func TestDecoder(t *testing.T) {
+	var data []byte
+	data = append(data, goodMessage1...)
+	data = append(data, goodMessage2...)
+	data = append(data, "document data"...)
+
+	// Small buffer makes some values unavailable via Peek
+	inputs := []io.Reader{
+		bytes.NewReader(data),
+		bufio.NewReaderSize(bytes.NewReader(data), 16),
+	}
+
+	for _, in := range inputs {
+		dec := NewDecoder(in, DecoderOptions{})
+
+		for i, expected := range [][]byte{goodMessage1, goodMessage2} {
+			var m, m2 Message
+			err := dec.Decode(&m)
+			assertNoError(t, err)
+
+			m2.DecodeBytes(expected)
+			if !m.Equal(m2) {
+				t.Errorf("%T: message %d decoded incorrectly", in, i)
+			}
+		}
+
+		rest, _ := ioutil.ReadAll(dec.Reader())
+		if string(rest) != "document data" {
+			t.Errorf("%T: rest of data: %q", in, rest)
+		}
+	}
+
+	// bytes.Buffer is consumed exactly by one message
+	buf := bytes.NewBuffer(append([]byte(nil), data...))
+	for i, expected := range [][]byte{goodMessage1, goodMessage2} {
+		var m, m2 Message
+		err := m.Decode(buf)
+		assertNoError(t, err)
+
+		m2.DecodeBytes(expected)
+		if !m.Equal(m2) {
+			t.Errorf("bytes.Buffer: message %d decoded incorrectly", i)
+		}
+	}
+
+	if buf.String() != "document data" {
+		t.Errorf("bytes.Buffer: rest of data: %q", buf.String())
+	}
+
+	// Errors and offsets are the same as without buffering
+	for n := 0; n < len(goodMessage1); n++ {
+		var m Message
+		err1 := m.Decode(struct{ io.Reader }{
+			bytes.NewReader(goodMessage1[:n])})
+		err2 := NewDecoder(bytes.NewReader(goodMessage1[:n]),
+			DecoderOptions{}).Decode(&m)
+		err3 := m.DecodeBytes(goodMessage1[:n])
+
+		if fmt.Sprint(err1) != fmt.Sprint(err2) {
+			t.Errorf("truncated at %d: %v, expected %v", n, err2, err1)
+		}
+
+		if fmt.Sprint(err1) != fmt.Sprint(err3) {
+			t.Errorf("truncated at %d: %v, expected %v", n, err3, err1)
+		}
+	}
+}
+
+// TestParseHeader tests ParseHeader
+func TestParseHeader(t *testing.T) {
+	m := NewRequest(MakeVersion(2, 0), OpGetJobs, 0x01020304)
+	data, err := m.EncodeBytes()
+	assertNoError(t, err)
+
+	for _, n := range []int{MessageHeaderSize, len(data)} {
+		v, code, id, err := ParseHeader(data[:n])
+		assertNoError(t, err)
+
+		if v != m.Version || code != m.Code || id != m.RequestID {
+			t.Errorf("ParseHeader(%d bytes): %s %d %d", n, v, code, id)
+		}
+	}
+
+	_, _, _, err = ParseHeader(data[:MessageHeaderSize-1])
+	assertErrorIs(t, err, "Message header truncated")
+}
+
+// readerFunc adapts function to io.Reader
+type readerFunc func(buf []byte) (int, error)
+
+func (f readerFunc) Read(buf []byte) (int, error) {
+	return f(buf)
+}
+
 // Test message decoding
 func testDecode(t *testing.T, data []byte, opt DecoderOptions,
 	mustFail, mustEncode bool) {
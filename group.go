@@ -8,7 +8,10 @@
 
 package goipp
 
-import "sort"
+import (
+	"fmt"
+	"sort"
+)
 
 // Group represents a group of attributes.
 //
@@ -28,6 +31,71 @@ type Group struct {
 // Since 1.1.0
 type Groups []Group
 
+// GroupTag represents the Tag, which is known to be a group tag.
+//
+// Group.Tag, Groups.Filter, Message.CopyGroup and other existing
+// APIs intentionally remain of the Tag type: changing type of the
+// exported field or of function parameters would break existing
+// code, that uses Tag constants (i.e., Group{Tag: TagJobGroup}),
+// which is not acceptable within the v1 API.
+//
+// So GroupTag only provides compile-time checking for the new code:
+// use GroupTag constants with MakeGroup and Group.GroupTag, or
+// MakeGroupTag to validate Tag, which comes from elsewhere, at
+// run time.
+type GroupTag Tag
+
+// Group tags:
+const (
+	GroupTagOperation         = GroupTag(TagOperationGroup)
+	GroupTagJob               = GroupTag(TagJobGroup)
+	GroupTagPrinter           = GroupTag(TagPrinterGroup)
+	GroupTagUnsupported       = GroupTag(TagUnsupportedGroup)
+	GroupTagSubscription      = GroupTag(TagSubscriptionGroup)
+	GroupTagEventNotification = GroupTag(TagEventNotificationGroup)
+	GroupTagResource          = GroupTag(TagResourceGroup)
+	GroupTagDocument          = GroupTag(TagDocumentGroup)
+	GroupTagSystem            = GroupTag(TagSystemGroup)
+	GroupTagFuture11          = GroupTag(TagFuture11Group)
+	GroupTagFuture12          = GroupTag(TagFuture12Group)
+	GroupTagFuture13          = GroupTag(TagFuture13Group)
+	GroupTagFuture14          = GroupTag(TagFuture14Group)
+	GroupTagFuture15          = GroupTag(TagFuture15Group)
+)
+
+// MakeGroupTag converts Tag into GroupTag.
+// It returns error, if tag is not a group tag.
+func MakeGroupTag(tag Tag) (GroupTag, error) {
+	if !tag.IsGroup() {
+		return 0, fmt.Errorf("Tag %s is not a group tag", tag)
+	}
+	return GroupTag(tag), nil
+}
+
+// Tag returns GroupTag as Tag
+func (gt GroupTag) Tag() Tag {
+	return Tag(gt)
+}
+
+// String returns name of the GroupTag
+func (gt GroupTag) String() string {
+	return Tag(gt).String()
+}
+
+// MakeGroup makes Group with the specified tag and attributes
+func MakeGroup(tag GroupTag, attrs Attributes) Group {
+	return Group{Tag(tag), attrs}
+}
+
+// GroupTag returns Tag of the group as GroupTag, so it can be
+// compared with the GroupTag constants.
+//
+// The tag is converted as is, without checking. Use MakeGroupTag
+// to validate the Tag, if the Group comes from elsewhere.
+func (g Group) GroupTag() GroupTag {
+	return GroupTag(g.Tag)
+}
+
 // Add Attribute to the Group
 func (g *Group) Add(attr Attribute) {
 	g.Attrs.Add(attr)
@@ -55,6 +123,18 @@ func (groups Groups) Clone() Groups {
 	return groups2
 }
 
+// Filter returns groups with the specified tag, in their
+// original order. Attributes are shared with the original groups.
+func (groups Groups) Filter(tag Tag) Groups {
+	var filtered Groups
+	for _, grp := range groups {
+		if grp.Tag == tag {
+			filtered = append(filtered, grp)
+		}
+	}
+	return filtered
+}
+
 // Equal checks that groups and groups2 are equal
 func (groups Groups) Equal(groups2 Groups) bool {
 	if len(groups) != len(groups2) {
@@ -0,0 +1,168 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Identify-Printer and Acknowledge-Identify-Printer operations
+ */
+
+package goipp
+
+import (
+	"fmt"
+)
+
+// IdentifyAction represents values of the identify-actions
+// attribute (PWG 5100.13)
+type IdentifyAction string
+
+// IdentifyAction values:
+const (
+	IdentifyDisplay IdentifyAction = "display" // Display a message
+	IdentifyFlash   IdentifyAction = "flash"   // Flash lights or display
+	IdentifySound   IdentifyAction = "sound"   // Make a sound
+	IdentifySpeak   IdentifyAction = "speak"   // Speak a message
+)
+
+// MaxIdentifyMessage is the length limit of the message
+// operation attribute of Identify-Printer, which is text(127)
+const MaxIdentifyMessage = 127
+
+// IdentifyOptions represents operation attributes of the
+// Identify-Printer request and Acknowledge-Identify-Printer
+// response in a friendly form.
+type IdentifyOptions struct {
+	Actions []IdentifyAction // identify-actions, nil for default
+	Message string           // message, for display and speak
+}
+
+// NewIdentifyPrinterRequest creates the Identify-Printer request
+// (PWG 5100.13).
+//
+// Message longer than MaxIdentifyMessage bytes is truncated at the
+// UTF-8 character boundary. Missed required attributes are filled
+// by FillDefaults.
+func NewIdentifyPrinterRequest(v Version, id uint32, printerURI string,
+	opts IdentifyOptions) *Message {
+
+	m := NewRequest(v, OpIdentifyPrinter, id)
+	m.Operation.Add(MakeAttribute("printer-uri", TagURI,
+		String(printerURI)))
+	opts.add(&m.Operation)
+
+	FillDefaults(m)
+
+	return m
+}
+
+// NewAcknowledgeIdentifyPrinterRequest creates the
+// Acknowledge-Identify-Printer request (PWG 5100.18), which
+// is used by the Proxy to fetch pending identification request
+// for the output device.
+func NewAcknowledgeIdentifyPrinterRequest(v Version, id uint32,
+	printerURI, outputDeviceUUID string) *Message {
+
+	m := NewRequest(v, OpAcknowledgeIdentifyPrinter, id)
+	m.Operation.Add(MakeAttribute("printer-uri", TagURI,
+		String(printerURI)))
+	m.Operation.Add(MakeAttribute("output-device-uuid", TagURI,
+		String(outputDeviceUUID)))
+
+	FillDefaults(m)
+
+	return m
+}
+
+// NewAcknowledgeIdentifyPrinterResponse creates the response to
+// the Acknowledge-Identify-Printer request, that carries the
+// pending identification request to the Proxy.
+func NewAcknowledgeIdentifyPrinterResponse(v Version, id uint32,
+	opts IdentifyOptions) *Message {
+
+	m := NewResponse(v, StatusOk, id)
+	m.Operation.Add(MakeAttribute("attributes-charset", TagCharset,
+		String("utf-8")))
+	m.Operation.Add(MakeAttribute("attributes-natural-language",
+		TagLanguage, String(DefaultLanguage)))
+	opts.add(&m.Operation)
+
+	return m
+}
+
+// ParseIdentifyOptions extracts IdentifyOptions from the operation
+// attributes of the Identify-Printer request or the
+// Acknowledge-Identify-Printer response.
+func ParseIdentifyOptions(attrs Attributes) (IdentifyOptions, error) {
+	var opts IdentifyOptions
+
+	for _, attr := range attrs {
+		var err error
+
+		switch attr.Name {
+		case "identify-actions":
+			for _, val := range attr.Values {
+				if val.T != TagKeyword {
+					err = fmt.Errorf("%s: keyword expected",
+						attr.Name)
+					break
+				}
+				opts.Actions = append(opts.Actions,
+					IdentifyAction(val.V.String()))
+			}
+
+		case "message":
+			err = fmt.Errorf("%s: single text expected", attr.Name)
+			if len(attr.Values) == 1 {
+				switch v := attr.Values[0].V.(type) {
+				case String:
+					opts.Message, err = string(v), nil
+				case TextWithLang:
+					opts.Message, err = v.Text, nil
+				}
+			}
+		}
+
+		if err != nil {
+			return IdentifyOptions{}, err
+		}
+	}
+
+	return opts, nil
+}
+
+// CheckIdentifyActions checks that all actions are listed in the
+// identify-actions-supported printer attribute.
+func CheckIdentifyActions(actions []IdentifyAction,
+	printer Attributes) error {
+
+	supported, _ := attrsFind(printer, "identify-actions-supported")
+
+NEXT:
+	for _, action := range actions {
+		for _, val := range supported.Values {
+			if val.V.String() == string(action) {
+				continue NEXT
+			}
+		}
+
+		return fmt.Errorf("identify-actions: %q not supported", action)
+	}
+
+	return nil
+}
+
+// add adds IdentifyOptions to the operation attributes
+func (opts IdentifyOptions) add(attrs *Attributes) {
+	if len(opts.Actions) != 0 {
+		actions := Attribute{Name: "identify-actions"}
+		for _, action := range opts.Actions {
+			actions.Values.Add(TagKeyword, String(action))
+		}
+		attrs.Add(actions)
+	}
+
+	if opts.Message != "" {
+		attrs.Add(MakeAttribute("message", TagText,
+			String(truncateUTF8(opts.Message, MaxIdentifyMessage))))
+	}
+}
@@ -0,0 +1,88 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Identify-Printer and Acknowledge-Identify-Printer operations test
+ */
+
+package goipp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestIdentifyPrinter tests Identify-Printer end-to-end
+func TestIdentifyPrinter(t *testing.T) {
+	printer := Attributes{
+		MakeAttr("identify-actions-supported", TagKeyword,
+			String("display"), String("sound")),
+	}
+
+	opts := IdentifyOptions{
+		Actions: []IdentifyAction{IdentifyDisplay, IdentifySound},
+		Message: strings.Repeat("x", MaxIdentifyMessage+10),
+	}
+
+	err := CheckIdentifyActions(opts.Actions, printer)
+	assertNoError(t, err)
+
+	err = CheckIdentifyActions([]IdentifyAction{IdentifyFlash}, printer)
+	assertErrorIs(t, err, `identify-actions: "flash" not supported`)
+
+	err = CheckIdentifyActions([]IdentifyAction{IdentifyFlash}, nil)
+	assertErrorIs(t, err, `identify-actions: "flash" not supported`)
+
+	// Identify-Printer request
+	rq := NewIdentifyPrinterRequest(DefaultVersion, 1,
+		"ipp://localhost/printers/test", opts)
+	assertNoError(t, rq.ValidateCode())
+
+	data, err := rq.EncodeBytes()
+	assertNoError(t, err)
+
+	var rq2 Message
+	err = rq2.DecodeBytes(data)
+	assertNoError(t, err)
+
+	opts2, err := ParseIdentifyOptions(rq2.Operation)
+	assertNoError(t, err)
+
+	expected := opts
+	expected.Message = opts.Message[:MaxIdentifyMessage]
+	if !reflect.DeepEqual(opts2, expected) {
+		t.Errorf("ParseIdentifyOptions:\n%+v\nexpected:\n%+v",
+			opts2, expected)
+	}
+
+	// Acknowledge-Identify-Printer
+	ack := NewAcknowledgeIdentifyPrinterRequest(DefaultVersion, 2,
+		"ipp://localhost/ipp/system",
+		"urn:uuid:e7a33a4a-d0e1-4a8f-9e3a-6a5b6d7c8e9f")
+	if _, found := attrsFind(ack.Operation, "output-device-uuid"); !found {
+		t.Errorf("output-device-uuid missed")
+	}
+
+	rsp := NewAcknowledgeIdentifyPrinterResponse(DefaultVersion, 2,
+		IdentifyOptions{Actions: []IdentifyAction{IdentifyFlash}})
+	opts3, err := ParseIdentifyOptions(rsp.Operation)
+	assertNoError(t, err)
+
+	if !reflect.DeepEqual(opts3.Actions, []IdentifyAction{IdentifyFlash}) ||
+		opts3.Message != "" {
+		t.Errorf("ParseIdentifyOptions: %+v", opts3)
+	}
+
+	// Errors
+	_, err = ParseIdentifyOptions(Attributes{
+		MakeAttr("identify-actions", TagName, String("flash")),
+	})
+	assertErrorIs(t, err, "identify-actions: keyword expected")
+
+	_, err = ParseIdentifyOptions(Attributes{
+		MakeAttr("message", TagInteger, Integer(1)),
+	})
+	assertErrorIs(t, err, "message: single text expected")
+}
@@ -0,0 +1,471 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Converters between goipp and github.com/phin1x/go-ipp types
+ */
+
+// Package ippadapter converts messages between goipp.Message and
+// request/response structures of the github.com/phin1x/go-ipp
+// package, so projects can migrate incrementally, or use goipp's
+// wire handling beneath the go-ipp high-level API.
+//
+// It lives in a separate module, so goipp itself remains free
+// of third-party dependencies.
+//
+// go-ipp represents values as plain Go values (int, bool, string
+// and time.Time) and has no representation for resolutions, ranges,
+// text with language, octet strings and collections. Conversion of
+// such values fails with error.
+//
+// go-ipp requests don't carry value tags: tags are looked up by
+// attribute name in ipp.AttributeTagMapping. FromRequest does the
+// same and falls back to the tag, implied by the Go type of value.
+package ippadapter
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/OpenPrinting/goipp"
+	"github.com/phin1x/go-ipp"
+)
+
+// ToRequest converts goipp request into ipp.Request.
+//
+// Only operation, job and printer groups can be represented
+// by go-ipp requests. Attributes of other groups cause an error.
+// Document payload is not converted; set Request.File and
+// Request.FileSize, if needed.
+func ToRequest(m *goipp.Message) (*ipp.Request, error) {
+	req := &ipp.Request{
+		ProtocolVersionMajor: int8(m.Version.Major()),
+		ProtocolVersionMinor: int8(m.Version.Minor()),
+		Operation:            int16(m.Code),
+		RequestId:            int32(m.RequestID),
+	}
+
+	for _, grp := range messageGroups(m) {
+		var dst *map[string]interface{}
+
+		switch grp.Tag {
+		case goipp.TagOperationGroup:
+			dst = &req.OperationAttributes
+		case goipp.TagJobGroup:
+			dst = &req.JobAttributes
+		case goipp.TagPrinterGroup:
+			dst = &req.PrinterAttributes
+		default:
+			if len(grp.Attrs) != 0 {
+				return nil, fmt.Errorf(
+					"%s: group not supported by go-ipp requests",
+					grp.Tag)
+			}
+			continue
+		}
+
+		if *dst == nil {
+			*dst = make(map[string]interface{})
+		}
+
+		for _, attr := range grp.Attrs {
+			v, err := requestValue(attr)
+			if err != nil {
+				return nil, err
+			}
+
+			(*dst)[attr.Name] = v
+		}
+	}
+
+	return req, nil
+}
+
+// FromRequest converts ipp.Request into goipp request.
+//
+// As go-ipp request attributes are unordered maps, attributes
+// of each group are sorted by name, except that the
+// attributes-charset and attributes-natural-language come first,
+// as IPP requires.
+func FromRequest(req *ipp.Request) (*goipp.Message, error) {
+	m := goipp.NewRequest(
+		goipp.MakeVersion(uint8(req.ProtocolVersionMajor),
+			uint8(req.ProtocolVersionMinor)),
+		goipp.Op(req.Operation), uint32(req.RequestId))
+
+	groups := []struct {
+		dst   *goipp.Attributes
+		attrs map[string]interface{}
+	}{
+		{&m.Operation, req.OperationAttributes},
+		{&m.Job, req.JobAttributes},
+		{&m.Printer, req.PrinterAttributes},
+	}
+
+	for _, grp := range groups {
+		for _, name := range sortedNames(grp.attrs) {
+			attr, err := requestAttr(name, grp.attrs[name])
+			if err != nil {
+				return nil, err
+			}
+
+			grp.dst.Add(attr)
+		}
+	}
+
+	return m, nil
+}
+
+// ToResponse converts goipp response into ipp.Response.
+//
+// Each job and printer group becomes an element of
+// Response.JobAttributes and Response.PrinterAttributes. Other
+// groups, except the operation group, cause an error.
+func ToResponse(m *goipp.Message) (*ipp.Response, error) {
+	rsp := &ipp.Response{
+		ProtocolVersionMajor: int8(m.Version.Major()),
+		ProtocolVersionMinor: int8(m.Version.Minor()),
+		StatusCode:           int16(m.Code),
+		RequestId:            int32(m.RequestID),
+		OperationAttributes:  make(ipp.Attributes),
+	}
+
+	for _, grp := range messageGroups(m) {
+		attrs := rsp.OperationAttributes
+		switch grp.Tag {
+		case goipp.TagOperationGroup:
+		case goipp.TagJobGroup:
+			attrs = make(ipp.Attributes)
+			rsp.JobAttributes = append(rsp.JobAttributes, attrs)
+		case goipp.TagPrinterGroup:
+			attrs = make(ipp.Attributes)
+			rsp.PrinterAttributes = append(rsp.PrinterAttributes, attrs)
+		default:
+			if len(grp.Attrs) != 0 {
+				return nil, fmt.Errorf(
+					"%s: group not supported by go-ipp responses",
+					grp.Tag)
+			}
+			continue
+		}
+
+		for _, attr := range grp.Attrs {
+			for _, val := range attr.Values {
+				v, err := toValue(attr.Name, val.V)
+				if err != nil {
+					return nil, err
+				}
+
+				attrs[attr.Name] = append(attrs[attr.Name],
+					ipp.Attribute{
+						Tag:   int8(val.T),
+						Name:  attr.Name,
+						Value: v,
+					})
+			}
+		}
+	}
+
+	return rsp, nil
+}
+
+// FromResponse converts ipp.Response into goipp response.
+// Response.Data is not converted.
+//
+// As go-ipp response attributes are unordered maps, attributes
+// of each group are sorted by name, except that the
+// attributes-charset and attributes-natural-language come first.
+func FromResponse(rsp *ipp.Response) (*goipp.Message, error) {
+	var groups goipp.Groups
+
+	add := func(tag goipp.Tag, attrs ipp.Attributes) error {
+		grp := goipp.Group{Tag: tag, Attrs: goipp.Attributes{}}
+		for _, name := range sortedNames(attrs) {
+			attr := goipp.Attribute{Name: name}
+			for _, a := range attrs[name] {
+				tag := goipp.Tag(a.Tag)
+				v, err := fromValue(name, tag, a.Value)
+				if err != nil {
+					return err
+				}
+				attr.Values.Add(tag, v)
+			}
+
+			if len(attr.Values) != 0 {
+				grp.Attrs.Add(attr)
+			}
+		}
+
+		groups.Add(grp)
+		return nil
+	}
+
+	err := add(goipp.TagOperationGroup, rsp.OperationAttributes)
+	for _, attrs := range rsp.JobAttributes {
+		if err == nil {
+			err = add(goipp.TagJobGroup, attrs)
+		}
+	}
+	for _, attrs := range rsp.PrinterAttributes {
+		if err == nil {
+			err = add(goipp.TagPrinterGroup, attrs)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	v := goipp.MakeVersion(uint8(rsp.ProtocolVersionMajor),
+		uint8(rsp.ProtocolVersionMinor))
+
+	return goipp.NewMessageWithGroups(v, goipp.Code(rsp.StatusCode),
+		uint32(rsp.RequestId), groups), nil
+}
+
+// messageGroups returns groups of the message, either from
+// m.Groups or, if it is nil, from the per-group fields
+func messageGroups(m *goipp.Message) goipp.Groups {
+	if m.Groups != nil {
+		return m.Groups
+	}
+
+	var groups goipp.Groups
+	named := []struct {
+		tag   goipp.Tag
+		attrs goipp.Attributes
+	}{
+		{goipp.TagOperationGroup, m.Operation},
+		{goipp.TagJobGroup, m.Job},
+		{goipp.TagPrinterGroup, m.Printer},
+		{goipp.TagUnsupportedGroup, m.Unsupported},
+		{goipp.TagSubscriptionGroup, m.Subscription},
+		{goipp.TagEventNotificationGroup, m.EventNotification},
+		{goipp.TagResourceGroup, m.Resource},
+		{goipp.TagDocumentGroup, m.Document},
+		{goipp.TagSystemGroup, m.System},
+	}
+
+	for _, grp := range named {
+		if grp.attrs != nil {
+			groups.Add(goipp.Group{Tag: grp.tag, Attrs: grp.attrs})
+		}
+	}
+
+	return groups
+}
+
+// requestValue converts values of goipp.Attribute into the value of
+// the go-ipp request attribute map: a single value or a slice of
+// values of the same Go type, as the go-ipp encoder expects
+func requestValue(attr goipp.Attribute) (interface{}, error) {
+	if len(attr.Values) == 0 {
+		return nil, fmt.Errorf("%s: attribute without value", attr.Name)
+	}
+
+	vals := make([]interface{}, len(attr.Values))
+	for i, val := range attr.Values {
+		v, err := toValue(attr.Name, val.V)
+		if err != nil {
+			return nil, err
+		}
+		vals[i] = v
+	}
+
+	if len(vals) == 1 {
+		return vals[0], nil
+	}
+
+	switch vals[0].(type) {
+	case int:
+		out := make([]int, len(vals))
+		for i := range vals {
+			out[i], _ = vals[i].(int)
+		}
+		return out, nil
+
+	case bool:
+		out := make([]bool, len(vals))
+		for i := range vals {
+			out[i], _ = vals[i].(bool)
+		}
+		return out, nil
+
+	case string:
+		out := make([]string, len(vals))
+		for i := range vals {
+			out[i], _ = vals[i].(string)
+		}
+		return out, nil
+	}
+
+	return nil, fmt.Errorf("%s: multiple %T values not supported",
+		attr.Name, vals[0])
+}
+
+// requestAttr converts value of the go-ipp request attribute map
+// into goipp.Attribute
+func requestAttr(name string, value interface{}) (goipp.Attribute, error) {
+	var vals []interface{}
+
+	switch v := value.(type) {
+	case []int:
+		for _, x := range v {
+			vals = append(vals, x)
+		}
+	case []bool:
+		for _, x := range v {
+			vals = append(vals, x)
+		}
+	case []string:
+		for _, x := range v {
+			vals = append(vals, x)
+		}
+	case []interface{}:
+		vals = v
+	default:
+		vals = []interface{}{v}
+	}
+
+	attr := goipp.Attribute{Name: name}
+	for _, v := range vals {
+		tag := goipp.TagZero
+		if t, found := ipp.AttributeTagMapping[name]; found {
+			tag = goipp.Tag(t)
+		}
+
+		val, err := fromValue(name, tag, v)
+		if err != nil {
+			return attr, err
+		}
+
+		if tag == goipp.TagZero || val.Type() == goipp.TypeVoid {
+			tag = defaultTag(val)
+		}
+
+		attr.Values.Add(tag, val)
+	}
+
+	if len(attr.Values) == 0 {
+		return attr, fmt.Errorf("%s: attribute without value", name)
+	}
+
+	return attr, nil
+}
+
+// toValue converts goipp.Value into the go-ipp value
+func toValue(name string, v goipp.Value) (interface{}, error) {
+	switch v := v.(type) {
+	case goipp.Void:
+		return nil, nil
+	case goipp.Integer:
+		return int(v), nil
+	case goipp.Boolean:
+		return bool(v), nil
+	case goipp.String:
+		return string(v), nil
+	case goipp.Time:
+		return v.Time, nil
+	}
+
+	if v.Type() == goipp.TypeString {
+		// Name, Keyword, URI and so on
+		return v.String(), nil
+	}
+
+	return nil, fmt.Errorf("%s: %s value not supported by go-ipp",
+		name, v.Type())
+}
+
+// fromValue converts go-ipp value into goipp.Value. If tag is not
+// TagZero, the value must match it
+func fromValue(name string, tag goipp.Tag, v interface{}) (
+	goipp.Value, error) {
+
+	var val goipp.Value
+
+	switch v := v.(type) {
+	case nil:
+		val = goipp.Void{}
+	case int:
+		val = goipp.Integer(v)
+	case int8:
+		val = goipp.Integer(v)
+	case int16:
+		val = goipp.Integer(v)
+	case int32:
+		val = goipp.Integer(v)
+	case bool:
+		val = goipp.Boolean(v)
+	case string:
+		val = goipp.String(v)
+	case time.Time:
+		val = goipp.Time{Time: v}
+	default:
+		return nil, fmt.Errorf("%s: %T value not supported", name, v)
+	}
+
+	if tag != goipp.TagZero && tag.Type() != val.Type() &&
+		val.Type() != goipp.TypeVoid {
+		return nil, fmt.Errorf("%s: %s value required, %T present",
+			name, tag, v)
+	}
+
+	return val, nil
+}
+
+// defaultTag returns tag for the value of attribute, not known
+// to go-ipp
+func defaultTag(v goipp.Value) goipp.Tag {
+	switch v.Type() {
+	case goipp.TypeVoid:
+		return goipp.TagNoValue
+	case goipp.TypeInteger:
+		return goipp.TagInteger
+	case goipp.TypeBoolean:
+		return goipp.TagBoolean
+	case goipp.TypeDateTime:
+		return goipp.TagDateTime
+	}
+
+	return goipp.TagKeyword
+}
+
+// sortedNames returns keys of the go-ipp attributes map, sorted by
+// name, with attributes-charset and attributes-natural-language first
+func sortedNames(attrs interface{}) []string {
+	var names []string
+
+	switch attrs := attrs.(type) {
+	case map[string]interface{}:
+		for name := range attrs {
+			names = append(names, name)
+		}
+	case ipp.Attributes:
+		for name := range attrs {
+			names = append(names, name)
+		}
+	}
+
+	rank := func(name string) int {
+		switch name {
+		case "attributes-charset":
+			return 0
+		case "attributes-natural-language":
+			return 1
+		}
+		return 2
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		ri, rj := rank(names[i]), rank(names[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return names[i] < names[j]
+	})
+
+	return names
+}
@@ -0,0 +1,113 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Converters between goipp and go-ipp types test
+ */
+
+package ippadapter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/OpenPrinting/goipp"
+	"github.com/phin1x/go-ipp"
+)
+
+// TestRequest tests ToRequest and FromRequest
+func TestRequest(t *testing.T) {
+	m := goipp.NewRequest(goipp.DefaultVersion, goipp.OpPrintJob, 5)
+	m.Operation.Add(goipp.MakeAttribute("attributes-charset",
+		goipp.TagCharset, goipp.String("utf-8")))
+	m.Operation.Add(goipp.MakeAttribute("job-name",
+		goipp.TagName, goipp.String("test")))
+	m.Operation.Add(goipp.MakeAttr("requested-attributes",
+		goipp.TagKeyword, goipp.String("a"), goipp.String("b")))
+	m.Job.Add(goipp.MakeAttribute("copies",
+		goipp.TagInteger, goipp.Integer(2)))
+
+	req, err := ToRequest(m)
+	if err != nil {
+		t.Fatalf("ToRequest: %s", err)
+	}
+
+	if req.Operation != int16(goipp.OpPrintJob) || req.RequestId != 5 {
+		t.Errorf("ToRequest: invalid header: %d %d",
+			req.Operation, req.RequestId)
+	}
+
+	if v, _ := req.JobAttributes["copies"].(int); v != 2 {
+		t.Errorf("ToRequest: copies: %v", req.JobAttributes["copies"])
+	}
+
+	if v, _ := req.OperationAttributes["requested-attributes"].([]string); len(v) != 2 {
+		t.Errorf("ToRequest: requested-attributes: %v",
+			req.OperationAttributes["requested-attributes"])
+	}
+
+	m2, err := FromRequest(req)
+	if err != nil {
+		t.Fatalf("FromRequest: %s", err)
+	}
+
+	if !m.Similar(*m2) {
+		t.Errorf("Request: not the same after round trip:\n%s",
+			goipp.ExplainDiff(*m, *m2))
+	}
+
+	// Unsupported values
+	m.Job.Add(goipp.MakeAttribute("page-ranges",
+		goipp.TagRange, goipp.Range{Lower: 1, Upper: 2}))
+	_, err = ToRequest(m)
+	if err == nil || !strings.HasPrefix(err.Error(), "page-ranges:") {
+		t.Errorf("ToRequest: unexpected error %v", err)
+	}
+}
+
+// TestResponse tests ToResponse and FromResponse
+func TestResponse(t *testing.T) {
+	m := goipp.NewResponse(goipp.DefaultVersion, goipp.StatusOk, 7)
+	m.Operation.Add(goipp.MakeAttribute("attributes-charset",
+		goipp.TagCharset, goipp.String("utf-8")))
+
+	groups := goipp.Groups{{Tag: goipp.TagOperationGroup,
+		Attrs: m.Operation}}
+	for _, id := range []int{1, 2} {
+		groups.Add(goipp.Group{Tag: goipp.TagJobGroup,
+			Attrs: goipp.Attributes{goipp.MakeAttribute("job-id",
+				goipp.TagInteger, goipp.Integer(id))}})
+	}
+
+	m = goipp.NewMessageWithGroups(m.Version, m.Code, m.RequestID,
+		groups)
+
+	rsp, err := ToResponse(m)
+	if err != nil {
+		t.Fatalf("ToResponse: %s", err)
+	}
+
+	if len(rsp.JobAttributes) != 2 {
+		t.Errorf("ToResponse: %d job groups", len(rsp.JobAttributes))
+	}
+
+	m2, err := FromResponse(rsp)
+	if err != nil {
+		t.Fatalf("FromResponse: %s", err)
+	}
+
+	if !m.Similar(*m2) {
+		t.Errorf("Response: not the same after round trip:\n%s",
+			goipp.ExplainDiff(*m, *m2))
+	}
+
+	// Mismatched tag and value
+	rsp.OperationAttributes["x"] = []ipp.Attribute{
+		{Tag: int8(goipp.TagInteger), Name: "x", Value: "str"},
+	}
+	_, err = FromResponse(rsp)
+	if err == nil || !strings.HasPrefix(err.Error(), "x:") {
+		t.Errorf("FromResponse: unexpected error %v", err)
+	}
+}
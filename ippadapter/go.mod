@@ -0,0 +1,10 @@
+module github.com/OpenPrinting/goipp/ippadapter
+
+go 1.11
+
+require (
+	github.com/OpenPrinting/goipp v0.0.0-00010101000000-000000000000
+	github.com/phin1x/go-ipp v1.6.1
+)
+
+replace github.com/OpenPrinting/goipp => ../
@@ -0,0 +1,195 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * The ippget event delivery method (RFC 3996)
+ */
+
+package goipp
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"sync"
+)
+
+// NotificationStream represents the stream of Get-Notifications
+// responses, received over a single HTTP connection, as defined by
+// the ippget event delivery method (RFC 3996).
+//
+// When request contains notify-wait=true, printer may hold the
+// connection open and send successive responses, as new events
+// occur. Each response is delivered to the C channel. Events are
+// contained in the event notification groups of these responses,
+// see [Message.Events].
+//
+// When stream terminates, C is closed. Use Err to obtain the
+// reason of termination. At this point, HTTP connection is already
+// released, so calling Close is not required.
+//
+// To abandon the stream before it terminates, call Close. Otherwise,
+// the stream holds the HTTP connection until printer closes it,
+// and if C is not drained, forever.
+type NotificationStream struct {
+	C <-chan *Message // Received responses
+
+	body        io.ReadCloser // HTTP response body
+	cancel      func()        // Cancels the stream
+	done        chan struct{} // Closed by Close
+	closeOnce   sync.Once     // Makes Close idempotent
+	releaseOnce sync.Once     // Makes release idempotent
+	releaseErr  error         // body.Close error
+	lock        sync.Mutex    // Access lock
+	closed      bool          // Close was called
+	err         error         // Termination error
+}
+
+// GetNotifications sends the Get-Notifications request to the printer
+// and returns the stream of responses.
+func (c *Client) GetNotifications(ctx context.Context, uri string,
+	req *Message) (*NotificationStream, error) {
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	body, err := c.post(ctx, uri, req, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return newNotificationStream(body, cancel), nil
+}
+
+// NewNotificationStream creates the NotificationStream that
+// decodes successive messages from the io.Reader.
+//
+// It is useful when HTTP connection is managed by the caller.
+// If in implements io.Closer, it is closed when the stream
+// terminates or by Close, whichever comes first.
+func NewNotificationStream(in io.Reader) *NotificationStream {
+	rc, ok := in.(io.ReadCloser)
+	if !ok {
+		rc = readNopCloser{in}
+	}
+	return newNotificationStream(rc, func() {})
+}
+
+// newNotificationStream creates the NotificationStream and
+// starts the reader goroutine
+func newNotificationStream(body io.ReadCloser,
+	cancel func()) *NotificationStream {
+
+	ch := make(chan *Message)
+	s := &NotificationStream{
+		C:      ch,
+		body:   body,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go s.read(ch)
+
+	return s
+}
+
+// Err returns the error that terminated the stream. If stream
+// terminated normally, because printer has closed the connection,
+// or by Close, it returns nil.
+//
+// Err should be called only after C is closed.
+func (s *NotificationStream) Err() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.err
+}
+
+// Close terminates the stream and closes HTTP connection.
+func (s *NotificationStream) Close() error {
+	var err error
+
+	s.closeOnce.Do(func() {
+		s.lock.Lock()
+		s.closed = true
+		s.lock.Unlock()
+
+		close(s.done)
+		err = s.release()
+	})
+
+	return err
+}
+
+// release cancels the stream context and closes HTTP response body.
+// It is called either by Close or when the reader goroutine exits.
+func (s *NotificationStream) release() error {
+	s.releaseOnce.Do(func() {
+		s.cancel()
+		s.releaseErr = s.body.Close()
+	})
+
+	return s.releaseErr
+}
+
+// read decodes successive messages and delivers them to the channel
+func (s *NotificationStream) read(ch chan<- *Message) {
+	defer close(ch)
+	defer s.release()
+
+	in := bufio.NewReader(s.body)
+	for {
+		// Check for EOF between messages
+		if _, err := in.Peek(1); err != nil {
+			if err != io.EOF {
+				s.setErr(err)
+			}
+			return
+		}
+
+		m := &Message{Role: MessageRoleResponse}
+		if err := m.Decode(in); err != nil {
+			s.setErr(err)
+			return
+		}
+
+		select {
+		case ch <- m:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// setErr sets the termination error. Errors, caused by Close,
+// are ignored.
+func (s *NotificationStream) setErr(err error) {
+	s.lock.Lock()
+	if !s.closed {
+		s.err = err
+	}
+	s.lock.Unlock()
+}
+
+// Events returns event notification groups of the message,
+// one group per event.
+func (m *Message) Events() []Attributes {
+	var events []Attributes
+
+	for _, grp := range m.attrGroups() {
+		if grp.Tag == TagEventNotificationGroup {
+			events = append(events, grp.Attrs)
+		}
+	}
+
+	return events
+}
+
+// readNopCloser wraps io.Reader into io.ReadCloser with
+// no-op Close method
+type readNopCloser struct {
+	io.Reader
+}
+
+// Close does nothing
+func (readNopCloser) Close() error { return nil }
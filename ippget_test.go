@@ -0,0 +1,151 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * The ippget event delivery method test
+ */
+
+package goipp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// testEventResponse creates Get-Notifications response with one event
+func testEventResponse(id uint32, event string) *Message {
+	m := NewResponse(DefaultVersion, StatusOk, id)
+	m.Operation.Add(MakeAttr("attributes-charset", TagCharset,
+		String("utf-8")))
+	m.EventNotification.Add(MakeAttr("notify-subscribed-event",
+		TagKeyword, String(event)))
+	return m
+}
+
+// TestNotificationStream tests NotificationStream
+func TestNotificationStream(t *testing.T) {
+	var stream bytes.Buffer
+	events := []string{"job-created", "job-completed", "printer-stopped"}
+
+	for _, event := range events {
+		data, err := testEventResponse(1, event).EncodeBytes()
+		assertNoError(t, err)
+		stream.Write(data)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var req Message
+			err := req.Decode(r.Body)
+			assertNoError(t, err)
+
+			w.Header().Set("Content-Type", ContentType)
+			w.Write(stream.Bytes())
+		}))
+	defer srv.Close()
+
+	req := NewRequest(DefaultVersion, OpGetNotifications, 1)
+	s, err := NewClient(nil).GetNotifications(context.Background(),
+		srv.URL, req)
+	if err != nil {
+		t.Fatalf("GetNotifications: %s", err)
+	}
+
+	defer s.Close()
+
+	received := []string{}
+	for m := range s.C {
+		for _, ev := range m.Events() {
+			received = append(received, ev[0].Values[0].V.String())
+		}
+	}
+
+	assertNoError(t, s.Err())
+
+	if len(received) != len(events) {
+		t.Fatalf("received %d events, expected %d",
+			len(received), len(events))
+	}
+
+	for i := range events {
+		if received[i] != events[i] {
+			t.Errorf("event %d: expected %s, present %s",
+				i, events[i], received[i])
+		}
+	}
+
+	// Truncated stream
+	data := stream.Bytes()
+	s = NewNotificationStream(bytes.NewReader(data[:len(data)-1]))
+
+	cnt := 0
+	for range s.C {
+		cnt++
+	}
+
+	if cnt != len(events)-1 {
+		t.Errorf("received %d events, expected %d", cnt, len(events)-1)
+	}
+
+	assertErrorIs(t, s.Err(), "Message truncated")
+
+	// Input is closed when stream terminates, without Close
+	in := &testCloseTracker{Reader: bytes.NewReader(data)}
+	s = NewNotificationStream(in)
+	for range s.C {
+	}
+
+	assertNoError(t, s.Err())
+	if !in.closed {
+		t.Errorf("input not closed when stream terminated")
+	}
+
+	// Close terminates the stream, and Err returns nil
+	pr, pw := io.Pipe()
+	defer pw.Close()
+
+	s = NewNotificationStream(pr)
+	go pw.Write(data[:len(data)/3])
+	<-s.C
+
+	assertNoError(t, s.Close())
+	for range s.C {
+	}
+
+	assertNoError(t, s.Err())
+}
+
+// testCloseTracker wraps io.Reader and tracks Close calls
+type testCloseTracker struct {
+	io.Reader
+	closed bool
+}
+
+// Close marks testCloseTracker as closed
+func (c *testCloseTracker) Close() error {
+	c.closed = true
+	return nil
+}
+
+// TestHTTPURL tests HTTPURL
+func TestHTTPURL(t *testing.T) {
+	tests := [][2]string{
+		{"ipp://localhost/ipp/print", "http://localhost:631/ipp/print"},
+		{"ipps://[::1]:8631/ipp/print", "https://[::1]:8631/ipp/print"},
+		{"http://localhost/ipp/print", "http://localhost/ipp/print"},
+		{"ftp://localhost/", ""},
+	}
+
+	for _, test := range tests {
+		out, _ := HTTPURL(test[0])
+		if out != test[1] {
+			t.Errorf("HTTPURL(%q): expected %q, present %q",
+				test[0], test[1], out)
+		}
+	}
+}
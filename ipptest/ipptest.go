@@ -0,0 +1,140 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Test helpers
+ */
+
+// Package ipptest provides helpers for tests of code, that uses
+// the goipp package: decoding and encoding of messages, that
+// panics on errors, fixture builders and assertions that produce
+// readable failure diffs.
+package ipptest
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/OpenPrinting/goipp"
+)
+
+// MustDecode decodes message from bytes. It panics on error.
+func MustDecode(data []byte) *goipp.Message {
+	m := &goipp.Message{}
+	err := m.DecodeBytes(data)
+	if err != nil {
+		panic(fmt.Errorf("ipptest.MustDecode: %s", err))
+	}
+	return m
+}
+
+// MustDecodeHex decodes message from hex string. Whitespace
+// within the string is ignored, so it is convenient to use
+// multi-line hex dumps. It panics on error.
+func MustDecodeHex(s string) *goipp.Message {
+	data, err := hex.DecodeString(strings.Join(strings.Fields(s), ""))
+	if err != nil {
+		panic(fmt.Errorf("ipptest.MustDecodeHex: %s", err))
+	}
+	return MustDecode(data)
+}
+
+// MustEncode encodes message into bytes. It panics on error.
+func MustEncode(m *goipp.Message) []byte {
+	data, err := m.EncodeBytes()
+	if err != nil {
+		panic(fmt.Errorf("ipptest.MustEncode: %s", err))
+	}
+	return data
+}
+
+// Builder modifies message, built by MustMessage
+type Builder func(m *goipp.Message)
+
+// MustMessage builds message by applying builders in order, and
+// returns it as if it was received from the wire: the message
+// is encoded and decoded back, so both Groups and named per-group
+// fields are filled. Message.Role is preserved.
+//
+// It panics, if message cannot be encoded.
+func MustMessage(builders ...Builder) *goipp.Message {
+	m := &goipp.Message{Version: goipp.DefaultVersion}
+	for _, b := range builders {
+		b(m)
+	}
+
+	m2 := MustDecode(MustEncode(m))
+	m2.Role = m.Role
+
+	return m2
+}
+
+// Request sets message header for the request
+func Request(op goipp.Op, id uint32) Builder {
+	return func(m *goipp.Message) {
+		m.Code = goipp.Code(op)
+		m.RequestID = id
+		m.Role = goipp.MessageRoleRequest
+	}
+}
+
+// Response sets message header for the response
+func Response(status goipp.Status, id uint32) Builder {
+	return func(m *goipp.Message) {
+		m.Code = goipp.Code(status)
+		m.RequestID = id
+		m.Role = goipp.MessageRoleResponse
+	}
+}
+
+// Version sets message version
+func Version(v goipp.Version) Builder {
+	return func(m *goipp.Message) {
+		m.Version = v
+	}
+}
+
+// Group adds group of attributes. Groups with the same tag
+// may be added many times (i.e., multiple job groups of
+// the Get-Jobs response).
+func Group(tag goipp.Tag, attrs ...goipp.Attribute) Builder {
+	return func(m *goipp.Message) {
+		m.Groups.Add(goipp.Group{Tag: tag, Attrs: attrs})
+	}
+}
+
+// Operation adds group of operation attributes
+func Operation(attrs ...goipp.Attribute) Builder {
+	return Group(goipp.TagOperationGroup, attrs...)
+}
+
+// Job adds group of job attributes
+func Job(attrs ...goipp.Attribute) Builder {
+	return Group(goipp.TagJobGroup, attrs...)
+}
+
+// Printer adds group of printer attributes
+func Printer(attrs ...goipp.Attribute) Builder {
+	return Group(goipp.TagPrinterGroup, attrs...)
+}
+
+// AssertEqual reports test failure with the list of differences,
+// if messages are not equal in terms of goipp.Message.Equal
+func AssertEqual(t testing.TB, want, got *goipp.Message) {
+	t.Helper()
+	if diffs := goipp.ExplainDiff(*want, *got); diffs != nil {
+		t.Errorf("Messages are not equal (want vs got):\n%s", diffs)
+	}
+}
+
+// AssertSimilar reports test failure with the list of differences,
+// if messages are not similar in terms of goipp.Message.Similar
+func AssertSimilar(t testing.TB, want, got *goipp.Message) {
+	t.Helper()
+	if diffs := goipp.ExplainSimilarDiff(*want, *got); diffs != nil {
+		t.Errorf("Messages are not similar (want vs got):\n%s", diffs)
+	}
+}
@@ -0,0 +1,121 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Test helpers test
+ */
+
+package ipptest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/OpenPrinting/goipp"
+)
+
+// fakeT records failures, reported via testing.TB
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+// TestMustMessage tests MustMessage and builders
+func TestMustMessage(t *testing.T) {
+	m := MustMessage(
+		Response(goipp.StatusOk, 5),
+		Operation(
+			goipp.MakeAttr("attributes-charset",
+				goipp.TagCharset, goipp.String("utf-8")),
+		),
+		Job(goipp.MakeAttr("job-id", goipp.TagInteger, goipp.Integer(1))),
+		Job(goipp.MakeAttr("job-id", goipp.TagInteger, goipp.Integer(2))),
+	)
+
+	if m.Role != goipp.MessageRoleResponse || m.RequestID != 5 ||
+		m.Version != goipp.DefaultVersion {
+		t.Errorf("Wrong message header: %+v", m)
+	}
+
+	if len(m.Groups) != 3 || len(m.Operation) != 1 || len(m.Job) != 2 {
+		t.Errorf("Wrong message groups: %+v", m)
+	}
+
+	// Hex round trip
+	data := MustEncode(m)
+	dump := ""
+	for i, b := range data {
+		if i%16 == 0 {
+			dump += "\n"
+		}
+		dump += fmt.Sprintf(" %2.2x", b)
+	}
+
+	AssertEqual(t, m, MustDecodeHex(dump))
+}
+
+// TestMustPanics tests that Must functions panic on errors
+func TestMustPanics(t *testing.T) {
+	tests := []func(){
+		func() { MustDecode([]byte{1, 2, 3}) },
+		func() { MustDecodeHex("xyz") },
+		func() {
+			MustEncode(&goipp.Message{
+				Groups: goipp.Groups{
+					{Tag: goipp.TagJobGroup, Attrs: goipp.Attributes{{}}},
+				},
+			})
+		},
+	}
+
+	for i, test := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d: panic expected", i)
+				}
+			}()
+			test()
+		}()
+	}
+}
+
+// TestAssert tests AssertEqual and AssertSimilar
+func TestAssert(t *testing.T) {
+	charset := goipp.MakeAttr("attributes-charset",
+		goipp.TagCharset, goipp.String("utf-8"))
+	lang := goipp.MakeAttr("attributes-natural-language",
+		goipp.TagLanguage, goipp.String("en-us"))
+
+	// Similar, but differently ordered
+	m1 := MustMessage(
+		Request(goipp.OpGetPrinterAttributes, 1),
+		Operation(charset, lang),
+	)
+
+	m2 := MustMessage(
+		Request(goipp.OpGetPrinterAttributes, 1),
+		Operation(lang, charset),
+	)
+
+	var ft fakeT
+	AssertEqual(&ft, m1, m1)
+	AssertSimilar(&ft, m1, m2)
+	if len(ft.errors) != 0 {
+		t.Errorf("Unexpected failures: %v", ft.errors)
+	}
+
+	AssertEqual(&ft, m1, m2)
+	if len(ft.errors) != 1 ||
+		!strings.Contains(ft.errors[0], "attributes-charset") {
+		t.Errorf("Unexpected failures: %v", ft.errors)
+	}
+}
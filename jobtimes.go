@@ -0,0 +1,76 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Job lifecycle timestamps
+ */
+
+package goipp
+
+import (
+	"errors"
+	"time"
+)
+
+// JobTimes contains lifecycle timestamps of the job, extracted from
+// the date-time-at-creation, date-time-at-processing and
+// date-time-at-completed job attributes (RFC 8011, 5.3.14).
+//
+// Printers report events that have not occurred yet either by the
+// 'no-value' out-of-band value or by omitting the attribute. In both
+// cases, the corresponding field is nil.
+type JobTimes struct {
+	Created    *time.Time // date-time-at-creation
+	Processing *time.Time // date-time-at-processing
+	Completed  *time.Time // date-time-at-completed
+}
+
+// JobTimes extracts JobTimes from the first job attributes group
+// of the message.
+//
+// It returns error, if message has no job attributes group or
+// some of timestamps has wrong syntax.
+func (m *Message) JobTimes() (JobTimes, error) {
+	for _, grp := range m.attrGroups() {
+		if grp.Tag == TagJobGroup {
+			return ParseJobTimes(grp.Attrs)
+		}
+	}
+
+	return JobTimes{}, errors.New("Job attributes missed")
+}
+
+// ParseJobTimes extracts JobTimes from the job attributes
+func ParseJobTimes(attrs Attributes) (JobTimes, error) {
+	var times JobTimes
+
+	for _, attr := range attrs {
+		var dst **time.Time
+
+		switch attr.Name {
+		case "date-time-at-creation":
+			dst = &times.Created
+		case "date-time-at-processing":
+			dst = &times.Processing
+		case "date-time-at-completed":
+			dst = &times.Completed
+		default:
+			continue
+		}
+
+		if len(attr.Values) == 1 && attr.Values[0].T.IsOutOfBand() {
+			*dst = nil
+			continue
+		}
+
+		t, err := accountingTime(attr)
+		if err != nil {
+			return JobTimes{}, err
+		}
+
+		*dst = &t
+	}
+
+	return times, nil
+}
@@ -0,0 +1,64 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Job lifecycle timestamps test
+ */
+
+package goipp
+
+import (
+	"testing"
+	"time"
+)
+
+// TestJobTimes tests Message.JobTimes and ParseJobTimes
+func TestJobTimes(t *testing.T) {
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	completed := created.Add(time.Minute)
+
+	m := NewResponse(DefaultVersion, StatusOk, 1)
+	m.Job.Add(MakeAttr("job-id", TagInteger, Integer(1)))
+	m.Job.Add(MakeAttr("date-time-at-creation", TagDateTime,
+		Time{created}))
+	m.Job.Add(MakeAttr("date-time-at-processing", TagNoValue, Void{}))
+	m.Job.Add(MakeAttr("date-time-at-completed", TagDateTime,
+		Time{completed}))
+
+	times, err := m.JobTimes()
+	assertNoError(t, err)
+
+	switch {
+	case times.Created == nil || !times.Created.Equal(created):
+		t.Errorf("Created: expected %s, present %v", created,
+			times.Created)
+	case times.Processing != nil:
+		t.Errorf("Processing: expected nil, present %s",
+			times.Processing)
+	case times.Completed == nil || !times.Completed.Equal(completed):
+		t.Errorf("Completed: expected %s, present %v", completed,
+			times.Completed)
+	}
+
+	// Missed attributes
+	times, err = ParseJobTimes(Attributes{
+		MakeAttr("job-id", TagInteger, Integer(1)),
+	})
+	assertNoError(t, err)
+
+	if times != (JobTimes{}) {
+		t.Errorf("missed attributes: expected nil times, present %v",
+			times)
+	}
+
+	// Errors
+	_, err = ParseJobTimes(Attributes{
+		MakeAttr("date-time-at-completed", TagInteger, Integer(10)),
+	})
+	assertErrorIs(t, err,
+		"date-time-at-completed: single dateTime expected")
+
+	_, err = NewResponse(DefaultVersion, StatusOk, 1).JobTimes()
+	assertErrorIs(t, err, "Job attributes missed")
+}
@@ -0,0 +1,529 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * JSON representation of messages and attributes
+ */
+
+package goipp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// JSON representation of the IPP objects
+//
+// Message:
+//
+//	{
+//	    "version":    "2.0",
+//	    "code":       11,
+//	    "request-id": 1,
+//	    "groups":     [group, ...]
+//	}
+//
+// Group:
+//
+//	{"tag": "operation-attributes-tag", "attributes": [attribute, ...]}
+//
+// Attribute:
+//
+//	{"name": "copies", "values": [value, ...]}
+//
+// Value:
+//
+//	{"tag": "integer", "value": 1}
+//
+// Representation of the value depends on its Type:
+//
+//	Void          null
+//	Integer       number
+//	Boolean       true or false
+//	String        string
+//	DateTime      string, RFC 3339 format
+//	Resolution    {"xres": 300, "yres": 300, "units": 3}
+//	Range         {"lower": 1, "upper": 100}
+//	TextWithLang  {"lang": "en-US", "text": "hello"}
+//	Binary        string, hex-encoded (see JSONOptions)
+//	Collection    [attribute, ...]
+//
+// Tags are represented by their names, as returned by Tag.String().
+//
+// JSONSchema returns the machine-readable schema of this representation.
+type jsonMessage struct {
+	Version   string      `json:"version"`
+	Code      Code        `json:"code"`
+	RequestID uint32      `json:"request-id"`
+	Groups    []jsonGroup `json:"groups"`
+}
+
+type jsonGroup struct {
+	Tag   string            `json:"tag"`
+	Attrs []json.RawMessage `json:"attributes"`
+}
+
+type jsonAttribute struct {
+	Name   string      `json:"name"`
+	Values []jsonValue `json:"values"`
+}
+
+type jsonValue struct {
+	Tag   string          `json:"tag"`
+	Value json.RawMessage `json:"value"`
+}
+
+type jsonResolution struct {
+	Xres  int   `json:"xres"`
+	Yres  int   `json:"yres"`
+	Units Units `json:"units"`
+}
+
+type jsonRange struct {
+	Lower int `json:"lower"`
+	Upper int `json:"upper"`
+}
+
+type jsonTextWithLang struct {
+	Lang string `json:"lang"`
+	Text string `json:"text"`
+}
+
+// JSONBinary defines JSON representation of Binary values
+type JSONBinary int
+
+// JSON representations of Binary values:
+const (
+	// JSONBinaryHex represents Binary as hex-encoded string.
+	// This is the default.
+	JSONBinaryHex JSONBinary = iota
+
+	// JSONBinaryBase64 represents Binary as base64-encoded string
+	// (standard encoding, with padding)
+	JSONBinaryBase64
+
+	// JSONBinaryAuto represents Binary as string, if it is printable
+	// UTF-8 text, and as hex-encoded string otherwise.
+	//
+	// This representation is convenient for logs, but ambiguous,
+	// so it cannot be unmarshaled.
+	JSONBinaryAuto
+)
+
+// JSONOptions represents options of the JSON representation
+type JSONOptions struct {
+	// Binary defines representation of Binary values
+	Binary JSONBinary
+
+	// Canonical enables the canonical representation, which is
+	// stable across runs and versions, and so suitable for snapshot
+	// tests and content hashes:
+	//   - keys of all JSON objects are sorted
+	//   - groups are stably sorted by tag
+	//   - attributes within groups and members of collections
+	//     are stably sorted by name
+	//   - output contains no insignificant whitespace
+	//
+	// Canonical JSON can be unmarshaled, and the result is similar
+	// (see Message.Similar) to the original message.
+	Canonical bool
+}
+
+// MarshalJSON encodes Message into JSON.
+// It implements [json.Marshaler] interface.
+func (m Message) MarshalJSON() ([]byte, error) {
+	return m.MarshalJSONEx(JSONOptions{})
+}
+
+// MarshalJSONEx encodes Message into JSON with options
+func (m Message) MarshalJSONEx(opt JSONOptions) ([]byte, error) {
+	groups := m.attrGroups()
+	if opt.Canonical {
+		groups = groups.Clone()
+		sort.SliceStable(groups, func(i, j int) bool {
+			return groups[i].Tag < groups[j].Tag
+		})
+	}
+
+	jm := jsonMessage{
+		Version:   m.Version.String(),
+		Code:      m.Code,
+		RequestID: m.RequestID,
+		Groups:    make([]jsonGroup, len(groups)),
+	}
+
+	for i, grp := range groups {
+		attrs, err := attrsMarshalJSON(grp.Attrs, opt)
+		if err != nil {
+			return nil, err
+		}
+
+		jm.Groups[i] = jsonGroup{grp.Tag.String(), attrs}
+	}
+
+	return jsonMarshal(jm, opt)
+}
+
+// UnmarshalJSON decodes Message from JSON.
+// It implements [json.Unmarshaler] interface.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	return m.UnmarshalJSONEx(data, JSONOptions{})
+}
+
+// UnmarshalJSONEx decodes Message from JSON with options.
+// Options must match options, used for encoding.
+func (m *Message) UnmarshalJSONEx(data []byte, opt JSONOptions) error {
+	var jm jsonMessage
+
+	err := json.Unmarshal(data, &jm)
+	if err != nil {
+		return err
+	}
+
+	var major, minor uint8
+	_, err = fmt.Sscanf(jm.Version, "%d.%d", &major, &minor)
+	if err != nil {
+		return fmt.Errorf("Invalid version %q", jm.Version)
+	}
+
+	groups := make(Groups, len(jm.Groups))
+	for i, jg := range jm.Groups {
+		tag, err := parseTag(jg.Tag)
+		if err != nil {
+			return err
+		}
+
+		if !tag.IsGroup() {
+			return fmt.Errorf("Tag %s is not a group tag", tag)
+		}
+
+		attrs, err := attrsUnmarshalJSON(jg.Attrs, opt)
+		if err != nil {
+			return err
+		}
+
+		groups[i] = Group{tag, attrs}
+	}
+
+	*m = *NewMessageWithGroups(MakeVersion(major, minor), jm.Code,
+		jm.RequestID, groups)
+
+	return nil
+}
+
+// MarshalJSON encodes Attribute into JSON.
+// It implements [json.Marshaler] interface.
+func (a Attribute) MarshalJSON() ([]byte, error) {
+	return a.MarshalJSONEx(JSONOptions{})
+}
+
+// MarshalJSONEx encodes Attribute into JSON with options
+func (a Attribute) MarshalJSONEx(opt JSONOptions) ([]byte, error) {
+	ja, err := a.marshalJSON(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonMarshal(ja, opt)
+}
+
+// marshalJSON converts Attribute into its JSON representation
+func (a Attribute) marshalJSON(opt JSONOptions) (jsonAttribute, error) {
+	ja := jsonAttribute{
+		Name:   a.Name,
+		Values: make([]jsonValue, len(a.Values)),
+	}
+
+	for i, v := range a.Values {
+		data, err := valueMarshalJSON(v.V, opt)
+		if err != nil {
+			return ja, fmt.Errorf("%q: %s", a.Name, err)
+		}
+
+		ja.Values[i] = jsonValue{v.T.String(), data}
+	}
+
+	return ja, nil
+}
+
+// UnmarshalJSON decodes Attribute from JSON.
+// It implements [json.Unmarshaler] interface.
+func (a *Attribute) UnmarshalJSON(data []byte) error {
+	return a.UnmarshalJSONEx(data, JSONOptions{})
+}
+
+// UnmarshalJSONEx decodes Attribute from JSON with options.
+// Options must match options, used for encoding.
+func (a *Attribute) UnmarshalJSONEx(data []byte, opt JSONOptions) error {
+	var ja jsonAttribute
+
+	err := json.Unmarshal(data, &ja)
+	if err != nil {
+		return err
+	}
+
+	attr := Attribute{Name: ja.Name}
+	for _, jv := range ja.Values {
+		tag, err := parseTag(jv.Tag)
+		if err != nil {
+			return fmt.Errorf("%q: %s", ja.Name, err)
+		}
+
+		val, err := valueUnmarshalJSON(tag, jv.Value, opt)
+		if err != nil {
+			return fmt.Errorf("%q: %s", ja.Name, err)
+		}
+
+		attr.Values.Add(tag, val)
+	}
+
+	*a = attr
+	return nil
+}
+
+// attrsMarshalJSON encodes Attributes into the slice of JSON objects
+func attrsMarshalJSON(attrs Attributes, opt JSONOptions) (
+	[]json.RawMessage, error) {
+
+	if opt.Canonical {
+		attrs = attrs.Clone()
+		sort.SliceStable(attrs, func(i, j int) bool {
+			return attrs[i].Name < attrs[j].Name
+		})
+	}
+
+	out := make([]json.RawMessage, len(attrs))
+	for i, attr := range attrs {
+		ja, err := attr.marshalJSON(opt)
+		if err != nil {
+			return nil, err
+		}
+
+		out[i], err = json.Marshal(ja)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+// jsonMarshal marshals v into JSON. With canonical option, keys
+// of all objects are sorted, as encoding/json does for maps.
+func jsonMarshal(v interface{}, opt JSONOptions) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || !opt.Canonical {
+		return data, err
+	}
+
+	// Numbers are kept as is, so there is no float rounding
+	var generic interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	err = dec.Decode(&generic)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(generic)
+}
+
+// attrsUnmarshalJSON decodes Attributes from the slice of JSON objects
+func attrsUnmarshalJSON(in []json.RawMessage, opt JSONOptions) (
+	Attributes, error) {
+
+	if in == nil {
+		return nil, nil
+	}
+
+	attrs := make(Attributes, len(in))
+	for i, data := range in {
+		err := attrs[i].UnmarshalJSONEx(data, opt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return attrs, nil
+}
+
+// valueMarshalJSON encodes Value into JSON
+func valueMarshalJSON(v Value, opt JSONOptions) ([]byte, error) {
+	var jv interface{}
+	var err error
+
+	switch v := v.(type) {
+	case Void:
+		jv = nil
+	case Integer:
+		jv = int32(v)
+	case Boolean:
+		jv = bool(v)
+	case String:
+		jv = string(v)
+	case Name, Keyword, MimeType, URI:
+		jv = v.String()
+	case Time:
+		jv = v.Time.Format(time.RFC3339Nano)
+	case Resolution:
+		jv = jsonResolution{v.Xres, v.Yres, v.Units}
+	case Range:
+		jv = jsonRange{v.Lower, v.Upper}
+	case TextWithLang:
+		jv = jsonTextWithLang{v.Lang, v.Text}
+	case Binary:
+		jv = binaryMarshalJSON(v, opt.Binary)
+	case BinaryRef:
+		var data Binary
+		data, err = v.Materialize()
+		jv = binaryMarshalJSON(data, opt.Binary)
+	case Collection:
+		jv, err = attrsMarshalJSON(Attributes(v), opt)
+	case ValueMarshaler:
+		// User-defined values are represented as binary
+		var data []byte
+		data, err = v.MarshalIPP()
+		jv = binaryMarshalJSON(data, opt.Binary)
+	default:
+		return nil, fmt.Errorf("%s: unsupported value type", v.Type())
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(jv)
+}
+
+// binaryMarshalJSON returns JSON string representation of Binary
+func binaryMarshalJSON(v Binary, format JSONBinary) string {
+	switch format {
+	case JSONBinaryBase64:
+		return base64.StdEncoding.EncodeToString(v)
+	case JSONBinaryAuto:
+		if binaryPrintable(v) {
+			return string(v)
+		}
+	}
+
+	return hex.EncodeToString(v)
+}
+
+// binaryUnmarshalJSON decodes Binary from its JSON string
+// representation
+func binaryUnmarshalJSON(s string, format JSONBinary) (Binary, error) {
+	switch format {
+	case JSONBinaryHex:
+		return hex.DecodeString(s)
+	case JSONBinaryBase64:
+		return base64.StdEncoding.DecodeString(s)
+	}
+
+	return nil, errors.New("Binary representation cannot be unmarshaled")
+}
+
+// binaryPrintable returns true, if Binary is printable UTF-8 text
+func binaryPrintable(v Binary) bool {
+	if !utf8.Valid(v) {
+		return false
+	}
+
+	for _, r := range string(v) {
+		if !unicode.IsPrint(r) && r != '\n' && r != '\r' && r != '\t' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// valueUnmarshalJSON decodes Value of the type, implied by tag, from JSON
+func valueUnmarshalJSON(tag Tag, data []byte, opt JSONOptions) (Value, error) {
+	var err error
+	var val Value
+
+	// Missed value decoded as JSON null
+	if len(bytes.TrimSpace(data)) == 0 {
+		data = []byte("null")
+	}
+
+	switch tag.Type() {
+	case TypeVoid:
+		val = Void{}
+
+	case TypeInteger:
+		var v int32
+		err = json.Unmarshal(data, &v)
+		val = Integer(v)
+
+	case TypeBoolean:
+		var v bool
+		err = json.Unmarshal(data, &v)
+		val = Boolean(v)
+
+	case TypeString:
+		var v string
+		err = json.Unmarshal(data, &v)
+		val = String(v)
+
+	case TypeDateTime:
+		var s string
+		var t time.Time
+		err = json.Unmarshal(data, &s)
+		if err == nil {
+			t, err = time.Parse(time.RFC3339Nano, s)
+		}
+		val = Time{t}
+
+	case TypeResolution:
+		var v jsonResolution
+		err = json.Unmarshal(data, &v)
+		val = Resolution{v.Xres, v.Yres, v.Units}
+
+	case TypeRange:
+		var v jsonRange
+		err = json.Unmarshal(data, &v)
+		val = Range{v.Lower, v.Upper}
+
+	case TypeTextWithLang:
+		var v jsonTextWithLang
+		err = json.Unmarshal(data, &v)
+		val = TextWithLang{v.Lang, v.Text}
+
+	case TypeBinary:
+		var s string
+		var v Binary
+		err = json.Unmarshal(data, &s)
+		if err == nil {
+			v, err = binaryUnmarshalJSON(s, opt.Binary)
+		}
+		val = v
+
+	case TypeCollection:
+		var in []json.RawMessage
+		var v Attributes
+		err = json.Unmarshal(data, &in)
+		if err == nil {
+			v, err = attrsUnmarshalJSON(in, opt)
+		}
+		val = Collection(v)
+
+	default:
+		err = errors.New("Tag cannot be used with value")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", tag, err)
+	}
+
+	return val, nil
+}
@@ -0,0 +1,203 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * JSON representation test
+ */
+
+package goipp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestJSONRoundTrip tests Message JSON round trip
+func TestJSONRoundTrip(t *testing.T) {
+	messages := []*Message{testEncodeDecodeMessage()}
+
+	for _, data := range [][]byte{goodMessage1, attrsHPOfficeJetPro8730} {
+		m := &Message{}
+		err := m.DecodeBytes(data)
+		assertNoError(t, err)
+		messages = append(messages, m)
+	}
+
+	for _, m1 := range messages {
+		data, err := json.Marshal(m1)
+		assertNoError(t, err)
+
+		var m2 Message
+		err = json.Unmarshal(data, &m2)
+		assertNoError(t, err)
+
+		if !m1.Equal(m2) {
+			t.Errorf("Message: not the same after JSON round trip")
+		}
+	}
+}
+
+// TestJSONErrors tests JSON decoding errors
+func TestJSONErrors(t *testing.T) {
+	type testData struct {
+		in  string // Input JSON
+		err string // Expected error
+	}
+
+	tests := []testData{
+		{
+			in:  `{"version":"x"}`,
+			err: `Invalid version "x"`,
+		},
+		{
+			in:  `{"version":"2.0","groups":[{"tag":"integer"}]}`,
+			err: `Tag integer is not a group tag`,
+		},
+		{
+			in:  `{"version":"2.0","groups":[{"tag":"bad-tag"}]}`,
+			err: `Unknown tag "bad-tag"`,
+		},
+		{
+			in: `{"version":"2.0","groups":[{"tag":"job-attributes-tag",` +
+				`"attributes":[{"name":"a","values":[{"tag":"integer","value":"x"}]}]}]}`,
+			err: `"a": integer: json: cannot unmarshal`,
+		},
+	}
+
+	for _, test := range tests {
+		var m Message
+		err := json.Unmarshal([]byte(test.in), &m)
+		assertErrorIs(t, err, test.err)
+	}
+}
+
+// TestJSONBinary tests JSON representations of Binary values
+func TestJSONBinary(t *testing.T) {
+	type testData struct {
+		format JSONBinary // Binary representation
+		in     Binary     // Input value
+		out    string     // Expected JSON value
+	}
+
+	tests := []testData{
+		{JSONBinaryHex, Binary("hello"), `"68656c6c6f"`},
+		{JSONBinaryBase64, Binary("hello"), `"aGVsbG8="`},
+		{JSONBinaryAuto, Binary("hello\n"), `"hello\n"`},
+		{JSONBinaryAuto, Binary{0, 1, 0xff}, `"0001ff"`},
+		{JSONBinaryAuto, Binary("\xd0\xbf\xd1\x80"), `"пр"`},
+	}
+
+	for _, test := range tests {
+		opt := JSONOptions{Binary: test.format}
+		m1 := NewResponse(DefaultVersion, StatusOk, 1)
+		m1.Printer.Add(MakeAttrCollection("col",
+			MakeAttribute("data", TagString, test.in)))
+
+		data, err := m1.MarshalJSONEx(opt)
+		assertNoError(t, err)
+
+		expected := `"values":[{"tag":"octetString","value":` +
+			test.out + `}]`
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("%d: %s: expected %s", test.format, data, expected)
+		}
+
+		var m2 Message
+		err = m2.UnmarshalJSONEx(data, opt)
+		if test.format == JSONBinaryAuto {
+			assertWithError(t, err)
+			continue
+		}
+
+		assertNoError(t, err)
+		if !m1.Equal(m2) {
+			t.Errorf("%d: not the same after JSON round trip",
+				test.format)
+		}
+	}
+}
+
+// TestJSONCanonical tests canonical JSON representation
+func TestJSONCanonical(t *testing.T) {
+	opt := JSONOptions{Canonical: true}
+
+	m1 := NewRequest(DefaultVersion, OpPrintJob, 1)
+	m1.Operation.Add(MakeAttr("attributes-charset", TagCharset,
+		String("utf-8")))
+	m1.Job.Add(MakeAttr("copies", TagInteger, Integer(2)))
+	m1.Job.Add(MakeAttrCollection("media-col",
+		MakeAttr("media-type", TagKeyword, String("stationery")),
+		MakeAttr("media-source", TagKeyword, String("tray-1"))))
+	m1.Job.Add(MakeAttr("printer-resolution", TagResolution,
+		Resolution{300, 600, UnitsDpi}))
+
+	// The same message with differently ordered groups,
+	// attributes and collection members
+	m2 := NewRequest(DefaultVersion, OpPrintJob, 1)
+	m2.Groups = Groups{
+		{TagJobGroup, Attributes{
+			m1.Job[2],
+			MakeAttrCollection("media-col",
+				MakeAttr("media-source", TagKeyword,
+					String("tray-1")),
+				MakeAttr("media-type", TagKeyword,
+					String("stationery"))),
+			m1.Job[0],
+		}},
+		{TagOperationGroup, m1.Operation},
+	}
+
+	data1, err := m1.MarshalJSONEx(opt)
+	assertNoError(t, err)
+
+	data2, err := m2.MarshalJSONEx(opt)
+	assertNoError(t, err)
+
+	if string(data1) != string(data2) {
+		t.Errorf("canonical JSON differs:\n%s\n%s", data1, data2)
+	}
+
+	expected := `{"code":2,"groups":[` +
+		`{"attributes":[{"name":"attributes-charset","values":` +
+		`[{"tag":"charset","value":"utf-8"}]}],` +
+		`"tag":"operation-attributes-tag"},` +
+		`{"attributes":[{"name":"copies","values":` +
+		`[{"tag":"integer","value":2}]},` +
+		`{"name":"media-col","values":[{"tag":"collection","value":[` +
+		`{"name":"media-source","values":` +
+		`[{"tag":"keyword","value":"tray-1"}]},` +
+		`{"name":"media-type","values":` +
+		`[{"tag":"keyword","value":"stationery"}]}]}]},` +
+		`{"name":"printer-resolution","values":[{"tag":"resolution",` +
+		`"value":{"units":3,"xres":300,"yres":600}}]}],` +
+		`"tag":"job-attributes-tag"}],` +
+		`"request-id":1,"version":"2.0"}`
+
+	if string(data1) != expected {
+		t.Errorf("canonical JSON:\nexpected: %s\npresent:  %s",
+			expected, data1)
+	}
+
+	// Canonical JSON round trip
+	var m3 Message
+	err = m3.UnmarshalJSONEx(data1, opt)
+	assertNoError(t, err)
+
+	if !m3.Similar(*m1) {
+		t.Errorf("canonical JSON round trip:\n%s",
+			ExplainSimilarDiff(*m1, m3))
+	}
+
+	// Canonical Attribute
+	data, err := m1.Job[2].MarshalJSONEx(opt)
+	assertNoError(t, err)
+
+	s := `{"name":"printer-resolution","values":[{"tag":"resolution",` +
+		`"value":{"units":3,"xres":300,"yres":600}}]}`
+	if string(data) != s {
+		t.Errorf("canonical Attribute JSON:\nexpected: %s\npresent:  %s",
+			s, data)
+	}
+}
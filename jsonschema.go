@@ -0,0 +1,196 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * JSON Schema of the JSON representation
+ */
+
+package goipp
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+)
+
+// JSONSchemaURI is the URI of the JSON Schema dialect, used by
+// JSONSchema
+const JSONSchemaURI = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema returns the JSON Schema (draft 2020-12) of the JSON
+// representation of Message, as produced by Message.MarshalJSONEx
+// with the specified options, so non-Go consumers can validate
+// goipp's output and generate bindings for it.
+//
+// Attribute, Value and Group are described in the "$defs" section
+// of the schema, under the "attribute", "value" and "group" names,
+// so they can be referenced, i.e., as "...#/$defs/attribute".
+//
+// Schema is generated from the same tables, that are used by
+// the encoder and decoder, so it always matches the implementation.
+// Only opt.Binary affects the schema.
+func JSONSchema(opt JSONOptions) []byte {
+	type object = map[string]interface{}
+
+	ref := func(name string) object {
+		return object{"$ref": "#/$defs/" + name}
+	}
+
+	arrayOf := func(items object) object {
+		return object{"type": "array", "items": items}
+	}
+
+	strict := func(required []string, props object) object {
+		return object{
+			"type":                 "object",
+			"required":             required,
+			"properties":           props,
+			"additionalProperties": false,
+		}
+	}
+
+	int32Schema := object{
+		"type":    "integer",
+		"minimum": math.MinInt32,
+		"maximum": math.MaxInt32,
+	}
+
+	// Collect tag names by type
+	var groupTags []string
+	valueTags := make(map[Type][]string)
+	for tag, name := range tagNames {
+		switch t := Tag(tag); {
+		case name == "":
+		case t.IsGroup():
+			groupTags = append(groupTags, name)
+		case !t.IsDelimiter():
+			valueTags[t.Type()] = append(valueTags[t.Type()], name)
+		}
+	}
+
+	// Schemas of values, by type
+	var binarySchema object
+	switch opt.Binary {
+	case JSONBinaryHex:
+		binarySchema = object{
+			"type":    "string",
+			"pattern": "^([0-9a-fA-F]{2})*$",
+		}
+	case JSONBinaryBase64:
+		binarySchema = object{
+			"type":            "string",
+			"contentEncoding": "base64",
+		}
+	default:
+		binarySchema = object{"type": "string"}
+	}
+
+	typeSchemas := map[Type]object{
+		TypeVoid:    {"type": "null"},
+		TypeInteger: int32Schema,
+		TypeBoolean: {"type": "boolean"},
+		TypeString:  {"type": "string"},
+		TypeDateTime: {
+			"type":   "string",
+			"format": "date-time",
+		},
+		TypeResolution: strict([]string{"xres", "yres", "units"},
+			object{
+				"xres": int32Schema,
+				"yres": int32Schema,
+				"units": object{
+					"type":    "integer",
+					"minimum": 0,
+					"maximum": math.MaxUint8,
+				},
+			}),
+		TypeRange: strict([]string{"lower", "upper"},
+			object{"lower": int32Schema, "upper": int32Schema}),
+		TypeTextWithLang: strict([]string{"lang", "text"},
+			object{
+				"lang": object{"type": "string"},
+				"text": object{"type": "string"},
+			}),
+		TypeBinary:     binarySchema,
+		TypeCollection: arrayOf(ref("attribute")),
+	}
+
+	// Value is one of the tag/value variants. Unnamed tags are
+	// written in hex and their values are always strings: either
+	// Binary or, for the reserved string tag, String.
+	types := make([]Type, 0, len(valueTags))
+	for t := range valueTags {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+
+	var variants []interface{}
+	for _, t := range types {
+		required := []string{"tag", "value"}
+		if t == TypeVoid {
+			required = required[:1]
+		}
+
+		variants = append(variants, strict(required, object{
+			"tag":   object{"enum": valueTags[t]},
+			"value": typeSchemas[t],
+		}))
+	}
+
+	variants = append(variants, strict([]string{"tag", "value"}, object{
+		"tag":   object{"type": "string", "pattern": "^0x[0-9a-fA-F]+$"},
+		"value": object{"type": "string"},
+	}))
+
+	schema := object{
+		"$schema": JSONSchemaURI,
+		"title":   "IPP message",
+		"$ref":    "#/$defs/message",
+		"$defs": object{
+			"message": strict(
+				[]string{"version", "code", "request-id", "groups"},
+				object{
+					"version": object{
+						"type":    "string",
+						"pattern": "^[0-9]+\\.[0-9]+$",
+					},
+					"code": object{
+						"type":    "integer",
+						"minimum": 0,
+						"maximum": math.MaxUint16,
+					},
+					"request-id": object{
+						"type":    "integer",
+						"minimum": 0,
+						"maximum": uint32(math.MaxUint32),
+					},
+					"groups": arrayOf(ref("group")),
+				}),
+
+			"group": strict([]string{"tag", "attributes"},
+				object{
+					// Future groups have no names
+					"tag": object{"anyOf": []interface{}{
+						object{"enum": groupTags},
+						object{
+							"type":    "string",
+							"pattern": "^0x0[b-fB-F]$",
+						},
+					}},
+					"attributes": arrayOf(ref("attribute")),
+				}),
+
+			"attribute": strict([]string{"name", "values"},
+				object{
+					"name":   object{"type": "string"},
+					"values": arrayOf(ref("value")),
+				}),
+
+			"value": object{"oneOf": variants},
+		},
+	}
+
+	data, _ := json.MarshalIndent(schema, "", "  ")
+	return data
+}
@@ -0,0 +1,273 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * JSON Schema test
+ */
+
+package goipp
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestJSONSchema validates JSON representation of messages against
+// JSONSchema
+func TestJSONSchema(t *testing.T) {
+	// Message with all named value tags
+	all := NewResponse(DefaultVersion, StatusOk, 1)
+	for tag, name := range tagNames {
+		var v Value
+
+		switch tag := Tag(tag); tag.Type() {
+		case TypeVoid:
+			v = Void{}
+		case TypeInteger:
+			v = Integer(-1)
+		case TypeBoolean:
+			v = Boolean(true)
+		case TypeString:
+			v = String("hello")
+		case TypeDateTime:
+			v = parseTime("01/02 03:04:05PM '06 -0700")
+		case TypeResolution:
+			v = Resolution{300, 600, UnitsDpi}
+		case TypeRange:
+			v = Range{1, 100}
+		case TypeTextWithLang:
+			v = TextWithLang{"en-US", "hello"}
+		case TypeBinary:
+			v = Binary{0, 1, 0xff}
+		case TypeCollection:
+			v = Collection{MakeAttribute("member", TagKeyword,
+				String("x"))}
+		default:
+			continue
+		}
+
+		all.Printer.Add(MakeAttribute(name, Tag(tag), v))
+	}
+
+	all.Printer.Add(MakeAttribute("extension", 0x40000001,
+		Binary("ext")))
+	all.Printer.Add(MakeAttribute("reserved", TagReservedString,
+		String("reserved")))
+
+	messages := []*Message{all, testEncodeDecodeMessage()}
+	for _, data := range [][]byte{goodMessage1, goodMessage2,
+		attrsHPOfficeJetPro8730} {
+		m := &Message{}
+		err := m.DecodeBytes(data)
+		assertNoError(t, err)
+		messages = append(messages, m)
+	}
+
+	for _, format := range []JSONBinary{JSONBinaryHex,
+		JSONBinaryBase64, JSONBinaryAuto} {
+
+		var schema map[string]interface{}
+		err := json.Unmarshal(JSONSchema(JSONOptions{Binary: format}),
+			&schema)
+		assertNoError(t, err)
+
+		if schema["$schema"] != JSONSchemaURI {
+			t.Errorf("$schema: %v", schema["$schema"])
+		}
+
+		v := jsonSchemaValidator{schema}
+		for i, m := range messages {
+			data, err := m.MarshalJSONEx(JSONOptions{Binary: format})
+			assertNoError(t, err)
+
+			var doc interface{}
+			json.Unmarshal(data, &doc)
+
+			if err = v.validate(schema, doc); err != nil {
+				t.Errorf("format=%d, message %d: %s", format, i, err)
+			}
+		}
+	}
+
+	// Invalid documents must be rejected
+	bad := []string{
+		`{}`,
+		`{"version":"2.0","code":0,"request-id":1,"groups":[],"x":1}`,
+		`{"version":"2.0","code":-1,"request-id":1,"groups":[]}`,
+		`{"version":"two","code":0,"request-id":1,"groups":[]}`,
+		`{"version":"2.0","code":0,"request-id":1,"groups":[` +
+			`{"tag":"integer","attributes":[]}]}`,
+		`{"version":"2.0","code":0,"request-id":1,"groups":[` +
+			`{"tag":"end-of-attributes-tag","attributes":[]}]}`,
+		`{"version":"2.0","code":0,"request-id":1,"groups":[` +
+			`{"tag":"printer-attributes-tag","attributes":[` +
+			`{"name":"a","values":[{"tag":"integer","value":"1"}]}]}]}`,
+		`{"version":"2.0","code":0,"request-id":1,"groups":[` +
+			`{"tag":"printer-attributes-tag","attributes":[` +
+			`{"name":"a","values":[{"tag":"integer","value":1.5}]}]}]}`,
+		`{"version":"2.0","code":0,"request-id":1,"groups":[` +
+			`{"tag":"printer-attributes-tag","attributes":[` +
+			`{"name":"a","values":[{"tag":"keyword"}]}]}]}`,
+		`{"version":"2.0","code":0,"request-id":1,"groups":[` +
+			`{"tag":"printer-attributes-tag","attributes":[` +
+			`{"name":"a","values":[{"tag":"rangeOfInteger",` +
+			`"value":{"lower":1}}]}]}]}`,
+		`{"version":"2.0","code":0,"request-id":1,"groups":[` +
+			`{"tag":"printer-attributes-tag","attributes":[` +
+			`{"name":"a","values":[{"tag":"octetString",` +
+			`"value":"xyz"}]}]}]}`,
+		`{"version":"2.0","code":0,"request-id":1,"groups":[` +
+			`{"tag":"printer-attributes-tag","attributes":[` +
+			`{"name":"a","values":[{"tag":"collection",` +
+			`"value":[{"name":"m"}]}]}]}]}`,
+	}
+
+	var schema map[string]interface{}
+	json.Unmarshal(JSONSchema(JSONOptions{}), &schema)
+	v := jsonSchemaValidator{schema}
+
+	for _, in := range bad {
+		var doc interface{}
+		err := json.Unmarshal([]byte(in), &doc)
+		assertNoError(t, err)
+
+		if v.validate(schema, doc) == nil {
+			t.Errorf("%s: invalid document accepted", in)
+		}
+	}
+}
+
+// jsonSchemaValidator is the minimal JSON Schema validator,
+// which supports only keywords, used by JSONSchema
+type jsonSchemaValidator struct {
+	root map[string]interface{} // Root schema
+}
+
+// validate validates the document against the schema
+func (v jsonSchemaValidator) validate(schema map[string]interface{},
+	doc interface{}) error {
+
+	for key, arg := range schema {
+		var err error
+
+		switch key {
+		case "$schema", "$defs", "title", "format", "contentEncoding":
+
+		case "$ref":
+			path := strings.TrimPrefix(arg.(string), "#/$defs/")
+			defs := v.root["$defs"].(map[string]interface{})
+			err = v.validate(defs[path].(map[string]interface{}), doc)
+
+		case "type":
+			if !jsonSchemaIsType(doc, arg.(string)) {
+				err = fmt.Errorf("%v: %s expected", doc, arg)
+			}
+
+		case "enum":
+			err = fmt.Errorf("%v: not in %v", doc, arg)
+			for _, item := range arg.([]interface{}) {
+				if item == doc {
+					err = nil
+				}
+			}
+
+		case "pattern":
+			s, ok := doc.(string)
+			if ok && !regexp.MustCompile(arg.(string)).MatchString(s) {
+				err = fmt.Errorf("%q: doesn't match %s", s, arg)
+			}
+
+		case "minimum", "maximum":
+			n, ok := doc.(float64)
+			if ok && ((key == "minimum" && n < arg.(float64)) ||
+				(key == "maximum" && n > arg.(float64))) {
+				err = fmt.Errorf("%v: out of range", doc)
+			}
+
+		case "required":
+			obj, ok := doc.(map[string]interface{})
+			for _, name := range arg.([]interface{}) {
+				if _, found := obj[name.(string)]; ok && !found {
+					err = fmt.Errorf("%q: missed", name)
+				}
+			}
+
+		case "properties":
+			obj, _ := doc.(map[string]interface{})
+			props := arg.(map[string]interface{})
+			for name, val := range obj {
+				if prop, found := props[name]; found && err == nil {
+					err = v.validate(
+						prop.(map[string]interface{}), val)
+				}
+			}
+
+		case "additionalProperties":
+			obj, _ := doc.(map[string]interface{})
+			props := schema["properties"].(map[string]interface{})
+			for name := range obj {
+				if _, found := props[name]; !found {
+					err = fmt.Errorf("%q: unexpected", name)
+				}
+			}
+
+		case "items":
+			arr, _ := doc.([]interface{})
+			for _, item := range arr {
+				if err == nil {
+					err = v.validate(
+						arg.(map[string]interface{}), item)
+				}
+			}
+
+		case "anyOf", "oneOf":
+			matches := 0
+			for _, sub := range arg.([]interface{}) {
+				if v.validate(sub.(map[string]interface{}), doc) == nil {
+					matches++
+				}
+			}
+
+			switch {
+			case matches == 0:
+				err = fmt.Errorf("%v: no %s matches", doc, key)
+			case key == "oneOf" && matches > 1:
+				err = fmt.Errorf("%v: %d oneOf matches", doc, matches)
+			}
+
+		default:
+			err = fmt.Errorf("%s: unsupported keyword", key)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// jsonSchemaIsType checks type of the document
+func jsonSchemaIsType(doc interface{}, typ string) bool {
+	switch doc := doc.(type) {
+	case nil:
+		return typ == "null"
+	case bool:
+		return typ == "boolean"
+	case string:
+		return typ == "string"
+	case float64:
+		return typ == "number" ||
+			(typ == "integer" && doc == float64(int64(doc)))
+	case []interface{}:
+		return typ == "array"
+	case map[string]interface{}:
+		return typ == "object"
+	}
+
+	return false
+}
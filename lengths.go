@@ -0,0 +1,237 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Attribute value length constraints
+ */
+
+package goipp
+
+import (
+	"fmt"
+	"math"
+)
+
+// Structural limits of the IPP wire format (RFC 8010, 3.1.4)
+const (
+	// MaxAttrNameLength is the maximum length of attribute name,
+	// in bytes
+	MaxAttrNameLength = math.MaxInt16
+
+	// MaxAttrValueLength is the maximum length of the encoded
+	// attribute value, in bytes
+	MaxAttrValueLength = math.MaxInt16
+)
+
+// LengthLimit is the maximum length of the attribute value, in bytes.
+// The zero LengthLimit means that length is not limited by the value
+// syntax, and only the wire format limit (MaxAttrValueLength) applies.
+type LengthLimit int
+
+// Length limits of value syntaxes, in bytes (RFC 8011, 5.1).
+// For textWithLanguage and nameWithLanguage values, limits apply
+// to the text part only.
+const (
+	MaxTextLength      LengthLimit = 1023 // text(MAX)
+	MaxNameLength      LengthLimit = 255  // name(MAX)
+	MaxKeywordLength   LengthLimit = 255  // keyword
+	MaxURILength       LengthLimit = 1023 // uri
+	MaxURISchemeLength LengthLimit = 63   // uriScheme
+	MaxCharsetLength   LengthLimit = 63   // charset
+	MaxLanguageLength  LengthLimit = 63   // naturalLanguage
+	MaxMimeTypeLength  LengthLimit = 255  // mimeMediaType
+)
+
+// LengthPolicy defines how attribute values, exceeding length
+// limits of the IPP specification, are handled by the encoder.
+type LengthPolicy int
+
+// Length policies:
+const (
+	// LengthIgnore disables length checking. Only the wire format
+	// limit (MaxAttrValueLength) is enforced. This is the default.
+	LengthIgnore LengthPolicy = iota
+
+	// LengthReject causes encoding to fail
+	LengthReject
+
+	// LengthTruncate silently truncates long values at the
+	// UTF-8 character boundary. The Message itself is not modified.
+	LengthTruncate
+)
+
+// attrMaxLength contains length limits of particular attributes,
+// which are more strict than the general limits of their syntax.
+//
+// Limits are taken from RFC 8011 and PWG 5100.x.
+var attrMaxLength = map[string]LengthLimit{
+	"printer-name":            127, // name(127)
+	"printer-location":        127, // text(127)
+	"printer-info":            127, // text(127)
+	"printer-make-and-model":  127, // text(127)
+	"status-message":          MaxStatusMessage,
+	"detailed-status-message": MaxDetailedStatusMessage,
+}
+
+// tagMaxLength contains general length limits of value syntaxes
+// (RFC 8011, 5.1).
+var tagMaxLength = map[Tag]LengthLimit{
+	TagName:      MaxNameLength,
+	TagNameLang:  MaxNameLength,
+	TagText:      MaxTextLength,
+	TagTextLang:  MaxTextLength,
+	TagKeyword:   MaxKeywordLength,
+	TagURI:       MaxURILength,
+	TagURIScheme: MaxURISchemeLength,
+	TagCharset:   MaxCharsetLength,
+	TagLanguage:  MaxLanguageLength,
+	TagMimeType:  MaxMimeTypeLength,
+}
+
+// ValueLengthLimit returns maximum length of the value of the
+// attribute (or collection member) with the specified name and tag,
+// in bytes, or 0, if length is not limited by the value syntax.
+//
+// For textWithLanguage and nameWithLanguage values, the limit
+// applies to the text part only.
+func ValueLengthLimit(name string, tag Tag) LengthLimit {
+	max := tagMaxLength[tag]
+	if max == 0 {
+		return 0
+	}
+
+	if n := attrMaxLength[name]; n != 0 && n < max {
+		max = n
+	}
+
+	return max
+}
+
+// ValidateLengths checks that all attribute values of the message
+// don't exceed limits, returned by ValueLengthLimit.
+func (m *Message) ValidateLengths() error {
+	_, err := m.applyLengths(LengthReject)
+	return err
+}
+
+// applyLengths applies LengthPolicy to the message.
+//
+// With LengthTruncate, it returns a shallow copy of the message,
+// with long values truncated. With LengthReject, it returns the
+// message itself or error.
+func (m *Message) applyLengths(policy LengthPolicy) (*Message, error) {
+	groups := m.attrGroups()
+	groups2 := make(Groups, len(groups))
+
+	for i, grp := range groups {
+		attrs, _, err := attrsApplyLengths(grp.Attrs, policy, "")
+		if err != nil {
+			return nil, err
+		}
+
+		groups2[i] = Group{grp.Tag, attrs}
+	}
+
+	if policy != LengthTruncate {
+		return m, nil
+	}
+
+	m2 := *m
+	m2.Groups = groups2
+
+	return &m2, nil
+}
+
+// attrsApplyLengths applies LengthPolicy to attributes.
+// Attributes are copied only if truncation actually happens,
+// and the changed flag is returned.
+func attrsApplyLengths(attrs Attributes, policy LengthPolicy,
+	prefix string) (attrs2 Attributes, changed bool, err error) {
+
+	attrs2 = attrs
+
+	for i, attr := range attrs {
+		values := attr.Values
+		for j, val := range attr.Values {
+			v, err := valueApplyLengths(attr.Name, val.T, val.V,
+				policy, prefix)
+			if err != nil {
+				return nil, false, err
+			}
+
+			if v == nil {
+				continue
+			}
+
+			if !changed {
+				attrs2 = attrs.Clone()
+				changed = true
+			}
+
+			if &values[0] == &attr.Values[0] {
+				values = make(Values, len(attr.Values))
+				copy(values, attr.Values)
+				attrs2[i].Values = values
+			}
+
+			values[j].V = v
+		}
+	}
+
+	return
+}
+
+// valueApplyLengths applies LengthPolicy to the single value.
+// It returns nil, if value is not changed.
+func valueApplyLengths(name string, tag Tag, v Value,
+	policy LengthPolicy, prefix string) (Value, error) {
+
+	if col, ok := v.(Collection); ok {
+		col2, changed, err := attrsApplyLengths(Attributes(col),
+			policy, prefix+name+"/")
+		if err != nil || !changed {
+			return nil, err
+		}
+		return Collection(col2), nil
+	}
+
+	max := ValueLengthLimit(name, tag)
+	if max == 0 {
+		return nil, nil
+	}
+
+	var text string
+	switch v := v.(type) {
+	case String:
+		text = string(v)
+	case Name, Keyword, MimeType, URI:
+		text = v.String()
+	case TextWithLang:
+		text = v.Text
+	default:
+		return nil, nil
+	}
+
+	if len(text) <= int(max) {
+		return nil, nil
+	}
+
+	if policy == LengthReject {
+		return nil, fmt.Errorf("%s%s: %s value exceeds %d bytes",
+			prefix, name, tag, max)
+	}
+
+	text = truncateUTF8(text, int(max))
+	switch v := v.(type) {
+	case Name:
+		return Name(text), nil
+	case Keyword:
+		return Keyword(text), nil
+	case TextWithLang:
+		v.Text = text
+		return v, nil
+	}
+
+	return String(text), nil
+}
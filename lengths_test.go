@@ -0,0 +1,111 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Attribute value length constraints test
+ */
+
+package goipp
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestValueLengthLimit tests ValueLengthLimit
+func TestValueLengthLimit(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  Tag
+		max  LengthLimit
+	}{
+		{"printer-name", TagName, 127},
+		{"printer-name", TagNameLang, 127},
+		{"job-name", TagName, 255},
+		{"printer-state-message", TagText, 1023},
+		{"status-message", TagText, 255},
+		{"media", TagKeyword, 255},
+		{"printer-uri", TagURI, 1023},
+		{"copies", TagInteger, 0},
+		{"document-format", TagMimeType, MaxMimeTypeLength},
+		{"attributes-charset", TagCharset, MaxCharsetLength},
+		{"uri-scheme", TagURIScheme, MaxURISchemeLength},
+	}
+
+	for _, test := range tests {
+		max := ValueLengthLimit(test.name, test.tag)
+		if max != test.max {
+			t.Errorf("ValueLengthLimit(%q, %s): expected %d, present %d",
+				test.name, test.tag, test.max, max)
+		}
+	}
+}
+
+// TestEncodeLengths tests EncodeEx with length policies
+func TestEncodeLengths(t *testing.T) {
+	long := strings.Repeat("x", 200)
+
+	m := NewResponse(DefaultVersion, StatusOk, 1)
+	m.Printer.Add(MakeAttr("printer-name", TagName, String(long)))
+	m.Printer.Add(MakeAttr("printer-info", TagTextLang,
+		TextWithLang{Lang: "en", Text: long}))
+	m.Printer.Add(MakeAttrCollection("media-col-default",
+		MakeAttr("media-key", TagKeyword, String(long+long))))
+
+	// LengthIgnore
+	_, err := m.EncodeBytesEx(EncoderOptions{})
+	assertNoError(t, err)
+
+	// LengthReject
+	_, err = m.EncodeBytesEx(EncoderOptions{Lengths: LengthReject})
+	assertErrorIs(t, err,
+		"printer-name: nameWithoutLanguage value exceeds 127 bytes")
+
+	m.Printer[0].Values[0].V = String("short")
+	err = m.ValidateLengths()
+	assertErrorIs(t, err,
+		"printer-info: textWithLanguage value exceeds 127 bytes")
+
+	m.Printer[1].Values[0].V = TextWithLang{Lang: "en", Text: "short"}
+	err = m.ValidateLengths()
+	assertErrorIs(t, err,
+		"media-col-default/media-key: keyword value exceeds 255 bytes")
+
+	// LengthTruncate
+	m.Printer[0].Values[0].V = String(long)
+	m.Printer[1].Values[0].V = TextWithLang{Lang: "en", Text: long}
+
+	data, err := m.EncodeBytesEx(EncoderOptions{Lengths: LengthTruncate})
+	assertNoError(t, err)
+
+	var m2 Message
+	err = m2.DecodeBytes(data)
+	assertNoError(t, err)
+
+	assertNoError(t, m2.ValidateLengths())
+
+	if s := m2.Printer[0].Values[0].V.String(); len(s) != 127 {
+		t.Errorf("printer-name: not truncated, len=%d", len(s))
+	}
+
+	twl := m2.Printer[1].Values[0].V.(TextWithLang)
+	if len(twl.Text) != 127 || twl.Lang != "en" {
+		t.Errorf("printer-info: bad truncation: %v", twl)
+	}
+
+	media := m2.Printer[2].Values[0].V.(Collection)
+	if s := media[0].Values[0].V.String(); len(s) != 255 {
+		t.Errorf("media-key: not truncated, len=%d", len(s))
+	}
+
+	// Original message must not be modified
+	if s := m.Printer[0].Values[0].V.String(); s != long {
+		t.Errorf("original message modified")
+	}
+
+	media = m.Printer[2].Values[0].V.(Collection)
+	if s := media[0].Values[0].V.String(); s != long+long {
+		t.Errorf("original collection modified")
+	}
+}
@@ -0,0 +1,867 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Message linter
+ */
+
+package goipp
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// LintSeverity defines severity of the LintFinding
+type LintSeverity int
+
+// Lint severities, in order of increasing severity:
+const (
+	LintInfo    LintSeverity = iota // Informational note
+	LintWarning                     // Likely a problem
+	LintError                       // Definitely a problem
+)
+
+// String returns name of the LintSeverity
+func (sev LintSeverity) String() string {
+	switch sev {
+	case LintInfo:
+		return "info"
+	case LintWarning:
+		return "warning"
+	case LintError:
+		return "error"
+	}
+
+	return fmt.Sprintf("severity-%d", int(sev))
+}
+
+// LintFinding represents a single finding of the Linter
+type LintFinding struct {
+	Rule     string       // Name of the rule
+	Severity LintSeverity // Finding severity
+	Path     string       // Path, i.e., "printer-attributes-tag/media"
+	Msg      string       // Human-readable description
+}
+
+// LintFindings represents a list of findings
+type LintFindings []LintFinding
+
+// String returns LintFinding as "severity: path: msg (rule)" string
+func (f LintFinding) String() string {
+	return fmt.Sprintf("%s: %s: %s (%s)", f.Severity, f.Path, f.Msg, f.Rule)
+}
+
+// String returns LintFindings as text, one finding per line
+func (findings LintFindings) String() string {
+	var buf bytes.Buffer
+	for _, f := range findings {
+		buf.WriteString(f.String())
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+// Worst returns the highest severity of findings, or -1, if
+// there are no findings
+func (findings LintFindings) Worst() LintSeverity {
+	worst := LintSeverity(-1)
+	for _, f := range findings {
+		if f.Severity > worst {
+			worst = f.Severity
+		}
+	}
+	return worst
+}
+
+// LintRule is the interface, implemented by rules of the Linter
+type LintRule interface {
+	// Name returns the rule name, i.e., "unknown-attribute"
+	Name() string
+
+	// Lint checks the message and returns findings
+	Lint(m *Message) LintFindings
+}
+
+// Linter checks messages against the configurable set of rules,
+// covering best practices beyond hard validation.
+//
+// Unlike validation errors, findings don't mean that message is
+// invalid. They are intended for developers of IPP servers and
+// clients, i.e., for use in CI.
+type Linter struct {
+	Rules []LintRule // Rules to check
+}
+
+// NewLinter creates a new Linter with the specified rules. If no
+// rules specified, DefaultLintRules are used.
+func NewLinter(rules ...LintRule) *Linter {
+	if len(rules) == 0 {
+		rules = DefaultLintRules()
+	}
+	return &Linter{Rules: rules}
+}
+
+// DefaultLintRules returns the default set of rules
+func DefaultLintRules() []LintRule {
+	return []LintRule{
+		LintUnknownAttributes{},
+		LintDeprecatedAttributes{},
+		LintTagMismatch{},
+		LintGroupSize{},
+	}
+}
+
+// Lint checks the message against all rules and returns findings,
+// ordered by rules
+func (l *Linter) Lint(m *Message) LintFindings {
+	var findings LintFindings
+	for _, rule := range l.Rules {
+		findings = append(findings, rule.Lint(m)...)
+	}
+	return findings
+}
+
+// LintUnknownAttributes reports attributes with unknown names.
+//
+// Names are checked against the built-in table of well-known
+// attributes. The -default, -supported, -ready, -database,
+// -preferred and -configured suffixes are stripped before lookup,
+// and vendor attributes, prefixed with "smiNNN-", are ignored.
+//
+// The built-in table is not exhaustive, so findings are
+// informational only.
+type LintUnknownAttributes struct {
+	// Known contains additional names, considered known
+	Known []string
+}
+
+// Name returns the rule name
+func (LintUnknownAttributes) Name() string { return "unknown-attribute" }
+
+// Lint checks the message
+func (rule LintUnknownAttributes) Lint(m *Message) LintFindings {
+	var findings LintFindings
+
+	lintWalk(m, func(path string, attr Attribute) {
+		if !rule.known(attr.Name) {
+			findings = append(findings, LintFinding{
+				Rule:     rule.Name(),
+				Severity: LintInfo,
+				Path:     path,
+				Msg:      "unknown attribute",
+			})
+		}
+	})
+
+	return findings
+}
+
+// known returns true, if attribute name is known
+func (rule LintUnknownAttributes) known(name string) bool {
+	if _, found := lintAttrs[lintBaseName(name)]; found {
+		return true
+	}
+
+	if _, found := lintAttrs[name]; found {
+		return true
+	}
+
+	if strings.HasPrefix(name, "smi") {
+		i := 3
+		for i < len(name) && '0' <= name[i] && name[i] <= '9' {
+			i++
+		}
+		if i > 3 && i < len(name) && name[i] == '-' {
+			return true
+		}
+	}
+
+	for _, known := range rule.Known {
+		if name == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LintDeprecatedAttributes reports deprecated attributes.
+type LintDeprecatedAttributes struct {
+	// Deprecated maps names of deprecated attributes to their
+	// replacements (or ""). If nil, the built-in table is used,
+	// which contains CUPS marker-xxx attributes, superseded by
+	// printer-supply (PWG 5100.13).
+	Deprecated map[string]string
+}
+
+// Name returns the rule name
+func (LintDeprecatedAttributes) Name() string { return "deprecated-attribute" }
+
+// Lint checks the message
+func (rule LintDeprecatedAttributes) Lint(m *Message) LintFindings {
+	var findings LintFindings
+
+	deprecated := rule.Deprecated
+	if deprecated == nil {
+		deprecated = lintDeprecated
+	}
+
+	lintWalk(m, func(path string, attr Attribute) {
+		replacement, found := deprecated[attr.Name]
+		if !found {
+			return
+		}
+
+		msg := "deprecated attribute"
+		if replacement != "" {
+			msg += ", use " + replacement
+		}
+
+		findings = append(findings, LintFinding{
+			Rule:     rule.Name(),
+			Severity: LintWarning,
+			Path:     path,
+			Msg:      msg,
+		})
+	})
+
+	return findings
+}
+
+// LintTagMismatch reports well-known attributes, encoded with
+// unexpected value tags (i.e., "copies" as keyword). The xxx-default
+// attributes are expected to have the same syntax, as xxx.
+//
+// Out-of-band values (i.e., no-value or unknown) are always allowed.
+type LintTagMismatch struct{}
+
+// Name returns the rule name
+func (LintTagMismatch) Name() string { return "tag-mismatch" }
+
+// Lint checks the message
+func (rule LintTagMismatch) Lint(m *Message) LintFindings {
+	var findings LintFindings
+
+	lintWalk(m, func(path string, attr Attribute) {
+		tags := lintAttrs[strings.TrimSuffix(attr.Name, "-default")]
+		if len(tags) == 0 {
+			return
+		}
+
+		for _, val := range attr.Values {
+			if val.T.IsOutOfBand() || lintTagIn(val.T, tags) {
+				continue
+			}
+
+			expected := make([]string, len(tags))
+			for i, tag := range tags {
+				expected[i] = tag.String()
+			}
+
+			findings = append(findings, LintFinding{
+				Rule:     rule.Name(),
+				Severity: LintWarning,
+				Path:     path,
+				Msg: fmt.Sprintf("%s value, expected %s",
+					val.T, strings.Join(expected, " or ")),
+			})
+			return
+		}
+	})
+
+	return findings
+}
+
+// LintGroupSize reports groups with too many attributes or values.
+// Values of collection members are counted too.
+type LintGroupSize struct {
+	MaxAttrs  int // Max attributes per group, 0 for default (500)
+	MaxValues int // Max values per group, 0 for default (10000)
+}
+
+// Name returns the rule name
+func (LintGroupSize) Name() string { return "group-size" }
+
+// Lint checks the message
+func (rule LintGroupSize) Lint(m *Message) LintFindings {
+	var findings LintFindings
+
+	maxAttrs := rule.MaxAttrs
+	if maxAttrs <= 0 {
+		maxAttrs = 500
+	}
+
+	maxValues := rule.MaxValues
+	if maxValues <= 0 {
+		maxValues = 10000
+	}
+
+	for _, grp := range m.attrGroups() {
+		if len(grp.Attrs) > maxAttrs {
+			findings = append(findings, LintFinding{
+				Rule:     rule.Name(),
+				Severity: LintWarning,
+				Path:     grp.Tag.String(),
+				Msg: fmt.Sprintf("%d attributes, exceeds %d",
+					len(grp.Attrs), maxAttrs),
+			})
+		}
+
+		if n := attrsCountValues(grp.Attrs); n > maxValues {
+			findings = append(findings, LintFinding{
+				Rule:     rule.Name(),
+				Severity: LintWarning,
+				Path:     grp.Tag.String(),
+				Msg: fmt.Sprintf("%d values, exceeds %d",
+					n, maxValues),
+			})
+		}
+	}
+
+	return findings
+}
+
+// lintWalk calls callback for each top-level attribute of the message
+func lintWalk(m *Message, callback func(path string, attr Attribute)) {
+	for _, grp := range m.attrGroups() {
+		for _, attr := range grp.Attrs {
+			callback(MakePath(grp.Tag, attr.Name).String(), attr)
+		}
+	}
+}
+
+// attrsCountValues counts values of attributes, including values
+// of collection members
+func attrsCountValues(attrs Attributes) int {
+	n := 0
+	for _, attr := range attrs {
+		n += len(attr.Values)
+		for _, val := range attr.Values {
+			if col, ok := val.V.(Collection); ok {
+				n += attrsCountValues(Attributes(col))
+			}
+		}
+	}
+	return n
+}
+
+// lintTagIn returns true, if tag is in tags
+func lintTagIn(tag Tag, tags []Tag) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// lintBaseName strips well-known suffixes from the attribute name
+func lintBaseName(name string) string {
+	for _, suffix := range []string{"-default", "-supported", "-ready",
+		"-database", "-preferred", "-configured"} {
+		if strings.HasSuffix(name, suffix) {
+			return strings.TrimSuffix(name, suffix)
+		}
+	}
+	return name
+}
+
+// lintDeprecated contains the built-in table of deprecated attributes
+var lintDeprecated = map[string]string{
+	"marker-change-time": "printer-supply",
+	"marker-colors":      "printer-supply",
+	"marker-high-levels": "printer-supply",
+	"marker-levels":      "printer-supply",
+	"marker-low-levels":  "printer-supply",
+	"marker-message":     "printer-supply-description",
+	"marker-names":       "printer-supply-description",
+	"marker-types":       "printer-supply",
+}
+
+// Tags of well-known attributes
+var (
+	lintTagsName     = []Tag{TagName, TagNameLang}
+	lintTagsText     = []Tag{TagText, TagTextLang}
+	lintTagsKeyword  = []Tag{TagKeyword}
+	lintTagsKwName   = []Tag{TagKeyword, TagName, TagNameLang}
+	lintTagsInteger  = []Tag{TagInteger}
+	lintTagsEnum     = []Tag{TagEnum}
+	lintTagsBoolean  = []Tag{TagBoolean}
+	lintTagsURI      = []Tag{TagURI}
+	lintTagsCol      = []Tag{TagBeginCollection}
+	lintTagsDateTime = []Tag{TagDateTime}
+	lintTagsMime     = []Tag{TagMimeType}
+	lintTagsLanguage = []Tag{TagLanguage}
+	lintTagsCharset  = []Tag{TagCharset}
+	lintTagsOctets   = []Tag{TagString}
+	lintTagsRes      = []Tag{TagResolution}
+	lintTagsRange    = []Tag{TagRange}
+	lintTagsAny      = []Tag{}
+)
+
+// lintAttrs contains the built-in table of well-known attributes
+// (RFC 8011, RFC 3995, PWG 5100.x), with their expected tags.
+//
+// Attributes with lintTagsAny are known, but their tags are not
+// checked, as their syntax varies.
+var lintAttrs = map[string][]Tag{
+	// Operation attributes
+	"attributes-charset":          lintTagsCharset,
+	"attributes-natural-language": lintTagsLanguage,
+	"compression":                 lintTagsKeyword,
+	"detailed-status-message":     lintTagsText,
+	"document-access-error":       lintTagsText,
+	"document-format":             lintTagsMime,
+	"document-name":               lintTagsName,
+	"document-number":             lintTagsInteger,
+	"document-password":           lintTagsOctets,
+	"document-uri":                lintTagsURI,
+	"first-index":                 lintTagsInteger,
+	"ipp-attribute-fidelity":      lintTagsBoolean,
+	"job-id":                      lintTagsInteger,
+	"job-ids":                     lintTagsInteger,
+	"job-k-octets":                lintTagsInteger,
+	"job-name":                    lintTagsName,
+	"job-uri":                     lintTagsURI,
+	"last-document":               lintTagsBoolean,
+	"limit":                       lintTagsInteger,
+	"my-jobs":                     lintTagsBoolean,
+	"requested-attributes":        lintTagsKeyword,
+	"requesting-user-name":        lintTagsName,
+	"requesting-user-uri":         lintTagsURI,
+	"status-message":              lintTagsText,
+	"printer-uri":                 lintTagsURI,
+	"which-jobs":                  lintTagsKeyword,
+
+	// Job template attributes
+	"copies":                           lintTagsInteger,
+	"finishings":                       lintTagsEnum,
+	"finishings-col":                   lintTagsCol,
+	"job-hold-until":                   lintTagsKwName,
+	"job-priority":                     lintTagsInteger,
+	"job-sheets":                       lintTagsKwName,
+	"media":                            lintTagsKwName,
+	"media-col":                        lintTagsCol,
+	"multiple-document-handling":       lintTagsKeyword,
+	"number-up":                        lintTagsInteger,
+	"orientation-requested":            lintTagsEnum,
+	"output-bin":                       lintTagsKwName,
+	"page-ranges":                      lintTagsRange,
+	"presentation-direction-number-up": lintTagsKeyword,
+	"print-color-mode":                 lintTagsKeyword,
+	"print-content-optimize":           lintTagsKeyword,
+	"print-quality":                    lintTagsEnum,
+	"print-rendering-intent":           lintTagsKeyword,
+	"print-scaling":                    lintTagsKeyword,
+	"printer-resolution":               lintTagsRes,
+	"sides":                            lintTagsKeyword,
+
+	// Job description and status attributes
+	"date-time-at-completed":    lintTagsDateTime,
+	"date-time-at-creation":     lintTagsDateTime,
+	"date-time-at-processing":   lintTagsDateTime,
+	"job-originating-user-name": lintTagsName,
+	"job-printer-up-time":       lintTagsInteger,
+	"job-printer-uri":           lintTagsURI,
+	"job-state":                 lintTagsEnum,
+	"job-state-message":         lintTagsText,
+	"job-state-reasons":         lintTagsKeyword,
+	"time-at-completed":         lintTagsInteger,
+	"time-at-creation":          lintTagsInteger,
+	"time-at-processing":        lintTagsInteger,
+
+	// Printer description and status attributes
+	"charset":                            lintTagsCharset,
+	"color":                              lintTagsBoolean,
+	"document-format-varying-attributes": lintTagsKeyword,
+	"document-format-version":            lintTagsText,
+	"epcl-version":                       lintTagsAny,
+	"generated-natural-language":         lintTagsLanguage,
+	"identify-actions":                   lintTagsKeyword,
+	"ipp-features":                       lintTagsKeyword,
+	"ipp-versions":                       lintTagsKeyword,
+	"job-constraints":                    lintTagsCol,
+	"job-creation-attributes":            lintTagsKeyword,
+	"job-pages-per-set":                  lintTagsAny,
+	"job-resolvers":                      lintTagsCol,
+	"jpeg-k-octets":                      lintTagsAny,
+	"jpeg-x-dimension":                   lintTagsAny,
+	"jpeg-y-dimension":                   lintTagsAny,
+	"landscape-orientation-requested":    lintTagsEnum,
+	"limit-operations":                   lintTagsEnum,
+	"manual-duplex":                      lintTagsBoolean,
+	"marker-change-time":                 lintTagsInteger,
+	"marker-colors":                      lintTagsName,
+	"marker-high-levels":                 lintTagsInteger,
+	"marker-levels":                      lintTagsInteger,
+	"marker-low-levels":                  lintTagsInteger,
+	"marker-message":                     lintTagsText,
+	"marker-names":                       lintTagsName,
+	"marker-types":                       lintTagsKeyword,
+	"media-bottom-margin":                lintTagsInteger,
+	"media-left-margin":                  lintTagsInteger,
+	"media-right-margin":                 lintTagsInteger,
+	"media-size":                         lintTagsCol,
+	"media-source":                       lintTagsKwName,
+	"media-top-margin":                   lintTagsInteger,
+	"media-type":                         lintTagsKwName,
+	"mopria-certified":                   lintTagsText,
+	"multiple-document-jobs":             lintTagsBoolean,
+	"multiple-operation-time-out":        lintTagsInteger,
+	"multiple-operation-time-out-action": lintTagsKeyword,
+	"natural-language":                   lintTagsLanguage,
+	"operations":                         lintTagsEnum,
+	"output-mode":                        lintTagsKeyword,
+	"overrides":                          lintTagsKeyword,
+	"pages-per-minute":                   lintTagsInteger,
+	"pages-per-minute-color":             lintTagsInteger,
+	"pclm-compression-method":            lintTagsKeyword,
+	"pclm-raster-back-side":              lintTagsKeyword,
+	"pclm-source-resolution":             lintTagsRes,
+	"pclm-strip-height":                  lintTagsInteger,
+	"pdf-fit-to-page":                    lintTagsBoolean,
+	"pdf-k-octets":                       lintTagsAny,
+	"pdf-size-constraints":               lintTagsAny,
+	"pdf-versions":                       lintTagsKeyword,
+	"pdl-override":                       lintTagsKeyword,
+	"preferred-attributes":               lintTagsAny,
+	"printer-alert":                      lintTagsOctets,
+	"printer-alert-description":          lintTagsText,
+	"printer-config-change-date-time":    lintTagsDateTime,
+	"printer-config-change-time":         lintTagsInteger,
+	"printer-current-time":               lintTagsDateTime,
+	"printer-device-id":                  lintTagsText,
+	"printer-dns-sd-name":                lintTagsName,
+	"printer-finisher":                   lintTagsOctets,
+	"printer-finisher-description":       lintTagsText,
+	"printer-firmware-name":              lintTagsName,
+	"printer-firmware-string-version":    lintTagsText,
+	"printer-firmware-version":           lintTagsOctets,
+	"printer-geo-location":               lintTagsURI,
+	"printer-get-attributes":             lintTagsKeyword,
+	"printer-icons":                      lintTagsURI,
+	"printer-info":                       lintTagsText,
+	"printer-input-tray":                 lintTagsOctets,
+	"printer-is-accepting-jobs":          lintTagsBoolean,
+	"printer-kind":                       lintTagsKeyword,
+	"printer-location":                   lintTagsText,
+	"printer-make-and-model":             lintTagsText,
+	"printer-more-info":                  lintTagsURI,
+	"printer-name":                       lintTagsName,
+	"printer-organization":               lintTagsText,
+	"printer-organizational-unit":        lintTagsText,
+	"printer-output-tray":                lintTagsOctets,
+	"printer-settable-attributes":        lintTagsKeyword,
+	"printer-state":                      lintTagsEnum,
+	"printer-state-change-date-time":     lintTagsDateTime,
+	"printer-state-change-time":          lintTagsInteger,
+	"printer-state-message":              lintTagsText,
+	"printer-state-reasons":              lintTagsKeyword,
+	"printer-strings-languages":          lintTagsLanguage,
+	"printer-strings-uri":                lintTagsURI,
+	"printer-supply":                     lintTagsOctets,
+	"printer-supply-description":         lintTagsText,
+	"printer-supply-info-uri":            lintTagsURI,
+	"printer-up-time":                    lintTagsInteger,
+	"printer-uuid":                       lintTagsURI,
+	"printer-wifi-ssid":                  lintTagsName,
+	"printer-wifi-state":                 lintTagsEnum,
+	"pwg-raster-document-resolution":     lintTagsRes,
+	"pwg-raster-document-sheet-back":     lintTagsKeyword,
+	"pwg-raster-document-type":           lintTagsKeyword,
+	"queued-job-count":                   lintTagsInteger,
+	"reference-uri-schemes":              lintTagsAny,
+	"urf":                                lintTagsKeyword,
+	"uri-authentication":                 lintTagsKeyword,
+	"uri-security":                       lintTagsKeyword,
+}
+
+// LintRequiredAttributes reports missed required attributes of
+// the particular group.
+//
+// The rule is applicable only to messages that contain the group,
+// so it doesn't complain about printer attributes, missed in
+// requests.
+type LintRequiredAttributes struct {
+	Group    Tag          // Group of attributes
+	Names    []string     // Names of required attributes
+	Severity LintSeverity // Severity of findings
+}
+
+// Name returns the rule name
+func (LintRequiredAttributes) Name() string { return "required-attribute" }
+
+// Lint checks the message
+func (rule LintRequiredAttributes) Lint(m *Message) LintFindings {
+	var findings LintFindings
+	var attrs Attributes
+	found := false
+
+	for _, grp := range m.attrGroups() {
+		if grp.Tag == rule.Group {
+			attrs = append(attrs, grp.Attrs...)
+			found = true
+		}
+	}
+
+	if !found {
+		return nil
+	}
+
+	for _, name := range attrs.Missing(rule.Names) {
+		findings = append(findings, LintFinding{
+			Rule:     rule.Name(),
+			Severity: rule.Severity,
+			Path:     MakePath(rule.Group, name).String(),
+			Msg:      "required attribute missed",
+		})
+	}
+
+	return findings
+}
+
+// LintRequiredOperationAttributes reports operation attributes,
+// missed in requests, as defined by RequiredOperationAttributes.
+//
+// The rule is applicable only to messages with MessageRoleRequest.
+type LintRequiredOperationAttributes struct{}
+
+// Name returns the rule name
+func (LintRequiredOperationAttributes) Name() string {
+	return "required-operation-attribute"
+}
+
+// Lint checks the message
+func (rule LintRequiredOperationAttributes) Lint(m *Message) LintFindings {
+	if m.Role != MessageRoleRequest {
+		return nil
+	}
+
+	op := Op(m.Code)
+
+	var ops Attributes
+	for _, grp := range m.attrGroups() {
+		if grp.Tag == TagOperationGroup {
+			ops = append(ops, grp.Attrs...)
+		}
+	}
+
+	var missed []string
+	for _, name := range ops.Missing(RequiredOperationAttributes(op)) {
+		// job-uri is the alternative to printer-uri+job-id
+		if (name == "printer-uri" || name == "job-id") &&
+			opInfoRegistry[op].target == opTargetJob {
+			if _, found := attrsFind(ops, "job-uri"); found {
+				continue
+			}
+		}
+
+		missed = append(missed, name)
+	}
+
+	var findings LintFindings
+	for _, name := range missed {
+		findings = append(findings, LintFinding{
+			Rule:     rule.Name(),
+			Severity: LintError,
+			Path:     MakePath(TagOperationGroup, name).String(),
+			Msg:      "required attribute missed",
+		})
+	}
+
+	return findings
+}
+
+// LintProfile is the named preset of lint rules, tuned for the
+// particular ecosystem.
+//
+// Profiles check both capabilities (Get-Printer-Attributes
+// responses) and requests, as rules apply only to the relevant
+// messages.
+type LintProfile struct {
+	Name  string     // Profile name
+	Rules []LintRule // Profile rules
+}
+
+// Linter returns Linter, configured for the profile
+func (p LintProfile) Linter() *Linter {
+	return &Linter{Rules: p.Rules}
+}
+
+// LintProfileCUPS returns the profile for CUPS and CUPS-based
+// servers.
+//
+// It requires only RFC 8011 printer attributes, knows CUPS
+// extension attributes and doesn't complain about deprecated
+// marker-xxx attributes, which CUPS still uses.
+func LintProfileCUPS() LintProfile {
+	return LintProfile{
+		Name: "cups",
+		Rules: []LintRule{
+			LintUnknownAttributes{Known: lintCUPSAttrs},
+			LintDeprecatedAttributes{Deprecated: map[string]string{}},
+			LintTagMismatch{},
+			LintGroupSize{},
+			LintRequiredOperationAttributes{},
+			LintRequiredAttributes{
+				Group:    TagPrinterGroup,
+				Names:    lintRequiredRFC8011,
+				Severity: LintError,
+			},
+		},
+	}
+}
+
+// LintProfileIPPEverywhere returns the profile for IPP Everywhere
+// printers (PWG 5100.14).
+func LintProfileIPPEverywhere() LintProfile {
+	return LintProfile{
+		Name: "ipp-everywhere",
+		Rules: append(DefaultLintRules(),
+			LintRequiredOperationAttributes{},
+			LintRequiredAttributes{
+				Group:    TagPrinterGroup,
+				Names:    lintRequiredRFC8011,
+				Severity: LintError,
+			},
+			LintRequiredAttributes{
+				Group:    TagPrinterGroup,
+				Names:    lintRequiredIPPEverywhere,
+				Severity: LintError,
+			},
+		),
+	}
+}
+
+// LintProfileAirPrint returns the profile for AirPrint printers.
+//
+// In addition to RFC 8011 attributes, AirPrint clients rely on
+// some other attributes, like urf-supported and printer-icons.
+// Their absence is reported as warning, as this profile is based
+// on the observed client behavior rather than on the public
+// specification.
+func LintProfileAirPrint() LintProfile {
+	return LintProfile{
+		Name: "airprint",
+		Rules: append(DefaultLintRules(),
+			LintRequiredOperationAttributes{},
+			LintRequiredAttributes{
+				Group:    TagPrinterGroup,
+				Names:    lintRequiredRFC8011,
+				Severity: LintError,
+			},
+			LintRequiredAttributes{
+				Group:    TagPrinterGroup,
+				Names:    lintRequiredAirPrint,
+				Severity: LintWarning,
+			},
+		),
+	}
+}
+
+// lintRequiredRFC8011 contains printer attributes, required
+// by RFC 8011, 5.4
+var lintRequiredRFC8011 = []string{
+	"charset-configured",
+	"charset-supported",
+	"compression-supported",
+	"document-format-default",
+	"document-format-supported",
+	"generated-natural-language-supported",
+	"ipp-versions-supported",
+	"natural-language-configured",
+	"operations-supported",
+	"pdl-override-supported",
+	"printer-is-accepting-jobs",
+	"printer-name",
+	"printer-state",
+	"printer-state-reasons",
+	"printer-up-time",
+	"printer-uri-supported",
+	"queued-job-count",
+	"uri-authentication-supported",
+	"uri-security-supported",
+}
+
+// lintRequiredIPPEverywhere contains printer attributes, required
+// by PWG 5100.14, in addition to lintRequiredRFC8011
+var lintRequiredIPPEverywhere = []string{
+	"color-supported",
+	"copies-default",
+	"copies-supported",
+	"finishings-default",
+	"finishings-supported",
+	"ipp-features-supported",
+	"media-col-default",
+	"media-col-ready",
+	"media-col-supported",
+	"media-default",
+	"media-ready",
+	"media-supported",
+	"media-size-supported",
+	"media-source-supported",
+	"media-type-supported",
+	"orientation-requested-default",
+	"orientation-requested-supported",
+	"output-bin-default",
+	"output-bin-supported",
+	"print-color-mode-default",
+	"print-color-mode-supported",
+	"print-quality-default",
+	"print-quality-supported",
+	"printer-device-id",
+	"printer-geo-location",
+	"printer-info",
+	"printer-location",
+	"printer-make-and-model",
+	"printer-more-info",
+	"printer-resolution-default",
+	"printer-resolution-supported",
+	"printer-uuid",
+	"pwg-raster-document-resolution-supported",
+	"pwg-raster-document-sheet-back",
+	"pwg-raster-document-type-supported",
+	"sides-default",
+	"sides-supported",
+}
+
+// lintRequiredAirPrint contains printer attributes, used by
+// AirPrint clients, in addition to lintRequiredRFC8011
+var lintRequiredAirPrint = []string{
+	"color-supported",
+	"media-col-default",
+	"media-col-ready",
+	"media-default",
+	"media-ready",
+	"media-supported",
+	"printer-icons",
+	"printer-make-and-model",
+	"printer-more-info",
+	"printer-uuid",
+	"sides-supported",
+	"urf-supported",
+}
+
+// lintCUPSAttrs contains names of CUPS extension attributes
+var lintCUPSAttrs = []string{
+	"auth-info",
+	"auth-info-required",
+	"device-uri",
+	"job-cancel-after",
+	"job-printer-state-message",
+	"job-printer-state-reasons",
+	"member-names",
+	"member-uris",
+	"notify-events",
+	"ppd-name",
+	"printer-commands",
+	"printer-is-shared",
+	"printer-is-temporary",
+	"printer-type",
+	"printer-type-mask",
+	"requesting-user-name-allowed",
+	"requesting-user-name-denied",
+}
@@ -0,0 +1,248 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Message linter test
+ */
+
+package goipp
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestLintRules tests individual lint rules
+func TestLintRules(t *testing.T) {
+	m := NewResponse(DefaultVersion, StatusOk, 1)
+	m.Printer.Add(MakeAttr("printer-name", TagName, String("printer")))
+	m.Printer.Add(MakeAttr("copies-default", TagKeyword, String("1")))
+	m.Printer.Add(MakeAttr("copies-supported", TagRange,
+		Range{1, 99}))
+	m.Printer.Add(MakeAttr("printer-geo-location", TagUnknown, Void{}))
+	m.Printer.Add(MakeAttr("printer-bogus-attribute", TagInteger,
+		Integer(1)))
+	m.Printer.Add(MakeAttr("smi11-vendor-attribute", TagInteger,
+		Integer(1)))
+	m.Printer.Add(MakeAttr("marker-levels", TagInteger, Integer(50)))
+
+	type testData struct {
+		rule     LintRule // The rule
+		findings []string // Expected findings
+	}
+
+	tests := []testData{
+		{
+			rule: LintUnknownAttributes{},
+			findings: []string{
+				"info: printer-attributes-tag/printer-bogus-attribute: " +
+					"unknown attribute (unknown-attribute)",
+			},
+		},
+		{
+			rule: LintUnknownAttributes{
+				Known: []string{"printer-bogus-attribute"},
+			},
+		},
+		{
+			rule: LintDeprecatedAttributes{},
+			findings: []string{
+				"warning: printer-attributes-tag/marker-levels: " +
+					"deprecated attribute, use printer-supply " +
+					"(deprecated-attribute)",
+			},
+		},
+		{
+			rule: LintDeprecatedAttributes{
+				Deprecated: map[string]string{"printer-name": ""},
+			},
+			findings: []string{
+				"warning: printer-attributes-tag/printer-name: " +
+					"deprecated attribute (deprecated-attribute)",
+			},
+		},
+		{
+			rule: LintTagMismatch{},
+			findings: []string{
+				"warning: printer-attributes-tag/copies-default: " +
+					"keyword value, expected integer (tag-mismatch)",
+			},
+		},
+		{
+			rule: LintGroupSize{},
+		},
+		{
+			rule: LintGroupSize{MaxAttrs: 5, MaxValues: 6},
+			findings: []string{
+				"warning: printer-attributes-tag: " +
+					"7 attributes, exceeds 5 (group-size)",
+				"warning: printer-attributes-tag: " +
+					"7 values, exceeds 6 (group-size)",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		findings := NewLinter(test.rule).Lint(m)
+		expected := strings.Join(test.findings, "\n")
+		if expected != "" {
+			expected += "\n"
+		}
+
+		if s := findings.String(); s != expected {
+			t.Errorf("%s:\nfindings:\n%s\nexpected:\n%s",
+				test.rule.Name(), s, expected)
+		}
+	}
+}
+
+// TestLintDefault tests Linter with default rules
+func TestLintDefault(t *testing.T) {
+	var m Message
+	err := m.DecodeBytes(attrsHPOfficeJetPro8730)
+	assertNoError(t, err)
+
+	findings := NewLinter().Lint(&m)
+	if findings.Worst() != LintWarning {
+		t.Errorf("Worst severity: %s, expected %s",
+			findings.Worst(), LintWarning)
+	}
+
+	// This printer uses octetString for printer-finisher-description
+	found := false
+	for _, f := range findings {
+		if f.Rule == "unknown-attribute" {
+			t.Errorf("Unexpected finding: %s", f)
+		}
+
+		if f.Path == "printer-attributes-tag/printer-finisher-description" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Errorf("Tag mismatch not detected:\n%s", findings)
+	}
+
+	if w := (LintFindings{}).Worst(); w != -1 {
+		t.Errorf("Worst severity of empty findings: %s", w)
+	}
+}
+
+// TestLintProfiles tests lint profiles
+func TestLintProfiles(t *testing.T) {
+	var caps Message
+	err := caps.DecodeBytes(attrsHPOfficeJetPro8730)
+	assertNoError(t, err)
+
+	var partial Message
+	err = partial.DecodeBytesEx(attrsHPOfficeJetPro8730, DecoderOptions{
+		AttributeFilter: func(group Tag, name string) bool {
+			return name != "printer-uuid" && name != "urf-supported"
+		},
+	})
+	assertNoError(t, err)
+
+	// Requests are checked for required operation attributes
+	rq := NewRequest(DefaultVersion, OpGetJobAttributes, 1)
+	rq.Operation.Add(MakeAttr("attributes-charset", TagCharset,
+		String("utf-8")))
+	rq.Operation.Add(MakeAttr("job-id", TagInteger, Integer(1)))
+
+	rqJobURI := NewRequest(DefaultVersion, OpGetJobAttributes, 1)
+	rqJobURI.Operation.Add(MakeAttr("attributes-charset", TagCharset,
+		String("utf-8")))
+	rqJobURI.Operation.Add(MakeAttr("attributes-natural-language",
+		TagLanguage, String("en-US")))
+	rqJobURI.Operation.Add(MakeAttr("job-uri", TagURI,
+		String("ipp://localhost/jobs/1")))
+
+	type testData struct {
+		profile LintProfile // The profile
+		m       *Message    // Message to check
+		rules   []string    // Rules of expected findings
+		paths   []string    // Paths of expected findings
+	}
+
+	tests := []testData{
+		{
+			profile: LintProfileCUPS(),
+			m:       &caps,
+			rules:   []string{"tag-mismatch"},
+			paths: []string{
+				"printer-attributes-tag/printer-finisher-description",
+			},
+		},
+		{
+			profile: LintProfileIPPEverywhere(),
+			m:       &caps,
+			rules:   []string{"deprecated-attribute", "tag-mismatch"},
+		},
+		{
+			profile: LintProfileIPPEverywhere(),
+			m:       &partial,
+			rules: []string{
+				"deprecated-attribute",
+				"tag-mismatch",
+				"required-attribute",
+			},
+			paths: []string{
+				"printer-attributes-tag/printer-uuid",
+			},
+		},
+		{
+			profile: LintProfileAirPrint(),
+			m:       &partial,
+			rules: []string{
+				"deprecated-attribute",
+				"tag-mismatch",
+				"required-attribute",
+			},
+			paths: []string{
+				"printer-attributes-tag/printer-uuid",
+				"printer-attributes-tag/urf-supported",
+			},
+		},
+		{
+			profile: LintProfileIPPEverywhere(),
+			m:       rq,
+			rules:   []string{"required-operation-attribute"},
+			paths: []string{
+				"operation-attributes-tag/attributes-natural-language",
+				"operation-attributes-tag/printer-uri",
+			},
+		},
+		{
+			profile: LintProfileCUPS(),
+			m:       rqJobURI,
+		},
+	}
+
+	for _, test := range tests {
+		findings := test.profile.Linter().Lint(test.m)
+
+		rules := make(map[string]bool)
+		paths := make(map[string]bool)
+		for _, f := range findings {
+			rules[f.Rule] = true
+			paths[f.Path] = true
+		}
+
+		if len(rules) != len(test.rules) {
+			t.Errorf("%s: findings:\n%s", test.profile.Name, findings)
+		}
+
+		for _, rule := range test.rules {
+			if !rules[rule] {
+				t.Errorf("%s: %s not reported", test.profile.Name, rule)
+			}
+		}
+
+		for _, path := range test.paths {
+			if !paths[path] {
+				t.Errorf("%s: %s not reported", test.profile.Name, path)
+			}
+		}
+	}
+}
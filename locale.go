@@ -0,0 +1,119 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Natural language of the process locale
+ */
+
+package goipp
+
+import (
+	"os"
+	"strings"
+)
+
+// DefaultLanguage is the natural language, used when no better
+// choice is available
+const DefaultLanguage = "en-US"
+
+// NewRequestWithDefaults creates a new request message, like
+// NewRequest, and adds attributes-charset (as "utf-8") and
+// attributes-natural-language (as returned by LocaleLanguage)
+// operation attributes.
+func NewRequestWithDefaults(v Version, op Op, id uint32) *Message {
+	m := NewRequest(v, op, id)
+	m.Operation.Add(MakeAttribute("attributes-charset", TagCharset,
+		String("utf-8")))
+	m.Operation.Add(MakeAttribute("attributes-natural-language",
+		TagLanguage, String(LocaleLanguage())))
+
+	return m
+}
+
+// LocaleLanguage returns natural language of the process locale,
+// suitable for the attributes-natural-language attribute.
+//
+// The locale is taken from the first non-empty LC_ALL, LC_MESSAGES
+// or LANG environment variable. If locale is not set, is "C" or
+// "POSIX", or cannot be parsed, DefaultLanguage is returned.
+func LocaleLanguage() string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if locale := os.Getenv(env); locale != "" {
+			if lang, ok := ParseLocale(locale); ok {
+				return lang
+			}
+			break
+		}
+	}
+
+	return DefaultLanguage
+}
+
+// ParseLocale converts POSIX locale name (language[_territory]
+// [.codeset][@modifier], i.e., "de_DE.UTF-8") into the BCP 47
+// language tag (i.e., "de-DE"). Codeset and modifier are ignored.
+//
+// It returns false for "C" and "POSIX" locales and for names it
+// cannot parse.
+func ParseLocale(locale string) (string, bool) {
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+
+	if locale == "C" || locale == "POSIX" {
+		return "", false
+	}
+
+	lang, region := locale, ""
+	if i := strings.IndexAny(locale, "_-"); i >= 0 {
+		lang, region = locale[:i], locale[i+1:]
+	}
+
+	if !localeAlpha(lang, 2, 3) {
+		return "", false
+	}
+
+	lang = strings.ToLower(lang)
+
+	switch {
+	case region == "":
+		return lang, true
+	case localeAlpha(region, 2, 2):
+		return lang + "-" + strings.ToUpper(region), true
+	case localeDigits(region, 3):
+		return lang + "-" + region, true
+	}
+
+	return "", false
+}
+
+// localeAlpha reports whether s consists of min...max ASCII letters
+func localeAlpha(s string, min, max int) bool {
+	if len(s) < min || len(s) > max {
+		return false
+	}
+
+	for _, c := range []byte(s) {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// localeDigits reports whether s consists of n ASCII digits
+func localeDigits(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+
+	for _, c := range []byte(s) {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,93 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Natural language of the process locale test
+ */
+
+package goipp
+
+import (
+	"os"
+	"testing"
+)
+
+// TestParseLocale tests ParseLocale
+func TestParseLocale(t *testing.T) {
+	tests := []struct {
+		locale string
+		lang   string
+		ok     bool
+	}{
+		{"de_DE.UTF-8", "de-DE", true},
+		{"de_DE.UTF-8@euro", "de-DE", true},
+		{"pt_br", "pt-BR", true},
+		{"EN-us", "en-US", true},
+		{"ru", "ru", true},
+		{"ast_ES", "ast-ES", true},
+		{"es_419.UTF-8", "es-419", true},
+		{"sr_RS@latin", "sr-RS", true},
+		{"C", "", false},
+		{"C.UTF-8", "", false},
+		{"POSIX", "", false},
+		{"", "", false},
+		{"english", "", false},
+		{"en_USA", "", false},
+		{"e1_US", "", false},
+	}
+
+	for _, test := range tests {
+		lang, ok := ParseLocale(test.locale)
+		if lang != test.lang || ok != test.ok {
+			t.Errorf("%q: expected (%q, %v), present (%q, %v)",
+				test.locale, test.lang, test.ok, lang, ok)
+		}
+	}
+}
+
+// TestLocaleLanguage tests LocaleLanguage and NewRequestWithDefaults
+func TestLocaleLanguage(t *testing.T) {
+	vars := []string{"LC_ALL", "LC_MESSAGES", "LANG"}
+	saved := make(map[string]string)
+	for _, env := range vars {
+		saved[env] = os.Getenv(env)
+	}
+
+	defer func() {
+		for _, env := range vars {
+			os.Setenv(env, saved[env])
+		}
+	}()
+
+	tests := []struct {
+		all, messages, lang string
+		expected            string
+	}{
+		{"", "", "", DefaultLanguage},
+		{"", "", "fr_FR.UTF-8", "fr-FR"},
+		{"", "de_AT", "fr_FR.UTF-8", "de-AT"},
+		{"ja_JP", "de_AT", "fr_FR.UTF-8", "ja-JP"},
+		{"C", "de_AT", "fr_FR.UTF-8", DefaultLanguage},
+		{"", "", "invalid locale", DefaultLanguage},
+	}
+
+	for _, test := range tests {
+		os.Setenv("LC_ALL", test.all)
+		os.Setenv("LC_MESSAGES", test.messages)
+		os.Setenv("LANG", test.lang)
+
+		if lang := LocaleLanguage(); lang != test.expected {
+			t.Errorf("%q/%q/%q: expected %q, present %q",
+				test.all, test.messages, test.lang,
+				test.expected, lang)
+		}
+
+		m := NewRequestWithDefaults(DefaultVersion, OpGetJobs, 1)
+		lang := m.operationText("attributes-natural-language")
+		if lang != test.expected {
+			t.Errorf("NewRequestWithDefaults: expected %q, present %q",
+				test.expected, lang)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Quick construction of collections
+ */
+
+package goipp
+
+import (
+	"sort"
+	"strings"
+)
+
+// MemberValue is the name/value pair of the collection member,
+// used by MakeCollectionOrdered
+type MemberValue struct {
+	Name string // Member name
+	V    Value  // Member value
+}
+
+// CollectionTags overrides tags of collection members, inferred by
+// MakeCollection and MakeCollectionOrdered, by member name:
+//
+//	col := goipp.CollectionTags{"media-key": goipp.TagName}.
+//		MakeCollection(map[string]goipp.Value{
+//			"media-key":  goipp.String("custom"),
+//			"media-type": goipp.String("stationery"),
+//		})
+type CollectionTags map[string]Tag
+
+// MakeCollection makes Collection from the map of member names to
+// values. As map is not ordered, members are sorted by name.
+//
+// It is intended for quick construction of collections in tests
+// and scripts. Tags of members are inferred as follows:
+//   - Name, Keyword, MimeType and URI values use their own tags
+//   - well-known members use the first registered tag, that matches
+//     the value type (see AttrSyntax)
+//   - otherwise, tag depends on the value type only: String values
+//     become keywords, Integer values become integers and so on
+//
+// Use CollectionTags to override inferred tags.
+func MakeCollection(members map[string]Value) Collection {
+	return CollectionTags(nil).MakeCollection(members)
+}
+
+// MakeCollectionOrdered makes Collection from name/value pairs,
+// preserving their order. Tags are inferred like MakeCollection does.
+func MakeCollectionOrdered(pairs ...MemberValue) Collection {
+	return CollectionTags(nil).MakeCollectionOrdered(pairs...)
+}
+
+// MakeCollection is like the MakeCollection function, with tags
+// of members, listed in tags, overridden
+func (tags CollectionTags) MakeCollection(
+	members map[string]Value) Collection {
+
+	pairs := make([]MemberValue, 0, len(members))
+	for name, v := range members {
+		pairs = append(pairs, MemberValue{name, v})
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Name < pairs[j].Name
+	})
+
+	return tags.MakeCollectionOrdered(pairs...)
+}
+
+// MakeCollectionOrdered is like the MakeCollectionOrdered function,
+// with tags of members, listed in tags, overridden
+func (tags CollectionTags) MakeCollectionOrdered(
+	pairs ...MemberValue) Collection {
+
+	col := make(Collection, len(pairs))
+	for i, pair := range pairs {
+		tag, found := tags[pair.Name]
+		if !found {
+			tag = makeColTag(pair.Name, pair.V)
+		}
+
+		col[i] = MakeAttribute(pair.Name, tag, pair.V)
+	}
+
+	return col
+}
+
+// makeColTag infers tag for the value of the member
+func makeColTag(name string, v Value) Tag {
+	switch v.(type) {
+	case Name:
+		return TagName
+	case Keyword:
+		return TagKeyword
+	case MimeType:
+		return TagMimeType
+	case URI:
+		return TagURI
+	}
+
+	for _, tag := range lintAttrs[strings.TrimSuffix(name, "-default")] {
+		if tag.Type() == v.Type() {
+			return tag
+		}
+	}
+
+	switch v.Type() {
+	case TypeVoid:
+		return TagNoValue
+	case TypeInteger:
+		return TagInteger
+	case TypeBoolean:
+		return TagBoolean
+	case TypeString:
+		return TagKeyword
+	case TypeDateTime:
+		return TagDateTime
+	case TypeResolution:
+		return TagResolution
+	case TypeRange:
+		return TagRange
+	case TypeTextWithLang:
+		return TagTextLang
+	case TypeBinary:
+		return TagString
+	case TypeCollection:
+		return TagBeginCollection
+	}
+
+	return TagZero
+}
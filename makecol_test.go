@@ -0,0 +1,67 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Quick construction of collections test
+ */
+
+package goipp
+
+import (
+	"testing"
+)
+
+// TestMakeCollection tests MakeCollection and MakeCollectionOrdered
+func TestMakeCollection(t *testing.T) {
+	size := MakeCollection(map[string]Value{
+		"y-dimension": Integer(29700),
+		"x-dimension": Integer(21000),
+	})
+
+	col := MakeCollection(map[string]Value{
+		"media-size":      size,
+		"media-type":      String("stationery"),
+		"media-key":       String("iso_a4_210x297mm"),
+		"finishings":      Integer(3),
+		"document-format": String("application/pdf"),
+		"job-name":        Name("test"),
+		"vendor-flag":     Boolean(true),
+		"vendor-blob":     Binary{1, 2, 3},
+	})
+
+	expected := Collection{
+		MakeAttr("document-format", TagMimeType,
+			String("application/pdf")),
+		MakeAttr("finishings", TagEnum, Integer(3)),
+		MakeAttr("job-name", TagName, Name("test")),
+		MakeAttr("media-key", TagKeyword, String("iso_a4_210x297mm")),
+		MakeAttrCollection("media-size",
+			MakeAttr("x-dimension", TagInteger, Integer(21000)),
+			MakeAttr("y-dimension", TagInteger, Integer(29700))),
+		MakeAttr("media-type", TagKeyword, String("stationery")),
+		MakeAttr("vendor-blob", TagString, Binary{1, 2, 3}),
+		MakeAttr("vendor-flag", TagBoolean, Boolean(true)),
+	}
+
+	if !Attributes(col).Equal(Attributes(expected)) {
+		t.Errorf("MakeCollection:\nexpected: %s\npresent:  %s",
+			expected, col)
+	}
+
+	// Ordered construction with overridden tags
+	col = CollectionTags{"media-key": TagName}.MakeCollectionOrdered(
+		MemberValue{"media-type", String("stationery")},
+		MemberValue{"media-key", String("custom")},
+	)
+
+	expected = Collection{
+		MakeAttr("media-type", TagKeyword, String("stationery")),
+		MakeAttr("media-key", TagName, String("custom")),
+	}
+
+	if !Attributes(col).Equal(Attributes(expected)) {
+		t.Errorf("MakeCollectionOrdered:\nexpected: %s\npresent:  %s",
+			expected, col)
+	}
+}
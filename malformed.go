@@ -0,0 +1,177 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Generator of malformed messages for conformance testing
+ */
+
+package goipp
+
+import (
+	"errors"
+)
+
+// Malformed represents a malformed variant of the valid message,
+// generated by MalformedMessages
+type Malformed struct {
+	// Name is the short name of malformation, i.e.,
+	// "missing-end-tag"
+	Name string
+
+	// Syntax is true, if message violates the wire format, defined
+	// by RFC 8010, so any decoder must reject it. Otherwise, message
+	// is syntactically correct but violates semantic rules of
+	// RFC 8011 (i.e., group order), which decoders are not required
+	// to check.
+	Syntax bool
+
+	// Data contains the encoded message
+	Data []byte
+}
+
+// MalformedMessages systematically generates malformed variants
+// of the seed message, to test decoders, including decoders of
+// third-party IPP implementations.
+//
+// Seed must be a valid message with at least one group and one
+// attribute. Some malformations are only possible with particular
+// seed content (i.e., integer attributes or multiple groups); if
+// seed lacks that content, these malformations are skipped.
+func MalformedMessages(seed *Message) ([]Malformed, error) {
+	data, err := seed.EncodeBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := wireEntries(data)
+	if len(entries) == 0 {
+		return nil, errors.New("Seed message contains no attributes")
+	}
+
+	var out []Malformed
+	add := func(name string, syntax bool, data []byte) {
+		out = append(out, Malformed{name, syntax, data})
+	}
+
+	first := entries[0]
+
+	// Syntax violations
+	add("truncated-header", true, wireCopy(data[:5]))
+	add("missing-end-tag", true, wireCopy(data[:len(data)-1]))
+	add("zero-tag", true, wirePatch(data, first.off, 0x00))
+
+	// Length overflow is guaranteed only for messages shorter
+	// than the max length
+	if len(data)-first.off < 0xffff {
+		add("name-length-overflow", true,
+			wirePatch(data, first.off+1, 0xff, 0xff))
+		add("value-length-overflow", true,
+			wirePatch(data, first.valueOff, 0xff, 0xff))
+	}
+
+	// Cut all group tags before the first attribute
+	add("attribute-without-group", true,
+		wireCut(data, 8, first.off-8, nil))
+	add("additional-value-first", true,
+		wireCut(data, first.off+1, 2+first.nameLen, []byte{0, 0}))
+	add("member-name-outside-collection", true,
+		wirePatch(data, first.off, byte(TagMemberName)))
+	add("end-collection-outside-collection", true,
+		wirePatch(data, first.off, byte(TagEndCollection)))
+
+	for _, e := range entries {
+		if e.tag == TagInteger || e.tag == TagEnum {
+			// Cut one byte of the integer value
+			patched := wirePatch(data, e.valueOff, 0, 3)
+			add("integer-bad-length", true,
+				wireCut(patched, e.valueOff+2, 1, nil))
+			break
+		}
+	}
+
+	// Semantic violations
+	groups := seed.attrGroups()
+
+	m := *seed
+
+	if groups[0].Tag == TagOperationGroup {
+		m.Groups = append(Groups{}, groups[1:]...)
+		if data, err := m.EncodeBytes(); err == nil {
+			add("missing-operation-group", false, data)
+		}
+
+		if len(groups) > 1 {
+			m.Groups = append(m.Groups, groups[0])
+			if data, err := m.EncodeBytes(); err == nil {
+				add("illegal-group-order", false, data)
+			}
+		}
+	}
+
+	if len(groups[0].Attrs) != 0 {
+		m.Groups = append(Groups{}, groups...)
+		m.Groups[0].Attrs = append(Attributes{groups[0].Attrs[0]},
+			groups[0].Attrs...)
+		if data, err := m.EncodeBytes(); err == nil {
+			add("duplicate-attribute", false, data)
+		}
+	}
+
+	return out, nil
+}
+
+// wireEntry describes position of the single tag-name-value
+// entry within the encoded message
+type wireEntry struct {
+	off      int // Offset of the value tag
+	tag      Tag // The value tag
+	nameLen  int // Length of name
+	valueOff int // Offset of the value length
+}
+
+// wireEntries returns all tag-name-value entries of the valid
+// encoded message, including collection members, in order
+func wireEntries(data []byte) []wireEntry {
+	var entries []wireEntry
+
+	off := 8 // Skip message header
+	for off < len(data) {
+		tag := Tag(data[off])
+		if tag.IsDelimiter() {
+			off++
+			continue
+		}
+
+		e := wireEntry{off: off, tag: tag}
+		e.nameLen = int(data[off+1])<<8 | int(data[off+2])
+		e.valueOff = off + 3 + e.nameLen
+		valueLen := int(data[e.valueOff])<<8 | int(data[e.valueOff+1])
+
+		entries = append(entries, e)
+		off = e.valueOff + 2 + valueLen
+	}
+
+	return entries
+}
+
+// wireCopy returns copy of data
+func wireCopy(data []byte) []byte {
+	return append([]byte(nil), data...)
+}
+
+// wirePatch returns copy of data with bytes at off replaced by patch
+func wirePatch(data []byte, off int, patch ...byte) []byte {
+	data = wireCopy(data)
+	copy(data[off:], patch)
+	return data
+}
+
+// wireCut returns copy of data with n bytes at off replaced
+// by insert
+func wireCut(data []byte, off, n int, insert []byte) []byte {
+	out := make([]byte, 0, len(data)-n+len(insert))
+	out = append(out, data[:off]...)
+	out = append(out, insert...)
+	return append(out, data[off+n:]...)
+}
@@ -0,0 +1,71 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Generator of malformed messages test
+ */
+
+package goipp
+
+import (
+	"testing"
+)
+
+// TestMalformedMessages tests that goipp decoder rejects all
+// syntactically malformed messages, generated by MalformedMessages
+func TestMalformedMessages(t *testing.T) {
+	seed := NewRequest(DefaultVersion, OpPrintJob, 1)
+	seed.Operation.Add(MakeAttr("attributes-charset", TagCharset,
+		String("utf-8")))
+	seed.Operation.Add(MakeAttr("attributes-natural-language",
+		TagLanguage, String("en-us")))
+	seed.Job.Add(MakeAttr("copies", TagInteger, Integer(1)))
+	seed.Job.Add(MakeAttrCollection("media-col",
+		MakeAttr("media-key", TagKeyword, String("a4"))))
+
+	malformed, err := MalformedMessages(seed)
+	assertNoError(t, err)
+
+	names := make(map[string]bool)
+	for _, mm := range malformed {
+		names[mm.Name] = true
+
+		var m Message
+		err := m.DecodeBytes(mm.Data)
+
+		switch {
+		case mm.Syntax && err == nil:
+			t.Errorf("%s: decoder accepted malformed message",
+				mm.Name)
+		case !mm.Syntax && err != nil:
+			t.Errorf("%s: %s", mm.Name, err)
+		}
+	}
+
+	expected := []string{
+		"truncated-header",
+		"missing-end-tag",
+		"zero-tag",
+		"name-length-overflow",
+		"value-length-overflow",
+		"attribute-without-group",
+		"additional-value-first",
+		"member-name-outside-collection",
+		"end-collection-outside-collection",
+		"integer-bad-length",
+		"missing-operation-group",
+		"illegal-group-order",
+		"duplicate-attribute",
+	}
+
+	for _, name := range expected {
+		if !names[name] {
+			t.Errorf("%s: not generated", name)
+		}
+	}
+
+	// Seed without attributes
+	_, err = MalformedMessages(NewRequest(DefaultVersion, OpPrintJob, 1))
+	assertErrorIs(t, err, "Seed message contains no attributes")
+}
@@ -0,0 +1,201 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * media-col collection builder and parser
+ */
+
+package goipp
+
+import (
+	"fmt"
+)
+
+// MediaOptions represents the content of the media-col collection
+// (PWG 5100.7) in a friendly form.
+//
+// Zero values of fields mean that the corresponding member
+// attribute is absent.
+type MediaOptions struct {
+	Width, Height Dimension     // media-size: x-dimension, y-dimension
+	Margins       *MediaMargins // media-*-margin; nil if absent
+	SizeName      string        // media-size-name, i.e. "iso_a4_210x297mm"
+	Type          string        // media-type, i.e. "stationery"
+	Source        string        // media-source, i.e. "tray-1"
+	Color         string        // media-color, i.e. "white"
+	Key           string        // media-key
+}
+
+// MediaMargins represents media margins. All-zero margins mean
+// borderless printing.
+type MediaMargins struct {
+	Left, Right, Top, Bottom Dimension
+}
+
+// MakeMediaCol makes the media-col attribute from MediaOptions.
+//
+// Use [Attribute.Name] to rename it, if needed (i.e., to
+// "media-col-default").
+func MakeMediaCol(opts MediaOptions) Attribute {
+	return Attribute{
+		Name: "media-col",
+		Values: Values{
+			{TagBeginCollection, MakeMediaColCollection(opts)},
+		},
+	}
+}
+
+// MakeMediaColCollection makes the media-col Collection value
+// from MediaOptions.
+func MakeMediaColCollection(opts MediaOptions) Collection {
+	var col Collection
+
+	if opts.Key != "" {
+		col.Add(MakeAttribute("media-key", TagKeyword,
+			String(opts.Key)))
+	}
+
+	if opts.Width != 0 || opts.Height != 0 {
+		col.Add(MakeAttrCollection("media-size",
+			MakeAttribute("x-dimension", TagInteger,
+				opts.Width.Integer()),
+			MakeAttribute("y-dimension", TagInteger,
+				opts.Height.Integer())))
+	}
+
+	if opts.SizeName != "" {
+		col.Add(MakeAttribute("media-size-name", TagKeyword,
+			String(opts.SizeName)))
+	}
+
+	if m := opts.Margins; m != nil {
+		col.Add(MakeAttribute("media-bottom-margin", TagInteger,
+			m.Bottom.Integer()))
+		col.Add(MakeAttribute("media-left-margin", TagInteger,
+			m.Left.Integer()))
+		col.Add(MakeAttribute("media-right-margin", TagInteger,
+			m.Right.Integer()))
+		col.Add(MakeAttribute("media-top-margin", TagInteger,
+			m.Top.Integer()))
+	}
+
+	if opts.Type != "" {
+		col.Add(MakeAttribute("media-type", TagKeyword,
+			String(opts.Type)))
+	}
+
+	if opts.Source != "" {
+		col.Add(MakeAttribute("media-source", TagKeyword,
+			String(opts.Source)))
+	}
+
+	if opts.Color != "" {
+		col.Add(MakeAttribute("media-color", TagKeyword,
+			String(opts.Color)))
+	}
+
+	return col
+}
+
+// ParseMediaCol parses the media-col Collection into MediaOptions.
+//
+// Unknown members are ignored. Members of the wrong type cause
+// an error. If only some of margins are present, the absent ones
+// are reported as zero.
+func ParseMediaCol(col Collection) (MediaOptions, error) {
+	var opts MediaOptions
+	var err error
+
+	for _, attr := range col {
+		switch attr.Name {
+		case "media-size":
+			var size Collection
+			size, err = memberCollection("media-col", attr)
+			for i := 0; err == nil && i < len(size); i++ {
+				var v int
+				switch size[i].Name {
+				case "x-dimension":
+					v, err = memberInteger("media-col", size[i])
+					opts.Width = Dimension(v)
+				case "y-dimension":
+					v, err = memberInteger("media-col", size[i])
+					opts.Height = Dimension(v)
+				}
+			}
+
+		case "media-bottom-margin", "media-left-margin",
+			"media-right-margin", "media-top-margin":
+			var v int
+			v, err = memberInteger("media-col", attr)
+			if opts.Margins == nil {
+				opts.Margins = &MediaMargins{}
+			}
+
+			switch attr.Name {
+			case "media-bottom-margin":
+				opts.Margins.Bottom = Dimension(v)
+			case "media-left-margin":
+				opts.Margins.Left = Dimension(v)
+			case "media-right-margin":
+				opts.Margins.Right = Dimension(v)
+			case "media-top-margin":
+				opts.Margins.Top = Dimension(v)
+			}
+
+		case "media-size-name":
+			opts.SizeName, err = memberString("media-col", attr)
+		case "media-type":
+			opts.Type, err = memberString("media-col", attr)
+		case "media-source":
+			opts.Source, err = memberString("media-col", attr)
+		case "media-color":
+			opts.Color, err = memberString("media-col", attr)
+		case "media-key":
+			opts.Key, err = memberString("media-col", attr)
+		}
+
+		if err != nil {
+			return MediaOptions{}, err
+		}
+	}
+
+	return opts, nil
+}
+
+// memberInteger returns value of the integer member of collection
+func memberInteger(col string, attr Attribute) (int, error) {
+	if len(attr.Values) == 1 {
+		if v, ok := attr.Values[0].V.(Integer); ok {
+			return int(v), nil
+		}
+	}
+	return 0, fmt.Errorf("%s: %s: single integer expected", col, attr.Name)
+}
+
+// memberString returns value of the keyword or name member of collection
+func memberString(col string, attr Attribute) (string, error) {
+	if len(attr.Values) == 1 {
+		switch v := attr.Values[0].V.(type) {
+		case String:
+			return string(v), nil
+		case Name, Keyword:
+			return v.String(), nil
+		case TextWithLang:
+			return v.Text, nil
+		}
+	}
+	return "", fmt.Errorf("%s: %s: single keyword or name expected",
+		col, attr.Name)
+}
+
+// memberCollection returns value of the collection member of collection
+func memberCollection(col string, attr Attribute) (Collection, error) {
+	if len(attr.Values) == 1 {
+		if v, ok := attr.Values[0].V.(Collection); ok {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %s: single collection expected",
+		col, attr.Name)
+}
@@ -0,0 +1,66 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * media-col collection builder and parser test
+ */
+
+package goipp
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestMediaCol tests MakeMediaCol and ParseMediaCol
+func TestMediaCol(t *testing.T) {
+	opts := MediaOptions{
+		Width:    21000,
+		Height:   29700,
+		Margins:  &MediaMargins{},
+		SizeName: "iso_a4_210x297mm",
+		Type:     "stationery",
+		Source:   "tray-1",
+	}
+
+	attr := MakeMediaCol(opts)
+
+	expected := MakeAttrCollection("media-col",
+		MakeAttrCollection("media-size",
+			MakeAttr("x-dimension", TagInteger, Integer(21000)),
+			MakeAttr("y-dimension", TagInteger, Integer(29700))),
+		MakeAttr("media-size-name", TagKeyword, String("iso_a4_210x297mm")),
+		MakeAttr("media-bottom-margin", TagInteger, Integer(0)),
+		MakeAttr("media-left-margin", TagInteger, Integer(0)),
+		MakeAttr("media-right-margin", TagInteger, Integer(0)),
+		MakeAttr("media-top-margin", TagInteger, Integer(0)),
+		MakeAttr("media-type", TagKeyword, String("stationery")),
+		MakeAttr("media-source", TagKeyword, String("tray-1")),
+	)
+
+	if !attr.Equal(expected) {
+		t.Errorf("MakeMediaCol:\nexpected: %s\npresent:  %s",
+			expected.Values, attr.Values)
+	}
+
+	opts2, err := ParseMediaCol(attr.Values[0].V.(Collection))
+	assertNoError(t, err)
+
+	if !reflect.DeepEqual(opts, opts2) {
+		t.Errorf("ParseMediaCol:\nexpected: %+v\npresent:  %+v",
+			opts, opts2)
+	}
+
+	// Errors
+	_, err = ParseMediaCol(Collection{
+		MakeAttrCollection("media-size",
+			MakeAttr("x-dimension", TagKeyword, String("wide"))),
+	})
+	assertErrorIs(t, err, "media-col: x-dimension: single integer expected")
+
+	_, err = ParseMediaCol(Collection{
+		MakeAttr("media-size", TagInteger, Integer(1)),
+	})
+	assertErrorIs(t, err, "media-col: media-size: single collection expected")
+}
@@ -0,0 +1,184 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Deep merge of collections and media database
+ */
+
+package goipp
+
+// Merge returns deep merge of collections v and v2.
+//
+// Members of v2 replace members of v with the same name, except
+// when both members are single collections: in this case, they
+// are merged recursively. Members of v2, missed in v, are appended.
+// Neither v nor v2 are modified.
+func (v Collection) Merge(v2 Collection) Collection {
+	merged := make(Collection, len(v), len(v)+len(v2))
+	copy(merged, v)
+
+	index := make(map[string]int, len(v))
+	for i, attr := range v {
+		if _, found := index[attr.Name]; !found {
+			index[attr.Name] = i
+		}
+	}
+
+	for _, attr := range v2 {
+		i, found := index[attr.Name]
+		if !found {
+			index[attr.Name] = len(merged)
+			merged.Add(attr)
+			continue
+		}
+
+		col1, ok1 := mediaDBSingleCollection(merged[i])
+		col2, ok2 := mediaDBSingleCollection(attr)
+		if ok1 && ok2 {
+			attr = Attribute{Name: attr.Name,
+				Values: Values{{TagBeginCollection, col1.Merge(col2)}}}
+		}
+
+		merged[i] = attr
+	}
+
+	return merged
+}
+
+// MediaDB is the database of media-col entries, i.e., for
+// synthesizing the media-col-database and media-col-ready printer
+// attributes from several sources.
+//
+// MediaDB skips duplicates (collections, similar to already added
+// ones) and indexes entries by media-size for fast lookup.
+//
+// The zero MediaDB is empty and ready for use.
+type MediaDB struct {
+	entries []Collection         // Entries, in order of addition
+	bySize  map[mediaDBKey][]int // Indices of entries by size
+	noSize  []int                // Indices of entries without size
+}
+
+// mediaDBKey is the MediaDB index key
+type mediaDBKey struct {
+	width, height Dimension
+}
+
+// Add adds media-col entry to the database. It returns false,
+// if entry is duplicate and was not added.
+func (db *MediaDB) Add(col Collection) bool {
+	key, ok := mediaDBSizeOf(col)
+
+	candidates := db.noSize
+	if ok {
+		candidates = db.bySize[key]
+	}
+
+	for _, i := range candidates {
+		if Attributes(db.entries[i]).Similar(Attributes(col)) {
+			return false
+		}
+	}
+
+	i := len(db.entries)
+	db.entries = append(db.entries, col)
+
+	if ok {
+		if db.bySize == nil {
+			db.bySize = make(map[mediaDBKey][]int)
+		}
+		db.bySize[key] = append(db.bySize[key], i)
+	} else {
+		db.noSize = append(db.noSize, i)
+	}
+
+	return true
+}
+
+// AddAttr adds all collection values of the attribute (i.e.,
+// media-col-database) to the database. It returns count of
+// added entries.
+func (db *MediaDB) AddAttr(attr Attribute) int {
+	added := 0
+	for _, val := range attr.Values {
+		if col, ok := val.V.(Collection); ok && db.Add(col) {
+			added++
+		}
+	}
+	return added
+}
+
+// Lookup returns entries with the specified media-size, in order
+// of addition.
+func (db *MediaDB) Lookup(width, height Dimension) []Collection {
+	var found []Collection
+	for _, i := range db.bySize[mediaDBKey{width, height}] {
+		found = append(found, db.entries[i])
+	}
+	return found
+}
+
+// Len returns count of entries in the database
+func (db *MediaDB) Len() int {
+	return len(db.entries)
+}
+
+// Collections returns all entries, in order of addition
+func (db *MediaDB) Collections() []Collection {
+	return append([]Collection(nil), db.entries...)
+}
+
+// Attribute makes the 1setOf collection attribute with the
+// specified name (i.e., "media-col-database") from all entries.
+func (db *MediaDB) Attribute(name string) Attribute {
+	attr := Attribute{Name: name}
+	for _, col := range db.entries {
+		attr.Values.Add(TagBeginCollection, col)
+	}
+	return attr
+}
+
+// mediaDBSizeOf returns media-size of the media-col entry. It
+// returns false, if media-size is missed or its dimensions are
+// not integers (i.e., ranges of custom sizes).
+func mediaDBSizeOf(col Collection) (mediaDBKey, bool) {
+	size, found := attrsFind(Attributes(col), "media-size")
+	if !found {
+		return mediaDBKey{}, false
+	}
+
+	members, ok := mediaDBSingleCollection(size)
+	if !ok {
+		return mediaDBKey{}, false
+	}
+
+	var key mediaDBKey
+	var okX, okY bool
+	for _, attr := range members {
+		if len(attr.Values) != 1 {
+			continue
+		}
+
+		v, ok := attr.Values[0].V.(Integer)
+		switch {
+		case !ok:
+		case attr.Name == "x-dimension":
+			key.width, okX = Dimension(v), true
+		case attr.Name == "y-dimension":
+			key.height, okY = Dimension(v), true
+		}
+	}
+
+	return key, okX && okY
+}
+
+// mediaDBSingleCollection returns value of the single collection
+// attribute
+func mediaDBSingleCollection(attr Attribute) (Collection, bool) {
+	if len(attr.Values) == 1 {
+		col, ok := attr.Values[0].V.(Collection)
+		return col, ok
+	}
+	return nil, false
+}
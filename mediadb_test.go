@@ -0,0 +1,120 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Deep merge of collections and media database test
+ */
+
+package goipp
+
+import (
+	"testing"
+)
+
+// TestCollectionMerge tests Collection.Merge
+func TestCollectionMerge(t *testing.T) {
+	opts := MediaOptions{
+		Width:  21000,
+		Height: 29700,
+		Type:   "stationery",
+	}
+	col1 := MakeMediaColCollection(opts)
+
+	col2 := Collection{
+		MakeAttrCollection("media-size",
+			MakeAttr("y-dimension", TagInteger, Integer(29701))),
+		MakeAttr("media-type", TagKeyword, String("photographic")),
+		MakeAttr("media-source", TagKeyword, String("tray-1")),
+	}
+
+	saved := Attributes(MakeMediaColCollection(opts))
+
+	merged := col1.Merge(col2)
+	expected := Collection{
+		MakeAttrCollection("media-size",
+			MakeAttr("x-dimension", TagInteger, Integer(21000)),
+			MakeAttr("y-dimension", TagInteger, Integer(29701))),
+		MakeAttr("media-type", TagKeyword, String("photographic")),
+		MakeAttr("media-source", TagKeyword, String("tray-1")),
+	}
+
+	if !merged.Equal(Attributes(expected)) {
+		t.Errorf("Collection.Merge:\nexpected: %s\npresent:  %s",
+			expected, merged)
+	}
+
+	if !col1.Equal(saved) {
+		t.Errorf("Collection.Merge: source modified: %s", col1)
+	}
+
+	// Collection replaced by non-collection and vice versa
+	col3 := Collection{MakeAttr("media-size", TagKeyword,
+		String("iso_a4_210x297mm"))}
+
+	merged = col1.Merge(col3)
+	if !merged[0].Equal(col3[0]) {
+		t.Errorf("Collection.Merge: member not replaced: %s", merged)
+	}
+}
+
+// TestMediaDB tests MediaDB
+func TestMediaDB(t *testing.T) {
+	a4 := MakeMediaColCollection(MediaOptions{Width: 21000,
+		Height: 29700, Type: "stationery"})
+	a4photo := MakeMediaColCollection(MediaOptions{Width: 21000,
+		Height: 29700, Type: "photographic"})
+	letter := MakeMediaColCollection(MediaOptions{Width: 21590,
+		Height: 27940})
+	custom := Collection{
+		MakeAttrCollection("media-size",
+			MakeAttr("x-dimension", TagRange, Range{7620, 21590}),
+			MakeAttr("y-dimension", TagRange, Range{12700, 35560})),
+	}
+
+	// a4, reordered
+	a4dup := Collection{a4[1], a4[0]}
+
+	var db MediaDB
+
+	attr := MakeAttribute("media-col-database", TagBeginCollection, a4)
+	attr.Values.Add(TagBeginCollection, a4photo)
+	attr.Values.Add(TagBeginCollection, custom)
+	attr.Values.Add(TagBeginCollection, a4dup)
+
+	if n := db.AddAttr(attr); n != 3 {
+		t.Errorf("MediaDB.AddAttr: expected 3, present %d", n)
+	}
+
+	if !db.Add(letter) {
+		t.Errorf("MediaDB.Add: letter not added")
+	}
+
+	if db.Add(custom) {
+		t.Errorf("MediaDB.Add: duplicate custom added")
+	}
+
+	if n := db.Len(); n != 4 {
+		t.Errorf("MediaDB.Len: expected 4, present %d", n)
+	}
+
+	found := db.Lookup(21000, 29700)
+	if len(found) != 2 || !found[0].Equal(Attributes(a4)) ||
+		!found[1].Equal(Attributes(a4photo)) {
+		t.Errorf("MediaDB.Lookup: unexpected result %v", found)
+	}
+
+	if found := db.Lookup(10000, 10000); found != nil {
+		t.Errorf("MediaDB.Lookup: unexpected result %v", found)
+	}
+
+	attr = db.Attribute("media-col-ready")
+	if attr.Name != "media-col-ready" || len(attr.Values) != 4 {
+		t.Errorf("MediaDB.Attribute: unexpected result %s", attr)
+	}
+
+	cols := db.Collections()
+	if len(cols) != 4 || !cols[3].Equal(Attributes(letter)) {
+		t.Errorf("MediaDB.Collections: unexpected result %v", cols)
+	}
+}
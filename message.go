@@ -10,6 +10,8 @@ package goipp
 
 import (
 	"bytes"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 )
@@ -53,6 +55,24 @@ type Message struct {
 	Code      Code    // Operation for request, status for response
 	RequestID uint32  // Set in request, returned in response
 
+	// Role of the message (request or response), if known.
+	//
+	// Role is not transmitted over the wire. It is set by NewRequest
+	// and NewResponse and affects interpretation of Code by
+	// ValidateCode and Formatter.FmtMessage. Decode leaves it
+	// unchanged, as role cannot be deduced from the message itself.
+	// Equal and Similar ignore Role.
+	Role MessageRole
+
+	// Meta contains metadata of the message, like transport
+	// metadata (remote address, HTTP headers, receive timestamp),
+	// that is carried through transform pipelines and handlers
+	// together with the message.
+	//
+	// Like Role, Meta is not transmitted over the wire, Decode
+	// leaves it unchanged, and Equal and Similar ignore it.
+	Meta MessageMeta
+
 	// Groups of Attributes
 	//
 	// This field allows to represent messages with repeated
@@ -98,6 +118,30 @@ type Message struct {
 	Future15          Attributes // /
 }
 
+// MessageRole represents role of the Message (request or response)
+type MessageRole int
+
+// Message roles
+const (
+	MessageRoleUnknown  MessageRole = iota // Role is not known
+	MessageRoleRequest                     // Client request
+	MessageRoleResponse                    // Server response
+)
+
+// String returns name of the MessageRole
+func (role MessageRole) String() string {
+	switch role {
+	case MessageRoleUnknown:
+		return "unknown"
+	case MessageRoleRequest:
+		return "request"
+	case MessageRoleResponse:
+		return "response"
+	}
+
+	return fmt.Sprintf("0x%x", int(role))
+}
+
 // NewRequest creates a new request message
 //
 // Use DefaultVersion as a first argument, if you don't
@@ -107,6 +151,7 @@ func NewRequest(v Version, op Op, id uint32) *Message {
 		Version:   v,
 		Code:      Code(op),
 		RequestID: id,
+		Role:      MessageRoleRequest,
 	}
 }
 
@@ -118,6 +163,7 @@ func NewResponse(v Version, status Status, id uint32) *Message {
 		Version:   v,
 		Code:      Code(status),
 		RequestID: id,
+		Role:      MessageRoleResponse,
 	}
 }
 
@@ -137,42 +183,41 @@ func NewMessageWithGroups(v Version, code Code,
 	}
 
 	for _, grp := range m.Groups {
-		switch grp.Tag {
-		case TagOperationGroup:
-			m.Operation = append(m.Operation, grp.Attrs...)
-		case TagJobGroup:
-			m.Job = append(m.Job, grp.Attrs...)
-		case TagPrinterGroup:
-			m.Printer = append(m.Printer, grp.Attrs...)
-		case TagUnsupportedGroup:
-			m.Unsupported = append(m.Unsupported, grp.Attrs...)
-		case TagSubscriptionGroup:
-			m.Subscription = append(m.Subscription, grp.Attrs...)
-		case TagEventNotificationGroup:
-			m.EventNotification = append(m.EventNotification,
-				grp.Attrs...)
-		case TagResourceGroup:
-			m.Resource = append(m.Resource, grp.Attrs...)
-		case TagDocumentGroup:
-			m.Document = append(m.Document, grp.Attrs...)
-		case TagSystemGroup:
-			m.System = append(m.System, grp.Attrs...)
-		case TagFuture11Group:
-			m.Future11 = append(m.Future11, grp.Attrs...)
-		case TagFuture12Group:
-			m.Future12 = append(m.Future12, grp.Attrs...)
-		case TagFuture13Group:
-			m.Future13 = append(m.Future13, grp.Attrs...)
-		case TagFuture14Group:
-			m.Future14 = append(m.Future14, grp.Attrs...)
-		case TagFuture15Group:
-			m.Future15 = append(m.Future15, grp.Attrs...)
+		if attrs := m.namedGroup(grp.Tag); attrs != nil {
+			*attrs = append(*attrs, grp.Attrs...)
 		}
 	}
 
 	return m
 }
 
+// CopyGroup copies all groups with the specified tag from m to dst,
+// i.e., for forwarding the job group from the request into an
+// internal record or for building the response, reusing groups of
+// the request.
+//
+// If dst.Groups is not nil, groups are appended to dst.Groups,
+// preserving their boundaries, and their attributes are appended
+// to the corresponding named per-group field (i.e., dst.Job).
+// Otherwise, attributes of all copied groups are appended to the
+// named field only.
+//
+// Attributes are copied with Attributes.Clone, so their values are
+// shared between messages.
+func (m *Message) CopyGroup(dst *Message, tag Tag) {
+	for _, grp := range m.attrGroups().Filter(tag) {
+		attrs := grp.Attrs.Clone()
+
+		if dst.Groups != nil {
+			dst.Groups.Add(Group{tag, attrs})
+		}
+
+		if named := dst.namedGroup(tag); named != nil {
+			*named = append(*named, attrs...)
+		}
+	}
+}
+
 // Equal checks that two messages are equal
 func (m Message) Equal(m2 Message) bool {
 	if m.Version != m2.Version ||
@@ -204,6 +249,39 @@ func (m Message) Similar(m2 Message) bool {
 	return groups.Similar(groups2)
 }
 
+// ValidateCode checks that Code is consistent with the message Role.
+//
+// For requests, Code must be within the standard or vendor range of
+// operation codes (0x0002-0x7fff). For responses, Code must belong
+// to one of the status code classes, defined by RFC 8011, 5.4.15:
+// successful (0x00xx), informational (0x01xx), redirection (0x02xx),
+// client error (0x04xx) or server error (0x05xx).
+//
+// If Role is unknown, ValidateCode returns an error.
+func (m *Message) ValidateCode() error {
+	switch m.Role {
+	case MessageRoleRequest:
+		op := Op(m.Code)
+		if !op.IsStandard() && !op.IsVendor() {
+			return fmt.Errorf("Invalid operation code 0x%4.4x",
+				int(m.Code))
+		}
+
+	case MessageRoleResponse:
+		switch m.Code >> 8 {
+		case 0x00, 0x01, 0x02, 0x04, 0x05:
+		default:
+			return fmt.Errorf("Invalid status code 0x%4.4x",
+				int(m.Code))
+		}
+
+	default:
+		return fmt.Errorf("Message role is %s", m.Role)
+	}
+
+	return nil
+}
+
 // Reset the message into initial state
 func (m *Message) Reset() {
 	*m = Message{}
@@ -226,7 +304,88 @@ func (m *Message) EncodeBytes() ([]byte, error) {
 	return buf.Bytes(), err
 }
 
+// EncodeEx encodes message into io.Writer
+//
+// It is extended version of the Encode method, with additional
+// EncoderOptions parameter
+func (m *Message) EncodeEx(out io.Writer, opt EncoderOptions) error {
+	if opt.Atomic {
+		return m.encodeAtomic(out, opt)
+	}
+
+	if opt.Version != 0 && opt.Version != m.Version {
+		m2 := *m
+		m2.Version = opt.Version
+		m = &m2
+	}
+
+	if opt.Coerce {
+		m = coerceHandler{&opt}.apply(m)
+	}
+
+	if opt.Lengths != LengthIgnore {
+		m2, err := m.applyLengths(opt.Lengths)
+		if err != nil {
+			return err
+		}
+		m = m2
+	}
+
+	if oversizeNeeded(&opt) {
+		m2, err := oversizeHandler{&opt}.apply(m)
+		if err != nil {
+			return err
+		}
+		m = m2
+	}
+
+	return m.Encode(out)
+}
+
+// encodeAtomic implements EncoderOptions.Atomic
+func (m *Message) encodeAtomic(out io.Writer, opt EncoderOptions) error {
+	opt.Atomic = false
+
+	if buf, ok := out.(*bytes.Buffer); ok {
+		l := buf.Len()
+		err := m.EncodeEx(buf, opt)
+		if err != nil {
+			buf.Truncate(l)
+		}
+		return err
+	}
+
+	var buf bytes.Buffer
+	err := m.EncodeEx(&buf, opt)
+	if err == nil {
+		_, err = out.Write(buf.Bytes())
+	}
+
+	return err
+}
+
+// EncodeBytesEx encodes message to byte slice
+//
+// It is extended version of the EncodeBytes method, with additional
+// EncoderOptions parameter
+func (m *Message) EncodeBytesEx(opt EncoderOptions) ([]byte, error) {
+	var buf bytes.Buffer
+
+	err := m.EncodeEx(&buf, opt)
+	return buf.Bytes(), err
+}
+
 // Decode reads message from io.Reader
+//
+// Decode consumes exactly one message: it never reads beyond the
+// TagEnd, that terminates the message, and so leaves io.Reader
+// positioned right after the end of message. This allows to decode
+// multiple messages sequentially from a single connection, or to
+// read the document data, that follows the request.
+//
+// Decoder reads data in small pieces, so buffered reader (i.e.,
+// bufio.Reader) or DecoderOptions.ReadAhead is recommended for
+// the network connections.
 func (m *Message) Decode(in io.Reader) error {
 	return m.DecodeEx(in, DecoderOptions{})
 }
@@ -236,16 +395,188 @@ func (m *Message) Decode(in io.Reader) error {
 // It is extended version of the Decode method, with additional
 // DecoderOptions parameter
 func (m *Message) DecodeEx(in io.Reader, opt DecoderOptions) error {
-	md := messageDecoder{
-		in:  in,
-		opt: opt,
+	md := newMessageDecoder(in, opt)
+
+	role, meta := m.Role, m.Meta
+	m.Reset()
+	m.Role, m.Meta = role, meta
+
+	err := md.decode(m)
+
+	if res := opt.Result; res != nil {
+		*res = DecodeResult{Bytes: md.cnt}
+		copy(res.SHA256[:], md.sum.Sum(nil))
+
+		for _, grp := range m.attrGroups() {
+			res.Groups++
+			res.Attrs += len(grp.Attrs)
+			res.Values += attrsCountValues(grp.Attrs)
+		}
+	}
+
+	return err
+}
+
+// DecodeHeaderAndOperation reads message header and operation
+// attributes from io.Reader and stops decoding at the beginning
+// of the next group of attributes.
+//
+// It is useful for routers and proxies, that only need Code or
+// operation attributes (i.e., status-message or printer-uri) to
+// decide where to send the rest of the message.
+//
+// On success, it returns io.Reader that yields the remaining part
+// of the message, starting from the tag of the next group, and
+// followed by the rest of input stream (i.e., document data). If
+// message contains only operation attributes, the returned reader
+// is positioned right after the end of message.
+//
+// Note, fields of m, other than header and Operation, are reset.
+func (m *Message) DecodeHeaderAndOperation(in io.Reader) (io.Reader, error) {
+	return m.DecodeHeaderAndOperationEx(in, DecoderOptions{})
+}
+
+// DecodeHeaderAndOperationEx reads message header and operation
+// attributes from io.Reader.
+//
+// It is extended version of the DecodeHeaderAndOperation method,
+// with additional DecoderOptions parameter
+func (m *Message) DecodeHeaderAndOperationEx(in io.Reader,
+	opt DecoderOptions) (io.Reader, error) {
+
+	cont, err := m.DecodeDeferredEx(in, opt)
+	if err != nil {
+		return nil, err
 	}
 
+	return cont.Rest(), nil
+}
+
+// DecodeDeferred reads message header and operation attributes
+// from io.Reader, like DecodeHeaderAndOperation, and returns
+// the DecodeContinuation, that can be later used to finish
+// decoding of the remaining groups of attributes from the
+// same io.Reader.
+//
+// It enables two-phase processing of messages, i.e., in proxies.
+func (m *Message) DecodeDeferred(in io.Reader) (*DecodeContinuation, error) {
+	return m.DecodeDeferredEx(in, DecoderOptions{})
+}
+
+// DecodeDeferredEx reads message header and operation attributes
+// from io.Reader and returns the DecodeContinuation.
+//
+// It is extended version of the DecodeDeferred method, with
+// additional DecoderOptions parameter
+func (m *Message) DecodeDeferredEx(in io.Reader,
+	opt DecoderOptions) (*DecodeContinuation, error) {
+
+	cont := &DecodeContinuation{
+		m:  m,
+		md: newMessageDecoder(in, opt),
+	}
+
+	role, meta := m.Role, m.Meta
 	m.Reset()
-	return md.decode(m)
+	m.Role, m.Meta = role, meta
+
+	err := cont.md.decodeHeader(m)
+	if err == nil {
+		cont.pending, err = cont.md.decodeAttrs(m, TagZero, true)
+	}
+
+	if err != nil {
+		return nil, cont.md.wrapErr(err)
+	}
+
+	cont.done = cont.pending == TagZero
+
+	return cont, nil
+}
+
+// DecodeContinuation represents the partially decoded Message,
+// returned by Message.DecodeDeferred.
+//
+// Either Finish or Rest may be used to consume the remaining
+// part of the message, but not both.
+type DecodeContinuation struct {
+	m       *Message       // Message being decoded
+	md      messageDecoder // Decoder state
+	pending Tag            // Pending group tag
+	done    bool           // Message is completely decoded
+	err     error          // Sticky error
+}
+
+// Done reports whether the Message is completely decoded
+func (cont *DecodeContinuation) Done() bool {
+	return cont.done
+}
+
+// Finish decodes the remaining groups of attributes into the
+// Message, which was passed to DecodeDeferred.
+//
+// After successful completion, the io.Reader is positioned right
+// after the end of message, as with Message.Decode.
+func (cont *DecodeContinuation) Finish() error {
+	if cont.done || cont.err != nil {
+		return cont.err
+	}
+
+	_, err := cont.md.decodeAttrs(cont.m, cont.pending, false)
+	cont.err = cont.md.wrapErr(err)
+	cont.done = true
+
+	return cont.err
+}
+
+// Rest returns io.Reader that yields the remaining part of the
+// message, starting from the tag of the next group, and followed
+// by the rest of input stream. If message is completely decoded,
+// it returns the input stream, positioned right after the end
+// of message.
+//
+// After Rest is called, Finish cannot be used anymore.
+func (cont *DecodeContinuation) Rest() io.Reader {
+	in := cont.md.in
+
+	if !cont.done {
+		in = io.MultiReader(bytes.NewReader([]byte{byte(cont.pending)}),
+			in)
+		cont.done = true
+		cont.err = errors.New("Message already consumed by Rest")
+	}
+
+	return in
+}
+
+// MessageHeaderSize is the size of the message header: version,
+// operation or status code and request ID
+const MessageHeaderSize = 8
+
+// ParseHeader parses the message header from the first
+// MessageHeaderSize bytes of data, without decoding the rest of
+// the message. It is useful for cheap routing and metrics in
+// high-volume proxies.
+//
+// Only header is checked; the rest of data may be truncated
+// or even missed.
+func ParseHeader(data []byte) (Version, Code, uint32, error) {
+	if len(data) < MessageHeaderSize {
+		return 0, 0, 0, errors.New("Message header truncated")
+	}
+
+	v := Version(binary.BigEndian.Uint16(data[0:2]))
+	code := Code(binary.BigEndian.Uint16(data[2:4]))
+	id := binary.BigEndian.Uint32(data[4:8])
+
+	return v, code, id, nil
 }
 
 // DecodeBytes decodes message from byte slice
+//
+// Data after the end of message (i.e., document data or the next
+// concatenated message) is ignored. To find where the message ends,
+// use DecodeBytesEx with DecoderOptions.Result (see DecodeResult.Bytes).
 func (m *Message) DecodeBytes(data []byte) error {
 	return m.Decode(bytes.NewBuffer(data))
 }
@@ -358,3 +689,41 @@ func (m *Message) attrGroups() Groups {
 
 	return groups[:out]
 }
+
+// namedGroup returns pointer to the named per-group field of the
+// message (i.e., &m.Job for TagJobGroup), or nil if tag is not
+// a group tag
+func (m *Message) namedGroup(tag Tag) *Attributes {
+	switch tag {
+	case TagOperationGroup:
+		return &m.Operation
+	case TagJobGroup:
+		return &m.Job
+	case TagPrinterGroup:
+		return &m.Printer
+	case TagUnsupportedGroup:
+		return &m.Unsupported
+	case TagSubscriptionGroup:
+		return &m.Subscription
+	case TagEventNotificationGroup:
+		return &m.EventNotification
+	case TagResourceGroup:
+		return &m.Resource
+	case TagDocumentGroup:
+		return &m.Document
+	case TagSystemGroup:
+		return &m.System
+	case TagFuture11Group:
+		return &m.Future11
+	case TagFuture12Group:
+		return &m.Future12
+	case TagFuture13Group:
+		return &m.Future13
+	case TagFuture14Group:
+		return &m.Future14
+	case TagFuture15Group:
+		return &m.Future15
+	}
+
+	return nil
+}
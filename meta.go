@@ -0,0 +1,46 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Message metadata
+ */
+
+package goipp
+
+// MessageMeta contains metadata of the Message, by key.
+//
+// Keys, defined by this package, are listed below. Applications
+// may use their own keys; to avoid collisions, keys should be
+// prefixed with the application or package name.
+type MessageMeta map[string]interface{}
+
+// Keys of the MessageMeta, defined by this package:
+const (
+	MetaRemoteAddr = "remote-addr" // Remote address, string
+	MetaHTTPHeader = "http-header" // HTTP header, http.Header
+	MetaReceived   = "received"    // Receive timestamp, time.Time
+)
+
+// SetMeta sets the metadata value, allocating Message.Meta,
+// if needed
+func (m *Message) SetMeta(key string, val interface{}) {
+	if m.Meta == nil {
+		m.Meta = make(MessageMeta)
+	}
+	m.Meta[key] = val
+}
+
+// Clone creates a shallow copy of MessageMeta
+func (meta MessageMeta) Clone() MessageMeta {
+	if meta == nil {
+		return nil
+	}
+
+	meta2 := make(MessageMeta, len(meta))
+	for key, val := range meta {
+		meta2[key] = val
+	}
+
+	return meta2
+}
@@ -0,0 +1,59 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Message metadata test
+ */
+
+package goipp
+
+import (
+	"testing"
+	"time"
+)
+
+// TestMessageMeta tests Message.Meta
+func TestMessageMeta(t *testing.T) {
+	now := time.Now()
+
+	m := NewRequest(DefaultVersion, OpGetJobs, 1)
+	m.SetMeta(MetaRemoteAddr, "192.168.0.1:631")
+	m.SetMeta(MetaReceived, now)
+
+	data, err := m.EncodeBytes()
+	assertNoError(t, err)
+
+	// Meta is not encoded and is ignored by Equal
+	var m2 Message
+	assertNoError(t, m2.DecodeBytes(data))
+
+	if m2.Meta != nil {
+		t.Errorf("Meta decoded: %v", m2.Meta)
+	}
+
+	if !m.Equal(m2) || !m.Similar(m2) {
+		t.Errorf("Meta is not ignored by Equal or Similar")
+	}
+
+	// Decode leaves Meta unchanged
+	meta := m.Meta
+	assertNoError(t, m.DecodeBytes(data))
+
+	if m.Meta[MetaRemoteAddr] != "192.168.0.1:631" ||
+		m.Meta[MetaReceived] != now {
+		t.Errorf("Meta is not preserved by Decode: %v", m.Meta)
+	}
+
+	// Clone makes a copy
+	clone := meta.Clone()
+	clone[MetaRemoteAddr] = "10.0.0.1:631"
+
+	if meta[MetaRemoteAddr] != "192.168.0.1:631" {
+		t.Errorf("Clone: original modified")
+	}
+
+	if MessageMeta(nil).Clone() != nil {
+		t.Errorf("Clone of nil: not nil")
+	}
+}
@@ -0,0 +1,163 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Metrics of printer status
+ */
+
+package goipp
+
+import (
+	"strconv"
+)
+
+// Names of metrics, returned by Message.PrinterMetrics. They follow
+// Prometheus naming conventions.
+const (
+	// MetricPrinterState is the printer-state value (3 - idle,
+	// 4 - processing, 5 - stopped)
+	MetricPrinterState = "ipp_printer_state"
+
+	// MetricPrinterStateReason is 1 for each printer-state-reasons
+	// value, with the "reason" label, except "none"
+	MetricPrinterStateReason = "ipp_printer_state_reason"
+
+	// MetricPrinterAcceptingJobs is the printer-is-accepting-jobs
+	// value, 1 or 0
+	MetricPrinterAcceptingJobs = "ipp_printer_accepting_jobs"
+
+	// MetricQueuedJobCount is the queued-job-count value
+	MetricQueuedJobCount = "ipp_queued_job_count"
+
+	// MetricMarkerLevel, MetricMarkerLowLevel and MetricMarkerHighLevel
+	// are the marker-levels, marker-low-levels and marker-high-levels
+	// values, per supply, with "marker", "type" and "color" labels.
+	// Negative levels (unknown or unavailable) are skipped.
+	MetricMarkerLevel     = "ipp_marker_level_percent"
+	MetricMarkerLowLevel  = "ipp_marker_low_level_percent"
+	MetricMarkerHighLevel = "ipp_marker_high_level_percent"
+)
+
+// MetricSample represents a single gauge sample
+type MetricSample struct {
+	Name   string            // Metric name
+	Labels map[string]string // Labels, nil if none
+	Value  float64           // Gauge value
+}
+
+// PrinterMetrics extracts numeric gauge samples from printer
+// attributes of the response (i.e., from Get-Printer-Attributes).
+//
+// Samples are returned in the stable order. Missed attributes or
+// attributes of unexpected type are silently skipped.
+//
+// Supplies are labeled by their marker-names value. If it is
+// missed, the supply index is used instead.
+func (m *Message) PrinterMetrics() []MetricSample {
+	var samples []MetricSample
+
+	add := func(name string, labels map[string]string, v int) {
+		samples = append(samples, MetricSample{name, labels, float64(v)})
+	}
+
+	if v, ok := metricsIntegers(m, "printer-state"); ok && len(v) != 0 {
+		add(MetricPrinterState, nil, v[0])
+	}
+
+	if attr, ok := spanFind(m, "printer-state-reasons",
+		TagPrinterGroup); ok {
+		for _, val := range attr.Values {
+			if reason := val.V.String(); reason != "none" {
+				add(MetricPrinterStateReason,
+					map[string]string{"reason": reason}, 1)
+			}
+		}
+	}
+
+	if attr, ok := spanFind(m, "printer-is-accepting-jobs",
+		TagPrinterGroup); ok && len(attr.Values) != 0 {
+		if v, ok := attr.Values[0].V.(Boolean); ok {
+			accepting := 0
+			if v {
+				accepting = 1
+			}
+			add(MetricPrinterAcceptingJobs, nil, accepting)
+		}
+	}
+
+	if v, ok := metricsIntegers(m, "queued-job-count"); ok && len(v) != 0 {
+		add(MetricQueuedJobCount, nil, v[0])
+	}
+
+	names := metricsStrings(m, "marker-names")
+	types := metricsStrings(m, "marker-types")
+	colors := metricsStrings(m, "marker-colors")
+
+	for _, metric := range []struct{ name, attr string }{
+		{MetricMarkerLevel, "marker-levels"},
+		{MetricMarkerLowLevel, "marker-low-levels"},
+		{MetricMarkerHighLevel, "marker-high-levels"},
+	} {
+		levels, _ := metricsIntegers(m, metric.attr)
+		for i, level := range levels {
+			if level < 0 {
+				continue
+			}
+
+			labels := map[string]string{
+				"marker": strconv.Itoa(i),
+				"type":   "",
+				"color":  "",
+			}
+			if i < len(names) {
+				labels["marker"] = names[i]
+			}
+			if i < len(types) {
+				labels["type"] = types[i]
+			}
+			if i < len(colors) {
+				labels["color"] = colors[i]
+			}
+
+			add(metric.name, labels, level)
+		}
+	}
+
+	return samples
+}
+
+// metricsIntegers returns integer values of the printer attribute
+func metricsIntegers(m *Message, name string) ([]int, bool) {
+	attr, found := spanFind(m, name, TagPrinterGroup)
+	if !found {
+		return nil, false
+	}
+
+	values := make([]int, 0, len(attr.Values))
+	for _, val := range attr.Values {
+		v, ok := val.V.(Integer)
+		if !ok {
+			return nil, false
+		}
+		values = append(values, int(v))
+	}
+
+	return values, true
+}
+
+// metricsStrings returns string values of the printer attribute
+func metricsStrings(m *Message, name string) []string {
+	attr, _ := spanFind(m, name, TagPrinterGroup)
+
+	values := make([]string, len(attr.Values))
+	for i, val := range attr.Values {
+		if v, ok := val.V.(TextWithLang); ok {
+			values[i] = v.Text
+		} else {
+			values[i] = val.V.String()
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,71 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Metrics of printer status test
+ */
+
+package goipp
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestPrinterMetrics tests Message.PrinterMetrics
+func TestPrinterMetrics(t *testing.T) {
+	m := NewResponse(DefaultVersion, StatusOk, 1)
+	m.Printer.Add(MakeAttr("printer-state", TagEnum, Integer(4)))
+	m.Printer.Add(MakeAttr("printer-state-reasons", TagKeyword,
+		String("media-low-warning"), String("toner-low-report")))
+	m.Printer.Add(MakeAttr("printer-is-accepting-jobs", TagBoolean,
+		Boolean(true)))
+	m.Printer.Add(MakeAttr("queued-job-count", TagInteger, Integer(2)))
+	m.Printer.Add(MakeAttr("marker-names", TagName,
+		String("Black Toner"), String("Cyan Toner")))
+	m.Printer.Add(MakeAttr("marker-types", TagKeyword,
+		String("toner"), String("toner")))
+	m.Printer.Add(MakeAttr("marker-colors", TagName,
+		String("#000000"), String("#00FFFF")))
+	m.Printer.Add(MakeAttr("marker-levels", TagInteger,
+		Integer(80), Integer(-2)))
+	m.Printer.Add(MakeAttr("marker-low-levels", TagInteger,
+		Integer(10), Integer(10), Integer(5)))
+
+	marker := func(name, typ, color string) map[string]string {
+		return map[string]string{
+			"marker": name, "type": typ, "color": color,
+		}
+	}
+
+	expected := []MetricSample{
+		{MetricPrinterState, nil, 4},
+		{MetricPrinterStateReason,
+			map[string]string{"reason": "media-low-warning"}, 1},
+		{MetricPrinterStateReason,
+			map[string]string{"reason": "toner-low-report"}, 1},
+		{MetricPrinterAcceptingJobs, nil, 1},
+		{MetricQueuedJobCount, nil, 2},
+		{MetricMarkerLevel, marker("Black Toner", "toner", "#000000"), 80},
+		{MetricMarkerLowLevel, marker("Black Toner", "toner", "#000000"), 10},
+		{MetricMarkerLowLevel, marker("Cyan Toner", "toner", "#00FFFF"), 10},
+		{MetricMarkerLowLevel, marker("2", "", ""), 5},
+	}
+
+	present := m.PrinterMetrics()
+	if !reflect.DeepEqual(present, expected) {
+		t.Errorf("PrinterMetrics:\nexpected: %v\npresent:  %v",
+			expected, present)
+	}
+
+	// Attributes of unexpected type are skipped
+	m = NewResponse(DefaultVersion, StatusOk, 1)
+	m.Printer.Add(MakeAttr("printer-state", TagKeyword, String("idle")))
+	m.Printer.Add(MakeAttr("printer-state-reasons", TagKeyword,
+		String("none")))
+
+	if present := m.PrinterMetrics(); len(present) != 0 {
+		t.Errorf("PrinterMetrics: unexpected samples %v", present)
+	}
+}
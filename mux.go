@@ -0,0 +1,189 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Server-side request routing by operation
+ */
+
+package goipp
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// Handler handles IPP requests on the server side.
+//
+// ServeIPP receives the decoded request and the document payload,
+// that follows the request (empty for requests without document),
+// and returns the response. It is the server-side counterpart
+// of Client.Do.
+//
+// ServeIPP should not return nil. If it does, OpMux.ServeHTTP
+// responds with server-error-internal-error.
+type Handler interface {
+	ServeIPP(ctx context.Context, req *Message, doc io.Reader) *Message
+}
+
+// HandlerFunc adapts function to the Handler interface
+type HandlerFunc func(ctx context.Context, req *Message,
+	doc io.Reader) *Message
+
+// ServeIPP calls f(ctx, req, doc)
+func (f HandlerFunc) ServeIPP(ctx context.Context, req *Message,
+	doc io.Reader) *Message {
+	return f(ctx, req, doc)
+}
+
+// Middleware wraps Handler into another Handler (i.e., for logging,
+// authentication or validation of requests)
+type Middleware func(next Handler) Handler
+
+// OpMux is the request multiplexer, that routes requests to
+// Handlers, registered per operation code, like http.ServeMux does
+// for URL paths. It is safe for concurrent use.
+//
+// Requests for operations without Handler are answered with
+// server-error-operation-not-supported (see OperationNotSupported).
+//
+// OpMux implements both Handler and http.Handler, so it can be
+// nested or served directly over HTTP.
+type OpMux struct {
+	// DecoderOptions are used by ServeHTTP to decode requests.
+	// Servers, exposed to untrusted clients, should set limits,
+	// like MaxMessageSize and MaxAttributes, here:
+	//
+	//	mux := goipp.NewOpMux()
+	//	mux.DecoderOptions.MaxMessageSize = 1 << 20
+	//	mux.DecoderOptions.MaxAttributes = 1000
+	//
+	// DecoderOptions must not be modified while OpMux is serving
+	// requests. As the options are shared between concurrent
+	// requests, Result should be nil.
+	DecoderOptions DecoderOptions
+
+	lock       sync.RWMutex   // Access lock
+	handlers   map[Op]Handler // Handlers, by Op
+	middleware []Middleware   // Middleware, outermost first
+}
+
+// NewOpMux creates a new OpMux
+func NewOpMux() *OpMux {
+	return &OpMux{handlers: make(map[Op]Handler)}
+}
+
+// Handle registers Handler for the operation. Registering the
+// same operation again replaces the Handler. Use nil to remove it.
+func (mux *OpMux) Handle(op Op, h Handler) {
+	mux.lock.Lock()
+	defer mux.lock.Unlock()
+
+	if h == nil {
+		delete(mux.handlers, op)
+		return
+	}
+
+	if mux.handlers == nil {
+		mux.handlers = make(map[Op]Handler)
+	}
+	mux.handlers[op] = h
+}
+
+// HandleFunc registers handler function for the operation
+func (mux *OpMux) HandleFunc(op Op, f func(ctx context.Context,
+	req *Message, doc io.Reader) *Message) {
+	mux.Handle(op, HandlerFunc(f))
+}
+
+// Use appends Middleware to the chain. Middleware is applied to
+// all requests, including requests for unsupported operations, and
+// the first added Middleware is the outermost one.
+func (mux *OpMux) Use(mw ...Middleware) {
+	mux.lock.Lock()
+	mux.middleware = append(mux.middleware, mw...)
+	mux.lock.Unlock()
+}
+
+// Handler returns Handler, registered for the operation, without
+// Middleware, and reports whether it was found
+func (mux *OpMux) Handler(op Op) (Handler, bool) {
+	mux.lock.RLock()
+	h, found := mux.handlers[op]
+	mux.lock.RUnlock()
+	return h, found
+}
+
+// ServeIPP dispatches the request to the Handler, registered for
+// its operation, through the Middleware chain
+func (mux *OpMux) ServeIPP(ctx context.Context, req *Message,
+	doc io.Reader) *Message {
+
+	mux.lock.RLock()
+	h, found := mux.handlers[Op(req.Code)]
+	middleware := mux.middleware
+	mux.lock.RUnlock()
+
+	if !found {
+		h = HandlerFunc(opMuxNotSupported)
+	}
+
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+
+	return h.ServeIPP(ctx, req, doc)
+}
+
+// ServeHTTP implements http.Handler. It decodes the request from
+// the HTTP request body, passes the rest of the body to the Handler
+// as document payload, and encodes the response.
+//
+// Non-POST requests are rejected with the HTTP status 405,
+// requests, exceeding DecoderOptions.MaxMessageSize, with 413,
+// and requests, that cannot be decoded, with 400.
+func (mux *OpMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed),
+			http.StatusMethodNotAllowed)
+		return
+	}
+
+	req := &Message{Role: MessageRoleRequest}
+	err := req.DecodeEx(r.Body, mux.DecoderOptions)
+	switch {
+	case err == ErrMessageTooLarge:
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rsp := mux.ServeIPP(r.Context(), req, r.Body)
+	if rsp == nil {
+		rsp = NewResponseTo(req, StatusErrorInternal)
+	}
+
+	// Drain remaining data, so HTTP connection may be reused
+	io.Copy(ioutil.Discard, r.Body)
+
+	data, err := rsp.EncodeBytes()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Write(data)
+}
+
+// opMuxNotSupported handles requests for unsupported operations
+func opMuxNotSupported(ctx context.Context, req *Message,
+	doc io.Reader) *Message {
+	return OperationNotSupported(req)
+}
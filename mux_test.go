@@ -0,0 +1,179 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Server-side request routing by operation test
+ */
+
+package goipp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestOpMux tests OpMux
+func TestOpMux(t *testing.T) {
+	var trace []string
+
+	mux := NewOpMux()
+
+	mux.HandleFunc(OpGetPrinterAttributes,
+		func(ctx context.Context, req *Message, doc io.Reader) *Message {
+			trace = append(trace, "get-printer-attributes")
+			return OKPrinterAttributes(req, Attributes{
+				MakeAttr("printer-name", TagName, String("test")),
+			})
+		})
+
+	mux.HandleFunc(OpPrintJob,
+		func(ctx context.Context, req *Message, doc io.Reader) *Message {
+			data, _ := ioutil.ReadAll(doc)
+			trace = append(trace, "print-job:"+string(data))
+			return JobCreated(req, 1, "ipp://localhost/jobs/1",
+				JobStatePending)
+		})
+
+	for _, name := range []string{"outer", "inner"} {
+		name := name
+		mux.Use(func(next Handler) Handler {
+			return HandlerFunc(func(ctx context.Context, req *Message,
+				doc io.Reader) *Message {
+				trace = append(trace, name+":"+Op(req.Code).String())
+				return next.ServeIPP(ctx, req, doc)
+			})
+		})
+	}
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	type testData struct {
+		op     Op       // Request operation
+		doc    string   // Document payload
+		status Status   // Expected status
+		trace  []string // Expected trace
+	}
+
+	tests := []testData{
+		{
+			op:     OpGetPrinterAttributes,
+			status: StatusOk,
+			trace: []string{
+				"outer:Get-Printer-Attributes",
+				"inner:Get-Printer-Attributes",
+				"get-printer-attributes",
+			},
+		},
+		{
+			op:     OpPrintJob,
+			doc:    "document data",
+			status: StatusOk,
+			trace: []string{
+				"outer:Print-Job",
+				"inner:Print-Job",
+				"print-job:document data",
+			},
+		},
+		{
+			op:     OpCancelJob,
+			status: StatusErrorOperationNotSupported,
+			trace: []string{
+				"outer:Cancel-Job",
+				"inner:Cancel-Job",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		trace = nil
+
+		req := NewRequestWithDefaults(DefaultVersion, test.op, 1)
+		var doc io.Reader
+		if test.doc != "" {
+			doc = strings.NewReader(test.doc)
+		}
+
+		rsp, err := NewClient(nil).Do(context.Background(), srv.URL,
+			req, doc)
+		assertNoError(t, err)
+
+		if rsp.Code != Code(test.status) {
+			t.Errorf("%s: status %s, expected %s", test.op,
+				Status(rsp.Code), test.status)
+		}
+
+		if strings.Join(trace, ",") != strings.Join(test.trace, ",") {
+			t.Errorf("%s: trace mismatch\nexpected: %q\npresent:  %q",
+				test.op, test.trace, trace)
+		}
+	}
+
+	// Removal of Handler
+	if _, found := mux.Handler(OpPrintJob); !found {
+		t.Errorf("Handler(%s): not found", OpPrintJob)
+	}
+
+	mux.Handle(OpPrintJob, nil)
+	if _, found := mux.Handler(OpPrintJob); found {
+		t.Errorf("Handler(%s): found after removal", OpPrintJob)
+	}
+
+	// HTTP errors
+	rsp, err := http.Get(srv.URL)
+	assertNoError(t, err)
+	rsp.Body.Close()
+	if rsp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET: HTTP status %d", rsp.StatusCode)
+	}
+
+	rsp, err = http.Post(srv.URL, ContentType,
+		bytes.NewReader(goodMessage1[:10]))
+	assertNoError(t, err)
+	rsp.Body.Close()
+	if rsp.StatusCode != http.StatusBadRequest {
+		t.Errorf("truncated request: HTTP status %d", rsp.StatusCode)
+	}
+
+	// Handler returns nil
+	mux.HandleFunc(OpCancelJob,
+		func(ctx context.Context, req *Message, doc io.Reader) *Message {
+			return nil
+		})
+
+	req := NewRequestWithDefaults(DefaultVersion, OpCancelJob, 1)
+	ippRsp, err := NewClient(nil).Do(context.Background(), srv.URL,
+		req, nil)
+	assertNoError(t, err)
+	if ippRsp.Code != Code(StatusErrorInternal) {
+		t.Errorf("nil response: status %s", Status(ippRsp.Code))
+	}
+
+	// DecoderOptions limits
+	data, err := NewRequestWithDefaults(DefaultVersion,
+		OpGetPrinterAttributes, 1).EncodeBytes()
+	assertNoError(t, err)
+
+	mux.DecoderOptions.MaxAttributes = 1
+	rsp, err = http.Post(srv.URL, ContentType, bytes.NewReader(data))
+	assertNoError(t, err)
+	rsp.Body.Close()
+	if rsp.StatusCode != http.StatusBadRequest {
+		t.Errorf("MaxAttributes: HTTP status %d", rsp.StatusCode)
+	}
+
+	mux.DecoderOptions = DecoderOptions{MaxMessageSize: len(data) - 1}
+	rsp, err = http.Post(srv.URL, ContentType, bytes.NewReader(data))
+	assertNoError(t, err)
+	rsp.Body.Close()
+	if rsp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("MaxMessageSize: HTTP status %d", rsp.StatusCode)
+	}
+}
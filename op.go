@@ -10,6 +10,7 @@ package goipp
 
 import (
 	"fmt"
+	"sync"
 )
 
 // Op represents an IPP Operation Code
@@ -150,9 +151,87 @@ func (op Op) String() string {
 		}
 	}
 
+	vendorOpNamesLock.RLock()
+	s := vendorOpNames[op]
+	vendorOpNamesLock.RUnlock()
+
+	if s != "" {
+		return s
+	}
+
 	return fmt.Sprintf("0x%4.4x", int(op))
 }
 
+// IsStandard reports whether op belongs to the range of standard
+// operations (0x0002-0x3fff), as defined by RFC 8011, 5.4.15.
+func (op Op) IsStandard() bool {
+	return op >= 0x0002 && op <= 0x3fff
+}
+
+// IsVendor reports whether op belongs to the range of vendor
+// extension operations (0x4000-0x7fff), as defined by
+// RFC 8011, 5.4.15.
+func (op Op) IsVendor() bool {
+	return op >= 0x4000 && op <= 0x7fff
+}
+
+// IsCUPS reports whether op belongs to the range of vendor
+// operations, used by CUPS (0x4000-0x4fff).
+func (op Op) IsCUPS() bool {
+	return op >= 0x4000 && op <= 0x4fff
+}
+
+// RegisterOpName registers name of the vendor-specific operation,
+// so it will be returned by Op.String and recognized by the
+// decoders of text representations of messages.
+//
+// Only operations within the vendor range can be registered,
+// and names of the operations, known by this package, cannot
+// be redefined.
+func RegisterOpName(op Op, name string) error {
+	switch {
+	case !op.IsVendor():
+		return fmt.Errorf("Op %s is not vendor-specific", op)
+	case int(op) < len(opNames) && opNames[op] != "":
+		return fmt.Errorf("Op %s is already defined", op)
+	case name == "":
+		return fmt.Errorf("Op %s: empty name", op)
+	}
+
+	vendorOpNamesLock.Lock()
+	vendorOpNames[op] = name
+	vendorOpNamesLock.Unlock()
+
+	return nil
+}
+
+// opByName returns Op by its name
+func opByName(name string) (Op, bool) {
+	for op, s := range opNames {
+		if s != "" && s == name {
+			return Op(op), true
+		}
+	}
+
+	vendorOpNamesLock.RLock()
+	defer vendorOpNamesLock.RUnlock()
+
+	for op, s := range vendorOpNames {
+		if s == name {
+			return op, true
+		}
+	}
+
+	return 0, false
+}
+
+// vendorOpNames contains names of the vendor-specific operations,
+// registered by RegisterOpName
+var (
+	vendorOpNames     = make(map[Op]string)
+	vendorOpNamesLock sync.RWMutex
+)
+
 var opNames = [...]string{
 	OpPrintJob:                        "Print-Job",
 	OpPrintURI:                        "Print-URI",
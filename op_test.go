@@ -0,0 +1,64 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * IPP Operation Codes test
+ */
+
+package goipp
+
+import (
+	"testing"
+)
+
+// TestOpRanges tests Op.IsStandard, Op.IsVendor and Op.IsCUPS
+func TestOpRanges(t *testing.T) {
+	tests := []struct {
+		op                     Op
+		standard, vendor, cups bool
+	}{
+		{0x0000, false, false, false},
+		{OpPrintJob, true, false, false},
+		{0x3fff, true, false, false},
+		{OpCupsGetDefault, false, true, true},
+		{0x4fff, false, true, true},
+		{0x5000, false, true, false},
+		{0x7fff, false, true, false},
+		{0x8000, false, false, false},
+	}
+
+	for _, test := range tests {
+		if test.op.IsStandard() != test.standard ||
+			test.op.IsVendor() != test.vendor ||
+			test.op.IsCUPS() != test.cups {
+			t.Errorf("0x%4.4x: range misdetected", int(test.op))
+		}
+	}
+}
+
+// TestRegisterOpName tests RegisterOpName
+func TestRegisterOpName(t *testing.T) {
+	op := Op(0x6001)
+
+	err := RegisterOpName(op, "Acme-Get-Toner-Level")
+	assertNoError(t, err)
+
+	if s := op.String(); s != "Acme-Get-Toner-Level" {
+		t.Errorf("Op.String: expected %q, present %q",
+			"Acme-Get-Toner-Level", s)
+	}
+
+	if found, ok := opByName("Acme-Get-Toner-Level"); !ok || found != op {
+		t.Errorf("opByName: 0x%4.4x not found", int(op))
+	}
+
+	err = RegisterOpName(OpPrintJob, "My-Print-Job")
+	assertErrorIs(t, err, "Op Print-Job is not vendor-specific")
+
+	err = RegisterOpName(OpCupsGetDefault, "My-Get-Default")
+	assertErrorIs(t, err, "Op CUPS-Get-Default is already defined")
+
+	err = RegisterOpName(0x6002, "")
+	assertErrorIs(t, err, "Op 0x6002: empty name")
+}
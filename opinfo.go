@@ -0,0 +1,275 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Operations metadata
+ */
+
+package goipp
+
+// opTarget defines the target of the operation, which
+// implies the set of required operation attributes
+type opTarget int
+
+const (
+	opTargetNone         opTarget = iota // No specific target
+	opTargetPrinter                      // Target is printer
+	opTargetJob                          // Target is job
+	opTargetSubscription                 // Target is subscription
+	opTargetSystem                       // Target is system
+)
+
+// opInfo contains metadata of the operation
+type opInfo struct {
+	target   opTarget // Operation target
+	required []string // Required attributes, in addition to target
+}
+
+// opInfoRegistry contains metadata of known operations
+//
+// Required operation attributes are taken from RFC 8011, RFC 3995,
+// PWG 5100.x and PWG 5100.22 for system operations
+var opInfoRegistry = map[Op]opInfo{
+	OpPrintJob:                    {opTargetPrinter, nil},
+	OpPrintURI:                    {opTargetPrinter, []string{"document-uri"}},
+	OpValidateJob:                 {opTargetPrinter, nil},
+	OpCreateJob:                   {opTargetPrinter, nil},
+	OpSendDocument:                {opTargetJob, []string{"last-document"}},
+	OpSendURI:                     {opTargetJob, []string{"document-uri", "last-document"}},
+	OpCancelJob:                   {opTargetJob, nil},
+	OpGetJobAttributes:            {opTargetJob, nil},
+	OpGetJobs:                     {opTargetPrinter, nil},
+	OpGetPrinterAttributes:        {opTargetPrinter, nil},
+	OpHoldJob:                     {opTargetJob, nil},
+	OpReleaseJob:                  {opTargetJob, nil},
+	OpRestartJob:                  {opTargetJob, nil},
+	OpPausePrinter:                {opTargetPrinter, nil},
+	OpResumePrinter:               {opTargetPrinter, nil},
+	OpPurgeJobs:                   {opTargetPrinter, nil},
+	OpSetPrinterAttributes:        {opTargetPrinter, nil},
+	OpSetJobAttributes:            {opTargetJob, nil},
+	OpGetPrinterSupportedValues:   {opTargetPrinter, nil},
+	OpCreatePrinterSubscriptions:  {opTargetPrinter, nil},
+	OpCreateJobSubscriptions:      {opTargetJob, nil},
+	OpGetSubscriptionAttributes:   {opTargetSubscription, nil},
+	OpGetSubscriptions:            {opTargetPrinter, nil},
+	OpRenewSubscription:           {opTargetSubscription, nil},
+	OpCancelSubscription:          {opTargetSubscription, nil},
+	OpGetNotifications:            {opTargetPrinter, []string{"notify-subscription-ids"}},
+	OpEnablePrinter:               {opTargetPrinter, nil},
+	OpDisablePrinter:              {opTargetPrinter, nil},
+	OpPausePrinterAfterCurrentJob: {opTargetPrinter, nil},
+	OpHoldNewJobs:                 {opTargetPrinter, nil},
+	OpReleaseHeldNewJobs:          {opTargetPrinter, nil},
+	OpDeactivatePrinter:           {opTargetPrinter, nil},
+	OpActivatePrinter:             {opTargetPrinter, nil},
+	OpRestartPrinter:              {opTargetPrinter, nil},
+	OpShutdownPrinter:             {opTargetPrinter, nil},
+	OpStartupPrinter:              {opTargetPrinter, nil},
+	OpReprocessJob:                {opTargetJob, nil},
+	OpCancelCurrentJob:            {opTargetPrinter, nil},
+	OpSuspendCurrentJob:           {opTargetPrinter, nil},
+	OpResumeJob:                   {opTargetJob, nil},
+	OpPromoteJob:                  {opTargetJob, nil},
+	OpScheduleJobAfter:            {opTargetJob, []string{"job-after-id"}},
+	OpCancelDocument:              {opTargetJob, []string{"document-number"}},
+	OpGetDocumentAttributes:       {opTargetJob, []string{"document-number"}},
+	OpGetDocuments:                {opTargetJob, nil},
+	OpSetDocumentAttributes:       {opTargetJob, []string{"document-number"}},
+	OpCancelJobs:                  {opTargetPrinter, nil},
+	OpCancelMyJobs:                {opTargetPrinter, nil},
+	OpResubmitJob:                 {opTargetJob, nil},
+	OpCloseJob:                    {opTargetJob, nil},
+	OpIdentifyPrinter:             {opTargetPrinter, nil},
+	OpValidateDocument:            {opTargetJob, nil},
+	OpAcknowledgeIdentifyPrinter:  {opTargetPrinter, nil},
+	OpGetPrinters:                 {opTargetSystem, nil},
+	OpGetSystemAttributes:         {opTargetSystem, nil},
+	OpGetSystemSupportedValues:    {opTargetSystem, nil},
+	OpSetSystemAttributes:         {opTargetSystem, nil},
+	OpCreatePrinter:               {opTargetSystem, nil},
+	OpDeletePrinter:               {opTargetSystem, []string{"printer-id"}},
+	OpDisableAllPrinters:          {opTargetSystem, nil},
+	OpEnableAllPrinters:           {opTargetSystem, nil},
+	OpPauseAllPrinters:            {opTargetSystem, nil},
+	OpResumeAllPrinters:           {opTargetSystem, nil},
+	OpRestartSystem:               {opTargetSystem, nil},
+	OpShutdownAllPrinters:         {opTargetSystem, nil},
+	OpStartupAllPrinters:          {opTargetSystem, nil},
+	OpCupsGetDefault:              {opTargetNone, nil},
+	OpCupsGetPrinters:             {opTargetNone, nil},
+	OpCupsAddModifyPrinter:        {opTargetPrinter, nil},
+	OpCupsDeletePrinter:           {opTargetPrinter, nil},
+	OpCupsGetClasses:              {opTargetNone, nil},
+	OpCupsAddModifyClass:          {opTargetPrinter, nil},
+	OpCupsDeleteClass:             {opTargetPrinter, nil},
+	OpCupsAcceptJobs:              {opTargetPrinter, nil},
+	OpCupsRejectJobs:              {opTargetPrinter, nil},
+	OpCupsSetDefault:              {opTargetPrinter, nil},
+	OpCupsGetDevices:              {opTargetNone, nil},
+	OpCupsGetPpds:                 {opTargetNone, nil},
+	OpCupsMoveJob:                 {opTargetJob, []string{"job-printer-uri"}},
+	OpCupsAuthenticateJob:         {opTargetJob, nil},
+	OpCupsGetPpd:                  {opTargetNone, nil},
+	OpCupsGetDocument:             {opTargetJob, []string{"document-number"}},
+	OpCupsCreateLocalPrinter:      {opTargetNone, nil},
+}
+
+// RequiredOperationAttributes returns names of operation attributes,
+// required for the operation.
+//
+// The list always starts with "attributes-charset" and
+// "attributes-natural-language", in this order, as required by
+// RFC 8011. For operations, targeted at job, job is identified by
+// "printer-uri" and "job-id"; note, "job-uri" is the allowed
+// alternative to this pair.
+//
+// For unknown operations, only "attributes-charset" and
+// "attributes-natural-language" are returned.
+func RequiredOperationAttributes(op Op) []string {
+	required := []string{"attributes-charset", "attributes-natural-language"}
+
+	info := opInfoRegistry[op]
+	switch info.target {
+	case opTargetPrinter:
+		required = append(required, "printer-uri")
+	case opTargetJob:
+		required = append(required, "printer-uri", "job-id")
+	case opTargetSubscription:
+		required = append(required, "printer-uri", "notify-subscription-id")
+	case opTargetSystem:
+		required = append(required, "system-uri")
+	}
+
+	return append(required, info.required...)
+}
+
+// FillDefaults inserts missed required operation attributes into
+// the request message, if it can choose a sensible value for them.
+//
+// Currently, it inserts "attributes-charset" (as "utf-8") and
+// "attributes-natural-language" (as DefaultLanguage). These attributes,
+// inserted or already present, are placed first and second in the
+// operation attributes, as required by RFC 8011, 4.1.4. Other required
+// attributes, if missed, cannot be defaulted and are returned to
+// the caller.
+//
+// If m.Groups is set, only m.Groups is examined and updated, as it
+// takes precedence over the named per-group fields (i.e., m.Operation).
+// Otherwise, m.Operation is used.
+func FillDefaults(m *Message) (missed []string) {
+	var defaults Attributes
+
+	op := Op(m.Code)
+	ops := m.Operation
+	if m.Groups != nil {
+		ops = nil
+		for _, grp := range m.Groups {
+			if grp.GroupTag() == GroupTagOperation {
+				ops = grp.Attrs
+				break
+			}
+		}
+	}
+
+	for _, name := range RequiredOperationAttributes(op) {
+		if _, found := attrsFind(ops, name); found {
+			continue
+		}
+
+		switch {
+		case name == "attributes-charset":
+			defaults.Add(MakeAttribute(name, TagCharset,
+				String("utf-8")))
+		case name == "attributes-natural-language":
+			defaults.Add(MakeAttribute(name, TagLanguage,
+				String(DefaultLanguage)))
+		case (name == "printer-uri" || name == "job-id") &&
+			opInfoRegistry[op].target == opTargetJob:
+			// job-uri is the alternative to printer-uri+job-id
+			if _, found := attrsFind(ops, "job-uri"); !found {
+				missed = append(missed, name)
+			}
+		default:
+			missed = append(missed, name)
+		}
+	}
+
+	// Insert defaults and make sure attributes-charset and
+	// attributes-natural-language come first, in this order
+	// (RFC 8011, 4.1.4)
+	ops, reordered := attrsMoveFirst(append(defaults, ops...),
+		"attributes-charset", "attributes-natural-language")
+
+	if len(defaults) == 0 && !reordered {
+		return
+	}
+
+	if m.Groups == nil {
+		m.Operation = ops
+		return
+	}
+
+	for i := range m.Groups {
+		if m.Groups[i].GroupTag() == GroupTagOperation {
+			m.Groups[i].Attrs = ops
+			return
+		}
+	}
+
+	m.Groups = append(Groups{MakeGroup(GroupTagOperation, ops)},
+		m.Groups...)
+
+	return
+}
+
+// attrsMoveFirst returns attributes with the named attributes moved
+// to the beginning, in the order of names. Order of other attributes
+// is preserved. If order changed, attributes are copied and the
+// changed flag is returned.
+func attrsMoveFirst(attrs Attributes, names ...string) (Attributes, bool) {
+	out := make(Attributes, 0, len(attrs))
+	for _, name := range names {
+		for _, attr := range attrs {
+			if attr.Name == name {
+				out = append(out, attr)
+			}
+		}
+	}
+
+	leading := len(out)
+	for _, attr := range attrs {
+		if !attrsMoveFirstNamed(attr.Name, names) {
+			out = append(out, attr)
+		}
+	}
+
+	for i := 0; i < leading; i++ {
+		if out[i].Name != attrs[i].Name {
+			return out, true
+		}
+	}
+
+	return attrs, false
+}
+
+// attrsMoveFirstNamed reports whether name is one of names
+func attrsMoveFirstNamed(name string, names []string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// attrsFind finds attribute by name
+func attrsFind(attrs Attributes, name string) (Attribute, bool) {
+	for _, attr := range attrs {
+		if attr.Name == name {
+			return attr, true
+		}
+	}
+	return Attribute{}, false
+}
@@ -0,0 +1,111 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Operations metadata test
+ */
+
+package goipp
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestRequiredOperationAttributes tests RequiredOperationAttributes
+func TestRequiredOperationAttributes(t *testing.T) {
+	type testData struct {
+		op       Op
+		required []string
+	}
+
+	tests := []testData{
+		{OpGetPrinterAttributes, []string{"attributes-charset",
+			"attributes-natural-language", "printer-uri"}},
+		{OpSendDocument, []string{"attributes-charset",
+			"attributes-natural-language", "printer-uri", "job-id",
+			"last-document"}},
+		{OpCupsGetDefault, []string{"attributes-charset",
+			"attributes-natural-language"}},
+		{Op(0x7fff), []string{"attributes-charset",
+			"attributes-natural-language"}},
+	}
+
+	for _, test := range tests {
+		required := RequiredOperationAttributes(test.op)
+		if !reflect.DeepEqual(required, test.required) {
+			t.Errorf("%s: expected %v, present %v",
+				test.op, test.required, required)
+		}
+	}
+}
+
+// TestFillDefaults tests FillDefaults
+func TestFillDefaults(t *testing.T) {
+	// Named groups
+	m := NewRequest(DefaultVersion, OpCancelJob, 1)
+	m.Operation.Add(MakeAttr("job-uri", TagURI,
+		String("ipp://localhost/jobs/1")))
+
+	missed := FillDefaults(m)
+	if missed != nil {
+		t.Errorf("FillDefaults: unexpected missed %v", missed)
+	}
+
+	if len(m.Operation) != 3 ||
+		m.Operation[0].Name != "attributes-charset" ||
+		m.Operation[1].Name != "attributes-natural-language" {
+		t.Errorf("FillDefaults: defaults not inserted properly")
+	}
+
+	// Groups
+	m = NewMessageWithGroups(DefaultVersion, Code(OpGetJobs), 1,
+		Groups{{TagJobGroup, Attributes{}}})
+
+	missed = FillDefaults(m)
+	if !reflect.DeepEqual(missed, []string{"printer-uri"}) {
+		t.Errorf("FillDefaults: unexpected missed %v", missed)
+	}
+
+	if len(m.Groups) != 2 || m.Groups[0].Tag != TagOperationGroup ||
+		len(m.Groups[0].Attrs) != 2 {
+		t.Errorf("FillDefaults: Groups not updated properly")
+	}
+
+	if m.Operation != nil {
+		t.Errorf("FillDefaults: Operation updated while Groups in use")
+	}
+
+	// Only attributes-natural-language is missed: it must
+	// follow the existing attributes-charset
+	m = NewRequest(DefaultVersion, OpGetPrinterAttributes, 1)
+	m.Operation.Add(MakeAttr("printer-uri", TagURI,
+		String("ipp://localhost/printers/test")))
+	m.Operation.Add(MakeAttr("attributes-charset", TagCharset,
+		String("utf-8")))
+
+	missed = FillDefaults(m)
+	if missed != nil {
+		t.Errorf("FillDefaults: unexpected missed %v", missed)
+	}
+
+	names := []string{}
+	for _, attr := range m.Operation {
+		names = append(names, attr.Name)
+	}
+
+	expected := []string{"attributes-charset",
+		"attributes-natural-language", "printer-uri"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("FillDefaults: wrong order:\nexpected: %v\npresent:  %v",
+			expected, names)
+	}
+
+	// Nothing is missed and order is right: message is not changed
+	ops := m.Operation
+	FillDefaults(m)
+	if &ops[0] != &m.Operation[0] {
+		t.Errorf("FillDefaults: Operation changed without a reason")
+	}
+}
@@ -0,0 +1,217 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Handling of oversize attribute values
+ */
+
+package goipp
+
+import (
+	"fmt"
+)
+
+// OversizePolicy defines how the encoder handles attribute values,
+// which don't fit the wire format limit (MaxAttrValueLength).
+//
+// Such values cannot be represented in the IPP message at all,
+// but may come from elsewhere, i.e., from vendor blobs, captured
+// from the wire and decoded with workarounds.
+type OversizePolicy int
+
+// Oversize policies:
+const (
+	// OversizeReject causes encoding to fail. This is the default.
+	OversizeReject OversizePolicy = iota
+
+	// OversizeTruncate truncates oversize values and reports
+	// it via EncoderOptions.Warning. String values are truncated
+	// at the UTF-8 character boundary.
+	//
+	// Only octetString and simple string values can be truncated.
+	// Other oversize values are rejected.
+	OversizeTruncate
+
+	// OversizeSplit splits oversize octetString values into
+	// multiple values of the same attribute, each of them
+	// within the limit. Note, this changes attribute from
+	// single value to 1setOf.
+	//
+	// Other oversize values are rejected.
+	OversizeSplit
+)
+
+// oversizeHandler applies OversizePolicy to attributes
+type oversizeHandler struct {
+	opt *EncoderOptions // Encoder options
+}
+
+// oversizeNeeded returns true, if EncoderOptions require
+// oversize values handling
+func oversizeNeeded(opt *EncoderOptions) bool {
+	if opt.Oversize != OversizeReject {
+		return true
+	}
+
+	for _, policy := range opt.OversizeAttrs {
+		if policy != OversizeReject {
+			return true
+		}
+	}
+
+	return false
+}
+
+// apply applies OversizePolicy to the message and returns its
+// shallow copy, if something has changed, or message itself
+func (h oversizeHandler) apply(m *Message) (*Message, error) {
+	groups := m.attrGroups()
+	groups2 := make(Groups, len(groups))
+	changed := false
+
+	for i, grp := range groups {
+		attrs, chg, err := h.attrs(grp.Attrs, Path{})
+		if err != nil {
+			return nil, err
+		}
+
+		groups2[i] = Group{grp.Tag, attrs}
+		changed = changed || chg
+	}
+
+	if !changed {
+		return m, nil
+	}
+
+	m2 := *m
+	m2.Groups = groups2
+
+	return &m2, nil
+}
+
+// attrs applies OversizePolicy to attributes. Attributes are
+// copied only if something has changed, and the changed flag
+// is returned.
+func (h oversizeHandler) attrs(attrs Attributes, path Path) (
+	attrs2 Attributes, changed bool, err error) {
+
+	attrs2 = attrs
+
+	for i, attr := range attrs {
+		values, chg, err := h.values(attr, path.Append(attr.Name))
+		if err != nil {
+			return nil, false, err
+		}
+
+		if chg {
+			if !changed {
+				attrs2 = attrs.Clone()
+				changed = true
+			}
+			attrs2[i].Values = values
+		}
+	}
+
+	return
+}
+
+// values applies OversizePolicy to values of the attribute, addressed
+// by path. Values are copied only if something has changed.
+func (h oversizeHandler) values(attr Attribute, path Path) (
+	values Values, changed bool, err error) {
+
+	policy := h.opt.Oversize
+	if p, found := h.opt.OversizeAttrs[attr.Name]; found {
+		policy = p
+	}
+
+	for i, val := range attr.Values {
+		var out Values
+
+		if col, ok := val.V.(Collection); ok {
+			col2, chg, err := h.attrs(Attributes(col), path)
+			if err != nil {
+				return nil, false, err
+			}
+
+			if chg {
+				out = Values{{val.T, Collection(col2)}}
+			}
+		} else {
+			out, err = h.value(path, val.T, val.V, policy)
+			if err != nil {
+				return nil, false, err
+			}
+		}
+
+		if out != nil && !changed {
+			values = make(Values, i, len(attr.Values)+len(out))
+			copy(values, attr.Values[:i])
+			changed = true
+		}
+
+		switch {
+		case out != nil:
+			values = append(values, out...)
+		case changed:
+			values = append(values, val)
+		}
+	}
+
+	return
+}
+
+// value applies OversizePolicy to the single value. It returns
+// replacement values or nil, if value is not changed.
+func (h oversizeHandler) value(path Path, tag Tag, v Value,
+	policy OversizePolicy) (Values, error) {
+
+	max := MaxAttrValueLength
+	if tag >= 0x100 {
+		max -= 4 // Extension tag is prepended to the value
+	}
+
+	data, err := valueEncode(v)
+	if err != nil || len(data) <= max {
+		return nil, err
+	}
+
+	switch policy {
+	case OversizeTruncate:
+		var v2 Value
+		switch v := v.(type) {
+		case Binary:
+			v2 = v[:max]
+		case String:
+			v2 = String(truncateUTF8(string(v), max))
+		}
+
+		if v2 != nil {
+			h.warning(fmt.Errorf("%s: %s value truncated "+
+				"from %d to %d bytes", path, tag, len(data), max))
+			return Values{{tag, v2}}, nil
+		}
+
+	case OversizeSplit:
+		if v, ok := v.(Binary); ok {
+			var values Values
+			for len(v) > max {
+				values.Add(tag, v[:max])
+				v = v[max:]
+			}
+			values.Add(tag, v)
+			return values, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s: %s value exceeds %d bytes",
+		path, tag, max)
+}
+
+// warning reports a warning via EncoderOptions.Warning callback
+func (h oversizeHandler) warning(err error) {
+	if h.opt.Warning != nil {
+		h.opt.Warning(err)
+	}
+}
@@ -0,0 +1,207 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Handling of oversize attribute values test
+ */
+
+package goipp
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestOversize tests EncoderOptions.Oversize policies
+func TestOversize(t *testing.T) {
+	blob := Binary(bytes.Repeat([]byte{0xaa}, 2*MaxAttrValueLength+10))
+	text := String(strings.Repeat("ж", MaxAttrValueLength))
+
+	m := NewResponse(DefaultVersion, StatusOk, 1)
+	m.Printer.Add(MakeAttr("vendor-blob", TagString, blob))
+	m.Printer.Add(MakeAttr("vendor-text", TagText, text))
+	m.Printer.Add(MakeAttrCollection("vendor-col",
+		MakeAttribute("vendor-blob", TagString, blob)))
+
+	type testData struct {
+		opt      EncoderOptions // Encoder options
+		err      string         // Expected error
+		warnings int            // Expected count of warnings
+		blob     []int          // Expected sizes of vendor-blob values
+		text     int            // Expected size of vendor-text
+	}
+
+	tests := []testData{
+		{
+			opt: EncoderOptions{},
+			err: "Attribute value exceeds 32767 bytes",
+		},
+		{
+			opt: EncoderOptions{
+				OversizeAttrs: map[string]OversizePolicy{
+					"vendor-blob": OversizeReject,
+				},
+			},
+			err: "Attribute value exceeds 32767 bytes",
+		},
+		{
+			opt:      EncoderOptions{Oversize: OversizeTruncate},
+			warnings: 3,
+			blob:     []int{MaxAttrValueLength},
+			text:     MaxAttrValueLength - 1,
+		},
+		{
+			opt: EncoderOptions{Oversize: OversizeSplit},
+			err: "vendor-text: textWithoutLanguage value " +
+				"exceeds 32767 bytes",
+		},
+		{
+			opt: EncoderOptions{
+				Oversize: OversizeSplit,
+				OversizeAttrs: map[string]OversizePolicy{
+					"vendor-text": OversizeTruncate,
+				},
+			},
+			warnings: 1,
+			blob: []int{MaxAttrValueLength, MaxAttrValueLength,
+				10},
+			text: MaxAttrValueLength - 1,
+		},
+		{
+			opt: EncoderOptions{
+				Oversize: OversizeTruncate,
+				OversizeAttrs: map[string]OversizePolicy{
+					"vendor-blob": OversizeReject,
+				},
+			},
+			err: "vendor-blob: octetString value exceeds 32767 bytes",
+		},
+	}
+
+	for i, test := range tests {
+		warnings := 0
+		test.opt.Warning = func(error) { warnings++ }
+
+		data, err := m.EncodeBytesEx(test.opt)
+		if test.err != "" {
+			assertErrorIs(t, err, test.err)
+			continue
+		}
+
+		assertNoError(t, err)
+		if warnings != test.warnings {
+			t.Errorf("%d: %d warnings, expected %d",
+				i, warnings, test.warnings)
+		}
+
+		var m2 Message
+		err = m2.DecodeBytes(data)
+		assertNoError(t, err)
+
+		for _, attrs := range []Attributes{
+			m2.Printer,
+			Attributes(m2.Printer[2].Values[0].V.(Collection)),
+		} {
+			var sizes []int
+			for _, v := range attrs[0].Values {
+				sizes = append(sizes, len(v.V.(Binary)))
+			}
+
+			if len(sizes) != len(test.blob) {
+				t.Errorf("%d: vendor-blob sizes %v, expected %v",
+					i, sizes, test.blob)
+				continue
+			}
+
+			for j := range sizes {
+				if sizes[j] != test.blob[j] {
+					t.Errorf("%d: vendor-blob sizes %v, "+
+						"expected %v", i, sizes, test.blob)
+				}
+			}
+		}
+
+		if n := len(m2.Printer[1].Values[0].V.(String)); n != test.text {
+			t.Errorf("%d: vendor-text size %d, expected %d",
+				i, n, test.text)
+		}
+	}
+
+	// The message itself must not be modified
+	if len(m.Printer[0].Values) != 1 ||
+		len(m.Printer[0].Values[0].V.(Binary)) != len(blob) {
+		t.Errorf("Message modified by encoder")
+	}
+}
+
+// TestEncodeAtomic tests EncoderOptions.Atomic
+func TestEncodeAtomic(t *testing.T) {
+	blob := Binary(bytes.Repeat([]byte{0xaa}, MaxAttrValueLength+1))
+
+	bad := NewResponse(DefaultVersion, StatusOk, 1)
+	bad.Printer.Add(MakeAttr("printer-name", TagName, String("printer")))
+	bad.Printer.Add(MakeAttr("vendor-blob", TagString, blob))
+
+	good := NewResponse(DefaultVersion, StatusOk, 1)
+	good.Printer.Add(MakeAttr("printer-name", TagName, String("printer")))
+
+	prefix := []byte("prefix")
+
+	// Non-atomic encoding leaves garbage in the buffer
+	buf := bytes.NewBuffer(append([]byte(nil), prefix...))
+	err := bad.EncodeEx(buf, EncoderOptions{})
+	assertWithError(t, err)
+	if buf.Len() == len(prefix) {
+		t.Errorf("non-atomic: half-encoded message expected")
+	}
+
+	// Atomic encoding into bytes.Buffer rolls back
+	buf = bytes.NewBuffer(append([]byte(nil), prefix...))
+	err = bad.EncodeEx(buf, EncoderOptions{Atomic: true})
+	assertWithError(t, err)
+	if !bytes.Equal(buf.Bytes(), prefix) {
+		t.Errorf("atomic (bytes.Buffer): buffer not rolled back")
+	}
+
+	// Atomic encoding into generic io.Writer writes nothing
+	var w atomicTestWriter
+	err = bad.EncodeEx(&w, EncoderOptions{Atomic: true})
+	assertWithError(t, err)
+	if w.writes != 0 {
+		t.Errorf("atomic (io.Writer): %d writes on error", w.writes)
+	}
+
+	// On success, output is the same as without Atomic, written
+	// by single Write
+	expected, err := good.EncodeBytes()
+	assertNoError(t, err)
+
+	buf = bytes.NewBuffer(append([]byte(nil), prefix...))
+	err = good.EncodeEx(buf, EncoderOptions{Atomic: true})
+	assertNoError(t, err)
+	if !bytes.Equal(buf.Bytes(), append(prefix, expected...)) {
+		t.Errorf("atomic (bytes.Buffer): output mismatch")
+	}
+
+	w = atomicTestWriter{}
+	err = good.EncodeEx(&w, EncoderOptions{Atomic: true})
+	assertNoError(t, err)
+	if w.writes != 1 || !bytes.Equal(w.buf.Bytes(), expected) {
+		t.Errorf("atomic (io.Writer): %d writes, output match: %v",
+			w.writes, bytes.Equal(w.buf.Bytes(), expected))
+	}
+}
+
+// atomicTestWriter is the io.Writer that counts calls to Write
+type atomicTestWriter struct {
+	buf    bytes.Buffer
+	writes int
+}
+
+// Write implements io.Writer
+func (w *atomicTestWriter) Write(data []byte) (int, error) {
+	w.writes++
+	return w.buf.Write(data)
+}
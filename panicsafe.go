@@ -0,0 +1,37 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Panic-safe decoding
+ */
+
+package goipp
+
+import (
+	"fmt"
+	"io"
+)
+
+// PanicSafeDecode decodes the message, like Message.DecodeEx, but
+// recovers any panic, occurred during decoding, and returns it as
+// error.
+//
+// The decoder is not expected to panic on arbitrary input, and it
+// is verified by tests. PanicSafeDecode is the last line of defense
+// for servers, that accept messages from untrusted sources and
+// cannot afford crashing because of the decoder bug.
+//
+// Note, a panic, raised by callbacks of DecoderOptions (i.e.,
+// Warning or AttributeFilter), is recovered as well.
+func PanicSafeDecode(m *Message, in io.Reader,
+	opt DecoderOptions) (err error) {
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("Decoder panic: %v", p)
+		}
+	}()
+
+	return m.DecodeEx(in, opt)
+}
@@ -0,0 +1,140 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Panic-safe decoding test
+ */
+
+package goipp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// TestPanicSafeDecode tests PanicSafeDecode
+func TestPanicSafeDecode(t *testing.T) {
+	var m Message
+
+	err := PanicSafeDecode(&m, bytes.NewReader(goodMessage1),
+		DecoderOptions{})
+	assertNoError(t, err)
+
+	err = PanicSafeDecode(&m, bytes.NewReader(goodMessage1[:20]),
+		DecoderOptions{})
+	assertErrorIs(t, err, "Message truncated")
+
+	opt := DecoderOptions{
+		AttributeFilter: func(group Tag, name string) bool {
+			panic("filter bug")
+		},
+	}
+
+	err = PanicSafeDecode(&m, bytes.NewReader(goodMessage1), opt)
+	assertErrorIs(t, err, "Decoder panic: filter bug")
+}
+
+// TestNoPanic verifies, using randomly mutated real messages, that
+// decoder and APIs, applied to decoded messages, never panic on
+// arbitrary input
+func TestNoPanic(t *testing.T) {
+	samples := [][]byte{
+		goodMessage1,
+		goodMessage2,
+		attrsHPOfficeJetPro8730,
+		attrsPantumM7300FDW,
+	}
+
+	options := []DecoderOptions{
+		{},
+		{EnableWorkarounds: true},
+		{Arena: true, Names: NewNameTable(0)},
+		{NamelessAcrossGroups: true, Warning: func(error) {}},
+		{DateTimeLocation: time.UTC, ValidateUTF8: UTF8Reject},
+		{AttributeFilter: func(group Tag, name string) bool {
+			return len(name)%2 == 0
+		}},
+	}
+
+	iterations := 3000
+	if testing.Short() {
+		iterations = 300
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < iterations; i++ {
+		data := noPanicMutate(r, samples[r.Intn(len(samples))])
+		opt := options[r.Intn(len(options))]
+		opt.BinaryRefs = nil
+		if r.Intn(4) == 0 {
+			opt.BinaryRefs = bytes.NewReader(data)
+			opt.BinaryRefMin = 4
+		}
+
+		func() {
+			defer func() {
+				if p := recover(); p != nil {
+					t.Errorf("iteration %d: panic: %v\ninput: %x",
+						i, p, data)
+				}
+			}()
+
+			noPanicExercise(data, opt)
+		}()
+	}
+}
+
+// noPanicMutate returns randomly mutated copy of data
+func noPanicMutate(r *rand.Rand, data []byte) []byte {
+	data = append([]byte(nil), data...)
+
+	for n := 1 + r.Intn(8); n > 0 && len(data) > 0; n-- {
+		i := r.Intn(len(data))
+		switch r.Intn(5) {
+		case 0: // Flip random bits
+			data[i] ^= byte(1 << uint(r.Intn(8)))
+		case 1: // Random byte
+			data[i] = byte(r.Intn(256))
+		case 2: // Interesting byte (tag or length boundary)
+			data[i] = []byte{0x00, 0x01, 0x03, 0x04, 0x10, 0x13,
+				0x34, 0x37, 0x4a, 0x7f, 0x80, 0xff}[r.Intn(12)]
+		case 3: // Truncate
+			data = data[:i]
+		case 4: // Duplicate a chunk
+			j := i + r.Intn(len(data)-i)
+			chunk := append([]byte(nil), data[i:j]...)
+			data = append(data[:j:j], append(chunk, data[j:]...)...)
+		}
+	}
+
+	return data
+}
+
+// noPanicExercise decodes data and, on success, runs decoded
+// message through various APIs
+func noPanicExercise(data []byte, opt DecoderOptions) {
+	var m Message
+	if m.DecodeBytesEx(data, opt) != nil {
+		return
+	}
+
+	m.Print(ioutil.Discard, true)
+	m.EncodeBytes()
+	json.Marshal(m)
+	NewLinter().Lint(&m)
+	ExplainSimilarDiff(m, m)
+	m.PrinterMetrics()
+	NewAttrIndex(&m)
+
+	f := NewFormatter()
+	f.FmtMessage(&m)
+
+	for _, grp := range m.Groups {
+		grp.Attrs.Flatten(".")
+	}
+}
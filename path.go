@@ -0,0 +1,233 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Attribute paths
+ */
+
+package goipp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PathAny is used as Path.GroupIndex and PathSegment.Index to
+// match any group or value
+const PathAny = -1
+
+// Path addresses attributes within the message, including members
+// of collections. Its string form looks as follows:
+//
+//	job-attributes-tag[1]/media-col[0]/media-size/x-dimension
+//
+// The first element is the group tag name, optionally followed by
+// index of group among groups with the same tag (without index,
+// all groups with this tag are addressed). It may be omitted to
+// address attributes in any group. The subsequent elements are
+// names of attribute and collection members, optionally followed
+// by index of value.
+//
+// The same addressing scheme is used by Message.FindAll, ExplainDiff
+// and Lint.
+type Path struct {
+	Group      Tag           // Group tag, TagZero for any group
+	GroupIndex int           // Group index or PathAny
+	Segments   []PathSegment // Attribute and member names
+}
+
+// PathSegment is the segment of the Path
+type PathSegment struct {
+	Name  string // Attribute or member name
+	Index int    // Value index or PathAny
+}
+
+// MakePath makes the Path from group tag (TagZero for any group)
+// and names of attribute and collection members. All indices are
+// set to PathAny.
+func MakePath(group Tag, names ...string) Path {
+	p := Path{Group: group, GroupIndex: PathAny}
+	for _, name := range names {
+		p.Segments = append(p.Segments, PathSegment{name, PathAny})
+	}
+	return p
+}
+
+// ParsePath parses the string form of the Path
+func ParsePath(s string) (Path, error) {
+	p := Path{GroupIndex: PathAny}
+	if s == "" {
+		return p, fmt.Errorf("Path %q: empty", s)
+	}
+
+	for i, elem := range strings.Split(s, "/") {
+		name, idx, err := pathParseSegment(elem)
+		if err != nil {
+			return Path{}, fmt.Errorf("Path %q: %s", s, err)
+		}
+
+		if i == 0 {
+			if tag, ok := pathGroupByName(name); ok {
+				p.Group, p.GroupIndex = tag, idx
+				continue
+			}
+		}
+
+		p.Segments = append(p.Segments, PathSegment{name, idx})
+	}
+
+	return p, nil
+}
+
+// String returns the string form of the Path
+func (p Path) String() string {
+	var elems []string
+
+	if p.Group != TagZero {
+		elems = append(elems, pathFormatSegment(p.Group.String(),
+			p.GroupIndex))
+	}
+
+	for _, seg := range p.Segments {
+		elems = append(elems, seg.String())
+	}
+
+	return strings.Join(elems, "/")
+}
+
+// String returns the string form of the PathSegment
+func (seg PathSegment) String() string {
+	return pathFormatSegment(seg.Name, seg.Index)
+}
+
+// Append returns the new Path with the segment appended
+func (p Path) Append(name string) Path {
+	segments := make([]PathSegment, len(p.Segments), len(p.Segments)+1)
+	copy(segments, p.Segments)
+	p.Segments = append(segments, PathSegment{name, PathAny})
+	return p
+}
+
+// WithIndex returns the new Path with the index of the last
+// segment set to idx. If Path has no segments, it sets GroupIndex.
+func (p Path) WithIndex(idx int) Path {
+	if len(p.Segments) == 0 {
+		p.GroupIndex = idx
+		return p
+	}
+
+	segments := make([]PathSegment, len(p.Segments))
+	copy(segments, p.Segments)
+	segments[len(segments)-1].Index = idx
+	p.Segments = segments
+
+	return p
+}
+
+// FindAll returns all attributes, addressed by the Path.
+//
+// If the last segment of the Path has index, returned attributes
+// contain only the value at this index. Path without segments
+// addresses nothing.
+func (m *Message) FindAll(p Path) []Attribute {
+	if len(p.Segments) == 0 {
+		return nil
+	}
+
+	var found []Attribute
+	counts := make(map[Tag]int)
+
+	for _, grp := range m.attrGroups() {
+		idx := counts[grp.Tag]
+		counts[grp.Tag]++
+
+		if p.Group != TagZero && (p.Group != grp.Tag ||
+			(p.GroupIndex != PathAny && p.GroupIndex != idx)) {
+			continue
+		}
+
+		found = pathFind(found, grp.Attrs, p.Segments)
+	}
+
+	return found
+}
+
+// pathFind appends attributes, addressed by segments, to found
+func pathFind(found []Attribute, attrs Attributes,
+	segments []PathSegment) []Attribute {
+
+	seg := segments[0]
+
+	for _, attr := range attrs {
+		if attr.Name != seg.Name {
+			continue
+		}
+
+		values := attr.Values
+		if seg.Index != PathAny {
+			if seg.Index >= len(values) {
+				continue
+			}
+			values = values[seg.Index : seg.Index+1]
+		}
+
+		if len(segments) == 1 {
+			found = append(found, Attribute{Name: attr.Name,
+				Values: values})
+			continue
+		}
+
+		for _, val := range values {
+			if col, ok := val.V.(Collection); ok {
+				found = pathFind(found, Attributes(col),
+					segments[1:])
+			}
+		}
+	}
+
+	return found
+}
+
+// pathParseSegment parses the path segment
+func pathParseSegment(s string) (string, int, error) {
+	name, idx := s, PathAny
+
+	if i := strings.IndexByte(s, '['); i >= 0 {
+		if !strings.HasSuffix(s, "]") {
+			return "", 0, fmt.Errorf("%q: missed ']'", s)
+		}
+
+		n, err := strconv.Atoi(s[i+1 : len(s)-1])
+		if err != nil || n < 0 {
+			return "", 0, fmt.Errorf("%q: invalid index", s)
+		}
+
+		name, idx = s[:i], n
+	}
+
+	if name == "" {
+		return "", 0, fmt.Errorf("%q: missed name", s)
+	}
+
+	return name, idx, nil
+}
+
+// pathFormatSegment formats the path segment
+func pathFormatSegment(name string, idx int) string {
+	if idx == PathAny {
+		return name
+	}
+	return name + "[" + strconv.Itoa(idx) + "]"
+}
+
+// pathGroupByName returns group tag by name
+func pathGroupByName(name string) (Tag, bool) {
+	for tag := TagOperationGroup; tag <= TagFuture15Group; tag++ {
+		if tag.IsGroup() && tag.String() == name {
+			return tag, true
+		}
+	}
+	return TagZero, false
+}
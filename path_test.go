@@ -0,0 +1,205 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Attribute paths test
+ */
+
+package goipp
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParsePath tests ParsePath and Path.String
+func TestParsePath(t *testing.T) {
+	type testData struct {
+		s    string // Input string
+		p    Path   // Expected path
+		err  string // Expected error
+		back string // Expected String(), if differs from s
+	}
+
+	tests := []testData{
+		{
+			s: "job-attributes-tag[1]/media-col[0]/media-size/x-dimension",
+			p: Path{TagJobGroup, 1, []PathSegment{
+				{"media-col", 0},
+				{"media-size", PathAny},
+				{"x-dimension", PathAny},
+			}},
+		},
+
+		{
+			s: "printer-attributes-tag/media",
+			p: MakePath(TagPrinterGroup, "media"),
+		},
+
+		{
+			s: "operation-attributes-tag",
+			p: MakePath(TagOperationGroup),
+		},
+
+		{
+			s: "copies[2]",
+			p: Path{TagZero, PathAny, []PathSegment{{"copies", 2}}},
+		},
+
+		{
+			s: "media-col/media-size",
+			p: MakePath(TagZero, "media-col", "media-size"),
+		},
+
+		{
+			s:   "",
+			err: `Path "": empty`,
+		},
+
+		{
+			s:   "media-col//media-size",
+			err: `Path "media-col//media-size": "": missed name`,
+		},
+
+		{
+			s:   "copies[1",
+			err: `Path "copies[1": "copies[1": missed ']'`,
+		},
+
+		{
+			s:   "copies[-1]",
+			err: `Path "copies[-1]": "copies[-1]": invalid index`,
+		},
+
+		{
+			s:   "[1]",
+			err: `Path "[1]": "[1]": missed name`,
+		},
+	}
+
+	for _, test := range tests {
+		p, err := ParsePath(test.s)
+		if test.err != "" {
+			assertErrorIs(t, err, test.err)
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%q: %s", test.s, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(p, test.p) {
+			t.Errorf("%q: parsed as %#v, expected %#v",
+				test.s, p, test.p)
+		}
+
+		back := test.back
+		if back == "" {
+			back = test.s
+		}
+
+		if s := p.String(); s != back {
+			t.Errorf("%q: formatted as %q, expected %q",
+				test.s, s, back)
+		}
+	}
+}
+
+// TestPathAppend tests that Path.Append and Path.WithIndex
+// don't affect the original Path
+func TestPathAppend(t *testing.T) {
+	p := MakePath(TagJobGroup, "media-col")
+	p1 := p.Append("media-size")
+	p2 := p.Append("media-type").WithIndex(0)
+
+	tests := []struct {
+		p        Path
+		expected string
+	}{
+		{p, "job-attributes-tag/media-col"},
+		{p1, "job-attributes-tag/media-col/media-size"},
+		{p2, "job-attributes-tag/media-col/media-type[0]"},
+		{p.WithIndex(3), "job-attributes-tag/media-col[3]"},
+		{MakePath(TagJobGroup).WithIndex(1), "job-attributes-tag[1]"},
+		{Path{}, ""},
+	}
+
+	for _, test := range tests {
+		if s := test.p.String(); s != test.expected {
+			t.Errorf("%#v: expected %q, present %q",
+				test.p, test.expected, s)
+		}
+	}
+}
+
+// TestMessageFindAll tests Message.FindAll
+func TestMessageFindAll(t *testing.T) {
+	mediaCol := func(x, y int) Attribute {
+		return MakeAttrCollection("media-col",
+			MakeAttrCollection("media-size",
+				MakeAttr("x-dimension", TagInteger, Integer(x)),
+				MakeAttr("y-dimension", TagInteger, Integer(y))))
+	}
+
+	m := NewRequest(DefaultVersion, OpPrintJob, 1)
+	m.Groups = Groups{
+		{TagOperationGroup, Attributes{
+			MakeAttr("attributes-charset", TagCharset,
+				String("utf-8")),
+		}},
+		{TagJobGroup, Attributes{
+			MakeAttr("copies", TagInteger, Integer(1), Integer(2)),
+			mediaCol(21000, 29700),
+		}},
+		{TagJobGroup, Attributes{
+			MakeAttr("copies", TagInteger, Integer(3)),
+			mediaCol(21590, 27940),
+		}},
+	}
+
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"copies", []string{"copies: 1,2", "copies: 3"}},
+		{"job-attributes-tag/copies", []string{"copies: 1,2", "copies: 3"}},
+		{"job-attributes-tag[0]/copies", []string{"copies: 1,2"}},
+		{"job-attributes-tag[1]/copies", []string{"copies: 3"}},
+		{"copies[1]", []string{"copies: 2"}},
+		{"copies[5]", nil},
+		{"operation-attributes-tag/copies", nil},
+		{"media-col/media-size/x-dimension", []string{
+			"x-dimension: 21000", "x-dimension: 21590"}},
+		{"job-attributes-tag[1]/media-col[0]/media-size/y-dimension",
+			[]string{"y-dimension: 27940"}},
+		{"media-col/x-dimension", nil},
+		{"operation-attributes-tag", nil},
+	}
+
+	for _, test := range tests {
+		p, err := ParsePath(test.path)
+		if err != nil {
+			t.Errorf("%q: %s", test.path, err)
+			continue
+		}
+
+		var present []string
+		for _, attr := range m.FindAll(p) {
+			s := attr.Name + ": "
+			for i, val := range attr.Values {
+				if i > 0 {
+					s += ","
+				}
+				s += val.V.String()
+			}
+			present = append(present, s)
+		}
+
+		if !reflect.DeepEqual(present, test.expected) {
+			t.Errorf("%q: expected %q, present %q",
+				test.path, test.expected, present)
+		}
+	}
+}
@@ -0,0 +1,543 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Protocol Buffers representation of messages
+ */
+
+package goipp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MarshalProto encodes Message into the Protocol Buffers format,
+// as defined by the goipp.proto schema, shipped with this package.
+//
+// It allows to exchange parsed IPP messages with services, written
+// in other languages, without requiring the protobuf runtime here.
+func (m *Message) MarshalProto() ([]byte, error) {
+	var pe protoEncoder
+
+	pe.putVarint(1, uint64(m.Version))
+	pe.putVarint(2, uint64(m.Code))
+	pe.putVarint(3, uint64(m.RequestID))
+
+	for _, grp := range m.attrGroups() {
+		var pg protoEncoder
+		pg.putVarint(1, uint64(grp.Tag))
+		for _, attr := range grp.Attrs {
+			err := pg.putAttribute(2, attr)
+			if err != nil {
+				return nil, err
+			}
+		}
+		pe.putMessage(4, &pg)
+	}
+
+	return pe.buf, nil
+}
+
+// UnmarshalProto decodes Message from the Protocol Buffers format,
+// as defined by the goipp.proto schema, shipped with this package.
+func (m *Message) UnmarshalProto(data []byte) error {
+	var version Version
+	var code Code
+	var id uint32
+	var groups Groups
+
+	pd := protoDecoder{data}
+	for !pd.done() {
+		f, err := pd.next()
+		if err != nil {
+			return err
+		}
+
+		switch f.num {
+		case 1:
+			version = Version(f.v)
+		case 2:
+			code = Code(f.v)
+		case 3:
+			id = uint32(f.v)
+		case 4:
+			var grp Group
+			grp, err = protoDecodeGroup(f.data)
+			groups = append(groups, grp)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	*m = *NewMessageWithGroups(version, code, id, groups)
+	return nil
+}
+
+// protoEncoder encodes Protocol Buffers messages
+type protoEncoder struct {
+	buf []byte // Output buffer
+}
+
+// putKey writes field key
+func (pe *protoEncoder) putKey(num, wiretype int) {
+	pe.putRawVarint(uint64(num)<<3 | uint64(wiretype))
+}
+
+// putRawVarint writes varint without the key
+func (pe *protoEncoder) putRawVarint(v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	pe.buf = append(pe.buf, tmp[:n]...)
+}
+
+// putVarint writes varint field. Zero values are omitted,
+// as proto3 requires
+func (pe *protoEncoder) putVarint(num int, v uint64) {
+	if v != 0 {
+		pe.putKey(num, 0)
+		pe.putRawVarint(v)
+	}
+}
+
+// putInt32 writes int32 field. Negative values are sign-extended
+// to 64 bits, as protobuf requires
+func (pe *protoEncoder) putInt32(num int, v int32) {
+	pe.putVarint(num, uint64(int64(v)))
+}
+
+// putBytes writes length-delimited field
+func (pe *protoEncoder) putBytes(num int, data []byte) {
+	pe.putKey(num, 2)
+	pe.putRawVarint(uint64(len(data)))
+	pe.buf = append(pe.buf, data...)
+}
+
+// putMessage writes nested message
+func (pe *protoEncoder) putMessage(num int, pe2 *protoEncoder) {
+	pe.putBytes(num, pe2.buf)
+}
+
+// putAttribute writes Attribute
+func (pe *protoEncoder) putAttribute(num int, attr Attribute) error {
+	var pa protoEncoder
+
+	if attr.Name != "" {
+		pa.putBytes(1, []byte(attr.Name))
+	}
+
+	for _, v := range attr.Values {
+		var pv protoEncoder
+		var pval protoEncoder
+
+		pv.putInt32(1, int32(v.T))
+
+		switch val := v.V.(type) {
+		case Void:
+			pv.putMessage(2, &pval)
+
+		case Integer:
+			pv.putKey(3, 0)
+			pv.putRawVarint(uint64(int64(val)))
+
+		case Boolean:
+			pv.putKey(4, 0)
+			if val {
+				pv.putRawVarint(1)
+			} else {
+				pv.putRawVarint(0)
+			}
+
+		case String:
+			pv.putBytes(5, []byte(val))
+
+		case Name, Keyword, MimeType, URI:
+			pv.putBytes(5, []byte(val.String()))
+
+		case Time:
+			_, off := val.Zone()
+			pval.putVarint(1, uint64(val.Unix()))
+			pval.putInt32(2, int32(val.Nanosecond()))
+			pval.putInt32(3, int32(off))
+			pv.putMessage(6, &pval)
+
+		case Resolution:
+			pval.putInt32(1, int32(val.Xres))
+			pval.putInt32(2, int32(val.Yres))
+			pval.putVarint(3, uint64(val.Units))
+			pv.putMessage(7, &pval)
+
+		case Range:
+			pval.putInt32(1, int32(val.Lower))
+			pval.putInt32(2, int32(val.Upper))
+			pv.putMessage(8, &pval)
+
+		case TextWithLang:
+			pval.putBytes(1, []byte(val.Lang))
+			pval.putBytes(2, []byte(val.Text))
+			pv.putMessage(9, &pval)
+
+		case Binary:
+			pv.putBytes(10, val)
+
+		case BinaryRef:
+			data, err := val.Materialize()
+			if err != nil {
+				return fmt.Errorf("%q: %s", attr.Name, err)
+			}
+			pv.putBytes(10, data)
+
+		case Collection:
+			for _, attr2 := range val {
+				err := pval.putAttribute(1, attr2)
+				if err != nil {
+					return err
+				}
+			}
+			pv.putMessage(11, &pval)
+
+		default:
+			return fmt.Errorf("%q: %s: unsupported value type",
+				attr.Name, v.V.Type())
+		}
+
+		pa.putMessage(2, &pv)
+	}
+
+	pe.putMessage(num, &pa)
+	return nil
+}
+
+// protoDecoder decodes Protocol Buffers messages
+type protoDecoder struct {
+	data []byte // Remaining input
+}
+
+// protoField represents a decoded field
+type protoField struct {
+	num  int    // Field number
+	v    uint64 // Value of varint and fixed fields
+	data []byte // Value of length-delimited fields
+}
+
+// done returns true if all input is consumed
+func (pd *protoDecoder) done() bool {
+	return len(pd.data) == 0
+}
+
+// varint decodes next varint
+func (pd *protoDecoder) varint() (uint64, error) {
+	v, n := binary.Uvarint(pd.data)
+	if n <= 0 {
+		return 0, errors.New("protobuf: bad varint")
+	}
+
+	pd.data = pd.data[n:]
+	return v, nil
+}
+
+// next decodes next field
+func (pd *protoDecoder) next() (protoField, error) {
+	var f protoField
+
+	key, err := pd.varint()
+	if err != nil {
+		return f, err
+	}
+
+	f.num = int(key >> 3)
+
+	switch key & 7 {
+	case 0:
+		f.v, err = pd.varint()
+
+	case 1:
+		if len(pd.data) < 8 {
+			return f, errors.New("protobuf: truncated fixed64")
+		}
+		f.v = binary.LittleEndian.Uint64(pd.data)
+		pd.data = pd.data[8:]
+
+	case 2:
+		var l uint64
+		l, err = pd.varint()
+		if err == nil && l > uint64(len(pd.data)) {
+			err = errors.New("protobuf: truncated field")
+		}
+		if err == nil {
+			f.data = pd.data[:l]
+			pd.data = pd.data[l:]
+		}
+
+	case 5:
+		if len(pd.data) < 4 {
+			return f, errors.New("protobuf: truncated fixed32")
+		}
+		f.v = uint64(binary.LittleEndian.Uint32(pd.data))
+		pd.data = pd.data[4:]
+
+	default:
+		err = fmt.Errorf("protobuf: unsupported wire type %d", key&7)
+	}
+
+	return f, err
+}
+
+// protoDecodeGroup decodes Group
+func protoDecodeGroup(data []byte) (Group, error) {
+	grp := Group{Attrs: Attributes{}}
+	hasTag := false
+
+	pd := protoDecoder{data}
+	for !pd.done() {
+		f, err := pd.next()
+		if err != nil {
+			return grp, err
+		}
+
+		switch f.num {
+		case 1:
+			hasTag = true
+			grp.Tag = Tag(int32(f.v))
+			if !grp.Tag.IsGroup() {
+				return grp, fmt.Errorf("Tag %s is not a group tag",
+					grp.Tag)
+			}
+
+		case 2:
+			var attr Attribute
+			attr, err = protoDecodeAttribute(f.data)
+			if err != nil {
+				return grp, err
+			}
+			grp.Attrs.Add(attr)
+		}
+	}
+
+	if !hasTag {
+		return grp, errors.New("protobuf: missed group tag")
+	}
+
+	return grp, nil
+}
+
+// protoDecodeAttribute decodes Attribute
+func protoDecodeAttribute(data []byte) (Attribute, error) {
+	var attr Attribute
+
+	pd := protoDecoder{data}
+	for !pd.done() {
+		f, err := pd.next()
+		if err != nil {
+			return attr, err
+		}
+
+		switch f.num {
+		case 1:
+			attr.Name = string(f.data)
+
+		case 2:
+			var tag Tag
+			var val Value
+			tag, val, err = protoDecodeValue(f.data)
+			if err != nil {
+				return attr, fmt.Errorf("%q: %s", attr.Name, err)
+			}
+			attr.Values.Add(tag, val)
+		}
+	}
+
+	return attr, nil
+}
+
+// protoDecodeValue decodes tagged Value
+func protoDecodeValue(data []byte) (Tag, Value, error) {
+	tag := TagZero
+	var val Value
+
+	pd := protoDecoder{data}
+	for !pd.done() {
+		f, err := pd.next()
+		if err != nil {
+			return tag, nil, err
+		}
+
+		switch f.num {
+		case 1:
+			tag = Tag(int32(f.v))
+		case 2:
+			val = Void{}
+		case 3:
+			val = Integer(int32(f.v))
+		case 4:
+			val = Boolean(f.v != 0)
+		case 5:
+			val = String(f.data)
+		case 6:
+			val, err = protoDecodeTime(f.data)
+		case 7:
+			val, err = protoDecodeResolution(f.data)
+		case 8:
+			val, err = protoDecodeRange(f.data)
+		case 9:
+			val, err = protoDecodeTextWithLang(f.data)
+		case 10:
+			val = Binary(append([]byte{}, f.data...))
+		case 11:
+			val, err = protoDecodeCollection(f.data)
+		}
+
+		if err != nil {
+			return tag, nil, err
+		}
+	}
+
+	switch {
+	case val == nil:
+		return tag, nil, fmt.Errorf("%s: missed value", tag)
+	case tag.Type() == TypeVoid:
+		val = Void{}
+	case tag.Type() != val.Type():
+		return tag, nil, fmt.Errorf("%s: %s value required, %s present",
+			tag, tag.Type(), val.Type())
+	}
+
+	return tag, val, nil
+}
+
+// protoDecodeTime decodes Time value
+func protoDecodeTime(data []byte) (Value, error) {
+	var sec int64
+	var nsec, off int32
+
+	pd := protoDecoder{data}
+	for !pd.done() {
+		f, err := pd.next()
+		if err != nil {
+			return nil, err
+		}
+
+		switch f.num {
+		case 1:
+			sec = int64(f.v)
+		case 2:
+			nsec = int32(f.v)
+		case 3:
+			off = int32(f.v)
+		}
+	}
+
+	// Build time zone the same way, as wire decoder does
+	dir := '+'
+	hours, mins := off/3600, (off/60)%60
+	if off < 0 {
+		dir = '-'
+		hours, mins = -hours, -mins
+	}
+
+	tzName := fmt.Sprintf("UTC%c%d", dir, hours)
+	if mins != 0 {
+		tzName += fmt.Sprintf(":%d", mins)
+	}
+
+	tz := time.FixedZone(tzName, int(off))
+
+	return Time{time.Unix(sec, int64(nsec)).In(tz)}, nil
+}
+
+// protoDecodeResolution decodes Resolution value
+func protoDecodeResolution(data []byte) (Value, error) {
+	var res Resolution
+
+	pd := protoDecoder{data}
+	for !pd.done() {
+		f, err := pd.next()
+		if err != nil {
+			return nil, err
+		}
+
+		switch f.num {
+		case 1:
+			res.Xres = int(int32(f.v))
+		case 2:
+			res.Yres = int(int32(f.v))
+		case 3:
+			res.Units = Units(f.v)
+		}
+	}
+
+	return res, nil
+}
+
+// protoDecodeRange decodes Range value
+func protoDecodeRange(data []byte) (Value, error) {
+	var rng Range
+
+	pd := protoDecoder{data}
+	for !pd.done() {
+		f, err := pd.next()
+		if err != nil {
+			return nil, err
+		}
+
+		switch f.num {
+		case 1:
+			rng.Lower = int(int32(f.v))
+		case 2:
+			rng.Upper = int(int32(f.v))
+		}
+	}
+
+	return rng, nil
+}
+
+// protoDecodeTextWithLang decodes TextWithLang value
+func protoDecodeTextWithLang(data []byte) (Value, error) {
+	var twl TextWithLang
+
+	pd := protoDecoder{data}
+	for !pd.done() {
+		f, err := pd.next()
+		if err != nil {
+			return nil, err
+		}
+
+		switch f.num {
+		case 1:
+			twl.Lang = string(f.data)
+		case 2:
+			twl.Text = string(f.data)
+		}
+	}
+
+	return twl, nil
+}
+
+// protoDecodeCollection decodes Collection value
+func protoDecodeCollection(data []byte) (Value, error) {
+	col := Collection{}
+
+	pd := protoDecoder{data}
+	for !pd.done() {
+		f, err := pd.next()
+		if err != nil {
+			return nil, err
+		}
+
+		if f.num == 1 {
+			var attr Attribute
+			attr, err = protoDecodeAttribute(f.data)
+			if err != nil {
+				return nil, err
+			}
+			col.Add(attr)
+		}
+	}
+
+	return col, nil
+}
@@ -0,0 +1,74 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Protocol Buffers representation test
+ */
+
+package goipp
+
+import (
+	"testing"
+)
+
+// TestProtoRoundTrip tests Message MarshalProto/UnmarshalProto round trip
+func TestProtoRoundTrip(t *testing.T) {
+	messages := []*Message{testEncodeDecodeMessage()}
+
+	for _, data := range [][]byte{goodMessage1, attrsHPOfficeJetPro8730} {
+		m := &Message{}
+		err := m.DecodeBytes(data)
+		assertNoError(t, err)
+		messages = append(messages, m)
+	}
+
+	m := NewRequest(DefaultVersion, OpPrintJob, 1)
+	m.Job.Add(MakeAttr("negative", TagInteger, Integer(-1), Integer(0)))
+	m.Job.Add(MakeAttr("range", TagRange, Range{-100, 0}))
+	m.Job.Add(MakeAttr("binary", TagString, Binary{}, Binary{1, 2, 3}))
+	messages = append(messages, m)
+
+	for _, m1 := range messages {
+		data, err := m1.MarshalProto()
+		assertNoError(t, err)
+
+		var m2 Message
+		err = m2.UnmarshalProto(data)
+		assertNoError(t, err)
+
+		if !m1.Equal(m2) {
+			t.Errorf("Message: not the same after protobuf round trip")
+		}
+	}
+}
+
+// TestProtoErrors tests UnmarshalProto errors
+func TestProtoErrors(t *testing.T) {
+	m := testEncodeDecodeMessage()
+	data, err := m.MarshalProto()
+	assertNoError(t, err)
+
+	// Truncated input must not panic
+	for i := 0; i < len(data); i++ {
+		var m2 Message
+		m2.UnmarshalProto(data[:i])
+	}
+
+	// Tag/value mismatch
+	m = NewRequest(DefaultVersion, OpPrintJob, 1)
+	m.Job.Add(MakeAttr("attr", TagInteger, String("bad")))
+	data, err = m.MarshalProto()
+	assertNoError(t, err)
+
+	err = m.UnmarshalProto(data)
+	assertErrorIs(t, err, `"attr": integer: Integer value required, String present`)
+
+	// Group without tag
+	data = []byte{
+		0x22, 0x00, // Field 4 (group), empty
+	}
+
+	err = m.UnmarshalProto(data)
+	assertErrorIs(t, err, "protobuf: missed group tag")
+}
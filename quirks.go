@@ -0,0 +1,129 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Quirks of known-buggy devices
+ */
+
+package goipp
+
+import (
+	"strings"
+)
+
+// Quirk describes workarounds, required for the known-buggy device.
+//
+// Device is identified by its printer-make-and-model and,
+// optionally, printer-firmware-string-version attributes.
+type Quirk struct {
+	Name string // Human-readable description
+
+	// MakeAndModel is the case-insensitive prefix of
+	// printer-make-and-model of affected devices
+	MakeAndModel string
+
+	// Firmware, if not empty, is the case-insensitive prefix of
+	// printer-firmware-string-version of affected devices
+	Firmware string
+
+	// EnableWorkarounds sets DecoderOptions.EnableWorkarounds
+	EnableWorkarounds bool
+
+	// IgnoreUTF8 disables UTF-8 validation by the decoder, for
+	// devices that send text in the wrong charset
+	IgnoreUTF8 bool
+
+	// Version, if not zero, sets EncoderOptions.Version, for
+	// devices that don't accept requests of other versions
+	Version Version
+}
+
+// Quirks represents set of quirks, applicable to the device
+type Quirks []Quirk
+
+// QuirksDB is the database of quirks of known-buggy devices
+type QuirksDB []Quirk
+
+// DefaultQuirksDB contains quirks of devices, known to goipp
+var DefaultQuirksDB = QuirksDB{
+	{
+		Name:              "Named attributes within collections",
+		MakeAndModel:      "Pantum M7300FDW",
+		EnableWorkarounds: true,
+	},
+}
+
+// Lookup returns quirks of device with the specified
+// printer-make-and-model and printer-firmware-string-version.
+// It returns nil, if device has no known quirks.
+func (db QuirksDB) Lookup(makeAndModel, firmware string) Quirks {
+	var quirks Quirks
+
+	for _, q := range db {
+		if quirksPrefix(makeAndModel, q.MakeAndModel) &&
+			(q.Firmware == "" || quirksPrefix(firmware, q.Firmware)) {
+			quirks = append(quirks, q)
+		}
+	}
+
+	return quirks
+}
+
+// LookupPrinter returns quirks of device, identified by its printer
+// attributes (i.e., from the Get-Printer-Attributes response).
+func (db QuirksDB) LookupPrinter(attrs Attributes) Quirks {
+	makeAndModel := quirksAttr(attrs, "printer-make-and-model")
+	if makeAndModel == "" {
+		return nil
+	}
+
+	firmware := quirksAttr(attrs, "printer-firmware-string-version")
+	return db.Lookup(makeAndModel, firmware)
+}
+
+// DecoderOptions returns opt with quirks applied
+func (quirks Quirks) DecoderOptions(opt DecoderOptions) DecoderOptions {
+	for _, q := range quirks {
+		if q.EnableWorkarounds {
+			opt.EnableWorkarounds = true
+		}
+		if q.IgnoreUTF8 {
+			opt.ValidateUTF8 = UTF8Ignore
+		}
+	}
+
+	return opt
+}
+
+// EncoderOptions returns opt with quirks applied
+func (quirks Quirks) EncoderOptions(opt EncoderOptions) EncoderOptions {
+	for _, q := range quirks {
+		if q.Version != 0 {
+			opt.Version = q.Version
+		}
+	}
+
+	return opt
+}
+
+// quirksPrefix reports whether s begins with case-insensitive prefix
+func quirksPrefix(s, prefix string) bool {
+	return len(s) >= len(prefix) &&
+		strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// quirksAttr returns text of the first value of the attribute,
+// or empty string, if attribute is missed
+func quirksAttr(attrs Attributes, name string) string {
+	attr, found := attrsFind(attrs, name)
+	if !found || len(attr.Values) == 0 {
+		return ""
+	}
+
+	if v, ok := attr.Values[0].V.(TextWithLang); ok {
+		return v.Text
+	}
+
+	return attr.Values[0].V.String()
+}
@@ -0,0 +1,125 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Quirks of known-buggy devices test
+ */
+
+package goipp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestQuirksLookup tests QuirksDB lookup and application
+func TestQuirksLookup(t *testing.T) {
+	db := QuirksDB{
+		{Name: "a", MakeAndModel: "Acme Laser", IgnoreUTF8: true},
+		{Name: "b", MakeAndModel: "Acme Laser 100", Firmware: "1.",
+			Version: MakeVersion(1, 1)},
+		{Name: "c", MakeAndModel: "Acme Inkjet", EnableWorkarounds: true},
+	}
+
+	type testData struct {
+		makeAndModel string // printer-make-and-model
+		firmware     string // printer-firmware-string-version
+		quirks       string // Names of expected quirks
+	}
+
+	tests := []testData{
+		{"Acme Laser 100", "1.2.3", "ab"},
+		{"ACME LASER 100", "2.0", "a"},
+		{"Acme Laser 200", "1.0", "a"},
+		{"acme inkjet", "", "c"},
+		{"Other", "1.0", ""},
+		{"", "", ""},
+	}
+
+	for _, test := range tests {
+		quirks := db.Lookup(test.makeAndModel, test.firmware)
+		names := ""
+		for _, q := range quirks {
+			names += q.Name
+		}
+
+		if names != test.quirks {
+			t.Errorf("%q %q: quirks %q, expected %q",
+				test.makeAndModel, test.firmware, names, test.quirks)
+		}
+	}
+
+	// Options
+	quirks := db.Lookup("Acme Laser 100", "1.0")
+	dopt := quirks.DecoderOptions(DecoderOptions{ValidateUTF8: UTF8Reject})
+	if dopt.ValidateUTF8 != UTF8Ignore || dopt.EnableWorkarounds {
+		t.Errorf("DecoderOptions: %+v", dopt)
+	}
+
+	eopt := quirks.EncoderOptions(EncoderOptions{})
+	if eopt.Version != MakeVersion(1, 1) {
+		t.Errorf("EncoderOptions: version %s", eopt.Version)
+	}
+
+	// LookupPrinter with the real capture
+	var m Message
+	err := m.DecodeBytesEx(attrsPantumM7300FDW,
+		DecoderOptions{EnableWorkarounds: true})
+	assertNoError(t, err)
+
+	quirks = DefaultQuirksDB.LookupPrinter(m.Printer)
+	if len(quirks) != 1 || !quirks[0].EnableWorkarounds {
+		t.Errorf("Pantum M7300FDW: quirks %+v", quirks)
+	}
+
+	err = m.DecodeBytes(attrsHPOfficeJetPro8730)
+	assertNoError(t, err)
+
+	if quirks = DefaultQuirksDB.LookupPrinter(m.Printer); quirks != nil {
+		t.Errorf("HP OfficeJet Pro 8730: quirks %+v", quirks)
+	}
+}
+
+// TestQuirksClient tests automatic application of quirks by Client
+func TestQuirksClient(t *testing.T) {
+	var versions []Version
+
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var req Message
+			err := req.Decode(r.Body)
+			assertNoError(t, err)
+			versions = append(versions, req.Version)
+
+			w.Header().Set("Content-Type", ContentType)
+			w.Write(attrsPantumM7300FDW)
+		}))
+	defer srv.Close()
+
+	clnt := NewClient(nil)
+	clnt.Quirks = QuirksDB{
+		{
+			MakeAndModel:      "Pantum M7300FDW",
+			EnableWorkarounds: true,
+			Version:           MakeVersion(1, 1),
+		},
+	}
+
+	req := NewRequest(DefaultVersion, OpGetPrinterAttributes, 1)
+	for i := 0; i < 2; i++ {
+		_, err := clnt.Do(context.Background(), srv.URL, req, nil)
+		assertNoError(t, err)
+	}
+
+	if len(versions) != 2 || versions[0] != DefaultVersion ||
+		versions[1] != MakeVersion(1, 1) {
+		t.Errorf("Request versions: %v", versions)
+	}
+
+	// Without quirks, response cannot be decoded
+	_, err := NewClient(nil).Do(context.Background(), srv.URL, req, nil)
+	assertWithError(t, err)
+}
@@ -0,0 +1,168 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Detection of replayed requests
+ */
+
+package goipp
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// Defaults of ReplayCache
+const (
+	DefaultReplayTTL        = 5 * time.Minute
+	DefaultReplayMaxEntries = 1024
+)
+
+// ReplayKey identifies the request for replay detection.
+//
+// Requests are considered duplicates, if they come from the same
+// client and have the same request-id, operation and attributes.
+type ReplayKey struct {
+	Client    string            // Client identity, i.e., remote address
+	RequestID uint32            // Request ID
+	Op        Op                // Operation
+	Hash      [sha256.Size]byte // SHA-256 of the encoded request
+}
+
+// MakeReplayKey makes ReplayKey of the request, received from the
+// client.
+//
+// Client identifies the client, i.e., by its remote address or
+// authenticated user name. Document data, following the request,
+// is not taken into account.
+func MakeReplayKey(client string, req *Message) (ReplayKey, error) {
+	data, err := req.EncodeBytes()
+	if err != nil {
+		return ReplayKey{}, err
+	}
+
+	key := ReplayKey{
+		Client:    client,
+		RequestID: req.RequestID,
+		Op:        Op(req.Code),
+		Hash:      sha256.Sum256(data),
+	}
+
+	return key, nil
+}
+
+// ReplayCache remembers responses to recently processed requests,
+// so servers can detect duplicate requests and return the cached
+// response instead of processing them again, as printers do for
+// Print-Job, retried by the client over the flaky link:
+//
+//	key, err := goipp.MakeReplayKey(r.RemoteAddr, req)
+//	if err == nil {
+//	        if rsp := cache.Lookup(key); rsp != nil {
+//	                return rsp
+//	        }
+//	}
+//
+//	rsp := process(req)
+//	cache.Store(key, rsp)
+//
+// Cached responses are shared between callers and must not be
+// modified. ReplayCache is safe for concurrent use.
+type ReplayCache struct {
+	// TTL is the time to live of cached responses. If zero,
+	// DefaultReplayTTL is used.
+	TTL time.Duration
+
+	// MaxEntries is the max count of cached responses. If zero,
+	// DefaultReplayMaxEntries is used. When exceeded, the oldest
+	// responses are evicted.
+	MaxEntries int
+
+	lock    sync.Mutex                 // Access lock
+	entries map[ReplayKey]*replayEntry // Cached responses
+	order   []ReplayKey                // Keys, oldest first
+}
+
+// replayEntry represents the cached response
+type replayEntry struct {
+	rsp     *Message  // The response
+	expires time.Time // Expiration time
+}
+
+// Lookup returns the cached response to the request, identified by
+// the key, or nil if request is not a duplicate.
+func (cache *ReplayCache) Lookup(key ReplayKey) *Message {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	cache.purge(time.Now())
+
+	if entry := cache.entries[key]; entry != nil {
+		return entry.rsp
+	}
+
+	return nil
+}
+
+// Store saves the response to the request, identified by the key.
+func (cache *ReplayCache) Store(key ReplayKey, rsp *Message) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	now := time.Now()
+
+	if cache.entries == nil {
+		cache.entries = make(map[ReplayKey]*replayEntry)
+	}
+
+	if entry := cache.entries[key]; entry != nil {
+		entry.rsp = rsp
+		return
+	}
+
+	ttl := cache.TTL
+	if ttl == 0 {
+		ttl = DefaultReplayTTL
+	}
+
+	cache.entries[key] = &replayEntry{rsp, now.Add(ttl)}
+	cache.order = append(cache.order, key)
+	cache.purge(now)
+}
+
+// Len returns count of cached responses, including expired but not
+// yet evicted.
+func (cache *ReplayCache) Len() int {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	return len(cache.entries)
+}
+
+// purge evicts expired and excessive entries. Entries are evicted
+// in order of their addition, which is also the order of expiration,
+// as all entries have the same TTL.
+//
+// Must be called under the lock.
+func (cache *ReplayCache) purge(now time.Time) {
+	max := cache.MaxEntries
+	if max <= 0 {
+		max = DefaultReplayMaxEntries
+	}
+
+	n := 0
+	for ; n < len(cache.order); n++ {
+		key := cache.order[n]
+		if len(cache.order)-n <= max &&
+			now.Before(cache.entries[key].expires) {
+			break
+		}
+		delete(cache.entries, key)
+	}
+
+	if n > 0 {
+		cache.order = append(cache.order[:0], cache.order[n:]...)
+	}
+}
@@ -0,0 +1,102 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Detection of replayed requests test
+ */
+
+package goipp
+
+import (
+	"testing"
+	"time"
+)
+
+// TestReplayKey tests MakeReplayKey
+func TestReplayKey(t *testing.T) {
+	newReq := func(id uint32, copies int) *Message {
+		m := NewRequest(DefaultVersion, OpPrintJob, id)
+		FillDefaults(m)
+		m.Job.Add(MakeAttr("copies", TagInteger, Integer(copies)))
+		return m
+	}
+
+	key, err := MakeReplayKey("client1", newReq(1, 1))
+	assertNoError(t, err)
+
+	tests := []struct {
+		client string
+		req    *Message
+		same   bool
+	}{
+		{"client1", newReq(1, 1), true},
+		{"client2", newReq(1, 1), false},
+		{"client1", newReq(2, 1), false},
+		{"client1", newReq(1, 2), false},
+	}
+
+	for i, test := range tests {
+		key2, err := MakeReplayKey(test.client, test.req)
+		assertNoError(t, err)
+
+		if (key == key2) != test.same {
+			t.Errorf("test %d: same key expected: %v", i, test.same)
+		}
+	}
+
+	bad := NewRequest(DefaultVersion, OpPrintJob, 1)
+	bad.Job.Add(MakeAttr("copies", TagZero, Integer(1)))
+	_, err = MakeReplayKey("client1", bad)
+	assertWithError(t, err)
+}
+
+// TestReplayCache tests ReplayCache
+func TestReplayCache(t *testing.T) {
+	req := NewRequest(DefaultVersion, OpPrintJob, 1)
+	FillDefaults(req)
+	rsp := JobCreated(req, 1, "ipp://localhost/jobs/1", JobStatePending)
+
+	key, err := MakeReplayKey("client", req)
+	assertNoError(t, err)
+
+	cache := &ReplayCache{}
+	if cache.Lookup(key) != nil {
+		t.Errorf("ReplayCache.Lookup: unexpected hit in empty cache")
+	}
+
+	cache.Store(key, rsp)
+	if cache.Lookup(key) != rsp {
+		t.Errorf("ReplayCache.Lookup: cached response not returned")
+	}
+
+	// Eviction by MaxEntries
+	cache = &ReplayCache{MaxEntries: 2}
+	keys := make([]ReplayKey, 3)
+	for i := range keys {
+		keys[i] = key
+		keys[i].RequestID = uint32(i)
+		cache.Store(keys[i], rsp)
+	}
+
+	if n := cache.Len(); n != 2 {
+		t.Errorf("ReplayCache.Len: expected 2, present %d", n)
+	}
+
+	if cache.Lookup(keys[0]) != nil || cache.Lookup(keys[2]) == nil {
+		t.Errorf("ReplayCache: oldest entry must be evicted")
+	}
+
+	// Expiration
+	cache = &ReplayCache{TTL: time.Millisecond}
+	cache.Store(key, rsp)
+	time.Sleep(5 * time.Millisecond)
+
+	if cache.Lookup(key) != nil {
+		t.Errorf("ReplayCache.Lookup: expired entry returned")
+	}
+
+	if n := cache.Len(); n != 0 {
+		t.Errorf("ReplayCache.Len: expected 0, present %d", n)
+	}
+}
@@ -0,0 +1,163 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Request and Response wrappers
+ */
+
+package goipp
+
+import (
+	"unicode/utf8"
+)
+
+// Length limits of status messages, as defined by RFC 8011, 4.1.6.2
+// and 4.1.6.3
+const (
+	MaxStatusMessage         LengthLimit = 255           // status-message is text(255)
+	MaxDetailedStatusMessage LengthLimit = MaxTextLength // detailed-status-message is text(MAX)
+)
+
+// Request is the thin wrapper around the request [Message],
+// with request-specific helpers.
+//
+// Request doesn't copy the Message; all changes made via
+// Request are visible via the wrapped Message and vice versa.
+// The zero Request wraps nil Message and cannot be used.
+type Request struct {
+	*Message
+}
+
+// Response is the thin wrapper around the response [Message],
+// with response-specific helpers.
+//
+// Response doesn't copy the Message; all changes made via
+// Response are visible via the wrapped Message and vice versa.
+// The zero Response wraps nil Message and cannot be used.
+type Response struct {
+	*Message
+}
+
+// AsRequest wraps the Message into the Request.
+// It sets m.Role to MessageRoleRequest.
+func AsRequest(m *Message) Request {
+	m.Role = MessageRoleRequest
+	return Request{m}
+}
+
+// AsResponse wraps the Message into the Response.
+// It sets m.Role to MessageRoleResponse.
+func AsResponse(m *Message) Response {
+	m.Role = MessageRoleResponse
+	return Response{m}
+}
+
+// Op returns the request operation code
+func (rq Request) Op() Op {
+	return Op(rq.Code)
+}
+
+// Status returns the response status code
+func (rsp Response) Status() Status {
+	return Status(rsp.Code)
+}
+
+// StatusMessage returns the value of the status-message
+// operation attribute, or "", if it is missed.
+func (rsp Response) StatusMessage() string {
+	return rsp.operationText("status-message")
+}
+
+// SetStatusMessage sets the status-message operation attribute.
+// Message longer than MaxStatusMessage bytes is truncated at the
+// UTF-8 character boundary.
+func (rsp Response) SetStatusMessage(msg string) {
+	rsp.setOperationAttr(MakeAttribute("status-message", TagText,
+		String(truncateUTF8(msg, int(MaxStatusMessage)))))
+}
+
+// DetailedStatusMessage returns the value of the
+// detailed-status-message operation attribute, or "",
+// if it is missed.
+func (rsp Response) DetailedStatusMessage() string {
+	return rsp.operationText("detailed-status-message")
+}
+
+// SetDetailedStatusMessage sets the detailed-status-message operation
+// attribute. Message longer than MaxDetailedStatusMessage bytes is
+// truncated at the UTF-8 character boundary.
+func (rsp Response) SetDetailedStatusMessage(msg string) {
+	rsp.setOperationAttr(MakeAttribute("detailed-status-message",
+		TagText, String(truncateUTF8(msg, int(MaxDetailedStatusMessage)))))
+}
+
+// operationText returns the value of the text operation attribute,
+// or "", if it is missed.
+func (m *Message) operationText(name string) string {
+	for _, grp := range m.attrGroups() {
+		if grp.Tag != TagOperationGroup {
+			continue
+		}
+
+		attr, found := attrsFind(grp.Attrs, name)
+		if found && len(attr.Values) != 0 {
+			switch v := attr.Values[0].V.(type) {
+			case String:
+				return string(v)
+			case Name, Keyword:
+				return v.String()
+			case TextWithLang:
+				return v.Text
+			}
+		}
+	}
+
+	return ""
+}
+
+// setOperationAttr sets the operation attribute, replacing the
+// existing attribute with the same name, if any. Both Groups and
+// Operation are updated.
+func (m *Message) setOperationAttr(attr Attribute) {
+	attrsSet(&m.Operation, attr)
+
+	if m.Groups != nil {
+		for i := range m.Groups {
+			if m.Groups[i].Tag == TagOperationGroup {
+				attrsSet(&m.Groups[i].Attrs, attr)
+				return
+			}
+		}
+
+		m.Groups = append(Groups{{TagOperationGroup, Attributes{attr}}},
+			m.Groups...)
+	}
+}
+
+// attrsSet replaces attribute with the same name or appends
+// the new one
+func attrsSet(attrs *Attributes, attr Attribute) {
+	for i := range *attrs {
+		if (*attrs)[i].Name == attr.Name {
+			(*attrs)[i] = attr
+			return
+		}
+	}
+
+	attrs.Add(attr)
+}
+
+// truncateUTF8 truncates string to at most max bytes, without
+// breaking the UTF-8 characters
+func truncateUTF8(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+
+	return s[:max]
+}
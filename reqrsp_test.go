@@ -0,0 +1,101 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Request and Response wrappers test
+ */
+
+package goipp
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestRequestResponse tests Request and Response wrappers
+func TestRequestResponse(t *testing.T) {
+	m := &Message{Code: Code(OpGetJobs)}
+	rq := AsRequest(m)
+
+	if rq.Op() != OpGetJobs {
+		t.Errorf("Request.Op: expected %s, present %s",
+			OpGetJobs, rq.Op())
+	}
+
+	if m.Role != MessageRoleRequest {
+		t.Errorf("AsRequest: role is %s", m.Role)
+	}
+
+	m = &Message{Code: Code(StatusErrorNotFound)}
+	m.Operation.Add(MakeAttr("status-message", TagText,
+		String("No such job")))
+	rsp := AsResponse(m)
+
+	if rsp.Status() != StatusErrorNotFound {
+		t.Errorf("Response.Status: expected %s, present %s",
+			StatusErrorNotFound, rsp.Status())
+	}
+
+	if s := rsp.StatusMessage(); s != "No such job" {
+		t.Errorf("Response.StatusMessage: expected %q, present %q",
+			"No such job", s)
+	}
+
+	if m.Role != MessageRoleResponse {
+		t.Errorf("AsResponse: role is %s", m.Role)
+	}
+
+	// Changes via wrapper are visible via the Message
+	rsp.Code = Code(StatusOk)
+	if m.Code != Code(StatusOk) {
+		t.Errorf("Response doesn't share the Message")
+	}
+
+	rsp = AsResponse(NewResponse(DefaultVersion, StatusOk, 1))
+	if s := rsp.StatusMessage(); s != "" {
+		t.Errorf("Response.StatusMessage: expected %q, present %q",
+			"", s)
+	}
+}
+
+// TestStatusMessages tests status-message and detailed-status-message
+// helpers
+func TestStatusMessages(t *testing.T) {
+	rsp := AsResponse(NewMessageWithGroups(DefaultVersion,
+		Code(StatusErrorBadRequest), 1, Groups{
+			{TagOperationGroup, Attributes{
+				MakeAttr("attributes-charset", TagCharset,
+					String("utf-8")),
+				MakeAttr("status-message", TagText,
+					String("old")),
+			}},
+		}))
+
+	rsp.SetStatusMessage("Bad request")
+	rsp.SetDetailedStatusMessage(strings.Repeat("\u00e9", 600))
+
+	if s := rsp.StatusMessage(); s != "Bad request" {
+		t.Errorf("StatusMessage: expected %q, present %q",
+			"Bad request", s)
+	}
+
+	// Truncated to 1022 bytes, as 1023 would break the last character
+	s := rsp.DetailedStatusMessage()
+	if s != strings.Repeat("\u00e9", 511) {
+		t.Errorf("DetailedStatusMessage: bad truncation, len=%d",
+			len(s))
+	}
+
+	// Both Groups and Operation must be updated
+	if len(rsp.Operation) != 3 || len(rsp.Groups[0].Attrs) != 3 {
+		t.Errorf("SetStatusMessage: Operation has %d attributes, "+
+			"Groups[0] has %d, expected 3",
+			len(rsp.Operation), len(rsp.Groups[0].Attrs))
+	}
+
+	rsp.SetStatusMessage(strings.Repeat("x", 300))
+	if s := rsp.StatusMessage(); len(s) != int(MaxStatusMessage) {
+		t.Errorf("SetStatusMessage: bad truncation, len=%d", len(s))
+	}
+}
@@ -0,0 +1,156 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Printer resources (icons and strings) fetching
+ */
+
+package goipp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// MaxResourceSize limits size of the resource, fetched
+// by Client.FetchResource
+const MaxResourceSize = 16 * 1024 * 1024
+
+// Resource represents a resource, fetched from the printer
+type Resource struct {
+	URI         string // Resource URI
+	ContentType string // Content-Type of the resource
+	Data        []byte // Resource data
+}
+
+// PrinterIcons returns URIs of the printer icons, as reported by
+// the printer-icons attribute of the Get-Printer-Attributes response.
+//
+// PWG 5100.13 requires icons to be listed in the order of increasing
+// size, typically 48x48, 128x128 and 512x512 pixels.
+func (m *Message) PrinterIcons() []string {
+	var uris []string
+
+	if attr, found := m.printerAttr("printer-icons"); found {
+		for _, v := range attr.Values {
+			if v.T == TagURI {
+				uris = append(uris, v.V.String())
+			}
+		}
+	}
+
+	return uris
+}
+
+// PrinterStringsURI returns URI of the localization strings
+// catalog, as reported by the printer-strings-uri attribute of the
+// Get-Printer-Attributes response, or "", if attribute is missed.
+func (m *Message) PrinterStringsURI() string {
+	attr, found := m.printerAttr("printer-strings-uri")
+	if found && len(attr.Values) == 1 && attr.Values[0].T == TagURI {
+		return attr.Values[0].V.String()
+	}
+	return ""
+}
+
+// printerAttr finds attribute in the printer attributes groups
+func (m *Message) printerAttr(name string) (Attribute, bool) {
+	for _, grp := range m.attrGroups() {
+		if grp.Tag == TagPrinterGroup {
+			if attr, found := attrsFind(grp.Attrs, name); found {
+				return attr, true
+			}
+		}
+	}
+	return Attribute{}, false
+}
+
+// FetchResource fetches the resource by its URI, using HTTP GET.
+//
+// The uri may use "ipp", "ipps", "http" or "https" scheme. The accept
+// parameter, if not empty, is sent as the Accept header.
+//
+// Resources larger than MaxResourceSize are rejected.
+func (c *Client) FetchResource(ctx context.Context, uri,
+	accept string) (*Resource, error) {
+
+	httpURL, err := HTTPURL(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequest(http.MethodGet, httpURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq = httpReq.WithContext(ctx)
+	if accept != "" {
+		httpReq.Header.Set("Accept", accept)
+	}
+
+	httpRsp, err := c.httpClient().Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	defer httpRsp.Body.Close()
+
+	if httpRsp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("HTTP: %s", httpRsp.Status)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(httpRsp.Body,
+		MaxResourceSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > MaxResourceSize {
+		return nil, fmt.Errorf("%s: resource too large", uri)
+	}
+
+	rsrc := &Resource{
+		URI:         uri,
+		ContentType: httpRsp.Header.Get("Content-Type"),
+		Data:        data,
+	}
+
+	return rsrc, nil
+}
+
+// FetchPrinterIcons fetches all printer icons, listed in the
+// Get-Printer-Attributes response, in the same order.
+func (c *Client) FetchPrinterIcons(ctx context.Context,
+	m *Message) ([]*Resource, error) {
+
+	var icons []*Resource
+
+	for _, uri := range m.PrinterIcons() {
+		icon, err := c.FetchResource(ctx, uri, "image/png")
+		if err != nil {
+			return nil, err
+		}
+		icons = append(icons, icon)
+	}
+
+	return icons, nil
+}
+
+// FetchPrinterStrings fetches the localization strings catalog,
+// referred by the Get-Printer-Attributes response.
+func (c *Client) FetchPrinterStrings(ctx context.Context,
+	m *Message) (*Resource, error) {
+
+	uri := m.PrinterStringsURI()
+	if uri == "" {
+		return nil, errors.New("printer-strings-uri: attribute missed")
+	}
+
+	return c.FetchResource(ctx, uri, "text/strings")
+}
@@ -0,0 +1,75 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Printer resources fetching test
+ */
+
+package goipp
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestFetchPrinterResources tests fetching of printer icons
+// and strings
+func TestFetchPrinterResources(t *testing.T) {
+	icon := []byte("\x89PNG\r\n\x1a\n")
+	strs := []byte("\"media-type.stationery\" = \"Plain Paper\";\n")
+
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/icon.png":
+				if a := r.Header.Get("Accept"); a != "image/png" {
+					t.Errorf("icon: bad Accept: %q", a)
+				}
+				w.Header().Set("Content-Type", "image/png")
+				w.Write(icon)
+			case "/en.strings":
+				if a := r.Header.Get("Accept"); a != "text/strings" {
+					t.Errorf("strings: bad Accept: %q", a)
+				}
+				w.Header().Set("Content-Type", "text/strings")
+				w.Write(strs)
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+	defer srv.Close()
+
+	m := NewResponse(DefaultVersion, StatusOk, 1)
+	m.Printer.Add(MakeAttr("printer-icons", TagURI,
+		String(srv.URL+"/icon.png")))
+	m.Printer.Add(MakeAttr("printer-strings-uri", TagURI,
+		String(srv.URL+"/en.strings")))
+
+	c := NewClient(nil)
+	ctx := context.Background()
+
+	icons, err := c.FetchPrinterIcons(ctx, m)
+	assertNoError(t, err)
+	if len(icons) != 1 || !bytes.Equal(icons[0].Data, icon) ||
+		icons[0].ContentType != "image/png" {
+		t.Errorf("FetchPrinterIcons: unexpected result %+v", icons)
+	}
+
+	rsrc, err := c.FetchPrinterStrings(ctx, m)
+	assertNoError(t, err)
+	if !bytes.Equal(rsrc.Data, strs) {
+		t.Errorf("FetchPrinterStrings: unexpected result %q", rsrc.Data)
+	}
+
+	// Errors
+	_, err = c.FetchPrinterStrings(ctx,
+		NewResponse(DefaultVersion, StatusOk, 1))
+	assertErrorIs(t, err, "printer-strings-uri: attribute missed")
+
+	_, err = c.FetchResource(ctx, srv.URL+"/missed", "")
+	assertErrorIs(t, err, "HTTP: 404 Not Found")
+}
@@ -0,0 +1,108 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Templates of common server responses
+ */
+
+package goipp
+
+// NewResponseTo creates the response to the request with the
+// specified status.
+//
+// Response has the same version and request-id as the request, and
+// its operation group starts with attributes-charset (as "utf-8")
+// and attributes-natural-language, copied from the request or set
+// to DefaultLanguage, if request doesn't have it (RFC 8011, 4.1.4.2).
+func NewResponseTo(req *Message, status Status) *Message {
+	lang := req.operationText("attributes-natural-language")
+	if lang == "" {
+		lang = DefaultLanguage
+	}
+
+	m := NewResponse(req.Version, status, req.RequestID)
+	m.Operation.Add(MakeAttribute("attributes-charset", TagCharset,
+		String("utf-8")))
+	m.Operation.Add(MakeAttribute("attributes-natural-language",
+		TagLanguage, String(lang)))
+
+	return m
+}
+
+// OKPrinterAttributes creates the successful response to the
+// Get-Printer-Attributes request.
+//
+// The printer group contains attributes from caps, filtered by the
+// requested-attributes operation attribute of the request. Missed
+// requested-attributes, "all", "printer-description" and
+// "job-template" select all attributes. Requested attributes, missed
+// in caps, are ignored.
+func OKPrinterAttributes(req *Message, caps Attributes) *Message {
+	m := NewResponseTo(req, StatusOk)
+
+	var requested map[string]bool
+	for _, grp := range req.attrGroups() {
+		if grp.Tag != TagOperationGroup {
+			continue
+		}
+
+		attr, found := attrsFind(grp.Attrs, "requested-attributes")
+		if !found {
+			continue
+		}
+
+		requested = make(map[string]bool)
+		for _, val := range attr.Values {
+			name := val.V.String()
+			if name == "all" || name == "printer-description" ||
+				name == "job-template" {
+				requested = nil
+				break
+			}
+
+			requested[name] = true
+		}
+	}
+
+	m.Printer = Attributes{}
+	for _, attr := range caps {
+		if requested == nil || requested[attr.Name] {
+			m.Printer.Add(attr)
+		}
+	}
+
+	return m
+}
+
+// JobCreated creates the successful response to the Print-Job,
+// Print-URI or Create-Job request.
+//
+// The job group contains job-id, job-uri, job-state and
+// job-state-reasons (as "none"), as required by RFC 8011, 4.2.1.2.
+func JobCreated(req *Message, jobID int, jobURI string,
+	state int) *Message {
+
+	m := NewResponseTo(req, StatusOk)
+	m.Job.Add(MakeAttribute("job-id", TagInteger, Integer(jobID)))
+	m.Job.Add(MakeAttribute("job-uri", TagURI, String(jobURI)))
+	m.Job.Add(MakeAttribute("job-state", TagEnum, Integer(state)))
+	m.Job.Add(MakeAttribute("job-state-reasons", TagKeyword,
+		String("none")))
+
+	return m
+}
+
+// OperationNotSupported creates the response to the request with
+// the unsupported operation, with the
+// server-error-operation-not-supported status.
+func OperationNotSupported(req *Message) *Message {
+	return NewResponseTo(req, StatusErrorOperationNotSupported)
+}
+
+// ServerErrorBusy creates the response to the request, which cannot
+// be processed because the server is busy, with the
+// server-error-busy status.
+func ServerErrorBusy(req *Message) *Message {
+	return NewResponseTo(req, StatusErrorBusy)
+}
@@ -0,0 +1,135 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Templates of common server responses test
+ */
+
+package goipp
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestResponseTemplates tests templates of common server responses
+func TestResponseTemplates(t *testing.T) {
+	req := NewRequest(MakeVersion(1, 1), OpGetPrinterAttributes, 42)
+	req.Operation.Add(MakeAttr("attributes-charset", TagCharset,
+		String("utf-8")))
+	req.Operation.Add(MakeAttr("attributes-natural-language",
+		TagLanguage, String("de")))
+
+	ops := Attributes{
+		MakeAttr("attributes-charset", TagCharset, String("utf-8")),
+		MakeAttr("attributes-natural-language", TagLanguage,
+			String("de")),
+	}
+
+	tests := []struct {
+		name   string
+		rsp    *Message
+		status Status
+	}{
+		{"OperationNotSupported", OperationNotSupported(req),
+			StatusErrorOperationNotSupported},
+		{"ServerErrorBusy", ServerErrorBusy(req), StatusErrorBusy},
+		{"NewResponseTo", NewResponseTo(req, StatusErrorNotFound),
+			StatusErrorNotFound},
+	}
+
+	for _, test := range tests {
+		rsp := test.rsp
+		switch {
+		case rsp.Role != MessageRoleResponse:
+			t.Errorf("%s: role is %s", test.name, rsp.Role)
+		case Status(rsp.Code) != test.status:
+			t.Errorf("%s: status is %s, expected %s",
+				test.name, Status(rsp.Code), test.status)
+		case rsp.Version != req.Version:
+			t.Errorf("%s: version is %s", test.name, rsp.Version)
+		case rsp.RequestID != req.RequestID:
+			t.Errorf("%s: request-id is %d", test.name, rsp.RequestID)
+		case !rsp.Operation.Equal(ops):
+			t.Errorf("%s: unexpected operation attributes: %v",
+				test.name, rsp.Operation)
+		}
+	}
+
+	// Default natural language
+	rsp := OperationNotSupported(NewRequest(DefaultVersion, OpPrintJob, 1))
+	if s := rsp.operationText("attributes-natural-language"); s != "en-US" {
+		t.Errorf("OperationNotSupported: natural language is %q", s)
+	}
+
+	// JobCreated
+	rsp = JobCreated(req, 7, "ipp://localhost/jobs/7", JobStatePending)
+	expected := Attributes{
+		MakeAttr("job-id", TagInteger, Integer(7)),
+		MakeAttr("job-uri", TagURI, String("ipp://localhost/jobs/7")),
+		MakeAttr("job-state", TagEnum, Integer(JobStatePending)),
+		MakeAttr("job-state-reasons", TagKeyword, String("none")),
+	}
+
+	if !rsp.Job.Equal(expected) {
+		t.Errorf("JobCreated: unexpected job attributes: %v", rsp.Job)
+	}
+}
+
+// TestOKPrinterAttributes tests OKPrinterAttributes
+func TestOKPrinterAttributes(t *testing.T) {
+	caps := Attributes{
+		MakeAttr("printer-name", TagName, String("Test")),
+		MakeAttr("printer-state", TagEnum, Integer(3)),
+		MakeAttr("copies-supported", TagRange, Range{1, 99}),
+	}
+
+	tests := []struct {
+		requested []string
+		expected  []string
+	}{
+		{nil, []string{"printer-name", "printer-state",
+			"copies-supported"}},
+		{[]string{"all"}, []string{"printer-name", "printer-state",
+			"copies-supported"}},
+		{[]string{"printer-state", "printer-description"},
+			[]string{"printer-name", "printer-state",
+				"copies-supported"}},
+		{[]string{"copies-supported", "printer-state", "unknown"},
+			[]string{"printer-state", "copies-supported"}},
+		{[]string{"unknown"}, nil},
+	}
+
+	for _, test := range tests {
+		req := NewRequest(DefaultVersion, OpGetPrinterAttributes, 1)
+		if test.requested != nil {
+			attr := Attribute{Name: "requested-attributes"}
+			for _, name := range test.requested {
+				attr.Values.Add(TagKeyword, String(name))
+			}
+			req.Operation.Add(attr)
+		}
+
+		rsp := OKPrinterAttributes(req, caps)
+		if Status(rsp.Code) != StatusOk {
+			t.Errorf("%v: status is %s", test.requested,
+				Status(rsp.Code))
+		}
+
+		if rsp.Printer == nil {
+			t.Errorf("%v: printer group missed", test.requested)
+		}
+
+		var present []string
+		for _, attr := range rsp.Printer {
+			present = append(present, attr.Name)
+		}
+
+		if strings.Join(present, ",") !=
+			strings.Join(test.expected, ",") {
+			t.Errorf("%v: expected %v, present %v",
+				test.requested, test.expected, present)
+		}
+	}
+}
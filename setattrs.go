@@ -0,0 +1,127 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Set-Printer-Attributes and Set-Job-Attributes deltas
+ */
+
+package goipp
+
+import (
+	"fmt"
+)
+
+// MakeDeleteAttr makes the attribute with the delete-attribute
+// out-of-band value, which requests the attribute to be deleted
+// by Set-Printer-Attributes or Set-Job-Attributes (RFC 3380, 3.2.1.2)
+func MakeDeleteAttr(name string) Attribute {
+	return MakeAttribute(name, TagDeleteAttr, Void{})
+}
+
+// MakeAdminDefineAttr makes the attribute with the admin-define
+// out-of-band value, which requests the printer to set the
+// administratively defined value of the attribute (RFC 3380, 3.2.1.2)
+func MakeAdminDefineAttr(name string) Attribute {
+	return MakeAttribute(name, TagAdminDefine, Void{})
+}
+
+// NewSetPrinterAttributesRequest creates the Set-Printer-Attributes
+// request (RFC 3380), that applies delta to printer attributes.
+//
+// Delta may contain attributes with new values, and attributes,
+// made by MakeDeleteAttr and MakeAdminDefineAttr. Missed required
+// attributes are filled by FillDefaults.
+func NewSetPrinterAttributesRequest(v Version, id uint32,
+	printerURI string, delta Attributes) *Message {
+
+	m := NewRequest(v, OpSetPrinterAttributes, id)
+	m.Operation.Add(MakeAttribute("printer-uri", TagURI,
+		String(printerURI)))
+	m.Printer = delta
+
+	FillDefaults(m)
+
+	return m
+}
+
+// NewSetJobAttributesRequest creates the Set-Job-Attributes request
+// (RFC 3380), that applies delta to attributes of the job.
+//
+// See NewSetPrinterAttributesRequest for details.
+func NewSetJobAttributesRequest(v Version, id uint32,
+	printerURI string, jobID int, delta Attributes) *Message {
+
+	m := NewRequest(v, OpSetJobAttributes, id)
+	m.Operation.Add(MakeAttribute("printer-uri", TagURI,
+		String(printerURI)))
+	m.Operation.Add(MakeAttribute("job-id", TagInteger,
+		Integer(jobID)))
+	m.Job = delta
+
+	FillDefaults(m)
+
+	return m
+}
+
+// Apply applies delta of Set-Printer-Attributes or Set-Job-Attributes
+// request to attrs, following RFC 3380 rules:
+//   - attribute with the delete-attribute value is removed from
+//     attrs; if it is missed, this is not an error
+//   - attribute with the admin-define value is removed from attrs
+//     as well, as its actual value is defined by the printer and
+//     not known in advance; it may be requested again (see
+//     [Attributes.Missing])
+//   - other attributes replace attributes of the same name in attrs
+//     or, if missed, are appended
+//
+// Apply is atomic: if delta is not valid (i.e., contains the same
+// attribute twice or delete-attribute value together with other
+// values), it returns error and attrs is not changed.
+func (attrs *Attributes) Apply(delta Attributes) error {
+	seen := make(map[string]struct{}, len(delta))
+	for _, attr := range delta {
+		if _, found := seen[attr.Name]; found {
+			return fmt.Errorf("%s: attribute repeated", attr.Name)
+		}
+		seen[attr.Name] = struct{}{}
+
+		if len(attr.Values) == 0 {
+			return fmt.Errorf("%s: attribute without value", attr.Name)
+		}
+
+		for _, val := range attr.Values {
+			if (val.T == TagDeleteAttr || val.T == TagAdminDefine) &&
+				len(attr.Values) != 1 {
+				return fmt.Errorf("%s: %s must be the only value",
+					attr.Name, val.T)
+			}
+		}
+	}
+
+	var update Attributes
+	remove := make(map[string]struct{})
+
+	for _, attr := range delta {
+		switch attr.Values[0].T {
+		case TagDeleteAttr, TagAdminDefine:
+			remove[attr.Name] = struct{}{}
+		default:
+			update.Add(attr)
+		}
+	}
+
+	if len(remove) != 0 {
+		out := (*attrs)[:0:0]
+		for _, attr := range *attrs {
+			if _, found := remove[attr.Name]; !found {
+				out.Add(attr)
+			}
+		}
+		*attrs = out
+	}
+
+	attrs.Merge(update)
+
+	return nil
+}
@@ -0,0 +1,115 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Set-Printer-Attributes and Set-Job-Attributes deltas test
+ */
+
+package goipp
+
+import (
+	"testing"
+)
+
+// TestSetAttributesRequest tests NewSetPrinterAttributesRequest
+// and NewSetJobAttributesRequest
+func TestSetAttributesRequest(t *testing.T) {
+	delta := Attributes{
+		MakeAttr("printer-location", TagText, String("Room 101")),
+		MakeDeleteAttr("printer-info"),
+		MakeAdminDefineAttr("media-default"),
+	}
+
+	m := NewSetPrinterAttributesRequest(DefaultVersion, 1,
+		"ipp://localhost/printers/test", delta)
+
+	data, err := m.EncodeBytes()
+	assertNoError(t, err)
+
+	var m2 Message
+	assertNoError(t, m2.DecodeBytes(data))
+
+	if !m2.Printer.Equal(delta) {
+		t.Errorf("Set-Printer-Attributes: delta not preserved:\n%s",
+			m2.Printer)
+	}
+
+	m = NewSetJobAttributesRequest(DefaultVersion, 1,
+		"ipp://localhost/printers/test", 5, delta[:2])
+
+	if Op(m.Code) != OpSetJobAttributes || !m.Job.Equal(delta[:2]) {
+		t.Errorf("Set-Job-Attributes: bad request")
+	}
+
+	if attr, _ := attrsFind(m.Operation, "job-id"); len(attr.Values) != 1 ||
+		attr.Values[0].V != Integer(5) {
+		t.Errorf("Set-Job-Attributes: job-id missed")
+	}
+}
+
+// TestAttributesApply tests Attributes.Apply
+func TestAttributesApply(t *testing.T) {
+	snapshot := Attributes{
+		MakeAttr("printer-info", TagText, String("Info")),
+		MakeAttr("printer-location", TagText, String("Room 1")),
+		MakeAttr("media-default", TagKeyword, String("iso_a4_210x297mm")),
+	}
+
+	attrs := snapshot.Clone()
+	err := attrs.Apply(Attributes{
+		MakeAttr("printer-location", TagText, String("Room 101")),
+		MakeAttr("printer-name", TagName, String("Test")),
+		MakeDeleteAttr("printer-info"),
+		MakeDeleteAttr("printer-geo-location"),
+		MakeAdminDefineAttr("media-default"),
+	})
+	assertNoError(t, err)
+
+	expected := Attributes{
+		MakeAttr("printer-location", TagText, String("Room 101")),
+		MakeAttr("printer-name", TagName, String("Test")),
+	}
+
+	if !attrs.Equal(expected) {
+		t.Errorf("Apply:\nexpected: %s\npresent:  %s", expected, attrs)
+	}
+
+	if !snapshot[0].Equal(MakeAttr("printer-info", TagText,
+		String("Info"))) {
+		t.Errorf("Apply: snapshot modified")
+	}
+
+	// Invalid deltas
+	bad := []struct {
+		delta Attributes
+		err   string
+	}{
+		{
+			Attributes{
+				MakeDeleteAttr("printer-info"),
+				MakeAttr("printer-info", TagText, String("Info")),
+			},
+			"printer-info: attribute repeated",
+		},
+		{
+			Attributes{MakeAttr("printer-info", TagDeleteAttr,
+				Void{}, Void{})},
+			"printer-info: delete-attribute must be the only value",
+		},
+		{
+			Attributes{{Name: "printer-info"}},
+			"printer-info: attribute without value",
+		},
+	}
+
+	for _, test := range bad {
+		attrs := snapshot.Clone()
+		err := attrs.Apply(test.delta)
+		assertErrorIs(t, err, test.err)
+
+		if !attrs.Equal(snapshot) {
+			t.Errorf("Apply: attrs modified on error %q", test.err)
+		}
+	}
+}
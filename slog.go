@@ -0,0 +1,148 @@
+//go:build go1.21
+// +build go1.21
+
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Structured logging (log/slog) support
+ */
+
+package goipp
+
+import (
+	"log/slog"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Limits of the structured log records
+const (
+	slogMaxValues    = 8  // Max values per attribute
+	slogMaxValueSize = 64 // Max length of each value, in bytes
+)
+
+// slogAttrs contains names of attributes, included into the
+// Message log record
+var slogAttrs = []string{
+	"printer-uri",
+	"job-uri",
+	"job-id",
+	"requesting-user-name",
+	"job-name",
+	"document-format",
+	"job-state",
+	"printer-state",
+	"status-message",
+	"detailed-status-message",
+}
+
+// LogValue implements slog.LogValuer interface for the Message.
+//
+// It produces a bounded log record, suitable for logging of
+// messages of any size: protocol version, operation or status
+// (depending on Message.Role), request ID, counts of attributes
+// and values and the small set of well-known attributes, if
+// present (i.e., printer-uri, job-id, status-message). Values of
+// these attributes are truncated.
+//
+// Available only when built with Go 1.21 or newer.
+func (m *Message) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("version", m.Version.String()),
+	}
+
+	switch m.Role {
+	case MessageRoleRequest:
+		attrs = append(attrs, slog.String("op", Op(m.Code).String()))
+	case MessageRoleResponse:
+		attrs = append(attrs,
+			slog.String("status", Status(m.Code).String()))
+	default:
+		attrs = append(attrs, slog.Int("code", int(m.Code)))
+	}
+
+	attrs = append(attrs, slog.Uint64("request-id", uint64(m.RequestID)))
+
+	groups, nattrs, nvalues := 0, 0, 0
+	for _, grp := range m.attrGroups() {
+		groups++
+		nattrs += len(grp.Attrs)
+		nvalues += attrsCountValues(grp.Attrs)
+	}
+
+	attrs = append(attrs,
+		slog.Int("groups", groups),
+		slog.Int("attrs", nattrs),
+		slog.Int("values", nvalues))
+
+	for _, name := range slogAttrs {
+		for _, grp := range m.attrGroups() {
+			if attr, found := attrsFind(grp.Attrs, name); found {
+				attrs = append(attrs,
+					slog.Any(name, slogValues(attr.Values)))
+				break
+			}
+		}
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// LogValue implements slog.LogValuer interface for the Attribute.
+//
+// It produces a bounded log record: attribute name, tag of
+// the first value, count of values and up to 8 values, each
+// truncated to 64 bytes. Collections are represented by
+// count of their members.
+//
+// Available only when built with Go 1.21 or newer.
+func (a Attribute) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("name", a.Name),
+	}
+
+	if len(a.Values) != 0 {
+		attrs = append(attrs, slog.String("tag", a.Values[0].T.String()))
+	}
+
+	attrs = append(attrs,
+		slog.Int("count", len(a.Values)),
+		slog.Any("values", slogValues(a.Values)))
+
+	return slog.GroupValue(attrs...)
+}
+
+// slogValues returns bounded string representation of values
+func slogValues(values Values) []string {
+	n := len(values)
+	if n > slogMaxValues {
+		n = slogMaxValues
+	}
+
+	out := make([]string, 0, n+1)
+	for _, val := range values[:n] {
+		var s string
+		if col, ok := val.V.(Collection); ok {
+			s = "{" + strconv.Itoa(len(col)) + " members}"
+		} else {
+			s = val.V.String()
+			if len(s) > slogMaxValueSize {
+				end := slogMaxValueSize
+				for end > 0 && !utf8.RuneStart(s[end]) {
+					end--
+				}
+				s = s[:end] + "..."
+			}
+		}
+
+		out = append(out, s)
+	}
+
+	if n < len(values) {
+		out = append(out, "...")
+	}
+
+	return out
+}
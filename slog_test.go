@@ -0,0 +1,88 @@
+//go:build go1.21
+// +build go1.21
+
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Structured logging (log/slog) support test
+ */
+
+package goipp
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// TestSlog tests slog.LogValuer implementation
+func TestSlog(t *testing.T) {
+	long := strings.Repeat("ж", 40)
+
+	m := NewRequest(DefaultVersion, OpPrintJob, 5)
+	m.Operation.Add(MakeAttr("attributes-charset", TagCharset,
+		String("utf-8")))
+	m.Operation.Add(MakeAttr("printer-uri", TagURI,
+		String("ipp://localhost/printers/test")))
+	m.Operation.Add(MakeAttr("job-name", TagName, String(long)))
+
+	many := MakeAttr("media-supported", TagKeyword, String("a4"))
+	for i := 0; i < 20; i++ {
+		many.Values.Add(TagKeyword, String("letter"))
+	}
+	col := MakeAttrCollection("media-col",
+		MakeAttribute("media-type", TagKeyword, String("auto")))
+
+	type testData struct {
+		v   slog.LogValuer // Value to log
+		out string         // Expected text output
+	}
+
+	tests := []testData{
+		{
+			v: m,
+			out: `msg=test v.version=2.0 v.op=Print-Job ` +
+				`v.request-id=5 v.groups=1 v.attrs=3 v.values=3 ` +
+				`v.printer-uri=[ipp://localhost/printers/test] ` +
+				`v.job-name=[` + long[:64] + `...]`,
+		},
+		{
+			v: NewResponse(DefaultVersion, StatusOk, 5),
+			out: `msg=test v.version=2.0 v.status=successful-ok ` +
+				`v.request-id=5 v.groups=0 v.attrs=0 v.values=0`,
+		},
+		{
+			v: many,
+			out: `msg=test v.name=media-supported v.tag=keyword ` +
+				`v.count=21 v.values="[a4 letter letter letter ` +
+				`letter letter letter letter ...]"`,
+		},
+		{
+			v: col,
+			out: `msg=test v.name=media-col v.tag=collection ` +
+				`v.count=1 v.values="[{1 members}]"`,
+		},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		log := slog.New(slog.NewTextHandler(&buf,
+			&slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if a.Key == slog.TimeKey || a.Key == slog.LevelKey {
+						return slog.Attr{}
+					}
+					return a
+				},
+			}))
+
+		log.Info("test", "v", test.v)
+		out := strings.TrimSpace(buf.String())
+		if out != test.out {
+			t.Errorf("output:\n%s\nexpected:\n%s", out, test.out)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * database/sql integration
+ */
+
+package goipp
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// MessageJSON is the Message, stored in SQL database as JSON
+// text rather that in the IPP wire format.
+//
+// Use it as follows:
+//
+//	db.Exec("INSERT INTO jobs (request) VALUES (?)", goipp.MessageJSON(*msg))
+//	db.QueryRow("SELECT request FROM jobs").Scan((*goipp.MessageJSON)(msg))
+type MessageJSON Message
+
+var (
+	_ = driver.Valuer(Message{})
+	_ = sql.Scanner(&Message{})
+	_ = driver.Valuer(MessageJSON{})
+	_ = sql.Scanner(&MessageJSON{})
+	_ = driver.Valuer(Attributes{})
+	_ = sql.Scanner(&Attributes{})
+)
+
+// Value returns Message, encoded in the IPP wire format.
+// It implements [driver.Valuer] interface.
+func (m Message) Value() (driver.Value, error) {
+	return m.EncodeBytes()
+}
+
+// Scan decodes Message, stored in SQL database.
+// It implements [sql.Scanner] interface.
+//
+// Both the IPP wire format and JSON are accepted and
+// detected automatically.
+func (m *Message) Scan(src interface{}) error {
+	data, err := sqlBytes(src)
+	if err != nil {
+		return err
+	}
+
+	if sqlIsJSON(data) {
+		return json.Unmarshal(data, m)
+	}
+
+	return m.DecodeBytes(data)
+}
+
+// Value returns Message, encoded as JSON.
+// It implements [driver.Valuer] interface.
+func (m MessageJSON) Value() (driver.Value, error) {
+	return json.Marshal(Message(m))
+}
+
+// Scan decodes Message, stored in SQL database.
+// It implements [sql.Scanner] interface.
+//
+// Both the IPP wire format and JSON are accepted and
+// detected automatically.
+func (m *MessageJSON) Scan(src interface{}) error {
+	return (*Message)(m).Scan(src)
+}
+
+// Value returns Attributes, encoded as JSON.
+// It implements [driver.Valuer] interface.
+func (attrs Attributes) Value() (driver.Value, error) {
+	if attrs == nil {
+		attrs = Attributes{}
+	}
+	return json.Marshal(attrs)
+}
+
+// Scan decodes Attributes, stored in SQL database as JSON.
+// It implements [sql.Scanner] interface.
+func (attrs *Attributes) Scan(src interface{}) error {
+	data, err := sqlBytes(src)
+	if err != nil {
+		return err
+	}
+
+	var attrs2 Attributes
+	err = json.Unmarshal(data, &attrs2)
+	if err == nil {
+		*attrs = attrs2
+	}
+
+	return err
+}
+
+// sqlBytes returns bytes of the value, received from SQL database
+func sqlBytes(src interface{}) ([]byte, error) {
+	switch src := src.(type) {
+	case []byte:
+		return src, nil
+	case string:
+		return []byte(src), nil
+	}
+
+	return nil, fmt.Errorf("Can't scan %T into IPP message", src)
+}
+
+// sqlIsJSON guesses if data, received from SQL database, is JSON
+//
+// IPP message always starts with the version, and major version
+// is never equal to '{' (0x7b), so the guess is reliable.
+func sqlIsJSON(data []byte) bool {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	return len(data) > 0 && data[0] == '{'
+}
@@ -0,0 +1,66 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * database/sql integration test
+ */
+
+package goipp
+
+import (
+	"testing"
+)
+
+// TestSQLMessage tests Message Value/Scan round trip
+func TestSQLMessage(t *testing.T) {
+	m1 := testEncodeDecodeMessage()
+
+	// Wire format
+	v, err := m1.Value()
+	assertNoError(t, err)
+
+	var m2 Message
+	err = m2.Scan(v)
+	assertNoError(t, err)
+
+	if !m1.Similar(m2) {
+		t.Errorf("Message: not the same after Value/Scan")
+	}
+
+	// JSON
+	v, err = MessageJSON(*m1).Value()
+	assertNoError(t, err)
+
+	if !sqlIsJSON(v.([]byte)) {
+		t.Errorf("MessageJSON: value is not JSON")
+	}
+
+	var m3 Message
+	err = (*MessageJSON)(&m3).Scan(string(v.([]byte)))
+	assertNoError(t, err)
+
+	if !m1.Similar(m3) {
+		t.Errorf("MessageJSON: not the same after Value/Scan")
+	}
+
+	// Errors
+	err = m3.Scan(12345)
+	assertErrorIs(t, err, "Can't scan int into IPP message")
+}
+
+// TestSQLAttributes tests Attributes Value/Scan round trip
+func TestSQLAttributes(t *testing.T) {
+	attrs1 := testEncodeDecodeMessage().Printer
+
+	v, err := attrs1.Value()
+	assertNoError(t, err)
+
+	var attrs2 Attributes
+	err = attrs2.Scan(v)
+	assertNoError(t, err)
+
+	if !attrs1.Equal(attrs2) {
+		t.Errorf("Attributes: not the same after Value/Scan")
+	}
+}
@@ -0,0 +1,27 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Values of the printer and job state attributes
+ */
+
+package goipp
+
+// Values of the printer-state attribute (RFC 8011, 5.4.11)
+const (
+	PrinterStateIdle       = 3
+	PrinterStateProcessing = 4
+	PrinterStateStopped    = 5
+)
+
+// Values of the job-state attribute (RFC 8011, 5.3.7)
+const (
+	JobStatePending           = 3
+	JobStatePendingHeld       = 4
+	JobStateProcessing        = 5
+	JobStateProcessingStopped = 6
+	JobStateCanceled          = 7
+	JobStateAborted           = 8
+	JobStateCompleted         = 9
+)
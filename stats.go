@@ -0,0 +1,225 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Attribute statistics across many messages
+ */
+
+package goipp
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// StatsMaxDistinct is the maximum number of distinct values,
+// tracked per attribute by Stats. Beyond this limit, the
+// cardinality is not counted anymore, to bound memory usage.
+const StatsMaxDistinct = 1024
+
+// Stats aggregates attribute statistics across many messages:
+// attribute frequency, value cardinality and size distribution.
+//
+// It is intended for analysis of the traffic of a fleet of
+// printers, or for tuning of the DecoderOptions.AttributeFilter.
+//
+// Stats is not safe for concurrent use.
+type Stats struct {
+	Messages int // Count of messages added
+
+	attrs map[statsKey]*AttrStats // Per-attribute statistics
+}
+
+// AttrStats contains statistics of the single attribute,
+// identified by its group and name
+type AttrStats struct {
+	Group Tag    // Attribute group
+	Name  string // Attribute name
+
+	Count    int // Count of attribute occurrences
+	Messages int // Count of messages, containing the attribute
+	Values   int // Total count of values
+
+	// Cardinality is the count of distinct values (tag and
+	// value). If CardinalityOverflow is true, there are more
+	// than StatsMaxDistinct distinct values and Cardinality
+	// is not exact.
+	Cardinality         int
+	CardinalityOverflow bool
+
+	// Size distribution, in bytes of the wire encoding
+	// of the attribute, including all its values.
+	//
+	// Hist[i] contains count of attributes with size in range
+	// [2^i...2^(i+1)), Hist[0] also counts attributes of zero
+	// size.
+	MinSize   int
+	MaxSize   int
+	TotalSize int
+	Hist      []int
+
+	distinct map[string]struct{} // Distinct values
+	lastMsg  int                 // Last message that contained attribute
+}
+
+// statsKey identifies attribute in Stats
+type statsKey struct {
+	group Tag
+	name  string
+}
+
+// NewStats creates a new, empty, Stats
+func NewStats() *Stats {
+	return &Stats{attrs: make(map[statsKey]*AttrStats)}
+}
+
+// Add adds the message to the statistics
+func (s *Stats) Add(m *Message) {
+	s.Messages++
+
+	for _, grp := range m.attrGroups() {
+		for _, attr := range grp.Attrs {
+			key := statsKey{grp.Tag, attr.Name}
+			st := s.attrs[key]
+			if st == nil {
+				st = &AttrStats{
+					Group:    grp.Tag,
+					Name:     attr.Name,
+					distinct: make(map[string]struct{}),
+				}
+				s.attrs[key] = st
+			}
+
+			st.add(attr, s.Messages)
+		}
+	}
+}
+
+// Attr returns statistics of the particular attribute
+func (s *Stats) Attr(group Tag, name string) (AttrStats, bool) {
+	st := s.attrs[statsKey{group, name}]
+	if st == nil {
+		return AttrStats{}, false
+	}
+	return st.export(), true
+}
+
+// Attrs returns statistics of all attributes, ordered by
+// frequency (most frequent first), then by group and name
+func (s *Stats) Attrs() []AttrStats {
+	stats := make([]AttrStats, 0, len(s.attrs))
+	for _, st := range s.attrs {
+		stats = append(stats, st.export())
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		a, b := &stats[i], &stats[j]
+		switch {
+		case a.Messages != b.Messages:
+			return a.Messages > b.Messages
+		case a.Group != b.Group:
+			return a.Group < b.Group
+		}
+		return a.Name < b.Name
+	})
+
+	return stats
+}
+
+// String returns the tabular report of the statistics
+func (s *Stats) String() string {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "%d messages\n", s.Messages)
+	for _, st := range s.Attrs() {
+		card := fmt.Sprintf("%d", st.Cardinality)
+		if st.CardinalityOverflow {
+			card += "+"
+		}
+
+		fmt.Fprintf(&buf, "%s/%s: messages=%d values=%d "+
+			"distinct=%s size=%d..%d avg=%d\n",
+			st.Group, st.Name, st.Messages, st.Values, card,
+			st.MinSize, st.MaxSize, st.TotalSize/st.Count)
+	}
+
+	return buf.String()
+}
+
+// export returns copy of AttrStats, safe to be returned to the caller
+func (st *AttrStats) export() AttrStats {
+	out := *st
+	out.Hist = append([]int(nil), st.Hist...)
+	out.distinct = nil
+	return out
+}
+
+// add adds the attribute to the statistics
+func (st *AttrStats) add(attr Attribute, msg int) {
+	st.Count++
+	if st.lastMsg != msg {
+		st.Messages++
+		st.lastMsg = msg
+	}
+
+	// Count values
+	st.Values += len(attr.Values)
+	for _, val := range attr.Values {
+		if st.CardinalityOverflow {
+			break
+		}
+
+		key := fmt.Sprintf("%s:%s", val.T, val.V)
+		if _, found := st.distinct[key]; !found {
+			if len(st.distinct) == StatsMaxDistinct {
+				st.CardinalityOverflow = true
+				st.distinct = nil
+				break
+			}
+			st.distinct[key] = struct{}{}
+			st.Cardinality++
+		}
+	}
+
+	// Update size distribution
+	size := statsAttrSize(attr)
+	if st.Count == 1 || size < st.MinSize {
+		st.MinSize = size
+	}
+	if size > st.MaxSize {
+		st.MaxSize = size
+	}
+	st.TotalSize += size
+
+	bucket := 0
+	for n := size; n > 1; n >>= 1 {
+		bucket++
+	}
+
+	for len(st.Hist) <= bucket {
+		st.Hist = append(st.Hist, 0)
+	}
+	st.Hist[bucket]++
+}
+
+// statsAttrSize returns size of the attribute wire encoding.
+// Attributes that cannot be encoded are counted as zero size.
+func statsAttrSize(attr Attribute) int {
+	var cnt statsCounter
+	me := messageEncoder{out: &cnt}
+	if me.encodeAttr(attr, true) != nil {
+		return 0
+	}
+	return int(cnt)
+}
+
+// statsCounter is the io.Writer that counts written bytes
+type statsCounter int
+
+// Write implements io.Writer interface
+func (cnt *statsCounter) Write(data []byte) (int, error) {
+	*cnt += statsCounter(len(data))
+	return len(data), nil
+}
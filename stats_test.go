@@ -0,0 +1,115 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Attribute statistics test
+ */
+
+package goipp
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestStats tests Stats aggregation
+func TestStats(t *testing.T) {
+	s := NewStats()
+
+	for i := 0; i < 3; i++ {
+		m := NewResponse(DefaultVersion, StatusOk, uint32(i))
+		m.Printer.Add(MakeAttr("printer-state", TagEnum, Integer(3+i%2)))
+		m.Printer.Add(MakeAttr("copies-supported", TagRange,
+			Range{1, 99}))
+		if i == 0 {
+			m.Printer.Add(MakeAttr("printer-name", TagName,
+				String("printer")))
+			m.Printer.Add(MakeAttr("printer-name", TagName,
+				String("duplicate")))
+		}
+		s.Add(m)
+	}
+
+	if s.Messages != 3 {
+		t.Errorf("Messages: %d, expected 3", s.Messages)
+	}
+
+	type testData struct {
+		name     string // Attribute name
+		count    int    // Expected Count
+		messages int    // Expected Messages
+		card     int    // Expected Cardinality
+		min, max int    // Expected MinSize and MaxSize
+	}
+
+	tests := []testData{
+		// 1 (tag) + 2 + 13 (name) + 2 + 4 (value) = 22
+		{"printer-state", 3, 3, 2, 22, 22},
+		// 1 (tag) + 2 + 16 (name) + 2 + 8 (value) = 29
+		{"copies-supported", 3, 3, 1, 29, 29},
+		// 1 (tag) + 2 + 12 (name) + 2 + len(value)
+		{"printer-name", 2, 1, 2, 24, 26},
+	}
+
+	for _, test := range tests {
+		st, ok := s.Attr(TagPrinterGroup, test.name)
+		if !ok {
+			t.Errorf("%s: not found", test.name)
+			continue
+		}
+
+		if st.Count != test.count || st.Messages != test.messages ||
+			st.Cardinality != test.card ||
+			st.MinSize != test.min || st.MaxSize != test.max {
+			t.Errorf("%s: %+v", test.name, st)
+		}
+
+		n := 0
+		for _, cnt := range st.Hist {
+			n += cnt
+		}
+		if n != st.Count {
+			t.Errorf("%s: Hist %v doesn't match count %d",
+				test.name, st.Hist, st.Count)
+		}
+	}
+
+	if _, ok := s.Attr(TagJobGroup, "printer-state"); ok {
+		t.Errorf("Attr: unexpected attribute found")
+	}
+
+	attrs := s.Attrs()
+	if len(attrs) != 3 || attrs[2].Name != "printer-name" {
+		t.Errorf("Attrs: wrong order: %+v", attrs)
+	}
+
+	str := s.String()
+	if !strings.HasPrefix(str, "3 messages\n") ||
+		!strings.Contains(str, "printer-attributes-tag/printer-name: "+
+			"messages=1 values=2 distinct=2 size=24..26 avg=25\n") {
+		t.Errorf("String:\n%s", str)
+	}
+}
+
+// TestStatsCardinalityOverflow tests Stats cardinality limit
+func TestStatsCardinalityOverflow(t *testing.T) {
+	s := NewStats()
+	for i := 0; i <= StatsMaxDistinct; i++ {
+		m := NewResponse(DefaultVersion, StatusOk, 1)
+		m.Job.Add(MakeAttr("job-name", TagName,
+			String(fmt.Sprintf("job-%d", i))))
+		s.Add(m)
+	}
+
+	st, _ := s.Attr(TagJobGroup, "job-name")
+	if !st.CardinalityOverflow || st.Cardinality != StatsMaxDistinct {
+		t.Errorf("Cardinality: %d, overflow: %v",
+			st.Cardinality, st.CardinalityOverflow)
+	}
+
+	if !strings.Contains(s.String(), "distinct=1024+") {
+		t.Errorf("String:\n%s", s)
+	}
+}
@@ -0,0 +1,103 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Mapping of response status to Go errors
+ */
+
+package goipp
+
+import (
+	"strings"
+)
+
+// StatusError is the error, returned by Response.Err for
+// non-successful responses.
+type StatusError struct {
+	Status      Status     // Response status
+	Message     string     // status-message, may be empty
+	Unsupported Attributes // Unsupported attributes, if any
+}
+
+// Errors for common non-successful statuses. They are intended
+// to be used as targets of errors.Is:
+//
+//	if errors.Is(rsp.Err(), goipp.ErrNotFound) {
+//	        ...
+//	}
+var (
+	ErrBadRequest                 = &StatusError{Status: StatusErrorBadRequest}
+	ErrForbidden                  = &StatusError{Status: StatusErrorForbidden}
+	ErrNotAuthenticated           = &StatusError{Status: StatusErrorNotAuthenticated}
+	ErrNotAuthorized              = &StatusError{Status: StatusErrorNotAuthorized}
+	ErrNotPossible                = &StatusError{Status: StatusErrorNotPossible}
+	ErrTimeout                    = &StatusError{Status: StatusErrorTimeout}
+	ErrNotFound                   = &StatusError{Status: StatusErrorNotFound}
+	ErrGone                       = &StatusError{Status: StatusErrorGone}
+	ErrDocumentFormatNotSupported = &StatusError{Status: StatusErrorDocumentFormatNotSupported}
+	ErrAttributesOrValues         = &StatusError{Status: StatusErrorAttributesOrValues}
+	ErrConflicting                = &StatusError{Status: StatusErrorConflicting}
+	ErrInternal                   = &StatusError{Status: StatusErrorInternal}
+	ErrOperationNotSupported      = &StatusError{Status: StatusErrorOperationNotSupported}
+	ErrServiceUnavailable         = &StatusError{Status: StatusErrorServiceUnavailable}
+	ErrVersionNotSupported        = &StatusError{Status: StatusErrorVersionNotSupported}
+	ErrNotAcceptingJobs           = &StatusError{Status: StatusErrorNotAcceptingJobs}
+	ErrBusy                       = &StatusError{Status: StatusErrorBusy}
+)
+
+// Err returns nil for successful responses (status codes
+// 0x0000-0x00ff) and *StatusError otherwise.
+//
+// StatusError contains status-message and attributes of the
+// unsupported-attributes group of the response.
+func (rsp Response) Err() error {
+	status := rsp.Status()
+	if status <= 0x00ff {
+		return nil
+	}
+
+	err := &StatusError{
+		Status:  status,
+		Message: rsp.StatusMessage(),
+	}
+
+	for _, grp := range rsp.attrGroups() {
+		if grp.Tag == TagUnsupportedGroup {
+			err.Unsupported = append(err.Unsupported, grp.Attrs...)
+		}
+	}
+
+	return err
+}
+
+// Error returns the error string. It contains status name,
+// status-message and names of unsupported attributes, if any:
+//
+//	client-error-attributes-or-values-not-supported: Bad media (unsupported: media, sides)
+func (e *StatusError) Error() string {
+	s := e.Status.String()
+
+	if e.Message != "" {
+		s += ": " + e.Message
+	}
+
+	if len(e.Unsupported) != 0 {
+		names := make([]string, len(e.Unsupported))
+		for i, attr := range e.Unsupported {
+			names[i] = attr.Name
+		}
+		s += " (unsupported: " + strings.Join(names, ", ") + ")"
+	}
+
+	return s
+}
+
+// Is reports whether target is *StatusError with the same Status.
+// It is used by errors.Is, so any StatusError matches ErrNotFound
+// and similar variables of the same status, regardless of message
+// and unsupported attributes.
+func (e *StatusError) Is(target error) bool {
+	t, ok := target.(*StatusError)
+	return ok && t.Status == e.Status
+}
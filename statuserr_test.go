@@ -0,0 +1,108 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Mapping of response status to Go errors test
+ */
+
+package goipp
+
+import (
+	"testing"
+)
+
+// TestResponseErr tests Response.Err
+func TestResponseErr(t *testing.T) {
+	type testData struct {
+		status      Status
+		message     string
+		unsupported []string
+		err         string
+		is          *StatusError
+	}
+
+	tests := []testData{
+		{
+			status: StatusOk,
+		},
+
+		{
+			status: StatusOkIgnoredOrSubstituted,
+		},
+
+		{
+			status: StatusErrorNotFound,
+			err:    "client-error-not-found",
+			is:     ErrNotFound,
+		},
+
+		{
+			status:  StatusErrorNotFound,
+			message: "No such job",
+			err:     "client-error-not-found: No such job",
+			is:      ErrNotFound,
+		},
+
+		{
+			status:      StatusErrorAttributesOrValues,
+			message:     "Bad options",
+			unsupported: []string{"media", "sides"},
+			err: "client-error-attributes-or-values-not-supported: " +
+				"Bad options (unsupported: media, sides)",
+			is: ErrAttributesOrValues,
+		},
+
+		{
+			status: StatusErrorBusy,
+			err:    "server-error-busy",
+			is:     ErrBusy,
+		},
+
+		{
+			status: StatusRedirectionOtherSite,
+			err:    "redirection-other-site",
+		},
+	}
+
+	for _, test := range tests {
+		m := NewResponse(DefaultVersion, test.status, 1)
+		rsp := AsResponse(m)
+		if test.message != "" {
+			rsp.SetStatusMessage(test.message)
+		}
+		for _, name := range test.unsupported {
+			m.Unsupported.Add(MakeAttr(name, TagUnsupportedValue,
+				Void{}))
+		}
+
+		err := rsp.Err()
+		if test.err == "" {
+			assertNoError(t, err)
+			continue
+		}
+
+		assertErrorIs(t, err, test.err)
+
+		e, ok := err.(*StatusError)
+		if !ok {
+			t.Errorf("%s: %T returned, *StatusError expected",
+				test.status, err)
+			continue
+		}
+
+		if len(e.Unsupported) != len(test.unsupported) {
+			t.Errorf("%s: %d unsupported attributes, expected %d",
+				test.status, len(e.Unsupported),
+				len(test.unsupported))
+		}
+
+		if test.is != nil && !e.Is(test.is) {
+			t.Errorf("%s: doesn't match %s", test.status, test.is)
+		}
+
+		if e.Is(ErrInternal) {
+			t.Errorf("%s: matches %s", test.status, ErrInternal)
+		}
+	}
+}
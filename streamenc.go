@@ -0,0 +1,155 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Group-wise streaming encoder
+ */
+
+package goipp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Encoder writes the message into the output stream incrementally,
+// group by group and attribute by attribute, without building the
+// Message in memory.
+//
+// It is intended for servers, that want to start sending the
+// response (i.e., its operation group) to the HTTP response writer
+// while the rest of it is still being computed:
+//
+//	enc := goipp.NewEncoder(w, goipp.DefaultVersion,
+//		goipp.Code(goipp.StatusOk), req.RequestID)
+//	enc.BeginGroup(goipp.TagOperationGroup)
+//	enc.WriteAttribute(charset)
+//	enc.WriteAttribute(language)
+//	enc.Flush()
+//
+//	enc.BeginGroup(goipp.TagPrinterGroup)
+//	for _, attr := range computePrinterAttrs() {
+//		enc.WriteAttribute(attr)
+//	}
+//
+//	err := enc.End()
+//
+// Message header is written before the first group. Errors are
+// sticky: after the first error, all subsequent calls return
+// the same error, so it is enough to check the result of End.
+//
+// Note, once something was written, errors cannot be reported to
+// the peer in the IPP way, as the status code is already sent.
+// See also AttributeWriter for streaming of huge attributes.
+type Encoder struct {
+	me      messageEncoder // Underlying encoder
+	version Version        // Message version
+	code    Code           // Operation or status code
+	id      uint32         // Request ID
+	started bool           // Header is written
+	group   Tag            // Current group, TagZero if none
+	ended   bool           // End is called
+	err     error          // Sticky error
+}
+
+// NewEncoder creates a new Encoder for the message with the
+// specified version, operation or status code and request ID
+func NewEncoder(out io.Writer, v Version, code Code, id uint32) *Encoder {
+	return &Encoder{
+		me:      messageEncoder{out: out},
+		version: v,
+		code:    code,
+		id:      id,
+	}
+}
+
+// BeginGroup starts the new group of attributes. The previous
+// group, if any, ends implicitly. Groups with the same tag may
+// be written many times (i.e., multiple job groups of the Get-Jobs
+// response).
+func (enc *Encoder) BeginGroup(tag Tag) error {
+	if enc.check() != nil {
+		return enc.err
+	}
+
+	if !tag.IsGroup() {
+		enc.err = fmt.Errorf("Tag %s is not a group tag", tag)
+		return enc.err
+	}
+
+	enc.err = enc.me.encodeTag(tag)
+	if enc.err == nil {
+		enc.group = tag
+	}
+
+	return enc.err
+}
+
+// WriteAttribute writes attribute into the current group
+func (enc *Encoder) WriteAttribute(attr Attribute) error {
+	switch {
+	case enc.check() != nil:
+	case enc.group == TagZero:
+		enc.err = fmt.Errorf("%s: attribute outside of group", attr.Name)
+	case attr.Name == "":
+		enc.err = errors.New("Attribute without name")
+	default:
+		enc.err = enc.me.encodeAttr(attr, true)
+	}
+
+	return enc.err
+}
+
+// End completes the message. After End, Encoder cannot be used
+// anymore.
+func (enc *Encoder) End() error {
+	if enc.check() != nil {
+		return enc.err
+	}
+
+	enc.err = enc.me.encodeTag(TagEnd)
+	enc.ended = true
+
+	return enc.err
+}
+
+// Flush flushes the output stream, if it supports flushing, like
+// http.ResponseWriter (via http.Flusher) and bufio.Writer do, so
+// data, written so far, is sent to the peer.
+func (enc *Encoder) Flush() error {
+	if enc.err != nil {
+		return enc.err
+	}
+
+	switch out := enc.me.out.(type) {
+	case interface{ Flush() error }:
+		enc.err = out.Flush()
+	case interface{ Flush() }:
+		out.Flush()
+	}
+
+	return enc.err
+}
+
+// check returns the sticky error and writes message header,
+// if not written yet
+func (enc *Encoder) check() error {
+	switch {
+	case enc.err != nil:
+	case enc.ended:
+		enc.err = errors.New("Encoder: message already ended")
+	case !enc.started:
+		enc.started = true
+		enc.err = enc.me.encodeU16(uint16(enc.version))
+		if enc.err == nil {
+			enc.err = enc.me.encodeU16(uint16(enc.code))
+		}
+		if enc.err == nil {
+			enc.err = enc.me.encodeU32(enc.id)
+		}
+	}
+
+	return enc.err
+}
@@ -0,0 +1,89 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Group-wise streaming encoder test
+ */
+
+package goipp
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestEncoder tests Encoder
+func TestEncoder(t *testing.T) {
+	m := NewResponse(DefaultVersion, StatusOk, 123)
+	m.Groups = Groups{
+		{TagOperationGroup, Attributes{
+			MakeAttr("attributes-charset", TagCharset, String("utf-8")),
+			MakeAttr("attributes-natural-language", TagLanguage,
+				String("en-US")),
+		}},
+		{TagJobGroup, Attributes{
+			MakeAttr("job-id", TagInteger, Integer(1)),
+		}},
+		{TagJobGroup, Attributes{
+			MakeAttr("job-id", TagInteger, Integer(2)),
+			MakeAttrCollection("media-col",
+				MakeAttr("media-type", TagKeyword,
+					String("stationery"))),
+		}},
+		{TagPrinterGroup, nil},
+	}
+
+	expected, err := m.EncodeBytes()
+	assertNoError(t, err)
+
+	// Encode the same message via Encoder
+	rec := httptest.NewRecorder()
+	enc := NewEncoder(rec, m.Version, m.Code, m.RequestID)
+
+	for i, grp := range m.Groups {
+		assertNoError(t, enc.BeginGroup(grp.Tag))
+		for _, attr := range grp.Attrs {
+			assertNoError(t, enc.WriteAttribute(attr))
+		}
+
+		if i == 0 {
+			assertNoError(t, enc.Flush())
+			if !rec.Flushed {
+				t.Errorf("Flush: output not flushed")
+			}
+		}
+	}
+
+	assertNoError(t, enc.End())
+
+	if !bytes.Equal(rec.Body.Bytes(), expected) {
+		t.Errorf("Encoder output mismatch\nexpected: %x\npresent:  %x",
+			expected, rec.Body.Bytes())
+	}
+
+	// Errors
+	var buf bytes.Buffer
+	attr := MakeAttr("job-id", TagInteger, Integer(1))
+
+	enc = NewEncoder(&buf, DefaultVersion, 0, 1)
+	err = enc.WriteAttribute(attr)
+	assertErrorIs(t, err, "job-id: attribute outside of group")
+	err = enc.End()
+	assertErrorIs(t, err, "job-id: attribute outside of group")
+
+	enc = NewEncoder(&buf, DefaultVersion, 0, 1)
+	err = enc.BeginGroup(TagInteger)
+	assertErrorIs(t, err, "Tag integer is not a group tag")
+
+	enc = NewEncoder(&buf, DefaultVersion, 0, 1)
+	enc.BeginGroup(TagOperationGroup)
+	err = enc.WriteAttribute(Attribute{Name: "job-id"})
+	assertErrorIs(t, err, "Attribute without value")
+
+	enc = NewEncoder(&buf, DefaultVersion, 0, 1)
+	assertNoError(t, enc.End())
+	err = enc.BeginGroup(TagOperationGroup)
+	assertErrorIs(t, err, "Encoder: message already ended")
+}
@@ -0,0 +1,209 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Localization strings catalog (.strings files)
+ */
+
+package goipp
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+)
+
+// StringsCatalog represents the localization strings catalog,
+// returned by printer-strings-uri (PWG 5100.13).
+//
+// The catalog maps keys to localized strings. Keys have the
+// "attribute-name.keyword" form for keyword values (i.e.,
+// "media-type.stationery"), or just "attribute-name" for the
+// attribute names themselves.
+type StringsCatalog map[string]string
+
+// ParseStrings parses the Apple/CUPS .strings file, formatted as:
+//
+//	/* Comment */
+//	"key" = "value";
+//	// Another comment
+//
+// Data is expected to be UTF-8 encoded. UTF-8 BOM, if present, is
+// ignored. Within quoted strings, \", \\, \n, \r, \t and \Uxxxx
+// escapes are recognized.
+func ParseStrings(data []byte) (StringsCatalog, error) {
+	p := stringsParser{data: bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")),
+		line: 1}
+	catalog := make(StringsCatalog)
+
+	for {
+		err := p.skipSpace()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.eof() {
+			return catalog, nil
+		}
+
+		key, err := p.quoted()
+		if err == nil {
+			err = p.expect('=')
+		}
+
+		var val string
+		if err == nil {
+			val, err = p.quoted()
+		}
+
+		if err == nil {
+			err = p.expect(';')
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		catalog[key] = val
+	}
+}
+
+// LocalizeKeyword returns localized name of the keyword value of
+// the attribute. If catalog doesn't contain the translation, the
+// keyword itself is returned.
+func LocalizeKeyword(attrName, keyword string, catalog StringsCatalog) string {
+	if s, found := catalog[attrName+"."+keyword]; found {
+		return s
+	}
+	return keyword
+}
+
+// stringsParser represents the .strings file parser
+type stringsParser struct {
+	data []byte // Remaining data
+	line int    // Current line number
+}
+
+// eof returns true at the end of input
+func (p *stringsParser) eof() bool {
+	return len(p.data) == 0
+}
+
+// errorf returns error, prefixed with the line number
+func (p *stringsParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+// advance consumes n bytes of input, counting lines
+func (p *stringsParser) advance(n int) {
+	p.line += bytes.Count(p.data[:n], []byte("\n"))
+	p.data = p.data[n:]
+}
+
+// skipSpace skips white space and comments
+func (p *stringsParser) skipSpace() error {
+	for !p.eof() {
+		switch {
+		case bytes.HasPrefix(p.data, []byte("/*")):
+			end := bytes.Index(p.data[2:], []byte("*/"))
+			if end < 0 {
+				return p.errorf("unterminated comment")
+			}
+			p.advance(end + 4)
+
+		case bytes.HasPrefix(p.data, []byte("//")):
+			end := bytes.IndexByte(p.data, '\n')
+			if end < 0 {
+				end = len(p.data)
+			}
+			p.advance(end)
+
+		case p.data[0] == ' ' || p.data[0] == '\t' ||
+			p.data[0] == '\r' || p.data[0] == '\n':
+			p.advance(1)
+
+		default:
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// expect skips white space and consumes the expected character
+func (p *stringsParser) expect(c byte) error {
+	err := p.skipSpace()
+	if err != nil {
+		return err
+	}
+
+	if p.eof() || p.data[0] != c {
+		return p.errorf("%q expected", c)
+	}
+
+	p.advance(1)
+	return nil
+}
+
+// quoted skips white space and parses the quoted string
+func (p *stringsParser) quoted() (string, error) {
+	err := p.skipSpace()
+	if err != nil {
+		return "", err
+	}
+
+	if p.eof() || p.data[0] != '"' {
+		return "", p.errorf("quoted string expected")
+	}
+
+	var buf bytes.Buffer
+	i := 1
+
+	for i < len(p.data) {
+		c := p.data[i]
+		switch c {
+		case '"':
+			p.advance(i + 1)
+			return buf.String(), nil
+
+		case '\\':
+			if i+1 >= len(p.data) {
+				break
+			}
+
+			i++
+			switch e := p.data[i]; e {
+			case 'n':
+				buf.WriteByte('\n')
+			case 'r':
+				buf.WriteByte('\r')
+			case 't':
+				buf.WriteByte('\t')
+			case 'U', 'u':
+				if i+5 > len(p.data) {
+					return "", p.errorf("invalid \\U escape")
+				}
+				r, err := strconv.ParseUint(string(p.data[i+1:i+5]),
+					16, 16)
+				if err != nil {
+					return "", p.errorf("invalid \\U escape")
+				}
+				var tmp [utf8.UTFMax]byte
+				n := utf8.EncodeRune(tmp[:], rune(r))
+				buf.Write(tmp[:n])
+				i += 4
+			default:
+				buf.WriteByte(e)
+			}
+
+		default:
+			buf.WriteByte(c)
+		}
+
+		i++
+	}
+
+	return "", p.errorf("unterminated string")
+}
@@ -0,0 +1,70 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Localization strings catalog test
+ */
+
+package goipp
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseStrings tests ParseStrings
+func TestParseStrings(t *testing.T) {
+	data := "\xef\xbb\xbf" +
+		"/* Media types */\n" +
+		"\"media-type\" = \"Media Type\";\n" +
+		"\"media-type.stationery\" = \"Plain Paper\";\n" +
+		"// Escapes\n" +
+		"\"printer-name\"=\"\\\"Office\\\"\\n\\U00e9\";"
+
+	expected := StringsCatalog{
+		"media-type":            "Media Type",
+		"media-type.stationery": "Plain Paper",
+		"printer-name":          "\"Office\"\n\u00e9",
+	}
+
+	catalog, err := ParseStrings([]byte(data))
+	assertNoError(t, err)
+
+	if !reflect.DeepEqual(catalog, expected) {
+		t.Errorf("ParseStrings:\nexpected: %q\npresent:  %q",
+			expected, catalog)
+	}
+
+	s := LocalizeKeyword("media-type", "stationery", catalog)
+	if s != "Plain Paper" {
+		t.Errorf("LocalizeKeyword: expected %q, present %q",
+			"Plain Paper", s)
+	}
+
+	s = LocalizeKeyword("media-type", "labels", catalog)
+	if s != "labels" {
+		t.Errorf("LocalizeKeyword: expected %q, present %q",
+			"labels", s)
+	}
+}
+
+// TestParseStringsErrors tests ParseStrings errors
+func TestParseStringsErrors(t *testing.T) {
+	tests := []struct {
+		data string
+		err  string
+	}{
+		{"/* comment", "line 1: unterminated comment"},
+		{"\n\nkey = \"value\";", "line 3: quoted string expected"},
+		{"\"key\" \"value\";", "line 1: '=' expected"},
+		{"\"key\" = \"value\"", "line 1: ';' expected"},
+		{"\"key\" = \"value", "line 1: unterminated string"},
+		{"\"key\" = \"\\U00zz\";", "line 1: invalid \\U escape"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseStrings([]byte(test.data))
+		assertErrorIs(t, err, test.err)
+	}
+}
@@ -0,0 +1,112 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Matching of values against xxx-supported attributes
+ */
+
+package goipp
+
+// Supported represents the value of the xxx-supported printer
+// attribute, so validation logic works uniformly regardless of
+// how printer advertises support.
+type Supported interface {
+	// Matches reports whether the value is supported
+	Matches(v Value) bool
+}
+
+var (
+	_ = Supported(SupportedBoolean(false))
+	_ = Supported(SupportedSet(nil))
+)
+
+// SupportedBoolean represents the boolean form of xxx-supported
+// attribute (i.e., page-ranges-supported): if true, any value is
+// supported, otherwise none.
+type SupportedBoolean bool
+
+// Matches reports whether the value is supported
+func (sup SupportedBoolean) Matches(v Value) bool {
+	return bool(sup)
+}
+
+// SupportedSet represents xxx-supported attribute as a set of
+// supported values. Value matches the set, if it matches any of
+// its values:
+//   - Integer matches equal Integer or Range that contains it
+//     (i.e., copies-supported is rangeOfInteger)
+//   - Range matches Range that contains it
+//   - Collection matches Collection, if each of its members is
+//     supported by the same-named member of the supported
+//     collection (i.e., media-size-supported, where dimensions
+//     may be integers or ranges)
+//   - Other values match similar values (see ValueSimilar),
+//     so String matches Keyword or MimeType with the same text
+type SupportedSet Values
+
+// ParseSupported parses xxx-supported attribute. It returns
+// SupportedBoolean, if attribute has a single boolean value, or
+// SupportedSet otherwise.
+func ParseSupported(attr Attribute) Supported {
+	if len(attr.Values) == 1 {
+		if v, ok := attr.Values[0].V.(Boolean); ok {
+			return SupportedBoolean(v)
+		}
+	}
+
+	return SupportedSet(attr.Values)
+}
+
+// Matches reports whether the value is supported
+func (sup SupportedSet) Matches(v Value) bool {
+	for _, s := range sup {
+		if supportedMatches(s.V, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// supportedMatches reports whether value v matches the single
+// supported value s
+func supportedMatches(s, v Value) bool {
+	switch v := v.(type) {
+	case Integer:
+		if s, ok := s.(IntegerOrRange); ok {
+			return s.Within(int(v))
+		}
+		return false
+
+	case Range:
+		if s, ok := s.(Range); ok {
+			return s.Lower <= v.Lower && v.Upper <= s.Upper
+		}
+		return false
+
+	case Collection:
+		s, ok := s.(Collection)
+		if !ok {
+			return false
+		}
+
+		for _, member := range v {
+			supMember, found := attrsFind(Attributes(s), member.Name)
+			if !found {
+				return false
+			}
+
+			set := SupportedSet(supMember.Values)
+			for _, val := range member.Values {
+				if !set.Matches(val.V) {
+					return false
+				}
+			}
+		}
+
+		return true
+	}
+
+	return ValueSimilar(s, v)
+}
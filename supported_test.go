@@ -0,0 +1,92 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Matching of values against xxx-supported attributes test
+ */
+
+package goipp
+
+import (
+	"testing"
+)
+
+// TestSupported tests ParseSupported and Supported.Matches
+func TestSupported(t *testing.T) {
+	size := func(x, y Value) Collection {
+		return Collection{
+			MakeAttr("x-dimension", TagInteger, x),
+			MakeAttr("y-dimension", TagInteger, y),
+		}
+	}
+
+	sizes := Attribute{Name: "media-size-supported"}
+	sizes.Values.Add(TagBeginCollection,
+		size(Integer(21000), Integer(29700)))
+	sizes.Values.Add(TagBeginCollection,
+		size(Range{7620, 21590}, Range{12700, 35560}))
+
+	tests := []struct {
+		attr    Attribute
+		v       Value
+		matches bool
+	}{
+		// Boolean form
+		{MakeAttr("page-ranges-supported", TagBoolean, Boolean(true)),
+			Range{1, 5}, true},
+		{MakeAttr("page-ranges-supported", TagBoolean, Boolean(false)),
+			Range{1, 5}, false},
+
+		// rangeOfInteger
+		{MakeAttr("copies-supported", TagRange, Range{1, 99}),
+			Integer(1), true},
+		{MakeAttr("copies-supported", TagRange, Range{1, 99}),
+			Integer(100), false},
+		{MakeAttr("copies-supported", TagRange, Range{1, 99}),
+			String("1"), false},
+
+		// 1setOf (integer | rangeOfInteger)
+		{MakeAttr("number-up-supported", TagInteger, Integer(1),
+			Range{2, 4}), Integer(3), true},
+		{MakeAttr("number-up-supported", TagInteger, Integer(1),
+			Range{2, 4}), Integer(6), false},
+
+		// Range within range
+		{MakeAttr("job-pages-per-set-supported", TagRange,
+			Range{1, 100}), Range{5, 10}, true},
+		{MakeAttr("job-pages-per-set-supported", TagRange,
+			Range{1, 100}), Range{50, 150}, false},
+
+		// 1setOf keyword and enum
+		{MakeAttr("sides-supported", TagKeyword, String("one-sided"),
+			String("two-sided-long-edge")),
+			String("two-sided-long-edge"), true},
+		{MakeAttr("sides-supported", TagKeyword, String("one-sided"),
+			String("two-sided-long-edge")),
+			Keyword("two-sided-long-edge"), true},
+		{MakeAttr("sides-supported", TagKeyword, String("one-sided")),
+			String("two-sided-short-edge"), false},
+		{MakeAttr("orientation-requested-supported", TagEnum,
+			Integer(3), Integer(4)), Integer(4), true},
+
+		// Collections of integers and ranges
+		{sizes, size(Integer(21000), Integer(29700)), true},
+		{sizes, size(Integer(10000), Integer(15000)), true},
+		{sizes, size(Integer(29700), Integer(42000)), false},
+		{sizes, Collection{MakeAttr("x-dimension", TagInteger,
+			Integer(21000))}, true},
+		{sizes, Collection{MakeAttr("z-dimension", TagInteger,
+			Integer(21000))}, false},
+		{sizes, Integer(21000), false},
+	}
+
+	for _, test := range tests {
+		matches := ParseSupported(test.attr).Matches(test.v)
+		if matches != test.matches {
+			t.Errorf("%s %s: Matches(%s): %v, expected %v",
+				test.attr.Name, test.attr.Values, test.v,
+				matches, test.matches)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Registered syntax of well-known attributes
+ */
+
+package goipp
+
+import (
+	"strconv"
+	"strings"
+)
+
+// AttrSyntax returns the registered syntax of the well-known
+// attribute, as it is written in the IPP specifications, i.e.,
+// "uri(1023)", "text(127)" or "1setOf keyword", or empty string,
+// if attribute is not known.
+//
+// The xxx-default attributes have the same syntax, as xxx.
+//
+// Syntax is sourced from the same built-in registry, which is
+// used by LintTagMismatch and ValueLengthLimit.
+func AttrSyntax(name string) string {
+	base := strings.TrimSuffix(name, "-default")
+
+	tags, found := lintAttrs[base]
+	if !found {
+		return ""
+	}
+
+	var syntaxes []string
+	for _, tag := range tags {
+		s := syntaxName(name, tag)
+		if len(syntaxes) == 0 || syntaxes[len(syntaxes)-1] != s {
+			syntaxes = append(syntaxes, s)
+		}
+	}
+
+	syntax := strings.Join(syntaxes, " | ")
+	switch {
+	case syntax == "":
+		return ""
+	case len(syntaxes) > 1 && attrSetOf[base]:
+		return "1setOf (" + syntax + ")"
+	case attrSetOf[base]:
+		return "1setOf " + syntax
+	}
+
+	return syntax
+}
+
+// syntaxName returns the name of the value syntax (RFC 8011, 5.1),
+// which corresponds to the tag, with the length limit for text,
+// name and uri
+func syntaxName(name string, tag Tag) string {
+	var s string
+
+	switch tag {
+	case TagText, TagTextLang:
+		s = "text"
+	case TagName, TagNameLang:
+		s = "name"
+	case TagURI:
+		s = "uri"
+	case TagString:
+		return "octetString"
+	case TagRange:
+		return "rangeOfInteger"
+	case TagMimeType:
+		return "mimeMediaType"
+	case TagBeginCollection:
+		return "collection"
+	default:
+		return tag.String()
+	}
+
+	max := ValueLengthLimit(name, tag)
+	switch {
+	case (s == "text" && max == MaxTextLength) ||
+		(s == "name" && max == MaxNameLength):
+		s += "(MAX)"
+	case max != 0:
+		s += "(" + strconv.Itoa(int(max)) + ")"
+	}
+
+	return s
+}
+
+// attrSetOf contains well-known attributes, registered as 1setOf
+var attrSetOf = map[string]bool{
+	"document-format-varying-attributes": true,
+	"finishings":                         true,
+	"finishings-col":                     true,
+	"identify-actions":                   true,
+	"job-ids":                            true,
+	"job-state-reasons":                  true,
+	"marker-colors":                      true,
+	"marker-high-levels":                 true,
+	"marker-levels":                      true,
+	"marker-low-levels":                  true,
+	"marker-names":                       true,
+	"marker-types":                       true,
+	"page-ranges":                        true,
+	"printer-alert":                      true,
+	"printer-alert-description":          true,
+	"printer-finisher":                   true,
+	"printer-finisher-description":       true,
+	"printer-icons":                      true,
+	"printer-input-tray":                 true,
+	"printer-output-tray":                true,
+	"printer-state-reasons":              true,
+	"printer-supply":                     true,
+	"printer-supply-description":         true,
+	"requested-attributes":               true,
+}
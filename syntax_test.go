@@ -0,0 +1,55 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Registered syntax of well-known attributes test
+ */
+
+package goipp
+
+import (
+	"testing"
+)
+
+// TestAttrSyntax tests AttrSyntax
+func TestAttrSyntax(t *testing.T) {
+	type testData struct {
+		name   string // Attribute name
+		syntax string // Expected syntax
+	}
+
+	tests := []testData{
+		{"printer-uri", "uri(1023)"},
+		{"printer-name", "name(127)"},
+		{"job-name", "name(MAX)"},
+		{"printer-info", "text(127)"},
+		{"status-message", "text(255)"},
+		{"document-format", "mimeMediaType"},
+		{"attributes-charset", "charset"},
+		{"attributes-natural-language", "naturalLanguage"},
+		{"copies", "integer"},
+		{"copies-default", "integer"},
+		{"print-quality", "enum"},
+		{"printer-resolution", "resolution"},
+		{"date-time-at-creation", "dateTime"},
+		{"document-password", "octetString"},
+		{"media-col", "collection"},
+		{"media", "keyword | name(MAX)"},
+		{"job-state-reasons", "1setOf keyword"},
+		{"page-ranges", "1setOf rangeOfInteger"},
+		{"finishings-default", "1setOf enum"},
+		{"marker-names", "1setOf name(MAX)"},
+		{"printer-alert-description", "1setOf text(MAX)"},
+		{"epcl-version", ""},
+		{"x-vendor", ""},
+	}
+
+	for _, test := range tests {
+		syntax := AttrSyntax(test.name)
+		if syntax != test.syntax {
+			t.Errorf("%s: expected %q, present %q",
+				test.name, test.syntax, syntax)
+		}
+	}
+}
@@ -10,6 +10,8 @@ package goipp
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 )
 
 // Tag represents a tag used in a binary representation
@@ -78,6 +80,12 @@ func (tag Tag) IsGroup() bool {
 	return tag.IsDelimiter() && tag != TagZero && tag != TagEnd
 }
 
+// IsOutOfBand returns true for out-of-band value tags, like
+// TagNoValue or TagUnknown (RFC 8010, 3.5.2)
+func (tag Tag) IsOutOfBand() bool {
+	return uint(tag) >= 0x10 && uint(tag) < 0x20
+}
+
 // Type returns Type of Value that corresponds to the tag
 func (tag Tag) Type() Type {
 	if tag.IsDelimiter() {
@@ -181,3 +189,32 @@ var tagNames = [...]string{
 	TagMimeType:         "mimeMediaType",
 	TagMemberName:       "memberAttrName",
 }
+
+// tagByName maps tag names, as returned by Tag.String(), back to tags
+var tagByName = map[string]Tag{}
+
+func init() {
+	for tag, s := range tagNames {
+		if s != "" {
+			tagByName[s] = Tag(tag)
+		}
+	}
+}
+
+// parseTag parses tag name, as returned by Tag.String(), back to Tag.
+// In addition to symbolic names, the hexadecimal form (i.e., "0x7f"
+// or "0x40000000") is accepted.
+func parseTag(s string) (Tag, error) {
+	if tag, ok := tagByName[s]; ok {
+		return tag, nil
+	}
+
+	if strings.HasPrefix(s, "0x") && len(s) > 2 {
+		v, err := strconv.ParseUint(s[2:], 16, 32)
+		if err == nil && v <= 0x7fffffff {
+			return Tag(v), nil
+		}
+	}
+
+	return TagZero, fmt.Errorf("Unknown tag %q", s)
+}
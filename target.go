@@ -0,0 +1,129 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Consistency check of printer-uri and HTTP request target
+ */
+
+package goipp
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// TargetCheck defines strictness of CheckPrinterURI
+type TargetCheck int
+
+// TargetCheck values:
+const (
+	// TargetCheckPath checks only that path of printer-uri
+	// matches path of the HTTP request target. This is the default.
+	TargetCheckPath TargetCheck = iota
+
+	// TargetCheckHost additionally checks that host of
+	// printer-uri matches the HTTP Host header, ignoring port
+	TargetCheckHost
+
+	// TargetCheckStrict additionally checks the port and that
+	// secure schemes (ipps, https) are used if and only if the
+	// request is received via TLS
+	TargetCheckStrict
+)
+
+// CheckPrinterURI checks that the printer-uri operation attribute
+// of the request matches the target of the HTTP request r, which
+// carries it. Per RFC 8011, 9.1 and RFC 7472, server should reject
+// the request, addressed to the printer it doesn't expect at this
+// HTTP target, i.e., with the client-error-bad-request status.
+//
+// Host names are compared case-insensitively. Missed port means the
+// default port of the scheme (631 for ipp and ipps). Missed path
+// means "/".
+//
+// If the request doesn't contain printer-uri, CheckPrinterURI
+// returns nil; presence of required attributes is checked
+// elsewhere (see Lint).
+func CheckPrinterURI(req *Message, r *http.Request, check TargetCheck) error {
+	s := req.operationText("printer-uri")
+	if s == "" {
+		return nil
+	}
+
+	uri, err := url.Parse(s)
+	if err != nil || uri.Host == "" {
+		return fmt.Errorf("printer-uri: %q: invalid URI", s)
+	}
+
+	scheme := strings.ToLower(uri.Scheme)
+	switch scheme {
+	case "ipp", "ipps", "http", "https":
+	default:
+		return fmt.Errorf("printer-uri: %q: invalid scheme", s)
+	}
+
+	if targetPath(uri.Path) != targetPath(r.URL.Path) {
+		return fmt.Errorf("printer-uri: path %q doesn't match "+
+			"request target %q", uri.Path, r.URL.Path)
+	}
+
+	if check < TargetCheckHost {
+		return nil
+	}
+
+	host := &url.URL{Host: r.Host}
+	if !strings.EqualFold(uri.Hostname(), host.Hostname()) {
+		return fmt.Errorf("printer-uri: host %q doesn't match "+
+			"request host %q", uri.Hostname(), host.Hostname())
+	}
+
+	if check < TargetCheckStrict {
+		return nil
+	}
+
+	secure := scheme == "ipps" || scheme == "https"
+	if secure != (r.TLS != nil) {
+		return fmt.Errorf("printer-uri: scheme %q doesn't match "+
+			"request security", uri.Scheme)
+	}
+
+	port, reqPort := uri.Port(), host.Port()
+	if port == "" {
+		port = targetDefaultPort(scheme)
+	}
+	if reqPort == "" {
+		reqPort = "80"
+		if r.TLS != nil {
+			reqPort = "443"
+		}
+	}
+
+	if port != reqPort {
+		return fmt.Errorf("printer-uri: port %s doesn't match "+
+			"request port %s", port, reqPort)
+	}
+
+	return nil
+}
+
+// targetPath returns normalized path of the target
+func targetPath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// targetDefaultPort returns default port of the URI scheme
+func targetDefaultPort(scheme string) string {
+	switch scheme {
+	case "http":
+		return "80"
+	case "https":
+		return "443"
+	}
+	return "631"
+}
@@ -0,0 +1,112 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Consistency check of printer-uri and HTTP request target test
+ */
+
+package goipp
+
+import (
+	"crypto/tls"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestCheckPrinterURI tests CheckPrinterURI
+func TestCheckPrinterURI(t *testing.T) {
+	type testData struct {
+		uri    string      // printer-uri, "" if missed
+		target string      // HTTP request target
+		tls    bool        // Request received via TLS
+		check  TargetCheck // Strictness
+		err    string      // Expected error
+	}
+
+	tests := []testData{
+		{uri: "", target: "http://localhost/ipp/print"},
+
+		{uri: "ipp://localhost/ipp/print",
+			target: "http://localhost:631/ipp/print"},
+
+		{uri: "ipp://printer.local/ipp/print",
+			target: "http://localhost/ipp/print"},
+
+		{uri: "ipp://localhost/ipp/print",
+			target: "http://localhost:631/ipp/scan",
+			err:    `printer-uri: path "/ipp/print" doesn't match request target "/ipp/scan"`},
+
+		{uri: "ipp://localhost", target: "http://localhost:631/"},
+
+		{uri: "ipp://printer.local/ipp/print",
+			target: "http://localhost/ipp/print",
+			check:  TargetCheckHost,
+			err:    `printer-uri: host "printer.local" doesn't match request host "localhost"`},
+
+		{uri: "ipp://LOCALHOST:8631/ipp/print",
+			target: "http://localhost:631/ipp/print",
+			check:  TargetCheckHost},
+
+		{uri: "ipp://localhost:8631/ipp/print",
+			target: "http://localhost:631/ipp/print",
+			check:  TargetCheckStrict,
+			err:    `printer-uri: port 8631 doesn't match request port 631`},
+
+		{uri: "ipp://localhost/ipp/print",
+			target: "http://localhost:631/ipp/print",
+			check:  TargetCheckStrict},
+
+		{uri: "ipps://localhost/ipp/print",
+			target: "https://localhost:631/ipp/print",
+			tls:    true,
+			check:  TargetCheckStrict},
+
+		{uri: "ipps://localhost/ipp/print",
+			target: "http://localhost:631/ipp/print",
+			check:  TargetCheckStrict,
+			err:    `printer-uri: scheme "ipps" doesn't match request security`},
+
+		{uri: "https://localhost/ipp/print",
+			target: "https://localhost/ipp/print",
+			tls:    true,
+			check:  TargetCheckStrict},
+
+		{uri: "ipp://[::1]/ipp/print",
+			target: "http://[::1]:631/ipp/print",
+			check:  TargetCheckStrict},
+
+		{uri: "mailto:root@localhost", target: "http://localhost/",
+			err: `printer-uri: "mailto:root@localhost": invalid URI`},
+
+		{uri: "ftp://localhost/", target: "http://localhost/",
+			err: `printer-uri: "ftp://localhost/": invalid scheme`},
+	}
+
+	for _, test := range tests {
+		req := NewRequest(DefaultVersion, OpGetPrinterAttributes, 1)
+		if test.uri != "" {
+			req.Operation.Add(MakeAttr("printer-uri", TagURI,
+				String(test.uri)))
+		}
+
+		r := httptest.NewRequest("POST", test.target, nil)
+		r.TLS = nil
+		if test.tls {
+			r.TLS = &tls.ConnectionState{}
+		}
+
+		err := CheckPrinterURI(req, r, test.check)
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("%q at %q: %s", test.uri, test.target, err)
+			}
+			continue
+		}
+
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%q at %q:\nexpected: %s\npresent:  %v",
+				test.uri, test.target, test.err, err)
+		}
+	}
+}
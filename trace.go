@@ -0,0 +1,78 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Tracing span attributes
+ */
+
+package goipp
+
+// Keys of the tracing span attributes, returned by
+// Message.SpanAttributes
+const (
+	SpanAttrVersion    = "ipp.version"     // Protocol version, string
+	SpanAttrOperation  = "ipp.operation"   // Operation name, string
+	SpanAttrStatus     = "ipp.status"      // Status name, string
+	SpanAttrCode       = "ipp.code"        // Operation or status code, int64
+	SpanAttrRequestID  = "ipp.request_id"  // Request ID, int64
+	SpanAttrPrinterURI = "ipp.printer_uri" // printer-uri, string
+	SpanAttrJobID      = "ipp.job_id"      // job-id, int64
+)
+
+// SpanAttributes returns key IPP fields of the message as a flat
+// map, suitable for use as tracing span attributes (i.e., with
+// OpenTelemetry).
+//
+// Values are either string or int64. The SpanAttrOperation or
+// SpanAttrStatus key is set depending on Message.Role; if role
+// is unknown, only SpanAttrCode is set. The SpanAttrPrinterURI
+// and SpanAttrJobID keys are set only if the corresponding
+// attributes present and have the appropriate type. The job-id
+// is taken from the operation attributes (request) or from the
+// first job attributes group (response).
+func (m *Message) SpanAttributes() map[string]interface{} {
+	attrs := map[string]interface{}{
+		SpanAttrVersion:   m.Version.String(),
+		SpanAttrCode:      int64(m.Code),
+		SpanAttrRequestID: int64(m.RequestID),
+	}
+
+	switch m.Role {
+	case MessageRoleRequest:
+		attrs[SpanAttrOperation] = Op(m.Code).String()
+	case MessageRoleResponse:
+		attrs[SpanAttrStatus] = Status(m.Code).String()
+	}
+
+	attr, found := spanFind(m, "printer-uri", TagOperationGroup)
+	if found && len(attr.Values) != 0 && attr.Values[0].T == TagURI {
+		attrs[SpanAttrPrinterURI] = attr.Values[0].V.String()
+	}
+
+	attr, found = spanFind(m, "job-id", TagOperationGroup, TagJobGroup)
+	if found && len(attr.Values) != 0 {
+		if v, ok := attr.Values[0].V.(Integer); ok {
+			attrs[SpanAttrJobID] = int64(v)
+		}
+	}
+
+	return attrs
+}
+
+// spanFind finds attribute by name in the first group with
+// the specified tag, trying tags in order
+func spanFind(m *Message, name string, tags ...Tag) (Attribute, bool) {
+	groups := m.attrGroups()
+	for _, tag := range tags {
+		for _, grp := range groups {
+			if grp.Tag == tag {
+				if attr, found := attrsFind(grp.Attrs, name); found {
+					return attr, true
+				}
+				break
+			}
+		}
+	}
+	return Attribute{}, false
+}
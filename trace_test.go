@@ -0,0 +1,74 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Tracing span attributes test
+ */
+
+package goipp
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestSpanAttributes tests Message.SpanAttributes
+func TestSpanAttributes(t *testing.T) {
+	rq := NewRequest(DefaultVersion, OpGetJobAttributes, 7)
+	rq.Operation.Add(MakeAttr("printer-uri", TagURI,
+		String("ipp://localhost/printers/test")))
+	rq.Operation.Add(MakeAttr("job-id", TagInteger, Integer(12)))
+
+	rsp := NewResponse(DefaultVersion, StatusErrorNotFound, 7)
+	rsp.Job.Add(MakeAttr("job-id", TagInteger, Integer(13)))
+
+	unknown := &Message{Version: DefaultVersion, Code: 2, RequestID: 1}
+	unknown.Operation.Add(MakeAttr("printer-uri", TagUnknown, Void{}))
+	unknown.Operation.Add(MakeAttr("job-id", TagKeyword, String("x")))
+
+	type testData struct {
+		m     *Message               // Input message
+		attrs map[string]interface{} // Expected attributes
+	}
+
+	tests := []testData{
+		{
+			m: rq,
+			attrs: map[string]interface{}{
+				SpanAttrVersion:    "2.0",
+				SpanAttrCode:       int64(OpGetJobAttributes),
+				SpanAttrRequestID:  int64(7),
+				SpanAttrOperation:  OpGetJobAttributes.String(),
+				SpanAttrPrinterURI: "ipp://localhost/printers/test",
+				SpanAttrJobID:      int64(12),
+			},
+		},
+		{
+			m: rsp,
+			attrs: map[string]interface{}{
+				SpanAttrVersion:   "2.0",
+				SpanAttrCode:      int64(StatusErrorNotFound),
+				SpanAttrRequestID: int64(7),
+				SpanAttrStatus:    "client-error-not-found",
+				SpanAttrJobID:     int64(13),
+			},
+		},
+		{
+			m: unknown,
+			attrs: map[string]interface{}{
+				SpanAttrVersion:   "2.0",
+				SpanAttrCode:      int64(2),
+				SpanAttrRequestID: int64(1),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		attrs := test.m.SpanAttributes()
+		if !reflect.DeepEqual(attrs, test.attrs) {
+			t.Errorf("SpanAttributes:\n%v\nexpected:\n%v",
+				attrs, test.attrs)
+		}
+	}
+}
@@ -13,23 +13,82 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"math"
+	"net/url"
+	"strings"
 	"time"
 )
 
-// Values represents a sequence of values with tags.
-// Usually Values used as a "payload" of Attribute
-type Values []struct {
+// TaggedValue represents a single value with its tag, the element
+// of Values.
+//
+// Before TaggedValue was introduced, the element of Values was the
+// anonymous struct with the same fields, so existing code, that uses
+// the anonymous struct, remains compatible.
+type TaggedValue struct {
 	T Tag   // The tag
 	V Value // The value
 }
 
+// MakeTaggedValue makes TaggedValue with the specified tag and value
+func MakeTaggedValue(t Tag, v Value) TaggedValue {
+	return TaggedValue{t, v}
+}
+
+// Values represents a sequence of values with tags.
+// Usually Values used as a "payload" of Attribute
+type Values []TaggedValue
+
+// MakeValues makes Values of one or more values with the same tag
+func MakeValues(t Tag, v1 Value, values ...Value) Values {
+	vals := make(Values, 0, len(values)+1)
+	vals.Add(t, v1)
+	for _, v := range values {
+		vals.Add(t, v)
+	}
+	return vals
+}
+
 // Add Value to Values
 func (values *Values) Add(t Tag, v Value) {
-	*values = append(*values, struct {
-		T Tag
-		V Value
-	}{t, v})
+	*values = append(*values, TaggedValue{t, v})
+}
+
+// AddTagged adds TaggedValue to Values
+func (values *Values) AddTagged(tv TaggedValue) {
+	*values = append(*values, tv)
+}
+
+// Delete deletes i-th value, preserving order of the remaining
+// values. Values are modified in place. i must be within range.
+func (values *Values) Delete(i int) {
+	v := *values
+	copy(v[i:], v[i+1:])
+	v[len(v)-1].V = nil
+	*values = v[:len(v)-1]
+}
+
+// RemoveIf removes all values, for which pred returns true,
+// preserving order of the remaining values. Values are modified
+// in place. It returns count of removed values.
+func (values *Values) RemoveIf(pred func(t Tag, v Value) bool) int {
+	v := *values
+	out := 0
+
+	for _, val := range v {
+		if !pred(val.T, val.V) {
+			v[out] = val
+			out++
+		}
+	}
+
+	for i := out; i < len(v); i++ {
+		v[i].V = nil
+	}
+
+	*values = v[:out]
+	return len(v) - out
 }
 
 // String converts Values to string
@@ -51,12 +110,38 @@ func (values Values) String() string {
 	return buf.String()
 }
 
+// OutOfBand returns true, if Values consist of the single
+// out-of-band value (i.e., TagNoValue), which means that the
+// attribute has no actual value.
+//
+// Note, the out-of-band no-value and the zero-length string value
+// (see EmptyString) are the different things and preserved as such
+// by decoder and encoder.
+func (values Values) OutOfBand() bool {
+	return len(values) == 1 && values[0].T.IsOutOfBand()
+}
+
+// EmptyString returns true, if Values consist of the single
+// zero-length value of the string type (i.e., empty TagText or
+// TagName value).
+//
+// Some firmware sends such values instead of the TagNoValue.
+func (values Values) EmptyString() bool {
+	return len(values) == 1 && values[0].T.Type() == TypeString &&
+		values[0].V.String() == ""
+}
+
 // Equal performs deep check of equality of two Values
 func (values Values) Equal(values2 Values) bool {
 	if len(values) != len(values2) {
 		return false
 	}
 
+	if len(values) != 0 && &values[0] == &values2[0] {
+		// The same slice, no need to compare values
+		return true
+	}
+
 	for i, v := range values {
 		v2 := values2[i]
 		if v.T != v2.T || !ValueEqual(v.V, v2.V) {
@@ -87,24 +172,38 @@ func (values Values) Similar(values2 Values) bool {
 //
 // IPP uses typed values, and type of each value is unambiguously
 // defined by the attribute tag
+//
+// Values of the standard tags are represented by the concrete
+// types, defined by this package. User-defined values may be used
+// with custom tags, see RegisterCustomTag and ValueMarshaler.
 type Value interface {
 	String() string
 	Type() Type
+}
+
+// valueCodec is implemented by the built-in Values
+type valueCodec interface {
+	Value
 	encode() ([]byte, error)
 	decode([]byte) (Value, error)
 }
 
 var (
-	_ = Value(Binary(nil))
-	_ = Value(Boolean(false))
-	_ = Value(Collection(nil))
-	_ = Value(Integer(0))
-	_ = Value(Range{})
-	_ = Value(Resolution{})
-	_ = Value(String(""))
-	_ = Value(TextWithLang{})
-	_ = Value(Time{time.Time{}})
-	_ = Value(Void{})
+	_ = valueCodec(Binary(nil))
+	_ = valueCodec(BinaryRef{})
+	_ = valueCodec(Boolean(false))
+	_ = valueCodec(Collection(nil))
+	_ = valueCodec(Integer(0))
+	_ = valueCodec(Keyword(""))
+	_ = valueCodec(MimeType(""))
+	_ = valueCodec(Name(""))
+	_ = valueCodec(Range{})
+	_ = valueCodec(Resolution{})
+	_ = valueCodec(String(""))
+	_ = valueCodec(TextWithLang{})
+	_ = valueCodec(Time{time.Time{}})
+	_ = valueCodec(URI{})
+	_ = valueCodec(Void{})
 )
 
 // IntegerOrRange is a Value of type Integer or Range
@@ -128,19 +227,55 @@ var (
 // Equality means that types and values are equal. For structured
 // values, like Collection, deep comparison is performed
 func ValueEqual(v1, v2 Value) bool {
-	if v1.Type() != v2.Type() {
-		return false
-	}
-
-	switch v1.Type() {
-	case TypeDateTime:
-		return v1.(Time).Equal(v2.(Time).Time)
-	case TypeBinary:
-		return bytes.Equal(v1.(Binary), v2.(Binary))
-	case TypeCollection:
-		c1 := Attributes(v1.(Collection))
-		c2 := Attributes(v2.(Collection))
-		return c1.Equal(c2)
+	// Type switch is used instead of the generic interface
+	// comparison, which is significantly slower
+	switch v1 := v1.(type) {
+	case Integer:
+		v2, ok := v2.(Integer)
+		return ok && v1 == v2
+	case Boolean:
+		v2, ok := v2.(Boolean)
+		return ok && v1 == v2
+	case String:
+		v2, ok := v2.(String)
+		return ok && v1 == v2
+	case Name:
+		v2, ok := v2.(Name)
+		return ok && v1 == v2
+	case Keyword:
+		v2, ok := v2.(Keyword)
+		return ok && v1 == v2
+	case MimeType:
+		v2, ok := v2.(MimeType)
+		return ok && v1 == v2
+	case URI:
+		// URI values are compared by content, not by pointer
+		v2, ok := v2.(URI)
+		return ok && v1.String() == v2.String()
+	case TextWithLang:
+		v2, ok := v2.(TextWithLang)
+		return ok && v1 == v2
+	case Range:
+		v2, ok := v2.(Range)
+		return ok && v1 == v2
+	case Resolution:
+		v2, ok := v2.(Resolution)
+		return ok && v1 == v2
+	case Time:
+		v2, ok := v2.(Time)
+		return ok && v1.Equal(v2.Time)
+	case Void:
+		_, ok := v2.(Void)
+		return ok
+	case Binary, BinaryRef:
+		return v2.Type() == TypeBinary &&
+			bytes.Equal(binaryBytes(v1), binaryBytes(v2))
+	case Collection:
+		v2, ok := v2.(Collection)
+		return ok && Attributes(v1).Equal(Attributes(v2))
+	case ValueMarshaler:
+		// User-defined values may be not comparable
+		return valueCustomEqual(v1, v2)
 	}
 
 	return v1 == v2
@@ -152,6 +287,8 @@ func ValueEqual(v1, v2 Value) bool {
 //     they are similar.
 //   - Binary and String values are similar, if they represent
 //     the same sequence of bytes.
+//   - String, Name, Keyword, MimeType and URI values are similar,
+//     if they represent the same string.
 //   - Two collections are similar, if they contain the same
 //     set of attributes (but may be differently ordered) and
 //     values of these attributes are similar.
@@ -165,10 +302,14 @@ func ValueSimilar(v1, v2 Value) bool {
 
 	switch {
 	case t1 == TypeBinary && t2 == TypeString:
-		return bytes.Equal(v1.(Binary), []byte(v2.(String)))
+		return bytes.Equal(binaryBytes(v1), []byte(v2.String()))
 
 	case t1 == TypeString && t2 == TypeBinary:
-		return bytes.Equal([]byte(v1.(String)), v2.(Binary))
+		return bytes.Equal([]byte(v1.String()), binaryBytes(v2))
+
+	case t1 == TypeString && t2 == TypeString:
+		// String vs Name, Keyword, MimeType or URI
+		return v1.String() == v2.String()
 
 	case t1 == TypeCollection && t2 == TypeCollection:
 		return Attributes(v1.(Collection)).Similar(
@@ -282,6 +423,365 @@ func (String) decode(data []byte) (Value, error) {
 	return String(data), nil
 }
 
+// Name is the Value that represents name (nameWithoutLanguage)
+//
+// Unlike generic String, it can be used only with TagName, and
+// Name values are not Equal to String values with the same text.
+// Its length is limited to 255 bytes (see ValueLengthLimit).
+//
+// Note, decoder returns names as String values, for backward
+// compatibility.
+//
+// Use with: TagName
+type Name string
+
+// String converts Name value to string
+func (v Name) String() string { return string(v) }
+
+// Type returns type of Value (TypeString for Name)
+func (Name) Type() Type { return TypeString }
+
+// WithLang returns nameWithLanguage value, represented as
+// TextWithLang, for use with TagNameLang
+func (v Name) WithLang(lang string) TextWithLang {
+	return TextWithLang{Lang: lang, Text: string(v)}
+}
+
+// Encode Name Value into wire format
+func (v Name) encode() ([]byte, error) {
+	return []byte(v), nil
+}
+
+// Decode Name Value from wire format
+func (Name) decode(data []byte) (Value, error) {
+	return Name(data), nil
+}
+
+// Keyword is the Value that represents keyword
+//
+// Keyword must start with the lowercase letter, followed by
+// lowercase letters, digits, '-', '.' or '_' (RFC 8011, 5.1.4).
+// This is checked by encoder, so typos like spaces in keywords
+// are detected before the message is sent.
+//
+// Like Name, Keyword values are not Equal to String values with
+// the same text, and decoder returns keywords as String values.
+//
+// Use with: TagKeyword
+type Keyword string
+
+// String converts Keyword value to string
+func (v Keyword) String() string { return string(v) }
+
+// Type returns type of Value (TypeString for Keyword)
+func (Keyword) Type() Type { return TypeString }
+
+// Validate checks that Keyword uses only allowed characters
+func (v Keyword) Validate() error {
+	if v == "" {
+		return errors.New("Keyword is empty")
+	}
+
+	if len(v) > int(MaxKeywordLength) {
+		return fmt.Errorf("Keyword exceeds %d bytes", MaxKeywordLength)
+	}
+
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		switch {
+		case 'a' <= c && c <= 'z':
+		case i > 0 && ('0' <= c && c <= '9' ||
+			c == '-' || c == '.' || c == '_'):
+		default:
+			return fmt.Errorf("Keyword %q: invalid character %q at %d",
+				string(v), c, i)
+		}
+	}
+
+	return nil
+}
+
+// Encode Keyword Value into wire format
+func (v Keyword) encode() ([]byte, error) {
+	err := v.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(v), nil
+}
+
+// Decode Keyword Value from wire format
+func (Keyword) decode(data []byte) (Value, error) {
+	return Keyword(data), nil
+}
+
+// MimeType is the Value that represents mimeMediaType
+//
+// MimeType has the "type/subtype" form, optionally followed by
+// parameters, i.e., "text/plain; charset=utf-8". Syntax is checked
+// by encoder. Type and subtype are case-insensitive.
+//
+// Decoder returns mimeMediaType values as String values.
+//
+// Use with: TagMimeType
+type MimeType string
+
+// MimeTypeAny is the special MimeType, that requests the printer to
+// automatically detect the document format
+const MimeTypeAny MimeType = "application/octet-stream"
+
+// String converts MimeType value to string
+func (v MimeType) String() string { return string(v) }
+
+// Type returns type of Value (TypeString for MimeType)
+func (MimeType) Type() Type { return TypeString }
+
+// Validate checks MimeType syntax
+func (v MimeType) Validate() error {
+	if len(v) > int(MaxMimeTypeLength) {
+		return fmt.Errorf("MimeType exceeds %d bytes", MaxMimeTypeLength)
+	}
+
+	typ, subtype := v.Split()
+	if !mimeToken(typ) || !mimeToken(subtype) {
+		return fmt.Errorf("MimeType %q: invalid syntax", string(v))
+	}
+
+	params := string(v)
+	if i := strings.IndexByte(params, ';'); i >= 0 {
+		params = params[i+1:]
+	} else {
+		params = ""
+	}
+
+	for params != "" {
+		param := params
+		if i := strings.IndexByte(params, ';'); i >= 0 {
+			param, params = params[:i], params[i+1:]
+		} else {
+			params = ""
+		}
+
+		i := strings.IndexByte(param, '=')
+		if i < 0 || !mimeToken(strings.TrimSpace(param[:i])) {
+			return fmt.Errorf("MimeType %q: invalid parameter",
+				string(v))
+		}
+	}
+
+	return nil
+}
+
+// Split returns lowercase type and subtype parts of the MimeType,
+// without parameters. If MimeType has invalid syntax, the missed
+// parts are returned empty.
+func (v MimeType) Split() (typ, subtype string) {
+	s := string(v)
+	if i := strings.IndexByte(s, ';'); i >= 0 {
+		s = s[:i]
+	}
+
+	s = strings.ToLower(strings.TrimSpace(s))
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+
+	return s, ""
+}
+
+// Matches reports whether MimeType matches the pattern.
+//
+// Parameters are ignored, and comparison is case-insensitive. Pattern
+// may use wildcards, like "image/*" or "*/*". The MimeTypeAny pattern
+// (application/octet-stream) matches any MimeType, as printer detects
+// the actual format automatically.
+func (v MimeType) Matches(pattern MimeType) bool {
+	typ, subtype := v.Split()
+	ptyp, psubtype := pattern.Split()
+
+	switch {
+	case ptyp == "*" && psubtype == "*":
+		return true
+	case MimeType(ptyp+"/"+psubtype) == MimeTypeAny:
+		return true
+	case ptyp != typ:
+		return false
+	}
+
+	return psubtype == "*" || psubtype == subtype
+}
+
+// NegotiateMimeType chooses the document-format value to send to
+// the printer, for the document of the specified format, using
+// the list of formats, supported by the printer (i.e., values of
+// document-format-supported).
+//
+// If format matches one of the supported formats, it is returned
+// as is. Otherwise, if printer supports MimeTypeAny, MimeTypeAny is
+// returned. Otherwise, false is returned.
+func NegotiateMimeType(format MimeType, supported []string) (MimeType, bool) {
+	anySupported := false
+
+	for _, s := range supported {
+		pattern := MimeType(s)
+		ptyp, psubtype := pattern.Split()
+		if MimeType(ptyp+"/"+psubtype) == MimeTypeAny {
+			anySupported = true
+		} else if format.Matches(pattern) {
+			return format, true
+		}
+	}
+
+	if anySupported {
+		return MimeTypeAny, true
+	}
+
+	return "", false
+}
+
+// Encode MimeType Value into wire format
+func (v MimeType) encode() ([]byte, error) {
+	err := v.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(v), nil
+}
+
+// Decode MimeType Value from wire format
+func (MimeType) decode(data []byte) (Value, error) {
+	return MimeType(data), nil
+}
+
+// mimeToken reports whether s is a valid MIME token (RFC 2045, 5.1)
+func mimeToken(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c >= 0x7f ||
+			strings.IndexByte("()<>@,;:\\\"/[]?=", c) >= 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// URI is the Value that represents uri, backed by the parsed url.URL
+//
+// The url.URL is shared between copies of the URI value, so it
+// should not be modified in place after the value is added to the
+// message. Use Normalize or url.URL copy for modifications.
+//
+// URI values are Equal if their string representations are equal.
+// Decoder returns URIs as String values, use ParseURI to convert.
+//
+// Use with: TagURI
+type URI struct {
+	*url.URL
+}
+
+// ParseURI parses the absolute URI
+func ParseURI(s string) (URI, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return URI{}, err
+	}
+
+	if !u.IsAbs() {
+		return URI{}, fmt.Errorf("URI %q: not absolute", s)
+	}
+
+	return URI{u}, nil
+}
+
+// String converts URI value to string
+func (v URI) String() string {
+	if v.URL == nil {
+		return ""
+	}
+	return v.URL.String()
+}
+
+// Type returns type of Value (TypeString for URI)
+func (URI) Type() Type { return TypeString }
+
+// CheckScheme returns error, if URI scheme is not one of the
+// specified schemes. Schemes are compared case-insensitively.
+func (v URI) CheckScheme(schemes ...string) error {
+	if v.URL != nil {
+		for _, scheme := range schemes {
+			if strings.EqualFold(v.Scheme, scheme) {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("URI %q: scheme must be one of: %s",
+		v.String(), strings.Join(schemes, ", "))
+}
+
+// Normalize returns normalized copy of the URI:
+//   - scheme and host are converted to lower case
+//   - the default port (631 for ipp and ipps, 80 for http
+//     and 443 for https) is removed
+//   - the empty path is replaced with "/"
+func (v URI) Normalize() URI {
+	if v.URL == nil {
+		return v
+	}
+
+	u := *v.URL
+	if u.User != nil {
+		user := *u.User
+		u.User = &user
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+
+	defport := ""
+	switch u.Scheme {
+	case "ipp", "ipps":
+		defport = "631"
+	case "http":
+		defport = "80"
+	case "https":
+		defport = "443"
+	}
+
+	if port := u.Port(); port != "" && port == defport {
+		u.Host = strings.TrimSuffix(u.Host, ":"+port)
+	}
+
+	if u.Opaque == "" && u.Path == "" {
+		u.Path = "/"
+		u.RawPath = ""
+	}
+
+	return URI{&u}
+}
+
+// Encode URI Value into wire format
+func (v URI) encode() ([]byte, error) {
+	if v.URL == nil {
+		return nil, errors.New("URI is nil")
+	}
+	return []byte(v.String()), nil
+}
+
+// Decode URI Value from wire format
+func (URI) decode(data []byte) (Value, error) {
+	u, err := url.Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+	return URI{u}, nil
+}
+
 // Time is the Value that represents DataTime
 //
 // Use with: TagTime
@@ -344,6 +844,17 @@ func (Time) decode(data []byte) (Value, error) {
 		return nil, errors.New("value must be 11 bytes")
 	}
 
+	return timeDecode(data, nil)
+}
+
+// timeDecode decodes Time value from wire format.
+//
+// If loc is nil, data must contain time zone information.
+// Otherwise, time zone information, if any, is ignored and
+// time is interpreted in the specified location. It allows
+// to decode dateTime values without time zone (8 bytes, or 9
+// bytes with only the direction from UTC).
+func timeDecode(data []byte, loc *time.Location) (Value, error) {
 	// Validate ranges
 	var err error
 	switch {
@@ -359,6 +870,8 @@ func (Time) decode(data []byte) (Value, error) {
 		err = fmt.Errorf("bad seconds %d", data[6])
 	case data[7] > 9:
 		err = fmt.Errorf("bad deciseconds %d", data[7])
+	case loc != nil:
+		// Time zone ignored
 	case data[8] != '+' && data[8] != '-':
 		return nil, errors.New("bad UTC sign")
 	case data[9] > 11:
@@ -372,17 +885,20 @@ func (Time) decode(data []byte) (Value, error) {
 	}
 
 	// Decode time zone
-	tzName := fmt.Sprintf("UTC%c%d", data[8], data[9])
-	if data[10] != 0 {
-		tzName += fmt.Sprintf(":%d", data[10])
-	}
+	tz := loc
+	if tz == nil {
+		tzName := fmt.Sprintf("UTC%c%d", data[8], data[9])
+		if data[10] != 0 {
+			tzName += fmt.Sprintf(":%d", data[10])
+		}
 
-	tzOff := 3600*int(data[9]) + 60*int(data[10])
-	if data[8] == '-' {
-		tzOff = -tzOff
-	}
+		tzOff := 3600*int(data[9]) + 60*int(data[10])
+		if data[8] == '-' {
+			tzOff = -tzOff
+		}
 
-	tz := time.FixedZone(tzName, tzOff)
+		tz = time.FixedZone(tzName, tzOff)
+	}
 
 	// Decode time
 	t := time.Date(
@@ -624,8 +1140,79 @@ func (v Binary) encode() ([]byte, error) {
 }
 
 // Decode Binary Value from wire format
+//
+// Data is copied, as decoder reuses its buffer
 func (Binary) decode(data []byte) (Value, error) {
-	return Binary(data), nil
+	return Binary(append(make([]byte, 0, len(data)), data...)), nil
+}
+
+// BinaryRef is the Value that represents a raw binary data, backed
+// by the io.ReaderAt (i.e., memory-mapped capture file) instead of
+// the in-memory copy. Data is read on demand.
+//
+// BinaryRef is interchangeable with Binary: it is encoded the same
+// way and compared by content. See DecoderOptions.BinaryRefs for
+// obtaining BinaryRef values from the decoder.
+type BinaryRef struct {
+	R   io.ReaderAt // Underlying data
+	Off int64       // Offset of value within R
+	Len int         // Length of value
+}
+
+// String converts BinaryRef value to string. Data is
+// formatted the same way as Binary. If data cannot be read,
+// empty string is returned.
+func (v BinaryRef) String() string {
+	data, _ := v.Materialize()
+	return data.String()
+}
+
+// Type returns type of Value (TypeBinary for BinaryRef)
+func (BinaryRef) Type() Type { return TypeBinary }
+
+// Materialize reads the referenced data into the Binary value
+func (v BinaryRef) Materialize() (Binary, error) {
+	if v.R == nil {
+		return nil, errors.New("BinaryRef: missed io.ReaderAt")
+	}
+
+	data := make(Binary, v.Len)
+	n, err := v.R.ReadAt(data, v.Off)
+	if n == len(data) {
+		return data, nil
+	}
+
+	if err == nil || err == io.EOF {
+		err = fmt.Errorf("BinaryRef: %d bytes at 0x%x: data truncated",
+			v.Len, v.Off)
+	}
+
+	return nil, err
+}
+
+// Encode BinaryRef Value into wire format
+func (v BinaryRef) encode() ([]byte, error) {
+	return v.Materialize()
+}
+
+// Decode BinaryRef Value from wire format
+//
+// As there is nothing to refer to, Binary is returned
+func (BinaryRef) decode(data []byte) (Value, error) {
+	return Binary(nil).decode(data)
+}
+
+// binaryBytes returns content of Binary or BinaryRef value. For
+// BinaryRef, nil is returned, if data cannot be read.
+func binaryBytes(v Value) Binary {
+	switch v := v.(type) {
+	case Binary:
+		return v
+	case BinaryRef:
+		data, _ := v.Materialize()
+		return data
+	}
+	return nil
 }
 
 // Collection is the Value that represents collection of attributes
@@ -658,6 +1245,59 @@ func (v Collection) String() string {
 	return buf.String()
 }
 
+// Validate checks that all collection members, including members
+// of nested collections, have non-empty and unique names.
+//
+// Encoder performs the same checks, so Validate is useful mostly
+// to check collections in advance.
+func (v Collection) Validate() error {
+	return v.validate("")
+}
+
+// validate checks the collection. Prefix is the path to the
+// collection within the outer collections, i.e., "media-size/"
+func (v Collection) validate(prefix string) error {
+	err := v.checkNames(prefix)
+	if err != nil {
+		return err
+	}
+
+	for _, attr := range v {
+		for _, val := range attr.Values {
+			if col, ok := val.V.(Collection); ok {
+				err = col.validate(prefix + attr.Name + "/")
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkNames checks names of collection members, without descending
+// into nested collections
+func (v Collection) checkNames(prefix string) error {
+	for i, attr := range v {
+		if attr.Name == "" {
+			if prefix == "" {
+				return errors.New("Collection member without name")
+			}
+			return fmt.Errorf("Collection member %q without name", prefix)
+		}
+
+		for _, attr2 := range v[:i] {
+			if attr.Name == attr2.Name {
+				return fmt.Errorf("Collection member %q is duplicated",
+					prefix+attr.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
 // Type returns type of Value (TypeCollection for Collection)
 func (Collection) Type() Type { return TypeCollection }
 
@@ -669,6 +1309,10 @@ func (Collection) encode() ([]byte, error) {
 }
 
 // Decode Collection Value from wire format
+//
+// Collection is decoded by the message decoder member by member,
+// so this method is never used for real and returns error, if
+// called directly.
 func (Collection) decode(data []byte) (Value, error) {
-	panic("internal error")
+	return nil, errors.New("Collection cannot be decoded as a single value")
 }
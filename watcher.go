@@ -0,0 +1,717 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Printer state change watcher
+ */
+
+package goipp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultWatchPollInterval is the default interval between polls
+// of the Watcher, and the default delay between Get-Notifications
+// requests, if printer doesn't specify notify-get-interval.
+const DefaultWatchPollInterval = 10 * time.Second
+
+// WatchMethod selects the mechanism, used by the Watcher
+type WatchMethod int
+
+// Watch methods:
+const (
+	// WatchAuto selects WatchNotifications, if printer supports
+	// it, and WatchPolling otherwise. This is the default.
+	WatchAuto WatchMethod = iota
+
+	// WatchNotifications creates printer subscription and fetches
+	// events with Get-Notifications requests, using the ippget
+	// event delivery method (RFC 3995, RFC 3996)
+	WatchNotifications
+
+	// WatchPolling periodically polls printer with the
+	// Get-Printer-Attributes and Get-Jobs requests
+	WatchPolling
+)
+
+// String returns the name of WatchMethod
+func (method WatchMethod) String() string {
+	switch method {
+	case WatchAuto:
+		return "auto"
+	case WatchNotifications:
+		return "notifications"
+	case WatchPolling:
+		return "polling"
+	}
+
+	return "unknown"
+}
+
+// WatchEventKind represents the kind of the WatchEvent
+type WatchEventKind int
+
+// WatchEvent kinds:
+const (
+	// WatchPrinterStateChanged is reported, when printer-state
+	// or printer-state-reasons has changed
+	WatchPrinterStateChanged WatchEventKind = iota
+
+	// WatchJobCompleted is reported, when job has reached
+	// the terminal state (completed, canceled or aborted)
+	WatchJobCompleted
+
+	// WatchSupplyLow is reported, when supply becomes low,
+	// either according to printer-state-reasons (i.e., toner-low)
+	// or to marker-levels and marker-low-levels
+	WatchSupplyLow
+)
+
+// String returns the name of WatchEventKind
+func (kind WatchEventKind) String() string {
+	switch kind {
+	case WatchPrinterStateChanged:
+		return "printer-state-changed"
+	case WatchJobCompleted:
+		return "job-completed"
+	case WatchSupplyLow:
+		return "supply-low"
+	}
+
+	return "unknown"
+}
+
+// WatchEvent represents the event, reported by the Watcher
+type WatchEvent struct {
+	Kind WatchEventKind // Event kind
+
+	// For WatchPrinterStateChanged
+	PrinterState        int      // printer-state
+	PrinterStateReasons []string // printer-state-reasons
+
+	// For WatchJobCompleted
+	JobID    int // job-id
+	JobState int // job-state
+
+	// For WatchSupplyLow: printer-state-reasons keyword without
+	// severity suffix (i.e., "toner-low") or marker name
+	Supply string
+
+	// Attributes, the event was made from: event notification
+	// attributes or printer or job attributes
+	Attrs Attributes
+}
+
+// WatchOptions contains parameters of the Watcher
+type WatchOptions struct {
+	// Method selects the mechanism. WatchAuto is the default.
+	Method WatchMethod
+
+	// PollInterval is the interval between polls. If zero,
+	// DefaultWatchPollInterval is used.
+	PollInterval time.Duration
+
+	// LeaseDuration is the requested notify-lease-duration of
+	// the subscription, in seconds. If zero, printer chooses.
+	LeaseDuration int
+}
+
+// Watcher watches the printer for state changes and reports
+// them as typed events to the C channel.
+//
+// Watcher terminates when its context is canceled, when Close
+// is called or on error. Then C is closed. Use Err to obtain the
+// error that terminated the Watcher.
+type Watcher struct {
+	C <-chan WatchEvent // Reported events
+
+	clnt      *Client         // Client for requests
+	uri       string          // Printer URI
+	opt       WatchOptions    // Watcher options
+	method    WatchMethod     // Selected method
+	cancel    func()          // Cancels the Watcher
+	lock      sync.Mutex      // Access lock
+	err       error           // Termination error
+	id        uint32          // Last request ID
+	subID     int             // notify-subscription-id
+	seq       int             // Next notify-sequence-number, 0 if unknown
+	known     bool            // Printer state is known
+	state     int             // Last printer-state
+	reasons   []string        // Last printer-state-reasons
+	lowReason map[string]bool // Low supplies, by printer-state-reasons
+	lowMarker map[string]bool // Low supplies, by marker levels
+	jobs      map[int]bool    // Completed jobs, seen by polling
+	jobsKnown bool            // Completed jobs were polled once
+}
+
+// watchRequestedAttributes are requested from printer
+var watchRequestedAttributes = []string{
+	"marker-levels",
+	"marker-low-levels",
+	"marker-names",
+	"notify-pull-method-supported",
+	"operations-supported",
+	"printer-state",
+	"printer-state-reasons",
+}
+
+// watchSupplyLowReasons contains printer-state-reasons keywords
+// (without severity suffix), that indicate low supply
+var watchSupplyLowReasons = map[string]bool{
+	"developer-low":     true,
+	"marker-supply-low": true,
+	"toner-low":         true,
+}
+
+// Watch starts watching the printer at the specified URI.
+//
+// It fetches the current printer state, which becomes the baseline
+// for subsequent events, selects the mechanism and, for
+// WatchNotifications, creates the subscription. With WatchAuto,
+// failure to create subscription silently falls back to polling.
+func (c *Client) Watch(ctx context.Context, uri string,
+	opt WatchOptions) (*Watcher, error) {
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	w := &Watcher{
+		clnt:      c,
+		uri:       uri,
+		opt:       opt,
+		method:    opt.Method,
+		cancel:    cancel,
+		lowReason: make(map[string]bool),
+		lowMarker: make(map[string]bool),
+		jobs:      make(map[int]bool),
+	}
+
+	attrs, err := w.getPrinterAttributes(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	w.update(attrs)
+
+	caps := PrinterCapabilities{URI: uri, Attrs: attrs}
+	ippget := caps.Supports(OpCreatePrinterSubscriptions) &&
+		caps.Supports(OpGetNotifications)
+	if ippget {
+		methods, _ := attrsFind(attrs, "notify-pull-method-supported")
+		ippget = watchHasKeyword(methods, "ippget")
+	}
+
+	switch {
+	case w.method == WatchNotifications && !ippget:
+		err = errors.New("Printer doesn't support ippget notifications")
+	case w.method == WatchNotifications:
+		err = w.subscribe(ctx)
+	case w.method == WatchAuto && ippget:
+		w.method = WatchNotifications
+		if w.subscribe(ctx) != nil {
+			w.method = WatchPolling
+		}
+	default:
+		w.method = WatchPolling
+	}
+
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	ch := make(chan WatchEvent)
+	w.C = ch
+
+	go w.run(ctx, ch)
+
+	return w, nil
+}
+
+// Method returns the mechanism, selected by the Watcher
+func (w *Watcher) Method() WatchMethod {
+	return w.method
+}
+
+// Err returns the error that terminated the Watcher. If Watcher
+// was terminated by Close or context cancellation, it returns nil.
+//
+// Err should be called only after C is closed.
+func (w *Watcher) Err() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.err
+}
+
+// Close terminates the Watcher. The subscription, if any, is
+// canceled in background.
+func (w *Watcher) Close() {
+	w.cancel()
+}
+
+// run runs the Watcher
+func (w *Watcher) run(ctx context.Context, ch chan<- WatchEvent) {
+	defer close(ch)
+
+	var err error
+	if w.method == WatchNotifications {
+		err = w.runNotifications(ctx, ch)
+		w.unsubscribe()
+	} else {
+		err = w.runPolling(ctx, ch)
+	}
+
+	if err != nil && ctx.Err() == nil {
+		w.lock.Lock()
+		w.err = err
+		w.lock.Unlock()
+	}
+
+	w.cancel()
+}
+
+// runPolling polls the printer until error or cancellation
+func (w *Watcher) runPolling(ctx context.Context,
+	ch chan<- WatchEvent) error {
+
+	ticker := time.NewTicker(w.pollInterval())
+	defer ticker.Stop()
+
+	for {
+		attrs, err := w.getPrinterAttributes(ctx)
+		if err != nil {
+			return err
+		}
+
+		events := w.update(attrs)
+
+		jobs, err := w.getCompletedJobs(ctx)
+		if err != nil {
+			return err
+		}
+
+		events = append(events, jobs...)
+
+		for _, ev := range events {
+			if !w.emit(ctx, ch, ev) {
+				return nil
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// runNotifications fetches notifications until error or cancellation
+func (w *Watcher) runNotifications(ctx context.Context,
+	ch chan<- WatchEvent) error {
+
+	for {
+		req := w.newRequest(OpGetNotifications)
+		req.Operation.Add(MakeAttribute("notify-subscription-ids",
+			TagInteger, Integer(w.subID)))
+		if w.seq > 0 {
+			req.Operation.Add(MakeAttribute("notify-sequence-numbers",
+				TagInteger, Integer(w.seq)))
+		}
+		req.Operation.Add(MakeAttribute("notify-wait",
+			TagBoolean, Boolean(true)))
+
+		s, err := w.clnt.GetNotifications(ctx, w.uri, req)
+		if err != nil {
+			return err
+		}
+
+		interval := w.pollInterval()
+
+		for m := range s.C {
+			if err = AsResponse(m).Err(); err != nil {
+				s.Close()
+				return err
+			}
+
+			if n, ok := watchInteger(m.Operation,
+				"notify-get-interval"); ok && n > 0 {
+				interval = time.Duration(n) * time.Second
+			}
+
+			for _, attrs := range m.Events() {
+				for _, ev := range w.notification(attrs) {
+					if !w.emit(ctx, ch, ev) {
+						s.Close()
+						return nil
+					}
+				}
+			}
+		}
+
+		err = s.Err()
+		s.Close()
+
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// emit sends event to the channel. It returns false, if Watcher
+// is terminated.
+func (w *Watcher) emit(ctx context.Context, ch chan<- WatchEvent,
+	ev WatchEvent) bool {
+
+	select {
+	case ch <- ev:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// update applies printer attributes to the tracked state and
+// returns resulting events
+func (w *Watcher) update(attrs Attributes) []WatchEvent {
+	var events []WatchEvent
+
+	state, stateOk := watchInteger(attrs, "printer-state")
+	reasons, reasonsOk := watchKeywords(attrs, "printer-state-reasons")
+
+	if stateOk || reasonsOk {
+		if !stateOk {
+			state = w.state
+		}
+		if !reasonsOk {
+			reasons = w.reasons
+		}
+
+		if w.known && (state != w.state ||
+			strings.Join(reasons, ",") != strings.Join(w.reasons, ",")) {
+			events = append(events, WatchEvent{
+				Kind:                WatchPrinterStateChanged,
+				PrinterState:        state,
+				PrinterStateReasons: reasons,
+				Attrs:               attrs,
+			})
+		}
+
+		w.state, w.reasons = state, reasons
+	}
+
+	if reasonsOk {
+		low := make(map[string]bool)
+		for _, reason := range reasons {
+			reason = watchReasonBase(reason)
+			if watchSupplyLowReasons[reason] {
+				low[reason] = true
+			}
+		}
+
+		events = w.updateLow(events, w.lowReason, low,
+			"printer-state-reasons", attrs)
+		w.lowReason = low
+	}
+
+	if levels, ok := watchIntegers(attrs, "marker-levels"); ok {
+		names, _ := watchKeywords(attrs, "marker-names")
+		lowLevels, _ := watchIntegers(attrs, "marker-low-levels")
+
+		low := make(map[string]bool)
+		for i, level := range levels {
+			if i < len(names) && i < len(lowLevels) &&
+				level >= 0 && level <= lowLevels[i] {
+				low[names[i]] = true
+			}
+		}
+
+		events = w.updateLow(events, w.lowMarker, low,
+			"marker-names", attrs)
+		w.lowMarker = low
+	}
+
+	w.known = true
+
+	return events
+}
+
+// updateLow appends WatchSupplyLow events for supplies, that
+// became low, in order of values of the source attribute
+// (printer-state-reasons or marker-names)
+func (w *Watcher) updateLow(events []WatchEvent, old, low map[string]bool,
+	source string, attrs Attributes) []WatchEvent {
+
+	if !w.known {
+		return events
+	}
+
+	supplies, _ := watchKeywords(attrs, source)
+	for _, supply := range supplies {
+		if source == "printer-state-reasons" {
+			supply = watchReasonBase(supply)
+		}
+
+		if low[supply] && !old[supply] {
+			old[supply] = true
+			events = append(events, WatchEvent{
+				Kind:   WatchSupplyLow,
+				Supply: supply,
+				Attrs:  attrs,
+			})
+		}
+	}
+
+	return events
+}
+
+// notification converts event notification attributes into events
+func (w *Watcher) notification(attrs Attributes) []WatchEvent {
+	var events []WatchEvent
+
+	if seq, ok := watchInteger(attrs, "notify-sequence-number"); ok {
+		w.seq = seq + 1
+	}
+
+	if jobState, ok := watchInteger(attrs, "job-state"); ok &&
+		jobState >= JobStateCanceled {
+		jobID, _ := watchInteger(attrs, "job-id")
+		events = append(events, WatchEvent{
+			Kind:     WatchJobCompleted,
+			JobID:    jobID,
+			JobState: jobState,
+			Attrs:    attrs,
+		})
+	}
+
+	if _, found := attrsFind(attrs, "printer-state"); found {
+		events = append(events, w.update(attrs)...)
+	}
+
+	return events
+}
+
+// getPrinterAttributes performs the Get-Printer-Attributes request
+func (w *Watcher) getPrinterAttributes(ctx context.Context) (
+	Attributes, error) {
+
+	requested := Attribute{Name: "requested-attributes"}
+	for _, name := range watchRequestedAttributes {
+		requested.Values.Add(TagKeyword, String(name))
+	}
+
+	req := w.newRequest(OpGetPrinterAttributes)
+	req.Operation.Add(requested)
+
+	rsp, err := w.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var attrs Attributes
+	for _, grp := range rsp.attrGroups() {
+		if grp.Tag == TagPrinterGroup {
+			attrs = append(attrs, grp.Attrs...)
+		}
+	}
+
+	return attrs, nil
+}
+
+// getCompletedJobs performs the Get-Jobs request and returns
+// WatchJobCompleted events for newly completed jobs. The first
+// call only remembers jobs, completed before.
+func (w *Watcher) getCompletedJobs(ctx context.Context) (
+	[]WatchEvent, error) {
+
+	w.id++
+	req := NewGetJobsRequest(DefaultVersion, w.id, w.uri,
+		GetJobsOptions{
+			WhichJobs:           WhichJobsCompleted,
+			RequestedAttributes: []string{"job-id", "job-state"},
+		})
+
+	rsp, err := w.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []WatchEvent
+	jobs := make(map[int]bool)
+
+	for _, grp := range rsp.attrGroups() {
+		if grp.Tag != TagJobGroup {
+			continue
+		}
+
+		jobID, ok := watchInteger(grp.Attrs, "job-id")
+		if !ok {
+			continue
+		}
+
+		jobs[jobID] = true
+		if w.jobsKnown && !w.jobs[jobID] {
+			jobState, _ := watchInteger(grp.Attrs, "job-state")
+			events = append(events, WatchEvent{
+				Kind:     WatchJobCompleted,
+				JobID:    jobID,
+				JobState: jobState,
+				Attrs:    grp.Attrs,
+			})
+		}
+	}
+
+	w.jobs, w.jobsKnown = jobs, true
+
+	return events, nil
+}
+
+// subscribe creates the printer subscription
+func (w *Watcher) subscribe(ctx context.Context) error {
+	req := w.newRequest(OpCreatePrinterSubscriptions)
+
+	events := MakeAttribute("notify-events", TagKeyword,
+		String("printer-state-changed"))
+	events.Values.Add(TagKeyword, String("job-completed"))
+
+	req.Subscription.Add(MakeAttribute("notify-pull-method", TagKeyword,
+		String("ippget")))
+	req.Subscription.Add(events)
+	if w.opt.LeaseDuration > 0 {
+		req.Subscription.Add(MakeAttribute("notify-lease-duration",
+			TagInteger, Integer(w.opt.LeaseDuration)))
+	}
+
+	rsp, err := w.do(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	id, ok := watchInteger(rsp.Subscription, "notify-subscription-id")
+	if !ok {
+		return errors.New("Create-Printer-Subscriptions: " +
+			"notify-subscription-id missed")
+	}
+
+	w.subID = id
+
+	return nil
+}
+
+// unsubscribe cancels the printer subscription. Errors are ignored.
+func (w *Watcher) unsubscribe() {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		DefaultWatchPollInterval)
+	defer cancel()
+
+	req := w.newRequest(OpCancelSubscription)
+	req.Operation.Add(MakeAttribute("notify-subscription-id",
+		TagInteger, Integer(w.subID)))
+
+	w.do(ctx, req)
+}
+
+// newRequest creates the request to the printer
+func (w *Watcher) newRequest(op Op) *Message {
+	w.id++
+	req := NewRequest(DefaultVersion, op, w.id)
+	req.Operation.Add(MakeAttribute("printer-uri", TagURI,
+		String(w.uri)))
+	FillDefaults(req)
+	return req
+}
+
+// do sends the request and checks response status
+func (w *Watcher) do(ctx context.Context, req *Message) (*Message, error) {
+	rsp, err := w.clnt.Do(ctx, w.uri, req, nil)
+	if err == nil {
+		err = AsResponse(rsp).Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return rsp, nil
+}
+
+// pollInterval returns the effective poll interval
+func (w *Watcher) pollInterval() time.Duration {
+	if w.opt.PollInterval > 0 {
+		return w.opt.PollInterval
+	}
+	return DefaultWatchPollInterval
+}
+
+// watchReasonBase strips severity suffix from printer-state-reasons
+// keyword
+func watchReasonBase(reason string) string {
+	for _, suffix := range []string{"-report", "-warning", "-error"} {
+		if strings.HasSuffix(reason, suffix) {
+			return strings.TrimSuffix(reason, suffix)
+		}
+	}
+	return reason
+}
+
+// watchInteger returns the first integer value of the attribute
+func watchInteger(attrs Attributes, name string) (int, bool) {
+	values, ok := watchIntegers(attrs, name)
+	if !ok || len(values) == 0 {
+		return 0, false
+	}
+	return values[0], true
+}
+
+// watchIntegers returns integer values of the attribute
+func watchIntegers(attrs Attributes, name string) ([]int, bool) {
+	attr, found := attrsFind(attrs, name)
+	if !found {
+		return nil, false
+	}
+
+	var values []int
+	for _, val := range attr.Values {
+		if v, ok := val.V.(Integer); ok {
+			values = append(values, int(v))
+		}
+	}
+
+	return values, true
+}
+
+// watchKeywords returns string values of the attribute
+func watchKeywords(attrs Attributes, name string) ([]string, bool) {
+	attr, found := attrsFind(attrs, name)
+	if !found {
+		return nil, false
+	}
+
+	values := make([]string, 0, len(attr.Values))
+	for _, val := range attr.Values {
+		values = append(values, val.V.String())
+	}
+
+	return values, true
+}
+
+// watchHasKeyword reports whether attribute has the keyword value
+func watchHasKeyword(attr Attribute, keyword string) bool {
+	for _, val := range attr.Values {
+		if val.T == TagKeyword && val.V.String() == keyword {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,257 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * Printer state change watcher test
+ */
+
+package goipp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testWatchPrinter is the fake printer for Watcher tests
+type testWatchPrinter struct {
+	ippget bool // Printer supports ippget
+
+	lock          sync.Mutex
+	getAttrsCount int  // Count of Get-Printer-Attributes
+	getJobsCount  int  // Count of Get-Jobs
+	canceled      bool // Cancel-Subscription received
+}
+
+// ServeHTTP implements http.Handler
+func (p *testWatchPrinter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req Message
+	err := req.Decode(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	rsp := NewResponseTo(&req, StatusOk)
+
+	switch Op(req.Code) {
+	case OpGetPrinterAttributes:
+		p.getAttrsCount++
+
+		ops := MakeAttribute("operations-supported", TagEnum,
+			Integer(OpGetPrinterAttributes))
+		ops.Values.Add(TagEnum, Integer(OpGetJobs))
+		if p.ippget {
+			ops.Values.Add(TagEnum,
+				Integer(OpCreatePrinterSubscriptions))
+			ops.Values.Add(TagEnum, Integer(OpGetNotifications))
+			ops.Values.Add(TagEnum, Integer(OpCancelSubscription))
+		}
+		rsp.Printer.Add(ops)
+		rsp.Printer.Add(MakeAttribute("notify-pull-method-supported",
+			TagKeyword, String("ippget")))
+
+		state, reason, level := PrinterStateIdle, "none", 50
+		if p.getAttrsCount >= 3 {
+			state = PrinterStateProcessing
+			reason = "marker-supply-low-warning"
+			level = 5
+		}
+
+		rsp.Printer.Add(MakeAttribute("printer-state", TagEnum,
+			Integer(state)))
+		rsp.Printer.Add(MakeAttribute("printer-state-reasons",
+			TagKeyword, String(reason)))
+		rsp.Printer.Add(MakeAttribute("marker-names", TagName,
+			String("Black")))
+		rsp.Printer.Add(MakeAttribute("marker-levels", TagInteger,
+			Integer(level)))
+		rsp.Printer.Add(MakeAttribute("marker-low-levels", TagInteger,
+			Integer(10)))
+
+	case OpGetJobs:
+		p.getJobsCount++
+
+		jobs := []int{1}
+		if p.getJobsCount >= 2 {
+			jobs = append(jobs, 2)
+		}
+
+		for _, id := range jobs {
+			job := Attributes{
+				MakeAttribute("job-id", TagInteger, Integer(id)),
+				MakeAttribute("job-state", TagEnum,
+					Integer(JobStateCompleted)),
+			}
+			rsp.Groups = append(rsp.Groups, Group{TagJobGroup, job})
+		}
+
+		if rsp.Groups != nil {
+			rsp.Groups = append(Groups{{TagOperationGroup,
+				rsp.Operation}}, rsp.Groups...)
+		}
+
+	case OpCreatePrinterSubscriptions:
+		rsp.Subscription.Add(MakeAttribute("notify-subscription-id",
+			TagInteger, Integer(7)))
+
+	case OpGetNotifications:
+		ev1 := Attributes{
+			MakeAttribute("notify-subscribed-event", TagKeyword,
+				String("printer-state-changed")),
+			MakeAttribute("notify-sequence-number", TagInteger,
+				Integer(1)),
+			MakeAttribute("printer-state", TagEnum,
+				Integer(PrinterStateStopped)),
+			MakeAttribute("printer-state-reasons", TagKeyword,
+				String("toner-low-report")),
+		}
+		ev2 := Attributes{
+			MakeAttribute("notify-subscribed-event", TagKeyword,
+				String("job-completed")),
+			MakeAttribute("notify-sequence-number", TagInteger,
+				Integer(2)),
+			MakeAttribute("job-id", TagInteger, Integer(5)),
+			MakeAttribute("job-state", TagEnum,
+				Integer(JobStateAborted)),
+		}
+
+		rsp.Groups = Groups{
+			{TagOperationGroup, rsp.Operation},
+			{TagEventNotificationGroup, ev1},
+			{TagEventNotificationGroup, ev2},
+		}
+
+	case OpCancelSubscription:
+		p.canceled = true
+	}
+
+	data, _ := rsp.EncodeBytes()
+	w.Header().Set("Content-Type", ContentType)
+	w.Write(data)
+}
+
+// testWatchReceive receives n events from the Watcher
+func testWatchReceive(t *testing.T, w *Watcher, n int) []WatchEvent {
+	var events []WatchEvent
+	timeout := time.After(5 * time.Second)
+
+	for len(events) < n {
+		select {
+		case ev, ok := <-w.C:
+			if !ok {
+				t.Fatalf("Watcher terminated: %v", w.Err())
+			}
+			events = append(events, ev)
+		case <-timeout:
+			t.Fatalf("timeout: received %d of %d events",
+				len(events), n)
+		}
+	}
+
+	return events
+}
+
+// TestWatcher tests Watcher with both polling and notifications
+func TestWatcher(t *testing.T) {
+	type expEvent struct {
+		kind   WatchEventKind
+		state  int
+		jobID  int
+		supply string
+	}
+
+	type testData struct {
+		ippget bool        // Printer supports ippget
+		method WatchMethod // Expected method
+		events []expEvent  // Expected events
+	}
+
+	tests := []testData{
+		{
+			ippget: false,
+			method: WatchPolling,
+			events: []expEvent{
+				{kind: WatchPrinterStateChanged,
+					state: PrinterStateProcessing},
+				{kind: WatchSupplyLow, supply: "marker-supply-low"},
+				{kind: WatchSupplyLow, supply: "Black"},
+				{kind: WatchJobCompleted, jobID: 2},
+			},
+		},
+		{
+			ippget: true,
+			method: WatchNotifications,
+			events: []expEvent{
+				{kind: WatchPrinterStateChanged,
+					state: PrinterStateStopped},
+				{kind: WatchSupplyLow, supply: "toner-low"},
+				{kind: WatchJobCompleted, jobID: 5},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		p := &testWatchPrinter{ippget: test.ippget}
+		srv := httptest.NewServer(p)
+
+		w, err := NewClient(nil).Watch(context.Background(), srv.URL,
+			WatchOptions{PollInterval: 10 * time.Millisecond})
+		if err != nil {
+			srv.Close()
+			t.Fatalf("Watch: %s", err)
+		}
+
+		if w.Method() != test.method {
+			t.Errorf("method: expected %s, present %s",
+				test.method, w.Method())
+		}
+
+		events := testWatchReceive(t, w, len(test.events))
+		for i, exp := range test.events {
+			ev := events[i]
+			if ev.Kind != exp.kind || ev.PrinterState != exp.state ||
+				ev.JobID != exp.jobID || ev.Supply != exp.supply {
+				t.Errorf("%s: event %d: expected %+v, present "+
+					"%s state=%d job=%d supply=%q",
+					test.method, i, exp, ev.Kind,
+					ev.PrinterState, ev.JobID, ev.Supply)
+			}
+		}
+
+		w.Close()
+		for range w.C {
+		}
+
+		assertNoError(t, w.Err())
+
+		p.lock.Lock()
+		canceled := p.canceled
+		p.lock.Unlock()
+
+		if canceled != test.ippget {
+			t.Errorf("%s: subscription canceled: %v",
+				test.method, canceled)
+		}
+
+		srv.Close()
+	}
+}
+
+// TestWatcherNoIppget tests WatchNotifications with printer, that
+// doesn't support it
+func TestWatcherNoIppget(t *testing.T) {
+	srv := httptest.NewServer(&testWatchPrinter{})
+	defer srv.Close()
+
+	_, err := NewClient(nil).Watch(context.Background(), srv.URL,
+		WatchOptions{Method: WatchNotifications})
+	assertErrorIs(t, err, "Printer doesn't support ippget notifications")
+}
@@ -0,0 +1,1010 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * YAML message fixtures
+ */
+
+package goipp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// YAML fixture format
+//
+// Messages may be written in a concise YAML-based format, intended
+// for hand-written test fixtures and configuration files:
+//
+//	version: 2.0
+//	operation: Get-Printer-Attributes    # or status: successful-ok
+//	request-id: 1
+//	groups:
+//	  - group: operation-attributes-tag
+//	    attributes:
+//	      attributes-charset:
+//	        charset: utf-8
+//	      requested-attributes:
+//	        keyword: [printer-name, media-col-database]
+//	  - group: job-attributes-tag
+//	    attributes:
+//	      media-col:
+//	        collection:
+//	          media-size:
+//	            collection:
+//	              x-dimension:
+//	                integer: 21000
+//	              y-dimension:
+//	                integer: 29700
+//
+// Each attribute is a mapping of tags to values. Multiple values
+// are written as a sequence, and attribute may have values of
+// different tags (i.e., integer and rangeOfInteger). Values of
+// collections are mappings of member attributes, and 1setOf
+// collection is a sequence of such mappings. Attribute names and
+// tags may repeat, to express messages that contain such repetitions.
+//
+// Values are written as follows:
+//
+//	integer, enum             123
+//	boolean                   true or false
+//	strings                   text, optionally quoted
+//	dateTime                  2006-01-02T15:04:05-07:00
+//	resolution                600x600dpi
+//	rangeOfInteger            1-100
+//	textWithLanguage          text [lang]
+//	octetString and unknown   hex-encoded bytes
+//	out-of-band (no-value)    empty value
+//
+// Only the subset of YAML, needed to express this format, is
+// supported. Errors refer to line numbers of the YAML input.
+
+// DecodeYAML reads message in the YAML fixture format from io.Reader
+func (m *Message) DecodeYAML(in io.Reader) error {
+	root, err := yamlParse(in)
+	if err != nil {
+		return err
+	}
+
+	m2, err := yamlMessage(root)
+	if err == nil {
+		*m = *m2
+	}
+
+	return err
+}
+
+// DecodeYAMLBytes decodes message in the YAML fixture format
+// from the byte slice
+func (m *Message) DecodeYAMLBytes(data []byte) error {
+	return m.DecodeYAML(bytes.NewReader(data))
+}
+
+// EncodeYAML writes message in the YAML fixture format into io.Writer.
+//
+// The 'request' parameter affects interpretation of Message.Code:
+// it is written either as operation or as status.
+func (m *Message) EncodeYAML(out io.Writer, request bool) error {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "version: %s\n", m.Version)
+	if request {
+		fmt.Fprintf(&buf, "operation: %s\n", Op(m.Code))
+	} else {
+		fmt.Fprintf(&buf, "status: %s\n", Status(m.Code))
+	}
+	fmt.Fprintf(&buf, "request-id: %d\n", m.RequestID)
+
+	groups := m.attrGroups()
+	if len(groups) != 0 {
+		buf.WriteString("groups:\n")
+	}
+
+	for _, grp := range groups {
+		fmt.Fprintf(&buf, "  - group: %s\n", grp.Tag)
+		if len(grp.Attrs) != 0 {
+			buf.WriteString("    attributes:\n")
+			err := yamlWriteAttrs(&buf, grp.Attrs, 6)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := buf.WriteTo(out)
+	return err
+}
+
+// EncodeYAMLBytes encodes message in the YAML fixture format
+// into byte slice
+func (m *Message) EncodeYAMLBytes(request bool) ([]byte, error) {
+	var buf bytes.Buffer
+	err := m.EncodeYAML(&buf, request)
+	return buf.Bytes(), err
+}
+
+// yamlWriteAttrs writes Attributes
+func yamlWriteAttrs(buf *bytes.Buffer, attrs Attributes, indent int) error {
+	pad := strings.Repeat(" ", indent)
+
+	for _, attr := range attrs {
+		if attr.Name == "" {
+			return errors.New("Attribute without name")
+		}
+
+		fmt.Fprintf(buf, "%s%s:\n", pad, yamlQuote(attr.Name))
+
+		// Group adjacent values of the same tag
+		values := attr.Values
+		for len(values) > 0 {
+			tag := values[0].T
+			n := 1
+			for n < len(values) && values[n].T == tag {
+				n++
+			}
+
+			err := yamlWriteValues(buf, tag, values[:n], indent+2)
+			if err != nil {
+				return fmt.Errorf("%q: %s", attr.Name, err)
+			}
+
+			values = values[n:]
+		}
+	}
+
+	return nil
+}
+
+// yamlWriteValues writes values of the same tag
+func yamlWriteValues(buf *bytes.Buffer, tag Tag, values Values,
+	indent int) error {
+
+	pad := strings.Repeat(" ", indent)
+
+	if tag.Type() == TypeCollection {
+		fmt.Fprintf(buf, "%s%s:\n", pad, tag)
+		for _, v := range values {
+			col, ok := v.V.(Collection)
+			if !ok {
+				return fmt.Errorf("%s: Collection value required, %s present",
+					tag, v.V.Type())
+			}
+
+			if len(values) > 1 {
+				fmt.Fprintf(buf, "%s  -\n", pad)
+				if err := yamlWriteAttrs(buf, Attributes(col), indent+4); err != nil {
+					return err
+				}
+			} else if err := yamlWriteAttrs(buf, Attributes(col), indent+2); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	strs := make([]string, len(values))
+	for i, v := range values {
+		s, err := yamlFormatValue(tag, v.V)
+		if err != nil {
+			return err
+		}
+		strs[i] = s
+	}
+
+	switch {
+	case tag.Type() == TypeVoid:
+		fmt.Fprintf(buf, "%s%s:\n", pad, tag)
+	case len(strs) == 1:
+		fmt.Fprintf(buf, "%s%s: %s\n", pad, tag, strs[0])
+	default:
+		fmt.Fprintf(buf, "%s%s: [%s]\n", pad, tag, strings.Join(strs, ", "))
+	}
+
+	return nil
+}
+
+// yamlFormatValue formats a single non-collection Value
+func yamlFormatValue(tag Tag, v Value) (string, error) {
+	if tag.Type() != TypeVoid && tag.Type() != v.Type() {
+		return "", fmt.Errorf("%s: %s value required, %s present",
+			tag, tag.Type(), v.Type())
+	}
+
+	switch v := v.(type) {
+	case Time:
+		return v.Time.Format(time.RFC3339Nano), nil
+	case Binary:
+		return yamlQuote(hex.EncodeToString(v)), nil
+	case BinaryRef:
+		data, err := v.Materialize()
+		return yamlQuote(hex.EncodeToString(data)), err
+	}
+
+	return yamlQuote(v.String()), nil
+}
+
+// yamlQuote quotes string, if it cannot be written as plain YAML scalar
+func yamlQuote(s string) string {
+	plain := s != "" && s != "~" && s != "null" &&
+		!strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@` ") &&
+		!strings.HasSuffix(s, " ") &&
+		!strings.Contains(s, ": ") &&
+		!strings.Contains(s, " #") &&
+		!strings.HasSuffix(s, ":") &&
+		!strings.ContainsAny(s, ",[]{}")
+
+	for _, c := range s {
+		if c < ' ' || c == 0x7f || c == '\\' || c == '"' {
+			plain = false
+		}
+	}
+
+	if plain {
+		return s
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case c == '\n':
+			buf.WriteString(`\n`)
+		case c == '\t':
+			buf.WriteString(`\t`)
+		case c < ' ' || c == 0x7f:
+			fmt.Fprintf(&buf, `\x%2.2x`, c)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	buf.WriteByte('"')
+
+	return buf.String()
+}
+
+// yamlNode represents a node of the parsed YAML document
+type yamlNode struct {
+	line   int         // Line number
+	kind   yamlKind    // Node kind
+	scalar string      // Scalar value
+	keys   []string    // Keys of mapping, in order
+	values []*yamlNode // Values of mapping or items of sequence
+}
+
+// yamlKind is the kind of yamlNode
+type yamlKind int
+
+const (
+	yamlScalar yamlKind = iota
+	yamlNull
+	yamlMapping
+	yamlSequence
+)
+
+// yamlError returns error, bound to the line number
+func yamlError(line int, format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// yamlLine represents a single non-empty line of YAML input
+type yamlLine struct {
+	num    int    // Line number
+	indent int    // Indentation
+	text   string // Text without indentation and comments
+}
+
+// yamlParser parses YAML input
+type yamlParser struct {
+	lines []yamlLine // Input lines
+	pos   int        // Current line
+}
+
+// yamlParse parses YAML input
+func yamlParse(in io.Reader) (*yamlNode, error) {
+	var p yamlParser
+
+	scanner := bufio.NewScanner(in)
+	num := 0
+	for scanner.Scan() {
+		num++
+		text := yamlStripComment(scanner.Text())
+		trimmed := strings.TrimLeft(text, " ")
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, yamlError(num, "tabs are not allowed for indentation")
+		}
+
+		trimmed = strings.TrimRight(trimmed, " \t\r")
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+
+		p.lines = append(p.lines, yamlLine{num, len(text) - len(strings.TrimLeft(text, " ")), trimmed})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(p.lines) == 0 {
+		return nil, errors.New("line 1: empty document")
+	}
+
+	root, err := p.parseBlock(p.lines[0].indent)
+	if err == nil && p.pos < len(p.lines) {
+		err = yamlError(p.lines[p.pos].num, "unexpected indentation")
+	}
+
+	return root, err
+}
+
+// yamlStripComment strips comment from the line
+func yamlStripComment(s string) string {
+	quote := byte(0)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// parseBlock parses block node at the given indentation
+func (p *yamlParser) parseBlock(indent int) (*yamlNode, error) {
+	line := &p.lines[p.pos]
+	if line.text == "-" || strings.HasPrefix(line.text, "- ") {
+		return p.parseSequence(indent)
+	}
+	return p.parseMapping(indent)
+}
+
+// parseSequence parses block sequence
+func (p *yamlParser) parseSequence(indent int) (*yamlNode, error) {
+	node := &yamlNode{line: p.lines[p.pos].num, kind: yamlSequence}
+
+	for p.pos < len(p.lines) {
+		line := &p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+
+		if line.indent > indent {
+			return nil, yamlError(line.num, "unexpected indentation")
+		}
+
+		if line.text != "-" && !strings.HasPrefix(line.text, "- ") {
+			break
+		}
+
+		var item *yamlNode
+		var err error
+
+		if line.text == "-" {
+			// Item on the following lines
+			p.pos++
+			if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+				item, err = p.parseBlock(p.lines[p.pos].indent)
+			} else {
+				item = &yamlNode{line: line.num, kind: yamlNull}
+			}
+		} else {
+			// Item starts at the same line. Rewrite the line,
+			// so item looks like the block at the deeper indentation
+			rest := strings.TrimLeft(line.text[1:], " ")
+			line.indent += len(line.text) - len(rest)
+			line.text = rest
+
+			if yamlIsMappingEntry(rest) {
+				item, err = p.parseMapping(line.indent)
+			} else {
+				item, err = p.parseScalar(line.num, rest)
+				p.pos++
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		node.values = append(node.values, item)
+	}
+
+	return node, nil
+}
+
+// parseMapping parses block mapping
+func (p *yamlParser) parseMapping(indent int) (*yamlNode, error) {
+	node := &yamlNode{line: p.lines[p.pos].num, kind: yamlMapping}
+
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+
+		if line.indent > indent {
+			return nil, yamlError(line.num, "unexpected indentation")
+		}
+
+		if !yamlIsMappingEntry(line.text) {
+			if strings.HasPrefix(line.text, "- ") || line.text == "-" {
+				break
+			}
+			return nil, yamlError(line.num, "mapping entry expected")
+		}
+
+		key, rest := yamlSplitEntry(line.text)
+		key, err := yamlUnquote(line.num, key)
+		if err != nil {
+			return nil, err
+		}
+
+		p.pos++
+
+		var val *yamlNode
+		switch {
+		case rest != "":
+			val, err = p.parseScalar(line.num, rest)
+
+		case p.pos < len(p.lines) && p.lines[p.pos].indent > indent:
+			val, err = p.parseBlock(p.lines[p.pos].indent)
+
+		case p.pos < len(p.lines) && p.lines[p.pos].indent == indent &&
+			(p.lines[p.pos].text == "-" ||
+				strings.HasPrefix(p.lines[p.pos].text, "- ")):
+			val, err = p.parseSequence(indent)
+
+		default:
+			val = &yamlNode{line: line.num, kind: yamlNull}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		node.keys = append(node.keys, key)
+		node.values = append(node.values, val)
+	}
+
+	return node, nil
+}
+
+// parseScalar parses scalar or flow sequence of scalars
+func (p *yamlParser) parseScalar(num int, s string) (*yamlNode, error) {
+	if strings.HasPrefix(s, "{") {
+		return nil, yamlError(num, "flow mappings are not supported")
+	}
+
+	if !strings.HasPrefix(s, "[") {
+		if s == "~" || s == "null" {
+			return &yamlNode{line: num, kind: yamlNull}, nil
+		}
+
+		v, err := yamlUnquote(num, s)
+		if err != nil {
+			return nil, err
+		}
+		return &yamlNode{line: num, kind: yamlScalar, scalar: v}, nil
+	}
+
+	if !strings.HasSuffix(s, "]") {
+		return nil, yamlError(num, "unterminated flow sequence")
+	}
+
+	node := &yamlNode{line: num, kind: yamlSequence}
+	items, err := yamlSplitFlow(num, s[1:len(s)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		v, err := yamlUnquote(num, item)
+		if err != nil {
+			return nil, err
+		}
+		node.values = append(node.values,
+			&yamlNode{line: num, kind: yamlScalar, scalar: v})
+	}
+
+	return node, nil
+}
+
+// yamlIsMappingEntry tells if line looks like "key: value" or "key:"
+func yamlIsMappingEntry(s string) bool {
+	key, _ := yamlSplitEntry(s)
+	return key != ""
+}
+
+// yamlSplitEntry splits "key: value" line into key and value
+// If line is not a mapping entry, it returns empty key.
+func yamlSplitEntry(s string) (key, value string) {
+	quote := byte(0)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && i == 0:
+			quote = c
+		case c == ':' && (i+1 == len(s) || s[i+1] == ' '):
+			return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:])
+		}
+	}
+	return "", ""
+}
+
+// yamlSplitFlow splits content of flow sequence into items
+func yamlSplitFlow(num int, s string) ([]string, error) {
+	var items []string
+
+	if strings.TrimSpace(s) == "" {
+		return items, nil
+	}
+
+	quote := byte(0)
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == ']' || c == '{' || c == '}':
+			return nil, yamlError(num, "nested flow collections are not supported")
+		case c == ',':
+			items = append(items, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+
+	if quote != 0 {
+		return nil, yamlError(num, "unterminated quoted string")
+	}
+
+	items = append(items, strings.TrimSpace(s[start:]))
+	return items, nil
+}
+
+// yamlUnquote unquotes scalar value, if it is quoted
+func yamlUnquote(num int, s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return "", yamlError(num, "unterminated quoted string")
+		}
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+
+	case strings.HasPrefix(s, `"`):
+		if len(s) < 2 || !strings.HasSuffix(s, `"`) {
+			return "", yamlError(num, "unterminated quoted string")
+		}
+
+		var buf bytes.Buffer
+		s = s[1 : len(s)-1]
+		for i := 0; i < len(s); i++ {
+			c := s[i]
+			if c != '\\' {
+				buf.WriteByte(c)
+				continue
+			}
+
+			i++
+			if i == len(s) {
+				return "", yamlError(num, "bad escape sequence")
+			}
+
+			switch s[i] {
+			case 'n':
+				buf.WriteByte('\n')
+			case 't':
+				buf.WriteByte('\t')
+			case 'r':
+				buf.WriteByte('\r')
+			case '0':
+				buf.WriteByte(0)
+			case '"', '\\', '/', ' ':
+				buf.WriteByte(s[i])
+			case 'x':
+				if i+2 >= len(s) {
+					return "", yamlError(num, "bad escape sequence")
+				}
+				v, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+				if err != nil {
+					return "", yamlError(num, "bad escape sequence")
+				}
+				buf.WriteByte(byte(v))
+				i += 2
+			case 'u':
+				if i+4 >= len(s) {
+					return "", yamlError(num, "bad escape sequence")
+				}
+				v, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+				if err != nil {
+					return "", yamlError(num, "bad escape sequence")
+				}
+				buf.WriteRune(rune(v))
+				i += 4
+			default:
+				return "", yamlError(num, "bad escape sequence")
+			}
+		}
+
+		return buf.String(), nil
+	}
+
+	return s, nil
+}
+
+// yamlCheckKeys checks that keys of mapping are not duplicated.
+//
+// Note, keys of attributes and values are not checked: messages
+// may contain duplicated attributes and attribute may contain
+// non-adjacent values with the same tag, and fixtures must be able
+// to express it.
+func yamlCheckKeys(node *yamlNode) error {
+	for i, key := range node.keys {
+		for _, key2 := range node.keys[:i] {
+			if key == key2 {
+				return yamlError(node.values[i].line,
+					"duplicated key %q", key)
+			}
+		}
+	}
+	return nil
+}
+
+// yamlMessage converts parsed YAML document into the Message
+func yamlMessage(root *yamlNode) (*Message, error) {
+	if root.kind != yamlMapping {
+		return nil, yamlError(root.line, "mapping expected")
+	}
+
+	if err := yamlCheckKeys(root); err != nil {
+		return nil, err
+	}
+
+	version := DefaultVersion
+	var code Code
+	var id uint32
+	var groups Groups
+	codeSet := false
+
+	for i, key := range root.keys {
+		val := root.values[i]
+		var err error
+
+		switch key {
+		case "version":
+			version, err = yamlVersion(val)
+
+		case "operation", "status", "code":
+			if codeSet {
+				return nil, yamlError(val.line,
+					"only one of operation, status or code allowed")
+			}
+			codeSet = true
+			code, err = yamlCode(key, val)
+
+		case "request-id":
+			var v uint64
+			if val.kind == yamlScalar {
+				v, err = strconv.ParseUint(val.scalar, 0, 32)
+			}
+			if val.kind != yamlScalar || err != nil {
+				return nil, yamlError(val.line, "invalid request-id")
+			}
+			id = uint32(v)
+
+		case "groups":
+			groups, err = yamlGroups(val)
+
+		default:
+			err = yamlError(val.line, "unknown key %q", key)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return NewMessageWithGroups(version, code, id, groups), nil
+}
+
+// yamlVersion decodes protocol version
+func yamlVersion(val *yamlNode) (Version, error) {
+	var major, minor uint8
+	if val.kind == yamlScalar {
+		n, _ := fmt.Sscanf(val.scalar, "%d.%d", &major, &minor)
+		if n == 2 {
+			return MakeVersion(major, minor), nil
+		}
+	}
+
+	return 0, yamlError(val.line, "invalid version")
+}
+
+// yamlCode decodes operation or status code
+func yamlCode(key string, val *yamlNode) (Code, error) {
+	if val.kind != yamlScalar {
+		return 0, yamlError(val.line, "invalid %s", key)
+	}
+
+	if v, err := strconv.ParseUint(val.scalar, 0, 16); err == nil {
+		return Code(v), nil
+	}
+
+	switch key {
+	case "operation":
+		if op, found := opByName(val.scalar); found {
+			return Code(op), nil
+		}
+	case "status":
+		for status, s := range statusNames {
+			if s != "" && s == val.scalar {
+				return Code(status), nil
+			}
+		}
+	}
+
+	return 0, yamlError(val.line, "unknown %s %q", key, val.scalar)
+}
+
+// yamlGroups decodes groups of attributes
+func yamlGroups(val *yamlNode) (Groups, error) {
+	if val.kind == yamlNull {
+		return nil, nil
+	}
+
+	if val.kind != yamlSequence {
+		return nil, yamlError(val.line, "groups: sequence expected")
+	}
+
+	groups := make(Groups, 0, len(val.values))
+	for _, item := range val.values {
+		if item.kind != yamlMapping {
+			return nil, yamlError(item.line, "group: mapping expected")
+		}
+
+		if err := yamlCheckKeys(item); err != nil {
+			return nil, err
+		}
+
+		grp := Group{Attrs: Attributes{}}
+		tagSet := false
+
+		for i, key := range item.keys {
+			v := item.values[i]
+			switch key {
+			case "group":
+				if v.kind != yamlScalar {
+					return nil, yamlError(v.line, "invalid group tag")
+				}
+
+				tag, err := parseTag(v.scalar)
+				if err != nil || !tag.IsGroup() {
+					return nil, yamlError(v.line,
+						"invalid group tag %q", v.scalar)
+				}
+
+				grp.Tag = tag
+				tagSet = true
+
+			case "attributes":
+				attrs, err := yamlAttrs(v)
+				if err != nil {
+					return nil, err
+				}
+				grp.Attrs = attrs
+
+			default:
+				return nil, yamlError(v.line, "unknown key %q", key)
+			}
+		}
+
+		if !tagSet {
+			return nil, yamlError(item.line, "missed group tag")
+		}
+
+		groups.Add(grp)
+	}
+
+	return groups, nil
+}
+
+// yamlAttrs decodes Attributes
+func yamlAttrs(val *yamlNode) (Attributes, error) {
+	if val.kind == yamlNull {
+		return Attributes{}, nil
+	}
+
+	if val.kind != yamlMapping {
+		return nil, yamlError(val.line, "attributes: mapping expected")
+	}
+
+	attrs := make(Attributes, 0, len(val.keys))
+	for i, name := range val.keys {
+		v := val.values[i]
+		if v.kind != yamlMapping {
+			return nil, yamlError(v.line,
+				"%q: mapping of tags to values expected", name)
+		}
+
+		attr := Attribute{Name: name}
+		for j, tagName := range v.keys {
+			tv := v.values[j]
+
+			tag, err := parseTag(tagName)
+			if err != nil || tag.IsDelimiter() ||
+				tag == TagMemberName || tag == TagEndCollection {
+				return nil, yamlError(tv.line,
+					"%q: invalid value tag %q", name, tagName)
+			}
+
+			err = yamlValues(&attr, tag, tv)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if len(attr.Values) == 0 {
+			return nil, yamlError(v.line, "%q: attribute without value", name)
+		}
+
+		attrs.Add(attr)
+	}
+
+	return attrs, nil
+}
+
+// yamlValues decodes values of the same tag and adds them to attribute
+func yamlValues(attr *Attribute, tag Tag, val *yamlNode) error {
+	items := []*yamlNode{val}
+	if val.kind == yamlSequence {
+		items = val.values
+	}
+
+	for _, item := range items {
+		var v Value
+		var err error
+
+		switch {
+		case tag.Type() == TypeCollection:
+			var attrs Attributes
+			attrs, err = yamlAttrs(item)
+			v = Collection(attrs)
+
+		case tag.Type() == TypeVoid:
+			v = Void{}
+
+		case item.kind == yamlNull:
+			err = yamlError(item.line, "%q: %s: missed value",
+				attr.Name, tag)
+
+		case item.kind != yamlScalar:
+			err = yamlError(item.line, "%q: %s: scalar value expected",
+				attr.Name, tag)
+
+		default:
+			v, err = yamlParseValue(tag, item.scalar)
+			if err != nil {
+				err = yamlError(item.line, "%q: %s: %s",
+					attr.Name, tag, err)
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+
+		attr.Values.Add(tag, v)
+	}
+
+	return nil
+}
+
+// yamlParseValue parses non-collection Value
+func yamlParseValue(tag Tag, s string) (Value, error) {
+	switch tag.Type() {
+	case TypeInteger:
+		v, err := strconv.ParseInt(s, 0, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", s)
+		}
+		return Integer(v), nil
+
+	case TypeBoolean:
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid boolean %q", s)
+		}
+		return Boolean(v), nil
+
+	case TypeString:
+		return String(s), nil
+
+	case TypeDateTime:
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dateTime %q", s)
+		}
+		return Time{t}, nil
+
+	case TypeResolution:
+		var res Resolution
+		var units string
+		n, _ := fmt.Sscanf(s, "%dx%d%s", &res.Xres, &res.Yres, &units)
+		switch {
+		case n != 3:
+		case units == "dpi":
+			res.Units = UnitsDpi
+			return res, nil
+		case units == "dpcm":
+			res.Units = UnitsDpcm
+			return res, nil
+		}
+		return nil, fmt.Errorf("invalid resolution %q", s)
+
+	case TypeRange:
+		// Lower bound may be negative, so search for
+		// separator, starting from the second character
+		if i := strings.Index(s[1:], "-"); s != "" && i >= 0 {
+			lower, err1 := strconv.ParseInt(s[:i+1], 10, 32)
+			upper, err2 := strconv.ParseInt(s[i+2:], 10, 32)
+			if err1 == nil && err2 == nil {
+				return Range{int(lower), int(upper)}, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid range %q", s)
+
+	case TypeTextWithLang:
+		if strings.HasSuffix(s, "]") {
+			if i := strings.LastIndex(s, " ["); i >= 0 {
+				return TextWithLang{
+					Lang: s[i+2 : len(s)-1],
+					Text: s[:i],
+				}, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid text with language %q", s)
+
+	case TypeBinary:
+		v, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex string %q", s)
+		}
+		return Binary(v), nil
+	}
+
+	return nil, errors.New("tag cannot be used with value")
+}
@@ -0,0 +1,155 @@
+/* Go IPP - IPP core protocol implementation in pure Go
+ *
+ * Copyright (C) 2020 and up by Alexander Pevzner (pzz@apevzner.com)
+ * See LICENSE for license terms and conditions
+ *
+ * YAML message fixtures test
+ */
+
+package goipp
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestYAMLRoundTrip tests EncodeYAML/DecodeYAML round trip
+func TestYAMLRoundTrip(t *testing.T) {
+	messages := []*Message{testEncodeDecodeMessage()}
+
+	for _, data := range [][]byte{goodMessage1, goodMessage2,
+		attrsHPOfficeJetPro8730} {
+		m := &Message{}
+		err := m.DecodeBytes(data)
+		assertNoError(t, err)
+		messages = append(messages, m)
+	}
+
+	m := NewRequest(DefaultVersion, OpPrintJob, 1)
+	m.Job.Add(MakeAttr("strings", TagText,
+		String(""), String("- a: b # c"), String("\"quoted\"\n"),
+		String("null"), String("[1, 2]")))
+	m.Job.Add(MakeAttr("ranges", TagRange, Range{-10, -5}, Range{1, 2}))
+	m.Job.Add(MakeAttr("binary", TagString, Binary{}, Binary{1, 2, 3}))
+	m.Job.Add(MakeAttr("empty-collections", TagBeginCollection,
+		Collection{}, Collection{}))
+	m.Job.Add(MakeAttr("mixed", TagInteger, Integer(1)))
+	m.Job[len(m.Job)-1].Values.Add(TagRange, Range{1, 2})
+	m.Job[len(m.Job)-1].Values.Add(TagInteger, Integer(3))
+	messages = append(messages, m)
+
+	for _, m1 := range messages {
+		data, err := m1.EncodeYAMLBytes(true)
+		assertNoError(t, err)
+
+		var m2 Message
+		err = m2.DecodeYAMLBytes(data)
+		assertNoError(t, err)
+
+		if err == nil && !m1.Equal(m2) {
+			t.Errorf("Message: not the same after YAML round trip:\n%s",
+				data)
+		}
+	}
+}
+
+// TestYAMLHandWritten tests decoding of hand-written fixture
+func TestYAMLHandWritten(t *testing.T) {
+	in := strings.Join([]string{
+		"# Hand-written fixture",
+		"version: 1.1",
+		"operation: Print-Job",
+		"request-id: 0x10",
+		"",
+		"groups:",
+		"- group: operation-attributes-tag",
+		"  attributes:",
+		"    attributes-charset:",
+		"      charset: utf-8",
+		"    requested-attributes:",
+		"      keyword:",
+		"        - all",
+		"        - 'media-col'   # quoted",
+		"- group: job-attributes-tag",
+		"  attributes:",
+		"    media-size-supported:",
+		"      collection:",
+		"        - x-dimension:",
+		"            integer: 21000",
+		"          y-dimension:",
+		"            integer: 29700",
+		"        - x-dimension:",
+		"            integer: 10000",
+		"          y-dimension:",
+		"            rangeOfInteger: 1-20000",
+		"    job-hold-until:",
+		"      no-value:",
+	}, "\n")
+
+	m := NewRequest(MakeVersion(1, 1), OpPrintJob, 16)
+	m.Operation.Add(MakeAttr("attributes-charset", TagCharset,
+		String("utf-8")))
+	m.Operation.Add(MakeAttr("requested-attributes", TagKeyword,
+		String("all"), String("media-col")))
+	m.Job.Add(MakeAttr("media-size-supported", TagBeginCollection,
+		Collection{
+			MakeAttr("x-dimension", TagInteger, Integer(21000)),
+			MakeAttr("y-dimension", TagInteger, Integer(29700)),
+		},
+		Collection{
+			MakeAttr("x-dimension", TagInteger, Integer(10000)),
+			MakeAttr("y-dimension", TagRange, Range{1, 20000}),
+		}))
+	m.Job.Add(MakeAttr("job-hold-until", TagNoValue, Void{}))
+
+	var m2 Message
+	err := m2.DecodeYAMLBytes([]byte(in))
+	assertNoError(t, err)
+
+	if !m.Equal(m2) {
+		t.Errorf("Hand-written fixture decoded incorrectly")
+	}
+}
+
+// TestYAMLErrors tests DecodeYAML errors
+func TestYAMLErrors(t *testing.T) {
+	type testData struct {
+		in  string // Input YAML
+		err string // Expected error
+	}
+
+	tests := []testData{
+		{"", "line 1: empty document"},
+		{"version: x", "line 1: invalid version"},
+		{"version: 2.0\nfoo: bar", `line 2: unknown key "foo"`},
+		{"operation: Bad-Op", `line 1: unknown operation "Bad-Op"`},
+		{"operation: Print-Job\nstatus: successful-ok",
+			"line 2: only one of operation, status or code allowed"},
+		{"request-id: -1", "line 1: invalid request-id"},
+		{"groups:\n  - group: integer", `line 2: invalid group tag "integer"`},
+		{"groups:\n  - attributes:", "line 2: missed group tag"},
+		{"groups:\n  - group: job-attributes-tag\n    attributes:\n" +
+			"      copies:\n        integer: many",
+			`line 5: "copies": integer: invalid integer "many"`},
+		{"groups:\n  - group: job-attributes-tag\n    attributes:\n" +
+			"      copies:\n        bad-tag: 1",
+			`line 5: "copies": invalid value tag "bad-tag"`},
+		{"groups:\n  - group: job-attributes-tag\n    attributes:\n" +
+			"      copies:\n        integer:",
+			`line 5: "copies": integer: missed value`},
+		{"groups:\n  - group: job-attributes-tag\n    attributes:\n" +
+			"      copies: 1",
+			`line 4: "copies": mapping of tags to values expected`},
+		{"version: 2.0\n  request-id: 1", "line 2: unexpected indentation"},
+		{"version: 2.0\nversion: 2.0", `line 2: duplicated key "version"`},
+		{"version: \"2.0", "line 1: unterminated quoted string"},
+		{"version: {a: b}", "line 1: flow mappings are not supported"},
+		{"\tversion: 2.0", "line 1: tabs are not allowed for indentation"},
+	}
+
+	for _, test := range tests {
+		var m Message
+		err := m.DecodeYAMLBytes([]byte(test.in))
+		assertErrorIs(t, err, test.err)
+	}
+}